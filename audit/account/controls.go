@@ -0,0 +1,14 @@
+// audit/account/controls.go
+package account
+
+import (
+	"aws-security-hub/types"
+)
+
+// GetControls returns all Amazon Account related controls
+func GetControls() []types.Control {
+	return []types.Control{
+		types.InventoryControl{ID: "Account.1", Check: "security-account-information-provided", Func: EvaluateSecurityAccountInformationProvided,
+			Actions: []string{"account:GetAlternateContact"}},
+	}
+}
@@ -3,81 +3,81 @@ package account
 
 import (
 	"context"
-	"log"
 
+	"aws-security-hub/compliance"
+	"aws-security-hub/inventory"
+	"aws-security-hub/logging"
+	"aws-security-hub/types"
 	"aws-security-hub/util"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/service/account"
 )
 
-func CheckSecurityAccountInformationProvided(cfg aws.Config) string {
-	compliance, err := util.LoadComplianceData("compliance/aws_security_hub.json")
-	if err != nil {
-		log.Printf("[ERROR] Error loading compliance data: %v", err)
-		return "NA"
-	}
-	util.PrintComplianceInfo(compliance, "Account.1")
+func CheckSecurityAccountInformationProvided(ctx context.Context, cfg aws.Config) string {
+	inv, _ := inventory.Collect(ctx, cfg, inventory.ServiceAccount)
+	status, _ := types.Resolve(EvaluateSecurityAccountInformationProvided(inv))
+	return status
+}
+
+func EvaluateSecurityAccountInformationProvided(inv *inventory.Inventory) (string, types.Findings) {
+	var findings types.Findings
+	logger := logging.Control("Account.1", inv)
+
+	util.LogComplianceInfo(logger, compliance.SecurityHub(), "Account.1")
 	/* Description:
 	This control checks if an Amazon Web Services (AWS) account has security contact information. The control fails if security contact information is not provided for the account.
 	*/
 
-	// Create Account client
-	client := account.NewFromConfig(cfg)
-
-	// Get alternate contacts for security
-	input := &account.GetAlternateContactInput{
-		AlternateContactType: "SECURITY",
-	}
-
-	contact, err := client.GetAlternateContact(context.TODO(), input)
-	if err != nil {
-		log.Printf("[ERROR] Failed to get security contact information: %v", err)
-		return "FAIL"
+	if inv.Account == nil {
+		return types.NotCollected(logger, inv, inventory.ServiceAccount, &findings), findings
 	}
 
-	if contact.AlternateContact == nil {
-		log.Println("[FAIL] No security contact information is configured")
-		return "FAIL"
+	logger = logger.With("resource", "account")
+	if inv.Account.SecurityContact == nil {
+		logger.Info("no security contact information is configured", "status", "FAIL")
+		findings.Fail("account", "No security contact information is configured")
+		return "FAIL", findings
 	}
 
 	// Check if all required fields are populated
 	hasAllFields := true
-	contactInfo := contact.AlternateContact
+	contactInfo := inv.Account.SecurityContact
 
-	if contactInfo.Name == nil || *contactInfo.Name == "" {
-		log.Println("  └─[FAIL] Security contact name is not configured")
+	if contactInfo.Name == "" {
+		logger.Debug("security contact name is not configured")
 		hasAllFields = false
 	} else {
-		log.Printf("  └─[PASS] Security contact name: %s", *contactInfo.Name)
+		logger.Debug("security contact name is configured", "name", contactInfo.Name)
 	}
 
-	if contactInfo.EmailAddress == nil || *contactInfo.EmailAddress == "" {
-		log.Println("  └─[FAIL] Security contact email is not configured")
+	if contactInfo.EmailAddress == "" {
+		logger.Debug("security contact email is not configured")
 		hasAllFields = false
 	} else {
-		log.Printf("  └─[PASS] Security contact email: %s", *contactInfo.EmailAddress)
+		logger.Debug("security contact email is configured", "email", contactInfo.EmailAddress)
 	}
 
-	if contactInfo.PhoneNumber == nil || *contactInfo.PhoneNumber == "" {
-		log.Println("  └─[FAIL] Security contact phone number is not configured")
+	if contactInfo.PhoneNumber == "" {
+		logger.Debug("security contact phone number is not configured")
 		hasAllFields = false
 	} else {
-		log.Printf("  └─[PASS] Security contact phone number: %s", *contactInfo.PhoneNumber)
+		logger.Debug("security contact phone number is configured", "phone", contactInfo.PhoneNumber)
 	}
 
-	if contactInfo.Title == nil || *contactInfo.Title == "" {
-		log.Println("  └─[FAIL] Security contact title is not configured")
+	if contactInfo.Title == "" {
+		logger.Debug("security contact title is not configured")
 		hasAllFields = false
 	} else {
-		log.Printf("  └─[PASS] Security contact title: %s", *contactInfo.Title)
+		logger.Debug("security contact title is configured", "title", contactInfo.Title)
 	}
 
 	if hasAllFields {
-		log.Println("[PASS] Security contact information is properly configured")
-		return "PASS"
+		logger.Debug("security contact information is properly configured", "status", "PASS")
+		findings.Pass("account", "Security contact information is properly configured")
+		return "PASS", findings
 	}
 
-	log.Println("[FAIL] Security contact information is incomplete")
-	return "FAIL"
+	logger.Info("security contact information is incomplete", "status", "FAIL")
+	findings.Fail("account", "Security contact information is incomplete")
+	return "FAIL", findings
 }
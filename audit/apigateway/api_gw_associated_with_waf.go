@@ -4,89 +4,84 @@ package apigateway
 import (
 	"context"
 	"fmt"
-	"log"
 
+	"aws-security-hub/compliance"
+	"aws-security-hub/inventory"
+	"aws-security-hub/logging"
+	"aws-security-hub/types"
 	"aws-security-hub/util"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/service/apigateway"
-	"github.com/aws/aws-sdk-go-v2/service/wafv2"
-	"github.com/aws/aws-sdk-go-v2/service/wafv2/types"
 )
 
-func CheckApiGwAssociatedWithWaf(cfg aws.Config) string {
-	compliance, err := util.LoadComplianceData("compliance/aws_security_hub.json")
-	if err != nil {
-		log.Printf("[ERROR] Error loading compliance data: %v", err)
-		return "NA"
-	}
-	util.PrintComplianceInfo(compliance, "APIGateway.4")
+func CheckApiGwAssociatedWithWaf(ctx context.Context, cfg aws.Config) string {
+	inv, _ := inventory.Collect(ctx, cfg, inventory.ServiceAPIGateway)
+	status, _ := types.Resolve(EvaluateApiGwAssociatedWithWaf(inv))
+	return status
+}
+
+func EvaluateApiGwAssociatedWithWaf(inv *inventory.Inventory) (string, types.Findings) {
+	var findings types.Findings
+	logger := logging.Control("APIGateway.4", inv)
+
+	util.LogComplianceInfo(logger, compliance.SecurityHub(), "APIGateway.4")
 	/* Description:
 	This control checks whether an API Gateway stage uses an AWS WAF web access control list (ACL). This control fails if an AWS WAF web ACL is not attached to a REST API Gateway stage.
 	*/
 
-	// Create API Gateway client
-	apiClient := apigateway.NewFromConfig(cfg)
+	if inv.APIGateway == nil {
+		return types.NotCollected(logger, inv, inventory.ServiceAPIGateway, &findings), findings
+	}
 
-	// Create WAFv2 client
-	wafClient := wafv2.NewFromConfig(cfg)
+	if len(inv.APIGateway.RestAPIs) == 0 {
+		logger.Info("no REST APIs found")
+		return "NA", findings
+	}
 
-	// Get all REST APIs
-	apis, err := apiClient.GetRestApis(context.TODO(), &apigateway.GetRestApisInput{})
-	if err != nil {
-		log.Printf("[ERROR] Failed to get REST APIs: %v", err)
-		return "NA"
+	if inv.APIGateway.WebACLs == nil {
+		if err := inv.APIGateway.Errors.Find("ListWebACLs"); err != nil {
+			logger.Error("WebACLs not collected", "error", err)
+			findings.Error("WebACLs", err)
+			return "ERROR", findings
+		}
+		logger.Info("WebACLs not collected")
+		return "NA", findings
 	}
 
-	if len(apis.Items) == 0 {
-		log.Println("[*] No REST APIs found")
-		return "NA"
+	// A stage missing from a web ACL whose resources could not be listed may still be associated with it
+	var listErr *inventory.APIError
+	for _, webACL := range inv.APIGateway.WebACLs {
+		if err := webACL.Errors.Find(); err != nil {
+			listErr = err
+		}
 	}
 
 	allAssociated := true
 
-	for _, api := range apis.Items {
-		log.Printf("[*] Checking API: %s", aws.ToString(api.Name))
-
-		// Get stages for each API
-		stages, err := apiClient.GetStages(context.TODO(), &apigateway.GetStagesInput{
-			RestApiId: api.Id,
-		})
-		if err != nil {
-			log.Printf("  └─[ERROR] Failed to get stages for API %s: %v", aws.ToString(api.Name), err)
+	for _, api := range inv.APIGateway.RestAPIs {
+		logger := logger.With("resource", api.ID)
+		logger.Debug("checking API")
+		if err := api.Errors.Find(); err != nil {
+			logger.Warn("stages not collected", "error", err)
+			findings.Error(api.ID, err)
 			continue
 		}
 
-		for _, stage := range stages.Item {
-			log.Printf("  └─[*] Checking stage: %s", aws.ToString(stage.StageName))
+		for _, stage := range api.Stages {
+			logger := logger.With("stage", stage.StageName)
+			logger.Debug("checking stage")
 
 			// Check if the stage is associated with a WAF WebACL
-			webACLs, err := wafClient.ListWebACLs(context.TODO(), &wafv2.ListWebACLsInput{
-				Scope: types.ScopeRegional,
-			})
-			if err != nil {
-				log.Printf("    └─[ERROR] Failed to list WebACLs: %v", err)
-				continue
-			}
+			stageARN := fmt.Sprintf("arn:aws:apigateway:%s::/restapis/%s/stages/%s",
+				inv.Region, api.ID, stage.StageName)
 
 			stageAssociated := false
-			for _, webACL := range webACLs.WebACLs {
-				resources, err := wafClient.ListResourcesForWebACL(context.TODO(), &wafv2.ListResourcesForWebACLInput{
-					WebACLArn:    webACL.ARN,
-					ResourceType: types.ResourceTypeApiGateway,
-				})
-				if err != nil {
-					log.Printf("    └─[ERROR] Failed to list resources for WebACL: %v", err)
-					continue
-				}
-
-				stageARN := fmt.Sprintf("arn:aws:apigateway:%s::/restapis/%s/stages/%s",
-					cfg.Region, aws.ToString(api.Id), aws.ToString(stage.StageName))
-
-				for _, resource := range resources.ResourceArns {
+			for _, webACL := range inv.APIGateway.WebACLs {
+				for _, resource := range webACL.ResourceARNs {
 					if resource == stageARN {
 						stageAssociated = true
-						log.Printf("    └─[PASS] Stage %s is associated with WebACL %s", aws.ToString(stage.StageName), aws.ToString(webACL.Name))
+						logger.Debug("stage is associated with a WebACL", "status", "PASS", "web_acl", webACL.Name)
+						findings.Pass(api.ID+"/"+stage.StageName, fmt.Sprintf("Associated with WebACL %s", webACL.Name))
 						break
 					}
 				}
@@ -96,18 +91,22 @@ func CheckApiGwAssociatedWithWaf(cfg aws.Config) string {
 				}
 			}
 
-			if !stageAssociated {
-				log.Printf("    └─[FAIL] Stage %s is not associated with any WebACL", aws.ToString(stage.StageName))
+			if !stageAssociated && listErr != nil {
+				logger.Warn("stage is not associated with any listed WebACL", "error", listErr)
+				findings.Error(api.ID+"/"+stage.StageName, listErr)
+			} else if !stageAssociated {
+				logger.Info("stage is not associated with any WebACL", "status", "FAIL")
+				findings.Fail(api.ID+"/"+stage.StageName, "Not associated with any WebACL")
 				allAssociated = false
 			}
 		}
 	}
 
 	if allAssociated {
-		log.Println("[PASS] All API Gateway stages are associated with WAF WebACLs")
-		return "PASS"
+		logger.Debug("all API Gateway stages are associated with WAF WebACLs")
+		return "PASS", findings
 	} else {
-		log.Println("[FAIL] One or more API Gateway stages are not associated with WAF WebACLs")
-		return "FAIL"
+		logger.Debug("one or more API Gateway stages are not associated with WAF WebACLs")
+		return "FAIL", findings
 	}
 }
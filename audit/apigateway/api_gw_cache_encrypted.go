@@ -3,60 +3,59 @@ package apigateway
 
 import (
 	"context"
-	"log"
 
+	"aws-security-hub/compliance"
+	"aws-security-hub/inventory"
+	"aws-security-hub/logging"
+	"aws-security-hub/types"
 	"aws-security-hub/util"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/service/apigateway"
 )
 
-func CheckApiGwCacheEncrypted(cfg aws.Config) string {
-	compliance, err := util.LoadComplianceData("compliance/aws_security_hub.json")
-	if err != nil {
-		log.Printf("[ERROR] Error loading compliance data: %v", err)
-		return "NA"
-	}
-	util.PrintComplianceInfo(compliance, "APIGateway.5")
+func CheckApiGwCacheEncrypted(ctx context.Context, cfg aws.Config) string {
+	inv, _ := inventory.Collect(ctx, cfg, inventory.ServiceAPIGateway)
+	status, _ := types.Resolve(EvaluateApiGwCacheEncrypted(inv))
+	return status
+}
+
+func EvaluateApiGwCacheEncrypted(inv *inventory.Inventory) (string, types.Findings) {
+	var findings types.Findings
+	logger := logging.Control("APIGateway.5", inv)
+
+	util.LogComplianceInfo(logger, compliance.SecurityHub(), "APIGateway.5")
 	/* Description:
 	This control checks whether all methods in API Gateway REST API stages that have cache enabled are encrypted. The control fails if any method in an API Gateway REST API stage is configured to cache and the cache is not encrypted. Security Hub evaluates the encryption of a particular method only when caching is enabled for that method.
 	*/
 
-	// Create API Gateway client
-	client := apigateway.NewFromConfig(cfg)
-
-	// Get all REST APIs
-	apis, err := client.GetRestApis(context.TODO(), &apigateway.GetRestApisInput{})
-	if err != nil {
-		log.Printf("[ERROR] Failed to get REST APIs: %v", err)
-		return "NA"
+	if inv.APIGateway == nil {
+		return types.NotCollected(logger, inv, inventory.ServiceAPIGateway, &findings), findings
 	}
 
-	if len(apis.Items) == 0 {
-		log.Println("[*] No REST APIs found")
-		return "NA"
+	if len(inv.APIGateway.RestAPIs) == 0 {
+		logger.Info("no REST APIs found")
+		return "NA", findings
 	}
 
 	allEncrypted := true
 
-	for _, api := range apis.Items {
-		log.Printf("[*] Checking API: %s", aws.ToString(api.Name))
-
-		// Get stages for each API
-		stages, err := client.GetStages(context.TODO(), &apigateway.GetStagesInput{
-			RestApiId: api.Id,
-		})
-		if err != nil {
-			log.Printf("  └─[ERROR] Failed to get stages for API %s: %v", aws.ToString(api.Name), err)
+	for _, api := range inv.APIGateway.RestAPIs {
+		logger := logger.With("resource", api.ID)
+		logger.Debug("checking API")
+		if err := api.Errors.Find(); err != nil {
+			logger.Warn("stages not collected", "error", err)
+			findings.Error(api.ID, err)
 			continue
 		}
 
-		for _, stage := range stages.Item {
-			log.Printf("  └─[*] Checking stage: %s", aws.ToString(stage.StageName))
+		for _, stage := range api.Stages {
+			logger := logger.With("stage", stage.StageName)
+			logger.Debug("checking stage")
 
 			if stage.CacheClusterEnabled {
 				if stage.CacheClusterSize == "" {
-					log.Printf("    └─[FAIL] Cache enabled but size not specified for stage %s", aws.ToString(stage.StageName))
+					logger.Info("cache enabled but size not specified", "status", "FAIL")
+					findings.Fail(api.ID+"/"+stage.StageName, "Cache enabled but size not specified")
 					allEncrypted = false
 					continue
 				}
@@ -71,22 +70,24 @@ func CheckApiGwCacheEncrypted(cfg aws.Config) string {
 				}
 
 				if !cacheEncrypted {
-					log.Printf("    └─[FAIL] Cache encryption is not enabled for stage %s", aws.ToString(stage.StageName))
+					logger.Info("cache encryption is not enabled", "status", "FAIL")
+					findings.Fail(api.ID+"/"+stage.StageName, "Cache encryption is not enabled")
 					allEncrypted = false
 				} else {
-					log.Printf("    └─[PASS] Cache encryption is enabled for stage %s", aws.ToString(stage.StageName))
+					logger.Debug("cache encryption is enabled", "status", "PASS")
+					findings.Pass(api.ID+"/"+stage.StageName, "Cache encryption is enabled")
 				}
 			} else {
-				log.Printf("    └─[INFO] Caching is not enabled for stage %s", aws.ToString(stage.StageName))
+				logger.Debug("caching is not enabled")
 			}
 		}
 	}
 
 	if allEncrypted {
-		log.Println("[PASS] All API Gateway REST API stages with caching enabled have encryption enabled")
-		return "PASS"
+		logger.Debug("all API Gateway REST API stages with caching enabled have encryption enabled")
+		return "PASS", findings
 	} else {
-		log.Println("[FAIL] One or more API Gateway REST API stages with caching enabled do not have encryption enabled")
-		return "FAIL"
+		logger.Debug("one or more API Gateway REST API stages with caching enabled do not have encryption enabled")
+		return "FAIL", findings
 	}
 }
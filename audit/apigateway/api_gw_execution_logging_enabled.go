@@ -3,86 +3,74 @@ package apigateway
 
 import (
 	"context"
-	"log"
+	"log/slog"
 
+	"aws-security-hub/compliance"
+	"aws-security-hub/inventory"
+	"aws-security-hub/logging"
+	"aws-security-hub/types"
 	"aws-security-hub/util"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/service/apigateway"
-	apigatewayv2 "github.com/aws/aws-sdk-go-v2/service/apigatewayv2"
 )
 
-func CheckApiGwExecutionLoggingEnabled(cfg aws.Config) string {
-	compliance, err := util.LoadComplianceData("compliance/aws_security_hub.json")
-	if err != nil {
-		log.Fatalf("[ERROR] Error loading compliance data: %v", err)
-		return "NA"
-	}
-	util.PrintComplianceInfo(compliance, "APIGateway.1")
+func CheckApiGwExecutionLoggingEnabled(ctx context.Context, cfg aws.Config) string {
+	inv, _ := inventory.Collect(ctx, cfg, inventory.ServiceAPIGateway)
+	status, _ := types.Resolve(EvaluateApiGwExecutionLoggingEnabled(inv))
+	return status
+}
+
+func EvaluateApiGwExecutionLoggingEnabled(inv *inventory.Inventory) (string, types.Findings) {
+	var findings types.Findings
+	logger := logging.Control("APIGateway.1", inv)
+
+	util.LogComplianceInfo(logger, compliance.SecurityHub(), "APIGateway.1")
 	/* Description:
 	This control checks whether all stages of an Amazon API Gateway REST or WebSocket API have logging enabled. The control fails if the loggingLevel isn't ERROR or INFO for all stages of the API. Unless you provide custom parameter values to indicate that a specific log type should be enabled, Security Hub produces a passed finding if the logging level is either ERROR or INFO.
 	*/
 
-	// Create API Gateway clients
-	apigatewayClient := apigateway.NewFromConfig(cfg)
-	apigatewayv2Client := apigatewayv2.NewFromConfig(cfg)
+	if inv.APIGateway == nil {
+		return types.NotCollected(logger, inv, inventory.ServiceAPIGateway, &findings), findings
+	}
 
 	// Check REST APIs and their stages
-	log.Println("[*] Checking REST APIs and their stages...")
-	restResult := checkRestAPIs(apigatewayClient)
+	logger.Debug("checking REST APIs and their stages")
+	restResult := checkRestAPIs(logger, inv.APIGateway.RestAPIs, &findings)
 
 	// Check WebSocket APIs and their stages
-	log.Println("[*] Checking WebSocket APIs and their stages...")
-	webSocketResult := checkWebSocketAPIs(apigatewayv2Client)
+	logger.Debug("checking WebSocket APIs and their stages")
+	webSocketResult := checkWebSocketAPIs(logger, inv.APIGateway.APIs, &findings)
 
 	// Determine overall result
 	if restResult == "NA" && webSocketResult == "NA" {
-		return "NA"
+		return "NA", findings
 	} else if restResult == "FAIL" || webSocketResult == "FAIL" {
-		return "FAIL"
+		return "FAIL", findings
 	} else if restResult == "PASS" && webSocketResult == "PASS" {
-		return "PASS"
+		return "PASS", findings
 	}
 
-	return "NA"
+	return "NA", findings
 }
 
-func checkRestAPIs(client *apigateway.Client) string {
-	var position *string
-	allEnabled := true
-	hasAPIs := false
-
-	for {
-		input := &apigateway.GetRestApisInput{
-			Limit: aws.Int32(500), // Maximum allowed value
-		}
-		if position != nil {
-			input.Position = position
-		}
-
-		output, err := client.GetRestApis(context.TODO(), input)
-		if err != nil {
-			log.Printf("└─[ERROR] Failed to get REST APIs: %v", err)
-			return "NA"
-		}
+func checkRestAPIs(logger *slog.Logger, apis []inventory.RestAPI, findings *types.Findings) string {
+	if len(apis) == 0 {
+		logger.Info("no REST APIs found")
+		return "PASS" // No APIs found, so consider it as compliant
+	}
 
-		for _, api := range output.Items {
-			hasAPIs = true
-			log.Printf("└─[+] REST API: %s (ID: %s)\n", aws.ToString(api.Name), aws.ToString(api.Id))
-			if !checkRestAPIStages(client, aws.ToString(api.Id)) {
-				allEnabled = false
-			}
+	allEnabled := true
+	for _, api := range apis {
+		logger := logger.With("resource", api.ID)
+		logger.Debug("checking REST API", "api_id", api.ID)
+		if err := api.Errors.Find(); err != nil {
+			logger.Warn("stages not collected", "error", err)
+			findings.Error(api.ID, err)
+			continue
 		}
-
-		if output.Position == nil {
-			break
+		if !checkRestAPIStages(logger, api.ID, api.Stages, findings) {
+			allEnabled = false
 		}
-		position = output.Position
-	}
-
-	if !hasAPIs {
-		log.Println("└─[*] No REST APIs found")
-		return "PASS" // No APIs found, so consider it as compliant
 	}
 
 	if allEnabled {
@@ -91,72 +79,51 @@ func checkRestAPIs(client *apigateway.Client) string {
 	return "FAIL"
 }
 
-func checkRestAPIStages(client *apigateway.Client, apiID string) bool {
-	input := &apigateway.GetStagesInput{
-		RestApiId: aws.String(apiID),
-	}
-	output, err := client.GetStages(context.TODO(), input)
-	if err != nil {
-		log.Printf("  └─[ERROR] Failed to get stages for REST API %s: %v", apiID, err)
-		return false
-	}
-
+func checkRestAPIStages(logger *slog.Logger, apiID string, stages []inventory.RestStage, findings *types.Findings) bool {
 	allEnabled := true
-	for _, stage := range output.Item {
-		log.Printf("  └─[*] Stage: %s\n", aws.ToString(stage.StageName))
+	for _, stage := range stages {
+		logger := logger.With("stage", stage.StageName)
+		logger.Debug("checking stage")
 		loggingEnabled := false
 		for _, settings := range stage.MethodSettings {
-			if settings.LoggingLevel != nil && *settings.LoggingLevel != "" && *settings.LoggingLevel != "OFF" {
+			if settings.LoggingLevel != "" && settings.LoggingLevel != "OFF" {
 				loggingEnabled = true
-				log.Printf("    └─[PASS] Logging: Enabled (Level: %s)\n", *settings.LoggingLevel)
+				logger.Debug("execution logging enabled", "status", "PASS", "logging_level", settings.LoggingLevel)
+				findings.Pass(apiID+"/"+stage.StageName, "Logging level "+settings.LoggingLevel)
 				break
 			}
 		}
 		if !loggingEnabled {
-			log.Printf("    └─[FAIL] Logging: Not enabled\n")
+			logger.Info("execution logging not enabled", "status", "FAIL")
+			findings.Fail(apiID+"/"+stage.StageName, "Execution logging is not enabled")
 			allEnabled = false
 		}
 	}
 	return allEnabled
 }
 
-func checkWebSocketAPIs(client *apigatewayv2.Client) string {
-	var nextToken *string
+func checkWebSocketAPIs(logger *slog.Logger, apis []inventory.API, findings *types.Findings) string {
 	allEnabled := true
 	hasAPIs := false
 
-	for {
-		input := &apigatewayv2.GetApisInput{
-			MaxResults: aws.String("100"), // Maximum allowed value as a string
-		}
-		if nextToken != nil {
-			input.NextToken = nextToken
-		}
-
-		output, err := client.GetApis(context.TODO(), input)
-		if err != nil {
-			log.Printf("└─[ERROR] Failed to get WebSocket APIs: %v", err)
-			return "NA"
-		}
-
-		for _, api := range output.Items {
-			if api.ProtocolType == "WEBSOCKET" {
-				hasAPIs = true
-				log.Printf("└─[+] WebSocket API: %s (ID: %s)\n", aws.ToString(api.Name), aws.ToString(api.ApiId))
-				if !checkWebSocketAPIStages(client, aws.ToString(api.ApiId)) {
-					allEnabled = false
-				}
+	for _, api := range apis {
+		if api.ProtocolType == "WEBSOCKET" {
+			hasAPIs = true
+			logger := logger.With("resource", api.ID)
+			logger.Debug("checking WebSocket API", "api_id", api.ID)
+			if err := api.Errors.Find("GetStages"); err != nil {
+				logger.Warn("stages not collected", "error", err)
+				findings.Error(api.ID, err)
+				continue
+			}
+			if !checkWebSocketAPIStages(logger, api.ID, api.Stages, findings) {
+				allEnabled = false
 			}
 		}
-
-		if output.NextToken == nil {
-			break
-		}
-		nextToken = output.NextToken
 	}
 
 	if !hasAPIs {
-		log.Println("└─[*] No WebSocket APIs found")
+		logger.Info("no WebSocket APIs found")
 		return "PASS" // No APIs found, so consider it as compliant
 	}
 
@@ -166,24 +133,18 @@ func checkWebSocketAPIs(client *apigatewayv2.Client) string {
 	return "FAIL"
 }
 
-func checkWebSocketAPIStages(client *apigatewayv2.Client, apiID string) bool {
-	input := &apigatewayv2.GetStagesInput{
-		ApiId: aws.String(apiID),
-	}
-	output, err := client.GetStages(context.TODO(), input)
-	if err != nil {
-		log.Printf("  └─[ERROR] Failed to get stages for WebSocket API %s: %v", apiID, err)
-		return false
-	}
-
+func checkWebSocketAPIStages(logger *slog.Logger, apiID string, stages []inventory.Stage, findings *types.Findings) bool {
 	allEnabled := true
-	for _, stage := range output.Items {
-		log.Printf("  └─[*] Stage: %s\n", aws.ToString(stage.StageName))
-		if stage.DefaultRouteSettings == nil || stage.DefaultRouteSettings.LoggingLevel == "OFF" {
-			log.Printf("    └─[FAIL] Logging: Not enabled\n")
+	for _, stage := range stages {
+		logger := logger.With("stage", stage.StageName)
+		logger.Debug("checking stage")
+		if stage.DefaultRouteLoggingLevel == "" || stage.DefaultRouteLoggingLevel == "OFF" {
+			logger.Info("execution logging not enabled", "status", "FAIL")
+			findings.Fail(apiID+"/"+stage.StageName, "Execution logging is not enabled")
 			allEnabled = false
 		} else {
-			log.Printf("    └─[PASS] Logging: Enabled (Level: %s)\n", string(stage.DefaultRouteSettings.LoggingLevel))
+			logger.Debug("execution logging enabled", "status", "PASS", "logging_level", stage.DefaultRouteLoggingLevel)
+			findings.Pass(apiID+"/"+stage.StageName, "Logging level "+stage.DefaultRouteLoggingLevel)
 		}
 	}
 	return allEnabled
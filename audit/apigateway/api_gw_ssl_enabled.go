@@ -3,71 +3,61 @@ package apigateway
 
 import (
 	"context"
-	"log"
+	"log/slog"
 
+	"aws-security-hub/compliance"
+	"aws-security-hub/inventory"
+	"aws-security-hub/logging"
+	"aws-security-hub/types"
 	"aws-security-hub/util"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/service/apigateway"
 )
 
-func CheckApiGwSslEnabled(cfg aws.Config) string {
-	compliance, err := util.LoadComplianceData("compliance/aws_security_hub.json")
-	if err != nil {
-		log.Printf("└─[ERROR] Error loading compliance data: %v", err)
-		return "NA"
-	}
-	util.PrintComplianceInfo(compliance, "APIGateway.2")
+func CheckApiGwSslEnabled(ctx context.Context, cfg aws.Config) string {
+	inv, _ := inventory.Collect(ctx, cfg, inventory.ServiceAPIGateway)
+	status, _ := types.Resolve(EvaluateApiGwSslEnabled(inv))
+	return status
+}
+
+func EvaluateApiGwSslEnabled(inv *inventory.Inventory) (string, types.Findings) {
+	var findings types.Findings
+	logger := logging.Control("APIGateway.2", inv)
+
+	util.LogComplianceInfo(logger, compliance.SecurityHub(), "APIGateway.2")
 	/* Description:
 	This control checks whether Amazon API Gateway REST API stages have SSL certificates configured. Backend systems use these certificates to authenticate that incoming requests are from API Gateway.
 	*/
 
-	// Create API Gateway client
-	apigatewayClient := apigateway.NewFromConfig(cfg)
+	if inv.APIGateway == nil {
+		return types.NotCollected(logger, inv, inventory.ServiceAPIGateway, &findings), findings
+	}
 
 	// Check REST APIs and their stages
-	log.Println("[*] Checking REST APIs and their stages for SSL certificates...")
-	result := checkRestAPIsForSSL(apigatewayClient)
+	logger.Debug("checking REST APIs and their stages for SSL certificates")
+	result := checkRestAPIsForSSL(logger, inv.APIGateway.RestAPIs, &findings)
 
-	return result
+	return result, findings
 }
 
-func checkRestAPIsForSSL(client *apigateway.Client) string {
-	var position *string
-	allEnabled := true
-	hasAPIs := false
-
-	for {
-		input := &apigateway.GetRestApisInput{
-			Limit: aws.Int32(500), // Maximum allowed value
-		}
-		if position != nil {
-			input.Position = position
-		}
-
-		output, err := client.GetRestApis(context.TODO(), input)
-		if err != nil {
-			log.Printf("└─[ERROR] Failed to get REST APIs: %v", err)
-			return "NA"
-		}
+func checkRestAPIsForSSL(logger *slog.Logger, apis []inventory.RestAPI, findings *types.Findings) string {
+	if len(apis) == 0 {
+		logger.Info("no REST APIs found")
+		return "NA"
+	}
 
-		for _, api := range output.Items {
-			hasAPIs = true
-			log.Printf("└─[+] REST API: %s (ID: %s)", aws.ToString(api.Name), aws.ToString(api.Id))
-			if !checkStagesForSSL(client, api.Id) {
-				allEnabled = false
-			}
+	allEnabled := true
+	for _, api := range apis {
+		logger := logger.With("resource", api.ID)
+		logger.Debug("checking REST API", "api_id", api.ID)
+		if err := api.Errors.Find(); err != nil {
+			logger.Warn("stages not collected", "error", err)
+			findings.Error(api.ID, err)
+			continue
 		}
-
-		if output.Position == nil {
-			break
+		if !checkStagesForSSL(logger, api.ID, api.Stages, findings) {
+			allEnabled = false
 		}
-		position = output.Position
-	}
-
-	if !hasAPIs {
-		log.Println("└─[*] No REST APIs found")
-		return "NA"
 	}
 
 	if allEnabled {
@@ -76,24 +66,18 @@ func checkRestAPIsForSSL(client *apigateway.Client) string {
 	return "FAIL"
 }
 
-func checkStagesForSSL(client *apigateway.Client, apiID *string) bool {
-	input := &apigateway.GetStagesInput{
-		RestApiId: apiID,
-	}
-	output, err := client.GetStages(context.TODO(), input)
-	if err != nil {
-		log.Printf("  └─[ERROR] Failed to get stages for REST API %s: %v", aws.ToString(apiID), err)
-		return false
-	}
-
+func checkStagesForSSL(logger *slog.Logger, apiID string, stages []inventory.RestStage, findings *types.Findings) bool {
 	allStagesSecure := true
-	for _, stage := range output.Item {
-		log.Printf("  └─[*] Stage: %s", aws.ToString(stage.StageName))
+	for _, stage := range stages {
+		logger := logger.With("stage", stage.StageName)
+		logger.Debug("checking stage")
 
-		if stage.ClientCertificateId != nil && *stage.ClientCertificateId != "" {
-			log.Printf("    └─[PASS] SSL certificate configured (ID: %s)", aws.ToString(stage.ClientCertificateId))
+		if stage.ClientCertificateID != "" {
+			logger.Debug("SSL certificate configured", "status", "PASS", "client_certificate", stage.ClientCertificateID)
+			findings.Pass(apiID+"/"+stage.StageName, "Client certificate "+stage.ClientCertificateID)
 		} else {
-			log.Printf("    └─[FAIL] SSL certificate not configured")
+			logger.Info("SSL certificate not configured", "status", "FAIL")
+			findings.Fail(apiID+"/"+stage.StageName, "SSL certificate not configured")
 			allStagesSecure = false
 		}
 	}
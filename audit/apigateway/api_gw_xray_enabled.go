@@ -3,71 +3,71 @@ package apigateway
 
 import (
 	"context"
-	"log"
 
+	"aws-security-hub/compliance"
+	"aws-security-hub/inventory"
+	"aws-security-hub/logging"
+	"aws-security-hub/types"
 	"aws-security-hub/util"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/service/apigateway"
 )
 
-func CheckApiGwXrayEnabled(cfg aws.Config) string {
-	compliance, err := util.LoadComplianceData("compliance/aws_security_hub.json")
-	if err != nil {
-		log.Printf("[ERROR] Error loading compliance data: %v", err)
-		return "NA"
-	}
-	util.PrintComplianceInfo(compliance, "APIGateway.3")
+func CheckApiGwXrayEnabled(ctx context.Context, cfg aws.Config) string {
+	inv, _ := inventory.Collect(ctx, cfg, inventory.ServiceAPIGateway)
+	status, _ := types.Resolve(EvaluateApiGwXrayEnabled(inv))
+	return status
+}
+
+func EvaluateApiGwXrayEnabled(inv *inventory.Inventory) (string, types.Findings) {
+	var findings types.Findings
+	logger := logging.Control("APIGateway.3", inv)
+
+	util.LogComplianceInfo(logger, compliance.SecurityHub(), "APIGateway.3")
 	/* Description:
 	This control checks whether AWS X-Ray active tracing is enabled for your Amazon API Gateway REST API stages.
 	*/
 
-	// Create API Gateway client
-	client := apigateway.NewFromConfig(cfg)
-
-	// Get all REST APIs
-	apis, err := client.GetRestApis(context.TODO(), &apigateway.GetRestApisInput{})
-	if err != nil {
-		log.Printf("[ERROR] Failed to get REST APIs: %v", err)
-		return "NA"
+	if inv.APIGateway == nil {
+		return types.NotCollected(logger, inv, inventory.ServiceAPIGateway, &findings), findings
 	}
 
-	if len(apis.Items) == 0 {
-		log.Println("[*] No REST APIs found")
-		return "NA"
+	if len(inv.APIGateway.RestAPIs) == 0 {
+		logger.Info("no REST APIs found")
+		return "NA", findings
 	}
 
 	allEnabled := true
 
-	for _, api := range apis.Items {
-		log.Printf("[*] Checking API: %s", aws.ToString(api.Name))
-
-		// Get stages for each API
-		stages, err := client.GetStages(context.TODO(), &apigateway.GetStagesInput{
-			RestApiId: api.Id,
-		})
-		if err != nil {
-			log.Printf("  └─[ERROR] Failed to get stages for API %s: %v", aws.ToString(api.Name), err)
+	for _, api := range inv.APIGateway.RestAPIs {
+		logger := logger.With("resource", api.ID)
+		logger.Debug("checking API")
+		if err := api.Errors.Find(); err != nil {
+			logger.Warn("stages not collected", "error", err)
+			findings.Error(api.ID, err)
 			continue
 		}
 
-		for _, stage := range stages.Item {
-			log.Printf("  └─[*] Checking stage: %s", aws.ToString(stage.StageName))
+		for _, stage := range api.Stages {
+			logger := logger.With("stage", stage.StageName)
+			logger.Debug("checking stage")
 
 			if stage.TracingEnabled {
-				log.Printf("    └─[PASS] X-Ray tracing enabled for stage %s", aws.ToString(stage.StageName))
+				logger.Debug("X-Ray tracing enabled", "status", "PASS")
+				findings.Pass(api.ID+"/"+stage.StageName, "X-Ray tracing enabled")
 			} else {
-				log.Printf("    └─[FAIL] X-Ray tracing disabled for stage %s", aws.ToString(stage.StageName))
+				logger.Info("X-Ray tracing disabled", "status", "FAIL")
+				findings.Fail(api.ID+"/"+stage.StageName, "X-Ray tracing disabled")
 				allEnabled = false
 			}
 		}
 	}
 
 	if allEnabled {
-		log.Println("[PASS] X-Ray tracing is enabled for all API Gateway REST API stages")
-		return "PASS"
+		logger.Debug("X-Ray tracing is enabled for all API Gateway REST API stages")
+		return "PASS", findings
 	} else {
-		log.Println("[FAIL] X-Ray tracing is not enabled for one or more API Gateway REST API stages")
-		return "FAIL"
+		logger.Debug("X-Ray tracing is not enabled for one or more API Gateway REST API stages")
+		return "FAIL", findings
 	}
 }
@@ -3,72 +3,71 @@ package apigateway
 
 import (
 	"context"
-	"log"
 
+	"aws-security-hub/compliance"
+	"aws-security-hub/inventory"
+	"aws-security-hub/logging"
+	"aws-security-hub/types"
 	"aws-security-hub/util"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/service/apigatewayv2"
 )
 
-func CheckApiGwv2AccessLogsEnabled(cfg aws.Config) string {
-	compliance, err := util.LoadComplianceData("compliance/aws_security_hub.json")
-	if err != nil {
-		log.Printf("[ERROR] Error loading compliance data: %v", err)
-		return "NA"
-	}
-	util.PrintComplianceInfo(compliance, "APIGateway.9")
+func CheckApiGwv2AccessLogsEnabled(ctx context.Context, cfg aws.Config) string {
+	inv, _ := inventory.Collect(ctx, cfg, inventory.ServiceAPIGateway)
+	status, _ := types.Resolve(EvaluateApiGwv2AccessLogsEnabled(inv))
+	return status
+}
+
+func EvaluateApiGwv2AccessLogsEnabled(inv *inventory.Inventory) (string, types.Findings) {
+	var findings types.Findings
+	logger := logging.Control("APIGateway.9", inv)
+
+	util.LogComplianceInfo(logger, compliance.SecurityHub(), "APIGateway.9")
 	/* Description:
 	This control checks if Amazon API Gateway V2 stages have access logging configured. This control fails if access log settings aren't defined.
 	*/
 
-	// Create API Gateway V2 client
-	client := apigatewayv2.NewFromConfig(cfg)
-
-	// Get all APIs
-	apis, err := client.GetApis(context.TODO(), &apigatewayv2.GetApisInput{})
-	if err != nil {
-		log.Printf("[ERROR] Failed to get APIs: %v", err)
-		return "NA"
+	if inv.APIGateway == nil {
+		return types.NotCollected(logger, inv, inventory.ServiceAPIGateway, &findings), findings
 	}
 
-	if len(apis.Items) == 0 {
-		log.Println("[*] No APIs found")
-		return "NA"
+	if len(inv.APIGateway.APIs) == 0 {
+		logger.Info("no APIs found")
+		return "NA", findings
 	}
 
 	allLogsEnabled := true
 
-	for _, api := range apis.Items {
-		log.Printf("[*] Checking API: %s", aws.ToString(api.Name))
-
-		// Get stages for each API
-		stages, err := client.GetStages(context.TODO(), &apigatewayv2.GetStagesInput{
-			ApiId: api.ApiId,
-		})
-		if err != nil {
-			log.Printf("  └─[ERROR] Failed to get stages for API %s: %v", aws.ToString(api.Name), err)
+	for _, api := range inv.APIGateway.APIs {
+		logger := logger.With("resource", api.ID)
+		logger.Debug("checking API")
+		if err := api.Errors.Find("GetStages"); err != nil {
+			logger.Warn("stages not collected", "error", err)
+			findings.Error(api.ID, err)
 			continue
 		}
 
-		for _, stage := range stages.Items {
-			log.Printf("  └─[*] Checking stage: %s", aws.ToString(stage.StageName))
+		for _, stage := range api.Stages {
+			logger := logger.With("stage", stage.StageName)
+			logger.Debug("checking stage")
 
-			if stage.AccessLogSettings == nil || stage.AccessLogSettings.DestinationArn == nil {
-				log.Printf("    └─[FAIL] Access logging not configured for stage %s", aws.ToString(stage.StageName))
+			if stage.AccessLogDestinationARN == "" {
+				logger.Info("access logging not configured", "status", "FAIL")
+				findings.Fail(api.ID+"/"+stage.StageName, "Access logging not configured")
 				allLogsEnabled = false
 			} else {
-				log.Printf("    └─[PASS] Access logging configured for stage %s. Destination ARN: %s",
-					aws.ToString(stage.StageName), aws.ToString(stage.AccessLogSettings.DestinationArn))
+				logger.Debug("access logging configured", "status", "PASS", "destination", stage.AccessLogDestinationARN)
+				findings.Pass(api.ID+"/"+stage.StageName, "Access logs delivered to "+stage.AccessLogDestinationARN)
 			}
 		}
 	}
 
 	if allLogsEnabled {
-		log.Println("[PASS] All API Gateway V2 stages have access logging configured")
-		return "PASS"
+		logger.Debug("all API Gateway V2 stages have access logging configured")
+		return "PASS", findings
 	} else {
-		log.Println("[FAIL] One or more API Gateway V2 stages do not have access logging configured")
-		return "FAIL"
+		logger.Debug("one or more API Gateway V2 stages do not have access logging configured")
+		return "FAIL", findings
 	}
 }
@@ -3,74 +3,72 @@ package apigateway
 
 import (
 	"context"
-	"log"
 
+	"aws-security-hub/compliance"
+	"aws-security-hub/inventory"
+	"aws-security-hub/logging"
+	"aws-security-hub/types"
 	"aws-security-hub/util"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/service/apigatewayv2"
 )
 
-func CheckApiGwv2AuthorizationTypeConfigured(cfg aws.Config) string {
-	compliance, err := util.LoadComplianceData("compliance/aws_security_hub.json")
-	if err != nil {
-		log.Printf("[ERROR] Error loading compliance data: %v", err)
-		return "NA"
-	}
-	util.PrintComplianceInfo(compliance, "APIGateway.8")
+func CheckApiGwv2AuthorizationTypeConfigured(ctx context.Context, cfg aws.Config) string {
+	inv, _ := inventory.Collect(ctx, cfg, inventory.ServiceAPIGateway)
+	status, _ := types.Resolve(EvaluateApiGwv2AuthorizationTypeConfigured(inv))
+	return status
+}
+
+func EvaluateApiGwv2AuthorizationTypeConfigured(inv *inventory.Inventory) (string, types.Findings) {
+	var findings types.Findings
+	logger := logging.Control("APIGateway.8", inv)
+
+	util.LogComplianceInfo(logger, compliance.SecurityHub(), "APIGateway.8")
 	/* Description:
 	This control checks if Amazon API Gateway routes have an authorization type. The control fails if the API Gateway route doesn't have any authorization type. Optionally, you can provide a custom parameter value if you want the control to pass only if the route uses the authorization type specified in the authorizationType parameter.
 	*/
 
-	// Create API Gateway v2 client
-	client := apigatewayv2.NewFromConfig(cfg)
-
-	// Get all APIs
-	apis, err := client.GetApis(context.TODO(), &apigatewayv2.GetApisInput{})
-	if err != nil {
-		log.Printf("[ERROR] Failed to get APIs: %v", err)
-		return "NA"
+	if inv.APIGateway == nil {
+		return types.NotCollected(logger, inv, inventory.ServiceAPIGateway, &findings), findings
 	}
 
-	if len(apis.Items) == 0 {
-		log.Println("[*] No APIs found")
-		return "NA"
+	if len(inv.APIGateway.APIs) == 0 {
+		logger.Info("no APIs found")
+		return "NA", findings
 	}
 
 	allConfigured := true
 	validAuthTypes := map[string]bool{"AWS_IAM": true, "CUSTOM": true, "JWT": true}
 
-	for _, api := range apis.Items {
-		log.Printf("[*] Checking API: %s", aws.ToString(api.Name))
-
-		// Get routes for each API
-		routes, err := client.GetRoutes(context.TODO(), &apigatewayv2.GetRoutesInput{
-			ApiId: api.ApiId,
-		})
-		if err != nil {
-			log.Printf("  └─[ERROR] Failed to get routes for API %s: %v", aws.ToString(api.Name), err)
+	for _, api := range inv.APIGateway.APIs {
+		logger := logger.With("resource", api.ID)
+		logger.Debug("checking API")
+		if err := api.Errors.Find("GetRoutes"); err != nil {
+			logger.Warn("routes not collected", "error", err)
+			findings.Error(api.ID, err)
 			continue
 		}
 
-		for _, route := range routes.Items {
-			log.Printf("  └─[*] Checking route: %s", aws.ToString(route.RouteKey))
+		for _, route := range api.Routes {
+			logger := logger.With("route", route.RouteKey)
+			logger.Debug("checking route")
 
-			if !validAuthTypes[string(route.AuthorizationType)] {
-				log.Printf("    └─[FAIL] Invalid or no authorization type configured for route %s. Type: %s",
-					aws.ToString(route.RouteKey), route.AuthorizationType)
+			if !validAuthTypes[route.AuthorizationType] {
+				logger.Info("invalid or no authorization type configured", "status", "FAIL", "authorization_type", route.AuthorizationType)
+				findings.Fail(api.ID+"/"+route.RouteKey, "Invalid or no authorization type: "+route.AuthorizationType)
 				allConfigured = false
 			} else {
-				log.Printf("    └─[PASS] Valid authorization type %s configured for route %s",
-					route.AuthorizationType, aws.ToString(route.RouteKey))
+				logger.Debug("valid authorization type configured", "status", "PASS", "authorization_type", route.AuthorizationType)
+				findings.Pass(api.ID+"/"+route.RouteKey, "Authorization type "+route.AuthorizationType)
 			}
 		}
 	}
 
 	if allConfigured {
-		log.Println("[PASS] All API Gateway routes have a valid authorization type configured (AWS_IAM, CUSTOM, or JWT)")
-		return "PASS"
+		logger.Debug("all API Gateway routes have a valid authorization type configured (AWS_IAM, CUSTOM, or JWT)")
+		return "PASS", findings
 	} else {
-		log.Println("[FAIL] One or more API Gateway routes do not have a valid authorization type configured (AWS_IAM, CUSTOM, or JWT)")
-		return "FAIL"
+		logger.Debug("one or more API Gateway routes do not have a valid authorization type configured (AWS_IAM, CUSTOM, or JWT)")
+		return "FAIL", findings
 	}
 }
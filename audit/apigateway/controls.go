@@ -0,0 +1,26 @@
+// audit/apigateway/controls.go
+package apigateway
+
+import (
+	"aws-security-hub/types"
+)
+
+// GetControls returns all API Gateway related controls
+func GetControls() []types.Control {
+	return []types.Control{
+		types.InventoryControl{ID: "APIGateway.1", Check: "api-gw-execution-logging-enabled", Func: EvaluateApiGwExecutionLoggingEnabled,
+			Actions: []string{"apigateway:GET"}},
+		types.InventoryControl{ID: "APIGateway.2", Check: "api-gw-ssl-enabled", Func: EvaluateApiGwSslEnabled,
+			Actions: []string{"apigateway:GET"}},
+		types.InventoryControl{ID: "APIGateway.3", Check: "api-gw-xray-enabled", Func: EvaluateApiGwXrayEnabled,
+			Actions: []string{"apigateway:GET"}},
+		types.InventoryControl{ID: "APIGateway.4", Check: "api-gw-associated-with-waf", Func: EvaluateApiGwAssociatedWithWaf,
+			Actions: []string{"apigateway:GET", "wafv2:ListWebACLs", "wafv2:ListResourcesForWebACL"}},
+		types.InventoryControl{ID: "APIGateway.5", Check: "api-gw-cache-encrypted", Func: EvaluateApiGwCacheEncrypted,
+			Actions: []string{"apigateway:GET"}},
+		types.InventoryControl{ID: "APIGateway.8", Check: "api-gwv2-authorization-type-configured", Func: EvaluateApiGwv2AuthorizationTypeConfigured,
+			Actions: []string{"apigateway:GET"}},
+		types.InventoryControl{ID: "APIGateway.9", Check: "api-gwv2-access-logs-enabled", Func: EvaluateApiGwv2AccessLogsEnabled,
+			Actions: []string{"apigateway:GET"}},
+	}
+}
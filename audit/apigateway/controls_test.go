@@ -0,0 +1,103 @@
+// audit/apigateway/controls_test.go
+package apigateway
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"aws-security-hub/inventory"
+	"aws-security-hub/inventory/inventorytest"
+)
+
+// apisAPI answers the API Gateway calls with two pages of v2 APIs, each with two pages of stages,
+// the stage without access logs on the last page
+func apisAPI(r inventorytest.Request) (int, string) {
+	next := r.Params.Get("nextToken")
+	switch {
+	case r.Path == "/restapis":
+		return http.StatusOK, `{"item":[]}`
+	case strings.HasPrefix(r.Host, "wafv2."):
+		return http.StatusOK, `{"WebACLs":[]}`
+	case r.Path == "/v2/apis":
+		if next == "" {
+			return http.StatusOK, `{"items":[{"apiId":"a1","name":"first","protocolType":"HTTP"}],"nextToken":"apis-2"}`
+		}
+		return http.StatusOK, `{"items":[{"apiId":"a2","name":"last","protocolType":"HTTP"}]}`
+	case strings.HasSuffix(r.Path, "/stages"):
+		if next == "" {
+			return http.StatusOK, `{"items":[{"stageName":"prod","accessLogSettings":{"destinationArn":"arn:aws:logs:ap-northeast-2:123456789012:log-group:api"}}],"nextToken":"stages-2"}`
+		}
+		return http.StatusOK, `{"items":[{"stageName":"dev"}]}`
+	case strings.HasSuffix(r.Path, "/routes"):
+		return http.StatusOK, `{"items":[{"routeKey":"GET /","authorizationType":"JWT"}]}`
+	}
+	return inventorytest.NotFound(r)
+}
+
+func TestApiGwv2AccessLogsEnabledEveryPage(t *testing.T) {
+	cfg, _ := inventorytest.Config(apisAPI)
+	inv, err := inventory.Collect(context.Background(), cfg, inventory.ServiceAPIGateway)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	status, findings := EvaluateApiGwv2AccessLogsEnabled(inv)
+	if status != "FAIL" {
+		t.Errorf("status = %s, want FAIL", status)
+	}
+	want := map[string]string{"a1/prod": "PASS", "a1/dev": "FAIL", "a2/prod": "PASS", "a2/dev": "FAIL"}
+	for _, finding := range findings {
+		if want[finding.Resource] != finding.Status {
+			t.Errorf("%s = %s, want %s", finding.Resource, finding.Status, want[finding.Resource])
+		}
+		delete(want, finding.Resource)
+	}
+	if len(want) > 0 {
+		t.Errorf("resources missing from the findings: %v", want)
+	}
+}
+
+// restAPIsAPI answers GetRestApis with two pages, the API whose stage has no web ACL on the last one
+func restAPIsAPI(r inventorytest.Request) (int, string) {
+	switch {
+	case r.Path == "/restapis":
+		if r.Params.Get("position") == "" {
+			return http.StatusOK, `{"item":[{"id":"r1","name":"first"}],"position":"apis-2"}`
+		}
+		return http.StatusOK, `{"item":[{"id":"r2","name":"last"}]}`
+	case strings.HasPrefix(r.Path, "/restapis/") && strings.HasSuffix(r.Path, "/stages"):
+		return http.StatusOK, `{"item":[{"stageName":"prod"}]}`
+	case r.Path == "/v2/apis":
+		return http.StatusOK, `{"items":[]}`
+	case strings.HasPrefix(r.Host, "wafv2.") && strings.Contains(r.Body, "WebACLArn"):
+		return http.StatusOK, `{"ResourceArns":["arn:aws:apigateway:ap-northeast-2::/restapis/r1/stages/prod"]}`
+	case strings.HasPrefix(r.Host, "wafv2."):
+		return http.StatusOK, `{"WebACLs":[{"Name":"api","ARN":"arn:aws:wafv2:ap-northeast-2:123456789012:regional/webacl/api/1"}]}`
+	}
+	return inventorytest.NotFound(r)
+}
+
+func TestApiGwAssociatedWithWafEveryPage(t *testing.T) {
+	cfg, _ := inventorytest.Config(restAPIsAPI)
+	inv, err := inventory.Collect(context.Background(), cfg, inventory.ServiceAPIGateway)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	status, findings := EvaluateApiGwAssociatedWithWaf(inv)
+	if status != "FAIL" {
+		t.Errorf("status = %s, want FAIL", status)
+	}
+	want := map[string]string{"r1/prod": "PASS", "r2/prod": "FAIL"}
+	for _, finding := range findings {
+		if want[finding.Resource] != finding.Status {
+			t.Errorf("%s = %s, want %s", finding.Resource, finding.Status, want[finding.Resource])
+		}
+		delete(want, finding.Resource)
+	}
+	if len(want) > 0 {
+		t.Errorf("resources missing from the findings: %v", want)
+	}
+}
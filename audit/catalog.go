@@ -0,0 +1,275 @@
+// audit/catalog.go
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"aws-security-hub/coverage"
+	"aws-security-hub/types"
+	"aws-security-hub/util"
+)
+
+// cisVersion is the CIS benchmark of compliance/cis_*.json, whose requirement titles are shown with mappings
+const cisVersion = "CIS AWS Foundations Benchmark v3.0.0/"
+
+// Entry is a control of the catalogue, implemented or not
+type Entry struct {
+	ID          string           `json:"ID"`
+	Check       string           `json:"Check,omitempty"`
+	Service     string           `json:"Service"`
+	Description string           `json:"Description"`
+	Severity    string           `json:"Severity,omitempty"`
+	Implemented bool             `json:"Implemented"`
+	Attributes  []util.Attribute `json:"Attributes,omitempty"`
+	Parameters  []ParameterInfo  `json:"Parameters,omitempty"`
+	Permissions []string         `json:"Permissions,omitempty"`
+	Mappings    []Mapping        `json:"Mappings,omitempty"`
+}
+
+// ParameterInfo describes a control parameter
+type ParameterInfo struct {
+	Name        string `json:"Name"`
+	Description string `json:"Description"`
+	Default     string `json:"Default"`
+}
+
+// Mapping is a requirement of another framework that a control maps to
+type Mapping struct {
+	Framework   string `json:"Framework,omitempty"` // cis, nist-800-53 or pci-dss; empty when unknown
+	Requirement string `json:"Requirement"`         // e.g. NIST.800-53.r5 AC-21
+	Title       string `json:"Title,omitempty"`     // title of the CIS v3.0.0 requirement, when known
+}
+
+// Catalog lists the controls of the Security Hub catalogue, with the compliance attributes of those in
+// the compliance JSON, then the implemented controls that are not in either (user-authored rules). cis
+// resolves the titles of CIS v3.0.0 mappings and may be nil.
+func Catalog(controls []types.Control, compliance, cis *util.Compliance, securityhub coverage.Catalogue) []Entry {
+	implemented := make(map[string]types.Control)
+	for _, control := range controls {
+		implemented[control.Metadata().ID] = control
+	}
+	requirements := make(map[string]util.Requirement)
+	for _, requirement := range compliance.Requirements {
+		requirements[requirement.Id] = requirement
+	}
+
+	var entries []Entry
+	listed := make(map[string]bool)
+	add := func(entry Entry, checks []string) {
+		if control, ok := implemented[entry.ID]; ok {
+			entry.withControl(control)
+		} else if len(checks) > 0 {
+			entry.Check = checks[0]
+		}
+		entries = append(entries, entry)
+		listed[entry.ID] = true
+	}
+	for _, control := range securityhub.Controls {
+		if requirement, ok := requirements[control.ID]; ok {
+			entry := newEntry(requirement, cis)
+			if entry.Severity == "" {
+				entry.Severity = control.Severity
+			}
+			add(entry, requirement.Checks)
+			continue
+		}
+		add(Entry{ID: control.ID, Service: entryService(control.ID), Description: control.Title, Severity: control.Severity}, nil)
+	}
+	for _, requirement := range compliance.Requirements {
+		if !listed[requirement.Id] {
+			add(newEntry(requirement, cis), requirement.Checks)
+		}
+	}
+	for _, control := range controls {
+		metadata := control.Metadata()
+		if listed[metadata.ID] {
+			continue
+		}
+		if metadata.Requirement != nil {
+			add(newEntry(*metadata.Requirement, cis), nil)
+		} else {
+			add(Entry{ID: metadata.ID, Service: entryService(metadata.ID)}, nil)
+		}
+	}
+	return entries
+}
+
+func newEntry(requirement util.Requirement, cis *util.Compliance) Entry {
+	entry := Entry{
+		ID:          requirement.Id,
+		Service:     entryService(requirement.Id),
+		Description: requirement.Description,
+		Attributes:  requirement.Attributes,
+	}
+	for _, attribute := range requirement.Attributes {
+		if entry.Severity == "" {
+			entry.Severity = attribute.Severity
+		}
+		entry.Mappings = append(entry.Mappings, mappings(attribute.RelatedRequirements, cis)...)
+	}
+	return entry
+}
+
+func (e *Entry) withControl(control types.Control) {
+	metadata := control.Metadata()
+	e.Implemented = true
+	e.Check = metadata.Check
+	e.Service = ControlService(control)
+	e.Permissions = control.Permissions()
+	for _, parameter := range metadata.Parameters {
+		e.Parameters = append(e.Parameters, ParameterInfo{Name: parameter.Name, Description: parameter.Description, Default: parameter.Default})
+	}
+}
+
+// entryService returns the inventory service of built-in controls, otherwise the lowercased ID prefix
+func entryService(id string) string {
+	if service := Service(id); service != "" {
+		return service
+	}
+	prefix, _, _ := strings.Cut(id, ".")
+	return strings.ToLower(prefix)
+}
+
+// mappings splits RelatedRequirements into the requirements of each framework
+func mappings(related string, cis *util.Compliance) []Mapping {
+	var result []Mapping
+	for _, requirement := range strings.Split(related, ",") {
+		requirement = strings.TrimSpace(requirement)
+		if requirement == "" {
+			continue
+		}
+		mapping := Mapping{Requirement: requirement}
+		for name, prefix := range frameworks {
+			if strings.HasPrefix(requirement, prefix) {
+				mapping.Framework = name
+			}
+		}
+		if id, ok := strings.CutPrefix(requirement, cisVersion); ok && cis != nil {
+			for _, cisRequirement := range cis.Requirements {
+				if cisRequirement.Id == id {
+					mapping.Title = cisRequirement.Description
+				}
+			}
+		}
+		result = append(result, mapping)
+	}
+	return result
+}
+
+// Filter selects catalogue entries; empty fields select everything
+type Filter struct {
+	Services      []string // inventory services or ID prefixes, e.g. cloudfront
+	Severities    []string
+	Frameworks    []string
+	Implemented   bool // only implemented controls
+	Unimplemented bool // only controls that are not implemented yet
+}
+
+// Match reports whether the entry passes every filter
+func (f Filter) Match(entry Entry) bool {
+	if (f.Implemented && !entry.Implemented) || (f.Unimplemented && entry.Implemented) {
+		return false
+	}
+	if len(f.Services) > 0 && !containsFold(f.Services, entry.Service) {
+		return false
+	}
+	if len(f.Severities) > 0 && !containsFold(f.Severities, entry.Severity) {
+		return false
+	}
+	if len(f.Frameworks) > 0 {
+		for _, mapping := range entry.Mappings {
+			if containsFold(f.Frameworks, mapping.Framework) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// Find returns the entry of a control ID, ignoring case, or nil
+func Find(entries []Entry, id string) *Entry {
+	for i := range entries {
+		if strings.EqualFold(entries[i].ID, id) {
+			return &entries[i]
+		}
+	}
+	return nil
+}
+
+// WriteJSON renders entries, or a single entry, as indented JSON
+func WriteJSON(w io.Writer, v interface{}) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "    ")
+	return encoder.Encode(v)
+}
+
+// WriteTable renders one line per entry
+func WriteTable(w io.Writer, entries []Entry) error {
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "ID\tSERVICE\tSEVERITY\tIMPLEMENTED\tDESCRIPTION")
+	for _, entry := range entries {
+		implemented := "no"
+		if entry.Implemented {
+			implemented = "yes"
+		}
+		fmt.Fprintf(table, "%s\t%s\t%s\t%s\t%s\n", entry.ID, entry.Service, entry.Severity, implemented, entry.Description)
+	}
+	return table.Flush()
+}
+
+// WriteDetails renders every field of an entry
+func WriteDetails(w io.Writer, entry Entry) error {
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(table, "ID:\t%s\n", entry.ID)
+	fmt.Fprintf(table, "Description:\t%s\n", entry.Description)
+	fmt.Fprintf(table, "Check:\t%s\n", entry.Check)
+	fmt.Fprintf(table, "Service:\t%s\n", entry.Service)
+	fmt.Fprintf(table, "Implemented:\t%t\n", entry.Implemented)
+	for _, attribute := range entry.Attributes {
+		fmt.Fprintf(table, "Section:\t%s\n", attribute.Section)
+		fmt.Fprintf(table, "Category:\t%s\n", attribute.Category)
+		fmt.Fprintf(table, "Severity:\t%s\n", attribute.Severity)
+		fmt.Fprintf(table, "Details:\t%s\n", attribute.Description)
+	}
+	if err := table.Flush(); err != nil {
+		return err
+	}
+
+	if len(entry.Parameters) > 0 {
+		fmt.Fprintln(w, "\nParameters:")
+		for _, parameter := range entry.Parameters {
+			fmt.Fprintf(w, "  %s (default %q): %s\n", parameter.Name, parameter.Default, parameter.Description)
+		}
+	}
+	if len(entry.Permissions) > 0 {
+		fmt.Fprintln(w, "\nIAM actions:")
+		for _, action := range entry.Permissions {
+			fmt.Fprintf(w, "  %s\n", action)
+		}
+	}
+	if len(entry.Mappings) > 0 {
+		fmt.Fprintln(w, "\nRelated requirements:")
+		for _, mapping := range entry.Mappings {
+			if mapping.Title != "" {
+				fmt.Fprintf(w, "  %s: %s\n", mapping.Requirement, mapping.Title)
+			} else {
+				fmt.Fprintf(w, "  %s\n", mapping.Requirement)
+			}
+		}
+	}
+	return nil
+}
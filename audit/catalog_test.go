@@ -0,0 +1,129 @@
+// audit/catalog_test.go
+package audit
+
+import (
+	"testing"
+
+	"aws-security-hub/compliance"
+	"aws-security-hub/coverage"
+	"aws-security-hub/types"
+	"aws-security-hub/util"
+)
+
+func testCatalog(t *testing.T, controls []types.Control) []Entry {
+	t.Helper()
+	securityhub, err := coverage.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return Catalog(controls, compliance.SecurityHub(), compliance.CIS(), securityhub)
+}
+
+func TestCatalogMatchesControls(t *testing.T) {
+	controls := Controls()
+	entries := testCatalog(t, controls)
+
+	// Every entry is listed once, and the implemented ones are exactly the registered controls
+	seen := make(map[string]bool)
+	implemented := 0
+	for _, entry := range entries {
+		if seen[entry.ID] {
+			t.Errorf("%s is listed twice", entry.ID)
+		}
+		seen[entry.ID] = true
+		if entry.Implemented {
+			implemented++
+		}
+	}
+	if implemented != len(controls) {
+		t.Errorf("%d entries are implemented, want %d", implemented, len(controls))
+	}
+
+	for _, control := range controls {
+		metadata := control.Metadata()
+		t.Run(metadata.ID, func(t *testing.T) {
+			entry := Find(entries, metadata.ID)
+			if entry == nil {
+				t.Fatal("not in the catalogue")
+			}
+			if !entry.Implemented || entry.Check != metadata.Check || entry.Service != ControlService(control) {
+				t.Errorf("entry = implemented %v, check %s, service %s; want check %s, service %s",
+					entry.Implemented, entry.Check, entry.Service, metadata.Check, ControlService(control))
+			}
+			if entry.Description == "" || entry.Severity == "" {
+				t.Errorf("entry lacks the compliance attributes: %+v", entry)
+			}
+			if len(entry.Permissions) == 0 {
+				t.Error("entry lists no IAM actions")
+			}
+			if len(entry.Parameters) != len(metadata.Parameters) {
+				t.Errorf("entry has %d parameters, want %d", len(entry.Parameters), len(metadata.Parameters))
+			}
+		})
+	}
+}
+
+func TestCatalogListsRulesLast(t *testing.T) {
+	rule := types.InventoryControl{
+		ID:          "Custom.1",
+		Check:       "custom_check",
+		Requirement: &util.Requirement{Id: "Custom.1", Description: "Custom rule", Attributes: []util.Attribute{{Severity: "Low"}}},
+	}
+	entries := testCatalog(t, append(Controls(), rule))
+
+	last := entries[len(entries)-1]
+	if last.ID != "Custom.1" || !last.Implemented || last.Check != "custom_check" || last.Description != "Custom rule" || last.Severity != "Low" {
+		t.Errorf("last entry = %+v", last)
+	}
+}
+
+func TestCatalogMappings(t *testing.T) {
+	entry := Find(testCatalog(t, Controls()), "s3.1")
+	if entry == nil {
+		t.Fatal("S3.1 is not in the catalogue")
+	}
+
+	// The CIS v3.0.0 mapping carries the title of the CIS requirement
+	want := Mapping{
+		Framework:   "cis",
+		Requirement: "CIS AWS Foundations Benchmark v3.0.0/2.1.4",
+		Title:       "Ensure that S3 Buckets are configured with 'Block public access (bucket settings)'",
+	}
+	if entry.Mappings[0] != want {
+		t.Errorf("first mapping = %+v, want %+v", entry.Mappings[0], want)
+	}
+	frameworks := make(map[string]bool)
+	for _, mapping := range entry.Mappings {
+		frameworks[mapping.Framework] = true
+	}
+	for _, framework := range Frameworks() {
+		if !frameworks[framework] {
+			t.Errorf("no %s mapping in %+v", framework, entry.Mappings)
+		}
+	}
+}
+
+func TestFilterMatch(t *testing.T) {
+	entry := Entry{ID: "S3.1", Service: "s3", Severity: "Medium", Implemented: true, Mappings: []Mapping{{Framework: "cis"}}}
+	tests := []struct {
+		name   string
+		filter Filter
+		want   bool
+	}{
+		{"no filter", Filter{}, true},
+		{"service ignoring case", Filter{Services: []string{"S3"}}, true},
+		{"another service", Filter{Services: []string{"ec2"}}, false},
+		{"severity", Filter{Severities: []string{"medium"}}, true},
+		{"framework", Filter{Frameworks: []string{"cis"}}, true},
+		{"unmapped framework", Filter{Frameworks: []string{"pci-dss"}}, false},
+		{"implemented", Filter{Implemented: true}, true},
+		{"unimplemented", Filter{Unimplemented: true}, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.filter.Match(entry); got != test.want {
+				t.Errorf("Match() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
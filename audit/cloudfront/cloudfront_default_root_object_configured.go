@@ -3,71 +3,66 @@ package cloudfront
 
 import (
 	"context"
-	"log"
 
+	"aws-security-hub/compliance"
+	"aws-security-hub/inventory"
+	"aws-security-hub/logging"
+	"aws-security-hub/types"
 	"aws-security-hub/util"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/service/cloudfront"
 )
 
-func CheckCloudfrontDefaultRootObjectConfigured(cfg aws.Config) string {
-	compliance, err := util.LoadComplianceData("compliance/aws_security_hub.json")
-	if err != nil {
-		log.Printf("[ERROR] Error loading compliance data: %v", err)
-		return "NA"
-	}
-	util.PrintComplianceInfo(compliance, "CloudFront.1")
+func CheckCloudfrontDefaultRootObjectConfigured(ctx context.Context, cfg aws.Config) string {
+	inv, _ := inventory.Collect(ctx, cfg, inventory.ServiceCloudFront)
+	status, _ := types.Resolve(EvaluateCloudfrontDefaultRootObjectConfigured(inv))
+	return status
+}
+
+func EvaluateCloudfrontDefaultRootObjectConfigured(inv *inventory.Inventory) (string, types.Findings) {
+	var findings types.Findings
+	logger := logging.Control("CloudFront.1", inv)
+
+	util.LogComplianceInfo(logger, compliance.SecurityHub(), "CloudFront.1")
 	/* Description:
 	This control checks whether an Amazon CloudFront distribution is configured to return a specific object that is the default root object. The control fails if the CloudFront distribution does not have a default root object configured.
 	*/
 
-	// Create CloudFront client with us-east-1 region
-	cfg.Region = "us-east-1" // CloudFront requires us-east-1 region
-	client := cloudfront.NewFromConfig(cfg)
-
-	// Get all distributions
-	distributions, err := client.ListDistributions(context.TODO(), &cloudfront.ListDistributionsInput{})
-	if err != nil {
-		log.Printf("[ERROR] Failed to get distributions: %v", err)
-		return "NA"
+	if inv.CloudFront == nil {
+		return types.NotCollected(logger, inv, inventory.ServiceCloudFront, &findings), findings
 	}
 
-	if distributions.DistributionList == nil || len(distributions.DistributionList.Items) == 0 {
-		log.Println("[*] No distributions found")
-		return "NA"
+	if len(inv.CloudFront.Distributions) == 0 {
+		logger.Info("no distributions found")
+		return "NA", findings
 	}
 
 	allConfigured := true
 
-	for _, distribution := range distributions.DistributionList.Items {
-		log.Printf("[*] Checking distribution: %s", aws.ToString(distribution.Id))
-
-		// Get distribution config
-		config, err := client.GetDistribution(context.TODO(), &cloudfront.GetDistributionInput{
-			Id: distribution.Id,
-		})
-		if err != nil {
-			log.Printf("  └─[ERROR] Failed to get distribution config for %s: %v", aws.ToString(distribution.Id), err)
+	for _, distribution := range inv.CloudFront.Distributions {
+		logger := logger.With("resource", distribution.ID)
+		logger.Debug("checking distribution")
+		if err := distribution.Errors.Find("GetDistribution"); err != nil {
+			logger.Warn("configuration not collected", "error", err)
+			findings.Error(distribution.ID, err)
 			continue
 		}
 
-		if config.Distribution.DistributionConfig.DefaultRootObject == nil ||
-			aws.ToString(config.Distribution.DistributionConfig.DefaultRootObject) == "" {
-			log.Printf("  └─[FAIL] Default root object not configured for distribution %s", aws.ToString(distribution.Id))
+		if distribution.DefaultRootObject == "" {
+			logger.Info("default root object not configured", "status", "FAIL")
+			findings.Fail(distribution.ID, "Default root object not configured")
 			allConfigured = false
 		} else {
-			log.Printf("  └─[PASS] Default root object configured for distribution %s: %s",
-				aws.ToString(distribution.Id),
-				aws.ToString(config.Distribution.DistributionConfig.DefaultRootObject))
+			logger.Debug("default root object configured", "status", "PASS", "root_object", distribution.DefaultRootObject)
+			findings.Pass(distribution.ID, "Default root object "+distribution.DefaultRootObject)
 		}
 	}
 
 	if allConfigured {
-		log.Println("[PASS] All distributions have default root object configured")
-		return "PASS"
+		logger.Debug("all distributions have default root object configured")
+		return "PASS", findings
 	}
 
-	log.Println("[FAIL] One or more distributions do not have default root object configured")
-	return "FAIL"
+	logger.Debug("one or more distributions do not have default root object configured")
+	return "FAIL", findings
 }
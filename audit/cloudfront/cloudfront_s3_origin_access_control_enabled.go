@@ -3,100 +3,85 @@ package cloudfront
 
 import (
 	"context"
-	"log"
-	"strings"
 
+	"aws-security-hub/compliance"
+	"aws-security-hub/inventory"
+	"aws-security-hub/logging"
+	"aws-security-hub/types"
 	"aws-security-hub/util"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/config"
-	"github.com/aws/aws-sdk-go-v2/service/cloudfront"
 )
 
-func CheckCloudfrontS3OriginAccessControlEnabled(cfg aws.Config) string {
-	compliance, err := util.LoadComplianceData("compliance/aws_security_hub.json")
-	if err != nil {
-		log.Printf("[ERROR] Error loading compliance data: %v", err)
-		return "NA"
-	}
-	util.PrintComplianceInfo(compliance, "CloudFront.13")
+func CheckCloudfrontS3OriginAccessControlEnabled(ctx context.Context, cfg aws.Config) string {
+	inv, _ := inventory.Collect(ctx, cfg, inventory.ServiceCloudFront)
+	status, _ := types.Resolve(EvaluateCloudfrontS3OriginAccessControlEnabled(inv))
+	return status
+}
+
+func EvaluateCloudfrontS3OriginAccessControlEnabled(inv *inventory.Inventory) (string, types.Findings) {
+	var findings types.Findings
+	logger := logging.Control("CloudFront.13", inv)
+
+	util.LogComplianceInfo(logger, compliance.SecurityHub(), "CloudFront.13")
 	/* Description:
 	This control checks whether an Amazon CloudFront distribution with an Amazon S3 origin has origin access control (OAC) configured. The control fails if OAC isn't configured for the CloudFront distribution.
 	*/
 
-	// Create CloudFront client with us-east-1 region
-	cloudfrontCfg, err := config.LoadDefaultConfig(context.TODO(),
-		config.WithRegion("us-east-1"),
-		config.WithCredentialsProvider(cfg.Credentials),
-	)
-	if err != nil {
-		log.Printf("[ERROR] Failed to create CloudFront config: %v", err)
-		return "NA"
+	if inv.CloudFront == nil {
+		return types.NotCollected(logger, inv, inventory.ServiceCloudFront, &findings), findings
 	}
 
-	client := cloudfront.NewFromConfig(cloudfrontCfg)
-
-	// Get all distributions
-	distributions, err := client.ListDistributions(context.TODO(), &cloudfront.ListDistributionsInput{})
-	if err != nil {
-		log.Printf("[ERROR] Failed to get distributions: %v", err)
-		return "NA"
-	}
-
-	if distributions.DistributionList == nil || len(distributions.DistributionList.Items) == 0 {
-		log.Println("[*] No distributions found")
-		return "NA"
+	if len(inv.CloudFront.Distributions) == 0 {
+		logger.Info("no distributions found")
+		return "NA", findings
 	}
 
 	allEnabled := true
 	foundS3Origin := false
 
-	for _, distribution := range distributions.DistributionList.Items {
-		log.Printf("[*] Checking distribution: %s", aws.ToString(distribution.Id))
-
-		// Get distribution config
-		config, err := client.GetDistribution(context.TODO(), &cloudfront.GetDistributionInput{
-			Id: distribution.Id,
-		})
-		if err != nil {
-			log.Printf("  └─[ERROR] Failed to get distribution config for %s: %v", aws.ToString(distribution.Id), err)
+	for _, distribution := range inv.CloudFront.Distributions {
+		logger := logger.With("resource", distribution.ID)
+		logger.Debug("checking distribution")
+		if err := distribution.Errors.Find("GetDistribution"); err != nil {
+			logger.Warn("configuration not collected", "error", err)
+			findings.Error(distribution.ID, err)
 			continue
 		}
 
 		// Check each origin
-		for _, origin := range config.Distribution.DistributionConfig.Origins.Items {
-			domainName := aws.ToString(origin.DomainName)
-
+		for _, origin := range distribution.Origins {
 			// Check if this is an S3 origin (not a website endpoint)
-			if !strings.Contains(domainName, ".s3.") || strings.Contains(domainName, ".s3-website-") {
+			if !origin.IsS3BucketOrigin() {
 				continue
 			}
 
 			foundS3Origin = true
-			log.Printf("  └─[*] Checking S3 origin: %s", aws.ToString(origin.Id))
+			logger := logger.With("origin", origin.ID)
+			logger.Debug("checking S3 origin")
 
-			if origin.OriginAccessControlId == nil || *origin.OriginAccessControlId == "" {
-				log.Printf("    └─[FAIL] Origin access control not configured for origin %s", aws.ToString(origin.Id))
+			if origin.OriginAccessControlID == "" {
+				logger.Info("origin access control not configured", "status", "FAIL")
+				findings.Fail(distribution.ID+"/"+origin.ID, "Origin access control not configured")
 				allEnabled = false
 			} else {
-				log.Printf("    └─[PASS] Origin access control configured (ID: %s) for origin %s",
-					aws.ToString(origin.OriginAccessControlId),
-					aws.ToString(origin.Id))
+				logger.Debug("origin access control configured", "status", "PASS", "origin_access_control", origin.OriginAccessControlID)
+				findings.Pass(distribution.ID+"/"+origin.ID, "Origin access control "+origin.OriginAccessControlID)
 			}
 		}
 	}
 
 	// If no S3 origins were found, return NA
 	if !foundS3Origin {
-		log.Println("[*] No S3 origins found in any distribution")
-		return "NA"
+		logger.Info("no S3 origins found in any distribution")
+		return "NA", findings
 	}
 
 	if allEnabled {
-		log.Println("[PASS] All CloudFront distributions with S3 origins have origin access control enabled")
-		return "PASS"
+		logger.Debug("all CloudFront distributions with S3 origins have origin access control enabled")
+		return "PASS", findings
 	}
 
-	log.Println("[FAIL] One or more CloudFront distributions with S3 origins do not have origin access control enabled")
-	return "FAIL"
+	logger.Debug("one or more CloudFront distributions with S3 origins do not have origin access control enabled")
+	return "FAIL", findings
 }
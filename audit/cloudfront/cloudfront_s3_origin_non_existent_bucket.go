@@ -3,102 +3,100 @@ package cloudfront
 
 import (
 	"context"
-	"log"
-	"strings"
 
+	"aws-security-hub/compliance"
+	"aws-security-hub/inventory"
+	"aws-security-hub/logging"
+	"aws-security-hub/types"
 	"aws-security-hub/util"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/config"
-	"github.com/aws/aws-sdk-go-v2/service/cloudfront"
-	"github.com/aws/aws-sdk-go-v2/service/s3"
 )
 
-func CheckCloudfrontS3OriginNonExistentBucket(cfg aws.Config) string {
-	compliance, err := util.LoadComplianceData("compliance/aws_security_hub.json")
-	if err != nil {
-		log.Printf("[ERROR] Error loading compliance data: %v", err)
-		return "NA"
-	}
-	util.PrintComplianceInfo(compliance, "CloudFront.12")
+func CheckCloudfrontS3OriginNonExistentBucket(ctx context.Context, cfg aws.Config) string {
+	inv, _ := inventory.Collect(ctx, cfg, inventory.ServiceCloudFront)
+	status, _ := types.Resolve(EvaluateCloudfrontS3OriginNonExistentBucket(inv))
+	return status
+}
+
+func EvaluateCloudfrontS3OriginNonExistentBucket(inv *inventory.Inventory) (string, types.Findings) {
+	var findings types.Findings
+	logger := logging.Control("CloudFront.12", inv)
+
+	util.LogComplianceInfo(logger, compliance.SecurityHub(), "CloudFront.12")
 	/* Description:
 	This control checks whether Amazon CloudFront distributions are pointing to non-existent Amazon S3 origins.
 	The control fails for a CloudFront distribution if the origin is configured to point to a non-existent bucket.
 	This control only applies to CloudFront distributions where an S3 bucket without static website hosting is the S3 origin.
 	*/
 
-	// Create CloudFront client with us-east-1 region
-	cloudfrontCfg, err := config.LoadDefaultConfig(context.TODO(),
-		config.WithRegion("us-east-1"),
-		config.WithCredentialsProvider(cfg.Credentials),
-	)
-	if err != nil {
-		log.Printf("[ERROR] Failed to create CloudFront config: %v", err)
-		return "NA"
+	if inv.CloudFront == nil {
+		return types.NotCollected(logger, inv, inventory.ServiceCloudFront, &findings), findings
 	}
 
-	// Create S3 client
-	s3Client := s3.NewFromConfig(cfg)
-	cloudFrontClient := cloudfront.NewFromConfig(cloudfrontCfg)
-
-	// Get all distributions
-	distributions, err := cloudFrontClient.ListDistributions(context.TODO(), &cloudfront.ListDistributionsInput{})
-	if err != nil {
-		log.Printf("[ERROR] Failed to get distributions: %v", err)
-		return "NA"
-	}
-
-	if distributions.DistributionList == nil || len(distributions.DistributionList.Items) == 0 {
-		log.Println("[*] No distributions found")
-		return "NA"
+	if len(inv.CloudFront.Distributions) == 0 {
+		logger.Info("no distributions found")
+		return "NA", findings
 	}
 
 	allOriginsExist := true
-
-	for _, distribution := range distributions.DistributionList.Items {
-		log.Printf("[*] Checking distribution: %s", aws.ToString(distribution.Id))
-
-		// Get distribution config
-		config, err := cloudFrontClient.GetDistribution(context.TODO(), &cloudfront.GetDistributionInput{
-			Id: distribution.Id,
-		})
-		if err != nil {
-			log.Printf("  └─[ERROR] Failed to get distribution config for %s: %v", aws.ToString(distribution.Id), err)
+	checkedOrigins := 0
+
+	for _, distribution := range inv.CloudFront.Distributions {
+		logger := logger.With("resource", distribution.ID)
+		logger.Debug("checking distribution")
+		if err := distribution.Errors.Find("GetDistribution"); err != nil {
+			logger.Warn("configuration not collected", "error", err)
+			findings.Error(distribution.ID, err)
 			continue
 		}
 
 		// Check each origin
-		for _, origin := range config.Distribution.DistributionConfig.Origins.Items {
+		for _, origin := range distribution.Origins {
 			// Check if this is an S3 origin (not a website endpoint)
-			domainName := aws.ToString(origin.DomainName)
-			if !strings.Contains(domainName, ".s3.") || strings.Contains(domainName, ".s3-website-") {
-				log.Printf("  └─[INFO] Origin %s is not an S3 bucket origin, skipping", aws.ToString(origin.Id))
+			if !origin.IsS3BucketOrigin() {
+				logger.Debug("origin is not an S3 bucket origin, skipping", "origin", origin.ID)
 				continue
 			}
 
-			// Extract bucket name from domain
-			bucketName := strings.Split(domainName, ".s3.")[0]
-			log.Printf("  └─[*] Checking S3 bucket origin: %s", bucketName)
+			bucketName := origin.BucketName()
+			logger := logger.With("origin", origin.ID, "bucket", bucketName)
+			logger.Debug("checking S3 bucket origin")
 
 			// Check if bucket exists
-			_, err := s3Client.HeadBucket(context.TODO(), &s3.HeadBucketInput{
-				Bucket: aws.String(bucketName),
-			})
+			if err := origin.Errors.Find(); err != nil {
+				logger.Warn("existence of S3 bucket is unknown", "error", err)
+				findings.Error(distribution.ID+"/"+origin.ID, err)
+				continue
+			}
+			if origin.BucketExists == nil {
+				logger.Debug("existence of S3 bucket is unknown, skipping")
+				continue
+			}
 
-			if err != nil {
-				log.Printf("    └─[FAIL] S3 bucket %s does not exist or is not accessible", bucketName)
+			checkedOrigins++
+			if !*origin.BucketExists {
+				logger.Info("S3 bucket does not exist or is not accessible", "status", "FAIL")
+				findings.Fail(distribution.ID+"/"+origin.ID, "S3 bucket "+bucketName+" does not exist or is not accessible")
 				allOriginsExist = false
 			} else {
-				log.Printf("    └─[PASS] S3 bucket %s exists and is accessible", bucketName)
+				logger.Debug("S3 bucket exists and is accessible", "status", "PASS")
+				findings.Pass(distribution.ID+"/"+origin.ID, "S3 bucket "+bucketName+" exists")
 			}
 		}
 	}
 
+	// If no S3 bucket origins could be checked, return NA
+	if checkedOrigins == 0 {
+		logger.Info("no S3 bucket origins with known existence found in any distribution")
+		return "NA", findings
+	}
+
 	if allOriginsExist {
-		log.Println("[PASS] All CloudFront distributions point to existing S3 buckets")
-		return "PASS"
+		logger.Debug("all CloudFront distributions point to existing S3 buckets")
+		return "PASS", findings
 	}
 
-	log.Println("[FAIL] One or more CloudFront distributions point to non-existent S3 buckets")
-	return "FAIL"
+	logger.Debug("one or more CloudFront distributions point to non-existent S3 buckets")
+	return "FAIL", findings
 }
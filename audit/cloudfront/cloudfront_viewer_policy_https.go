@@ -3,95 +3,90 @@ package cloudfront
 
 import (
 	"context"
-	"log"
+	"strings"
 
+	"aws-security-hub/compliance"
+	"aws-security-hub/inventory"
+	"aws-security-hub/logging"
+	"aws-security-hub/types"
 	"aws-security-hub/util"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/config"
-	"github.com/aws/aws-sdk-go-v2/service/cloudfront"
-	"github.com/aws/aws-sdk-go-v2/service/cloudfront/types"
+	cloudfrontTypes "github.com/aws/aws-sdk-go-v2/service/cloudfront/types"
 )
 
-func CheckCloudfrontViewerPolicyHttps(cfg aws.Config) string {
-	compliance, err := util.LoadComplianceData("compliance/aws_security_hub.json")
-	if err != nil {
-		log.Printf("[ERROR] Error loading compliance data: %v", err)
-		return "NA"
-	}
-	util.PrintComplianceInfo(compliance, "CloudFront.3")
+func CheckCloudfrontViewerPolicyHttps(ctx context.Context, cfg aws.Config) string {
+	inv, _ := inventory.Collect(ctx, cfg, inventory.ServiceCloudFront)
+	status, _ := types.Resolve(EvaluateCloudfrontViewerPolicyHttps(inv))
+	return status
+}
+
+func EvaluateCloudfrontViewerPolicyHttps(inv *inventory.Inventory) (string, types.Findings) {
+	var findings types.Findings
+	logger := logging.Control("CloudFront.3", inv)
+
+	util.LogComplianceInfo(logger, compliance.SecurityHub(), "CloudFront.3")
 	/* Description:
 	This control checks whether an Amazon CloudFront distribution requires viewers to use HTTPS directly or whether it uses redirection.
 	The control fails if ViewerProtocolPolicy is set to allow-all for defaultCacheBehavior or for cacheBehaviors.
 	*/
 
-	// Create CloudFront client with us-east-1 region
-	cloudfrontCfg, err := config.LoadDefaultConfig(context.TODO(),
-		config.WithRegion("us-east-1"),
-		config.WithCredentialsProvider(cfg.Credentials),
-	)
-	if err != nil {
-		log.Printf("[ERROR] Failed to create CloudFront config: %v", err)
-		return "NA"
-	}
-
-	client := cloudfront.NewFromConfig(cloudfrontCfg)
-
-	// Get all distributions
-	distributions, err := client.ListDistributions(context.TODO(), &cloudfront.ListDistributionsInput{})
-	if err != nil {
-		log.Printf("[ERROR] Failed to get distributions: %v", err)
-		return "NA"
+	if inv.CloudFront == nil {
+		return types.NotCollected(logger, inv, inventory.ServiceCloudFront, &findings), findings
 	}
 
-	if distributions.DistributionList == nil || len(distributions.DistributionList.Items) == 0 {
-		log.Println("[*] No distributions found")
-		return "NA"
+	if len(inv.CloudFront.Distributions) == 0 {
+		logger.Info("no distributions found")
+		return "NA", findings
 	}
 
 	allHttps := true
 
-	for _, distribution := range distributions.DistributionList.Items {
-		log.Printf("[*] Checking distribution: %s", aws.ToString(distribution.Id))
-
-		// Get distribution config
-		config, err := client.GetDistribution(context.TODO(), &cloudfront.GetDistributionInput{
-			Id: distribution.Id,
-		})
-		if err != nil {
-			log.Printf("  └─[ERROR] Failed to get distribution config for %s: %v", aws.ToString(distribution.Id), err)
+	for _, distribution := range inv.CloudFront.Distributions {
+		logger := logger.With("resource", distribution.ID)
+		logger.Debug("checking distribution")
+		if err := distribution.Errors.Find("GetDistribution"); err != nil {
+			logger.Warn("configuration not collected", "error", err)
+			findings.Error(distribution.ID, err)
 			continue
 		}
 
+		var httpBehaviors []string
+
 		// Check default cache behavior
-		defaultBehavior := config.Distribution.DistributionConfig.DefaultCacheBehavior
-		if defaultBehavior.ViewerProtocolPolicy == types.ViewerProtocolPolicyAllowAll {
-			log.Printf("  └─[FAIL] Default cache behavior allows HTTP for distribution %s", aws.ToString(distribution.Id))
+		if distribution.ViewerProtocolPolicy == string(cloudfrontTypes.ViewerProtocolPolicyAllowAll) {
+			logger.Debug("default cache behavior allows HTTP")
+			httpBehaviors = append(httpBehaviors, "default")
 			allHttps = false
 		} else {
-			log.Printf("  └─[PASS] Default cache behavior requires HTTPS for distribution %s (Policy: %s)",
-				aws.ToString(distribution.Id),
-				defaultBehavior.ViewerProtocolPolicy)
+			logger.Debug("default cache behavior requires HTTPS", "policy", distribution.ViewerProtocolPolicy)
 		}
 
 		// Check additional cache behaviors
-		for _, behavior := range config.Distribution.DistributionConfig.CacheBehaviors.Items {
-			if behavior.ViewerProtocolPolicy == types.ViewerProtocolPolicyAllowAll {
-				log.Printf("    └─[FAIL] Cache behavior for path pattern %s allows HTTP", aws.ToString(behavior.PathPattern))
+		for _, behavior := range distribution.CacheBehaviors {
+			if behavior.ViewerProtocolPolicy == string(cloudfrontTypes.ViewerProtocolPolicyAllowAll) {
+				logger.Debug("cache behavior allows HTTP", "path_pattern", behavior.PathPattern)
+				httpBehaviors = append(httpBehaviors, behavior.PathPattern)
 				allHttps = false
 			} else {
-				log.Printf("    └─[PASS] Cache behavior for path pattern %s requires HTTPS (Policy: %s)",
-					aws.ToString(behavior.PathPattern),
-					behavior.ViewerProtocolPolicy)
+				logger.Debug("cache behavior requires HTTPS", "path_pattern", behavior.PathPattern, "policy", behavior.ViewerProtocolPolicy)
 			}
 		}
+
+		if len(httpBehaviors) > 0 {
+			logger.Info("cache behaviors allow HTTP", "status", "FAIL", "path_patterns", httpBehaviors)
+			findings.Fail(distribution.ID, "Cache behaviors allow HTTP: "+strings.Join(httpBehaviors, ", "))
+		} else {
+			logger.Debug("all cache behaviors require HTTPS", "status", "PASS")
+			findings.Pass(distribution.ID, "All cache behaviors require HTTPS")
+		}
 	}
 
 	if allHttps {
-		log.Println("[PASS] All CloudFront distributions require HTTPS or redirect to HTTPS")
-		return "PASS"
+		logger.Debug("all CloudFront distributions require HTTPS or redirect to HTTPS")
+		return "PASS", findings
 	}
 
-	log.Println("[FAIL] One or more CloudFront distributions allow HTTP")
-	return "FAIL"
+	logger.Debug("one or more CloudFront distributions allow HTTP")
+	return "FAIL", findings
 }
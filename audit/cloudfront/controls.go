@@ -0,0 +1,27 @@
+// audit/cloudfront/controls.go
+package cloudfront
+
+import (
+	"aws-security-hub/types"
+)
+
+// GetControls returns all CloudFront related controls
+func GetControls() []types.Control {
+	return []types.Control{
+		types.InventoryControl{ID: "CloudFront.1", Check: "cloudfront-default-root-object-configured", Func: EvaluateCloudfrontDefaultRootObjectConfigured,
+			Actions: []string{"cloudfront:ListDistributions", "cloudfront:GetDistribution"}},
+		types.InventoryControl{ID: "CloudFront.3", Check: "cloudfront-viewer-policy-https", Func: EvaluateCloudfrontViewerPolicyHttps,
+			Actions: []string{"cloudfront:ListDistributions", "cloudfront:GetDistribution"}},
+		types.InventoryControl{ID: "CloudFront.4", Check: "cloudfront-origin-failover-enabled", Func: EvaluateCloudfrontOriginFailoverEnabled,
+			Actions: []string{"cloudfront:ListDistributions", "cloudfront:GetDistribution"}},
+		types.InventoryControl{ID: "CloudFront.5", Check: "cloudfront-accesslogs-enabled", Func: EvaluateCloudfrontAccesslogsEnabled,
+			Actions: []string{"cloudfront:ListDistributions", "cloudfront:GetDistribution"}},
+		types.InventoryControl{ID: "CloudFront.12", Check: "cloudfront-s3-origin-non-existent-bucket", Func: EvaluateCloudfrontS3OriginNonExistentBucket,
+			Actions: []string{"cloudfront:ListDistributions", "cloudfront:GetDistribution", "s3:ListBucket", "s3:GetBucketLocation"}},
+		types.InventoryControl{ID: "CloudFront.13", Check: "cloudfront-s3-origin-access-control-enabled", Func: EvaluateCloudfrontS3OriginAccessControlEnabled,
+			Actions: []string{"cloudfront:ListDistributions", "cloudfront:GetDistribution"}},
+		types.InventoryControl{ID: "CloudFront.14", Check: "tagged-cloudfront-distribution", Func: EvaluateTaggedCloudfrontDistribution,
+			Actions:    []string{"cloudfront:ListDistributions", "cloudfront:GetDistribution", "cloudfront:ListTagsForResource"},
+			Parameters: []types.Parameter{requiredTagKeys}},
+	}
+}
@@ -0,0 +1,57 @@
+// audit/cloudfront/controls_test.go
+package cloudfront
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"aws-security-hub/inventory"
+	"aws-security-hub/inventory/inventorytest"
+)
+
+// distributionsAPI answers ListDistributions with two pages, the distribution without a default root
+// object on the last one
+func distributionsAPI(r inventorytest.Request) (int, string) {
+	switch {
+	case r.Path == "/2020-05-31/distribution":
+		if r.Params.Get("Marker") == "" {
+			return http.StatusOK, `<DistributionList><IsTruncated>true</IsTruncated><NextMarker>E2</NextMarker><Quantity>1</Quantity><MaxItems>1</MaxItems><Marker></Marker>
+<Items><DistributionSummary><Id>E1</Id><ARN>arn:aws:cloudfront::123456789012:distribution/E1</ARN></DistributionSummary></Items></DistributionList>`
+		}
+		return http.StatusOK, `<DistributionList><IsTruncated>false</IsTruncated><Quantity>1</Quantity><MaxItems>1</MaxItems><Marker>E2</Marker>
+<Items><DistributionSummary><Id>E2</Id><ARN>arn:aws:cloudfront::123456789012:distribution/E2</ARN></DistributionSummary></Items></DistributionList>`
+	case r.Path == "/2020-05-31/distribution/E1":
+		return http.StatusOK, `<Distribution><Id>E1</Id><ARN>arn:aws:cloudfront::123456789012:distribution/E1</ARN>
+<DistributionConfig><DefaultRootObject>index.html</DefaultRootObject></DistributionConfig></Distribution>`
+	case r.Path == "/2020-05-31/distribution/E2":
+		return http.StatusOK, `<Distribution><Id>E2</Id><ARN>arn:aws:cloudfront::123456789012:distribution/E2</ARN>
+<DistributionConfig></DistributionConfig></Distribution>`
+	case r.Path == "/2020-05-31/tagging":
+		return http.StatusOK, `<Tags><Items></Items></Tags>`
+	}
+	return inventorytest.NotFound(r)
+}
+
+func TestCloudfrontDefaultRootObjectConfiguredEveryPage(t *testing.T) {
+	cfg, _ := inventorytest.Config(distributionsAPI)
+	inv, err := inventory.Collect(context.Background(), cfg, inventory.ServiceCloudFront)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	status, findings := EvaluateCloudfrontDefaultRootObjectConfigured(inv)
+	if status != "FAIL" {
+		t.Errorf("status = %s, want FAIL", status)
+	}
+	want := map[string]string{"E1": "PASS", "E2": "FAIL"}
+	for _, finding := range findings {
+		if want[finding.Resource] != finding.Status {
+			t.Errorf("%s = %s, want %s", finding.Resource, finding.Status, want[finding.Resource])
+		}
+		delete(want, finding.Resource)
+	}
+	if len(want) > 0 {
+		t.Errorf("resources missing from the findings: %v", want)
+	}
+}
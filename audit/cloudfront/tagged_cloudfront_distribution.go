@@ -3,100 +3,120 @@ package cloudfront
 
 import (
 	"context"
-	"log"
+	"fmt"
 	"strings"
 
+	"aws-security-hub/compliance"
+	"aws-security-hub/inventory"
+	"aws-security-hub/logging"
+	"aws-security-hub/types"
 	"aws-security-hub/util"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/config"
-	"github.com/aws/aws-sdk-go-v2/service/cloudfront"
 )
 
-func CheckTaggedCloudfrontDistribution(cfg aws.Config) string {
-	compliance, err := util.LoadComplianceData("compliance/aws_security_hub.json")
-	if err != nil {
-		log.Printf("[ERROR] Error loading compliance data: %v", err)
-		return "NA"
+// requiredTagKeys are the tag keys every distribution must have; without any, one user-defined tag is enough
+var requiredTagKeys = types.Parameter{
+	Name:        "requiredTagKeys",
+	Description: "Comma-separated tag keys every distribution must have (up to 6); empty requires any user-defined tag",
+	Validate: func(value string) error {
+		if keys := splitTagKeys(value); len(keys) > 6 {
+			return fmt.Errorf("%d tag keys given, at most 6 are allowed", len(keys))
+		}
+		return nil
+	},
+}
+
+// RequiredTagKeys returns the configured tag keys CloudFront.14 requires, empty when any user-defined tag will do
+func RequiredTagKeys() []string {
+	return splitTagKeys(requiredTagKeys.Value("CloudFront.14"))
+}
+
+func splitTagKeys(value string) []string {
+	var keys []string
+	for _, key := range strings.Split(value, ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			keys = append(keys, key)
+		}
 	}
-	util.PrintComplianceInfo(compliance, "CloudFront.14")
+	return keys
+}
+
+func CheckTaggedCloudfrontDistribution(ctx context.Context, cfg aws.Config) string {
+	inv, _ := inventory.Collect(ctx, cfg, inventory.ServiceCloudFront)
+	status, _ := types.Resolve(EvaluateTaggedCloudfrontDistribution(inv))
+	return status
+}
+
+func EvaluateTaggedCloudfrontDistribution(inv *inventory.Inventory) (string, types.Findings) {
+	var findings types.Findings
+	logger := logging.Control("CloudFront.14", inv)
+
+	util.LogComplianceInfo(logger, compliance.SecurityHub(), "CloudFront.14")
 	/* Description:
 	A tag is a label that you assign to an AWS resource, and it consists of a key and an optional value. You can create tags to categorize resources by purpose, owner, environment, or other criteria. Tags can help you identify, organize, search for, and filter resources. Tagging also helps you track accountable resource owners for actions and notifications. When you use tagging, you can implement attribute-based access control (ABAC) as an authorization strategy, which defines permissions based on tags. You can attach tags to IAM entities (users or roles) and to AWS resources. You can create a single ABAC policy or a separate set of policies for your IAM principals. You can design these ABAC policies to allow operations when the principal's tag matches the resource tag.
 	*/
 
-	// Create CloudFront client with us-east-1 region
-	cloudfrontCfg, err := config.LoadDefaultConfig(context.TODO(),
-		config.WithRegion("us-east-1"),
-		config.WithCredentialsProvider(cfg.Credentials),
-	)
-	if err != nil {
-		log.Printf("[ERROR] Failed to create CloudFront config: %v", err)
-		return "NA"
+	if inv.CloudFront == nil {
+		return types.NotCollected(logger, inv, inventory.ServiceCloudFront, &findings), findings
 	}
 
-	client := cloudfront.NewFromConfig(cloudfrontCfg)
-
-	// Get all distributions
-	distributions, err := client.ListDistributions(context.TODO(), &cloudfront.ListDistributionsInput{})
-	if err != nil {
-		log.Printf("[ERROR] Failed to get distributions: %v", err)
-		return "NA"
-	}
-
-	if distributions.DistributionList == nil || len(distributions.DistributionList.Items) == 0 {
-		log.Println("[*] No distributions found")
-		return "NA"
+	if len(inv.CloudFront.Distributions) == 0 {
+		logger.Info("no distributions found")
+		return "NA", findings
 	}
 
 	allTagged := true
+	required := RequiredTagKeys()
 
-	for _, distribution := range distributions.DistributionList.Items {
-		log.Printf("[*] Checking distribution: %s", aws.ToString(distribution.Id))
+	for _, distribution := range inv.CloudFront.Distributions {
+		logger := logger.With("resource", distribution.ID)
+		logger.Debug("checking distribution")
 
-		// Get distribution details to get ARN
-		distDetail, err := client.GetDistribution(context.TODO(), &cloudfront.GetDistributionInput{
-			Id: distribution.Id,
-		})
-		if err != nil {
-			log.Printf("  └─[ERROR] Failed to get distribution details for %s: %v", aws.ToString(distribution.Id), err)
+		if err := distribution.Errors.Find("GetDistribution", "ListTagsForResource"); err != nil {
+			logger.Warn("tags not collected", "error", err)
+			findings.Error(distribution.ID, err)
 			continue
 		}
-
-		// Get distribution tags using the correct ARN
-		tagsOutput, err := client.ListTagsForResource(context.TODO(), &cloudfront.ListTagsForResourceInput{
-			Resource: distDetail.Distribution.ARN,
-		})
-		if err != nil {
-			log.Printf("  └─[ERROR] Failed to get tags for distribution %s: %v", aws.ToString(distribution.Id), err)
+		if distribution.Tags == nil {
+			logger.Warn("tags not collected")
 			continue
 		}
 
 		// Filter out system tags (starting with 'aws:')
 		userTags := make(map[string]string)
-		for _, tag := range tagsOutput.Tags.Items {
-			if !strings.HasPrefix(aws.ToString(tag.Key), "aws:") {
-				userTags[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+		for key, value := range distribution.Tags {
+			if !strings.HasPrefix(key, "aws:") {
+				userTags[key] = value
+			}
+		}
+
+		var missing []string
+		for _, key := range required {
+			if _, ok := userTags[key]; !ok {
+				missing = append(missing, key)
 			}
 		}
 
 		if len(userTags) == 0 {
-			log.Printf("  └─[FAIL] No user-defined tags found for distribution %s", aws.ToString(distribution.Id))
+			logger.Info("no user-defined tags found", "status", "FAIL")
+			findings.Fail(distribution.ID, "No user-defined tags")
+			allTagged = false
+		} else if len(missing) > 0 {
+			logger.Info("distribution is missing required tags", "status", "FAIL", "missing", missing)
+			findings.Fail(distribution.ID, "Missing required tags: "+strings.Join(missing, ", "))
 			allTagged = false
 		} else {
-			log.Printf("  └─[PASS] Distribution %s has tags", aws.ToString(distribution.Id))
-			// Display current tags
-			log.Println("    └─[INFO] Current tags:")
-			for key, value := range userTags {
-				log.Printf("      └─ %s: %s", key, value)
-			}
+			logger.Debug("distribution has tags", "status", "PASS", "tags", userTags)
+			findings.Pass(distribution.ID, fmt.Sprintf("%d user-defined tag(s)", len(userTags)))
 		}
 	}
 
 	if allTagged {
-		log.Println("[PASS] All CloudFront distributions have at least one user-defined tag")
-		return "PASS"
+		logger.Debug("all CloudFront distributions have at least one user-defined tag")
+		return "PASS", findings
 	}
 
-	log.Println("[FAIL] One or more CloudFront distributions have no user-defined tags")
-	return "FAIL"
+	logger.Debug("one or more CloudFront distributions have no user-defined tags")
+	return "FAIL", findings
 }
@@ -0,0 +1,22 @@
+package documentdb
+
+import (
+	"aws-security-hub/types"
+)
+
+// GetControls returns all DocumentDB related controls
+func GetControls() []types.Control {
+	// The DocumentDB management API is authorized with rds: actions
+	return []types.Control{
+		types.InventoryControl{ID: "DocumentDB.1", Check: "docdb-cluster-encrypted", Func: EvaluateDocdbClusterEncrypted,
+			Actions: []string{"rds:DescribeDBClusters"}},
+		types.InventoryControl{ID: "DocumentDB.2", Check: "docdb-cluster-backup-retention-check", Func: EvaluateDocdbClusterBackupRetentionCheck,
+			Actions: []string{"rds:DescribeDBClusters"}, Parameters: []types.Parameter{minimumBackupRetentionPeriod}},
+		types.InventoryControl{ID: "DocumentDB.3", Check: "docdb-cluster-snapshot-public-prohibited", Func: EvaluateDocdbClusterSnapshotPublicProhibited,
+			Actions: []string{"rds:DescribeDBClusterSnapshots", "rds:DescribeDBClusterSnapshotAttributes"}},
+		types.InventoryControl{ID: "DocumentDB.4", Check: "docdb-cluster-audit-logging-enabled", Func: EvaluateDocdbClusterAuditLoggingEnabled,
+			Actions: []string{"rds:DescribeDBClusters"}},
+		types.InventoryControl{ID: "DocumentDB.5", Check: "docdb-cluster-deletion-protection-enabled", Func: EvaluateDocdbClusterDeletionProtectionEnabled,
+			Actions: []string{"rds:DescribeDBClusters"}},
+	}
+}
@@ -0,0 +1,97 @@
+// audit/documentdb/controls_test.go
+package documentdb
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"aws-security-hub/inventory"
+	"aws-security-hub/inventory/inventorytest"
+	"aws-security-hub/types"
+)
+
+// clustersAPI answers DescribeDBClusters with two pages, the unencrypted cluster on the last one
+func clustersAPI(r inventorytest.Request) (int, string) {
+	switch r.Action() {
+	case "DescribeDBClusters":
+		if r.Params.Get("Marker") == "" {
+			return http.StatusOK, `<DescribeDBClustersResponse><DescribeDBClustersResult><DBClusters>
+<DBCluster><DBClusterIdentifier>first</DBClusterIdentifier><StorageEncrypted>true</StorageEncrypted><DeletionProtection>true</DeletionProtection></DBCluster>
+</DBClusters><Marker>page-2</Marker></DescribeDBClustersResult></DescribeDBClustersResponse>`
+		}
+		return http.StatusOK, `<DescribeDBClustersResponse><DescribeDBClustersResult><DBClusters>
+<DBCluster><DBClusterIdentifier>last</DBClusterIdentifier><StorageEncrypted>false</StorageEncrypted><DeletionProtection>false</DeletionProtection></DBCluster>
+</DBClusters></DescribeDBClustersResult></DescribeDBClustersResponse>`
+	case "DescribeDBClusterSnapshots":
+		return http.StatusOK, `<DescribeDBClusterSnapshotsResponse><DescribeDBClusterSnapshotsResult><DBClusterSnapshots/></DescribeDBClusterSnapshotsResult></DescribeDBClusterSnapshotsResponse>`
+	}
+	return inventorytest.NotFound(r)
+}
+
+func TestControlsEvaluateEveryPage(t *testing.T) {
+	cfg, _ := inventorytest.Config(clustersAPI)
+	inv, err := inventory.Collect(context.Background(), cfg, inventory.ServiceDocumentDB)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		id       string
+		evaluate func(*inventory.Inventory) (string, types.Findings)
+	}{
+		{"DocumentDB.1", EvaluateDocdbClusterEncrypted},
+		{"DocumentDB.5", EvaluateDocdbClusterDeletionProtectionEnabled},
+	}
+	for _, test := range tests {
+		t.Run(test.id, func(t *testing.T) {
+			status, findings := test.evaluate(inv)
+			if status != "FAIL" {
+				t.Errorf("status = %s, want FAIL", status)
+			}
+			want := map[string]string{"first": "PASS", "last": "FAIL"}
+			for _, finding := range findings {
+				if want[finding.Resource] != finding.Status {
+					t.Errorf("%s = %s, want %s", finding.Resource, finding.Status, want[finding.Resource])
+				}
+				delete(want, finding.Resource)
+			}
+			if len(want) > 0 {
+				t.Errorf("resources missing from the findings: %v", want)
+			}
+		})
+	}
+}
+
+func TestControlsReportUnresolvedClustersAsError(t *testing.T) {
+	// An IaC cluster whose settings are intrinsic functions is neither compliant nor non-compliant
+	inv := &inventory.Inventory{DocumentDB: &inventory.DocumentDB{Clusters: []inventory.DocDBCluster{{
+		Identifier: "Cluster (template.yaml:3)",
+		Errors: inventory.APIErrors{{
+			Operation: "DescribeDBClusters",
+			Code:      "Unresolved",
+			Message:   "StorageEncrypted is !Ref Encrypt, which cannot be resolved from the template",
+		}},
+	}}}}
+
+	tests := []struct {
+		id       string
+		evaluate func(*inventory.Inventory) (string, types.Findings)
+	}{
+		{"DocumentDB.1", EvaluateDocdbClusterEncrypted},
+		{"DocumentDB.2", EvaluateDocdbClusterBackupRetentionCheck},
+		{"DocumentDB.4", EvaluateDocdbClusterAuditLoggingEnabled},
+		{"DocumentDB.5", EvaluateDocdbClusterDeletionProtectionEnabled},
+	}
+	for _, test := range tests {
+		t.Run(test.id, func(t *testing.T) {
+			status, findings := types.Resolve(test.evaluate(inv))
+			if status != "ERROR" {
+				t.Errorf("status = %s, want ERROR", status)
+			}
+			if len(findings) != 1 || findings[0].Status != "ERROR" || findings[0].ErrorCode != "Unresolved" {
+				t.Errorf("findings = %+v, want one Unresolved ERROR", findings)
+			}
+		})
+	}
+}
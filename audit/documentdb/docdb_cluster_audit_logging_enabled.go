@@ -2,74 +2,83 @@ package documentdb
 
 import (
 	"context"
-	"log"
+	"log/slog"
 
+	"aws-security-hub/compliance"
+	"aws-security-hub/inventory"
+	"aws-security-hub/logging"
+	"aws-security-hub/types"
 	"aws-security-hub/util"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/service/docdb"
 )
 
-func CheckDocdbClusterAuditLoggingEnabled(cfg aws.Config) string {
-	compliance, err := util.LoadComplianceData("compliance/aws_security_hub.json")
-	if err != nil {
-		log.Printf("└─[ERROR] Error loading compliance data: %v", err)
-		return "NA"
-	}
-	util.PrintComplianceInfo(compliance, "DocumentDB.4")
+func CheckDocdbClusterAuditLoggingEnabled(ctx context.Context, cfg aws.Config) string {
+	slog.Debug("fetching DocumentDB clusters", "control", "DocumentDB.4")
+	inv, _ := inventory.Collect(ctx, cfg, inventory.ServiceDocumentDB)
+	status, _ := types.Resolve(EvaluateDocdbClusterAuditLoggingEnabled(inv))
+	return status
+}
+
+func EvaluateDocdbClusterAuditLoggingEnabled(inv *inventory.Inventory) (string, types.Findings) {
+	var findings types.Findings
+	logger := logging.Control("DocumentDB.4", inv)
+
+	util.LogComplianceInfo(logger, compliance.SecurityHub(), "DocumentDB.4")
 	/* Description:
 	This control checks whether an Amazon DocumentDB cluster publishes audit logs to Amazon CloudWatch Logs. The control fails if the cluster doesn't publish audit logs to CloudWatch Logs.
 	*/
 
-	// Create DocumentDB client
-	client := docdb.NewFromConfig(cfg)
+	if inv.DocumentDB == nil {
+		return types.NotCollected(logger, inv, inventory.ServiceDocumentDB, &findings), findings
+	}
 
-	// Describe DocumentDB clusters
-	log.Println("[*] Fetching DocumentDB clusters...")
-	input := &docdb.DescribeDBClustersInput{}
-	paginator := docdb.NewDescribeDBClustersPaginator(client, input)
+	if err := inv.DocumentDB.Errors.Find("DescribeDBClusters"); err != nil {
+		logger.Error("clusters not fully listed", "error", err)
+		findings.Error(inventory.ServiceDocumentDB, err)
+	}
 
 	clustersWithoutAuditLogging := 0
 	totalClusters := 0
 
-	for paginator.HasMorePages() {
-		output, err := paginator.NextPage(context.TODO())
-		if err != nil {
-			log.Printf("└─[ERROR] Failed to describe DocumentDB clusters: %v", err)
-			return "NA"
+	for _, cluster := range inv.DocumentDB.Clusters {
+		totalClusters++
+		logger := logger.With("resource", cluster.Identifier)
+		logger.Debug("checking cluster")
+		if err := cluster.Errors.Find(); err != nil {
+			logger.Warn("cluster settings not resolved", "error", err)
+			findings.Error(cluster.Identifier, err)
+			continue
 		}
 
-		for _, cluster := range output.DBClusters {
-			totalClusters++
-			log.Printf("└─[*] Checking cluster: %s", aws.ToString(cluster.DBClusterIdentifier))
-
-			auditLoggingEnabled := false
-			for _, logExport := range cluster.EnabledCloudwatchLogsExports {
-				if logExport == "audit" {
-					auditLoggingEnabled = true
-					break
-				}
+		auditLoggingEnabled := false
+		for _, logExport := range cluster.EnabledCloudwatchLogsExports {
+			if logExport == "audit" {
+				auditLoggingEnabled = true
+				break
 			}
+		}
 
-			if !auditLoggingEnabled {
-				log.Printf("  └─[FAIL] Cluster %s does not have audit logging enabled", aws.ToString(cluster.DBClusterIdentifier))
-				clustersWithoutAuditLogging++
-			} else {
-				log.Printf("  └─[PASS] Cluster %s has audit logging enabled", aws.ToString(cluster.DBClusterIdentifier))
-			}
+		if !auditLoggingEnabled {
+			logger.Info("cluster does not have audit logging enabled", "status", "FAIL")
+			findings.Fail(cluster.Identifier, "Audit logs are not exported to CloudWatch Logs")
+			clustersWithoutAuditLogging++
+		} else {
+			logger.Debug("cluster has audit logging enabled", "status", "PASS")
+			findings.Pass(cluster.Identifier, "Audit logs are exported to CloudWatch Logs")
 		}
 	}
 
 	if totalClusters == 0 {
-		log.Println("└─[*] No DocumentDB clusters found")
-		return "NA"
+		logger.Info("no DocumentDB clusters found")
+		return "NA", findings
 	}
 
 	if clustersWithoutAuditLogging > 0 {
-		log.Printf("└─[FAIL] %d out of %d DocumentDB clusters do not have audit logging enabled", clustersWithoutAuditLogging, totalClusters)
-		return "FAIL"
+		logger.Debug("DocumentDB clusters do not have audit logging enabled", "count", clustersWithoutAuditLogging, "total", totalClusters)
+		return "FAIL", findings
 	}
 
-	log.Println("└─[PASS] All DocumentDB clusters have audit logging enabled")
-	return "PASS"
+	logger.Debug("all DocumentDB clusters have audit logging enabled")
+	return "PASS", findings
 }
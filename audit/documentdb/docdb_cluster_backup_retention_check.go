@@ -2,66 +2,92 @@ package documentdb
 
 import (
 	"context"
-	"log"
+	"fmt"
+	"log/slog"
+	"strconv"
 
+	"aws-security-hub/compliance"
+	"aws-security-hub/inventory"
+	"aws-security-hub/logging"
+	"aws-security-hub/types"
 	"aws-security-hub/util"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/service/docdb"
 )
 
-func CheckDocdbClusterBackupRetentionCheck(cfg aws.Config) string {
-	compliance, err := util.LoadComplianceData("compliance/aws_security_hub.json")
-	if err != nil {
-		log.Printf("└─[ERROR] Error loading compliance data: %v", err)
-		return "NA"
-	}
-	util.PrintComplianceInfo(compliance, "DocumentDB.2")
+// minimumBackupRetentionPeriod is the number of days of backups a cluster must keep at least
+var minimumBackupRetentionPeriod = types.Parameter{
+	Name:        "minimumBackupRetentionPeriod",
+	Description: "Minimum backup retention period in days",
+	Default:     "7",
+	Validate:    types.IntBetween(7, 35),
+}
+
+// MinimumBackupRetentionPeriod returns the configured minimum backup retention period of DocumentDB.2 in days
+func MinimumBackupRetentionPeriod() int32 {
+	retention, _ := strconv.Atoi(minimumBackupRetentionPeriod.Value("DocumentDB.2"))
+	return int32(retention)
+}
+
+func CheckDocdbClusterBackupRetentionCheck(ctx context.Context, cfg aws.Config) string {
+	slog.Debug("fetching DocumentDB clusters", "control", "DocumentDB.2")
+	inv, _ := inventory.Collect(ctx, cfg, inventory.ServiceDocumentDB)
+	status, _ := types.Resolve(EvaluateDocdbClusterBackupRetentionCheck(inv))
+	return status
+}
+
+func EvaluateDocdbClusterBackupRetentionCheck(inv *inventory.Inventory) (string, types.Findings) {
+	var findings types.Findings
+	logger := logging.Control("DocumentDB.2", inv)
+
+	util.LogComplianceInfo(logger, compliance.SecurityHub(), "DocumentDB.2")
 	/* Description:
 	This control checks whether an Amazon DocumentDB cluster has a backup retention period greater than or equal to the specified time frame. The control fails if the backup retention period is less than the specified time frame. Unless you provide a custom parameter value for the backup retention period, Security Hub uses a default value of 7 days.
 	*/
 
-	// Create DocumentDB client
-	client := docdb.NewFromConfig(cfg)
+	if inv.DocumentDB == nil {
+		return types.NotCollected(logger, inv, inventory.ServiceDocumentDB, &findings), findings
+	}
 
-	// Describe DocumentDB clusters
-	log.Println("[*] Fetching DocumentDB clusters...")
-	input := &docdb.DescribeDBClustersInput{}
-	resp, err := client.DescribeDBClusters(context.TODO(), input)
-	if err != nil {
-		log.Printf("└─[ERROR] Failed to describe DocumentDB clusters: %v", err)
-		return "NA"
+	if err := inv.DocumentDB.Errors.Find("DescribeDBClusters"); err != nil {
+		logger.Error("clusters not fully listed", "error", err)
+		findings.Error(inventory.ServiceDocumentDB, err)
 	}
 
-	if len(resp.DBClusters) == 0 {
-		log.Println("└─[*] No DocumentDB clusters found")
-		return "NA"
+	if len(inv.DocumentDB.Clusters) == 0 {
+		logger.Info("no DocumentDB clusters found")
+		return "NA", findings
 	}
 
-	minRetentionPeriod := int32(7) // Default minimum retention period in days
+	minRetentionPeriod := MinimumBackupRetentionPeriod()
 	insufficientRetentionClusters := 0
 
-	for _, cluster := range resp.DBClusters {
-		log.Printf("└─[*] Checking cluster: %s", aws.ToString(cluster.DBClusterIdentifier))
+	for _, cluster := range inv.DocumentDB.Clusters {
+		logger := logger.With("resource", cluster.Identifier)
+		logger.Debug("checking cluster")
+		if err := cluster.Errors.Find(); err != nil {
+			logger.Warn("cluster settings not resolved", "error", err)
+			findings.Error(cluster.Identifier, err)
+			continue
+		}
 
-		if cluster.BackupRetentionPeriod == nil || *cluster.BackupRetentionPeriod < minRetentionPeriod {
-			log.Printf("  └─[FAIL] Cluster %s has insufficient backup retention period: %d days (minimum: %d days)",
-				aws.ToString(cluster.DBClusterIdentifier),
-				aws.ToInt32(cluster.BackupRetentionPeriod),
-				minRetentionPeriod)
+		if cluster.BackupRetentionPeriod < minRetentionPeriod {
+			logger.Info("cluster has insufficient backup retention period", "status", "FAIL",
+				"retention_days", cluster.BackupRetentionPeriod, "minimum_days", minRetentionPeriod)
+			findings.Fail(cluster.Identifier, fmt.Sprintf("Backup retention period of %d days is below %d days", cluster.BackupRetentionPeriod, minRetentionPeriod))
 			insufficientRetentionClusters++
 		} else {
-			log.Printf("  └─[PASS] Cluster %s has sufficient backup retention period: %d days",
-				aws.ToString(cluster.DBClusterIdentifier),
-				*cluster.BackupRetentionPeriod)
+			logger.Debug("cluster has sufficient backup retention period", "status", "PASS",
+				"retention_days", cluster.BackupRetentionPeriod)
+			findings.Pass(cluster.Identifier, fmt.Sprintf("Backup retention period of %d days", cluster.BackupRetentionPeriod))
 		}
 	}
 
 	if insufficientRetentionClusters > 0 {
-		log.Printf("└─[FAIL] %d DocumentDB clusters found with insufficient backup retention period", insufficientRetentionClusters)
-		return "FAIL"
+		logger.Debug("DocumentDB clusters found with insufficient backup retention period", "count", insufficientRetentionClusters)
+		return "FAIL", findings
 	}
 
-	log.Println("└─[PASS] All DocumentDB clusters have sufficient backup retention period")
-	return "PASS"
+	logger.Debug("all DocumentDB clusters have sufficient backup retention period")
+	return "PASS", findings
 }
@@ -2,66 +2,75 @@ package documentdb
 
 import (
 	"context"
-	"log"
+	"log/slog"
 
+	"aws-security-hub/compliance"
+	"aws-security-hub/inventory"
+	"aws-security-hub/logging"
+	"aws-security-hub/types"
 	"aws-security-hub/util"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/service/docdb"
 )
 
-func CheckDocdbClusterDeletionProtectionEnabled(cfg aws.Config) string {
-	compliance, err := util.LoadComplianceData("compliance/aws_security_hub.json")
-	if err != nil {
-		log.Printf("└─[ERROR] Error loading compliance data: %v", err)
-		return "NA"
-	}
-	util.PrintComplianceInfo(compliance, "DocumentDB.5")
+func CheckDocdbClusterDeletionProtectionEnabled(ctx context.Context, cfg aws.Config) string {
+	slog.Debug("fetching DocumentDB clusters", "control", "DocumentDB.5")
+	inv, _ := inventory.Collect(ctx, cfg, inventory.ServiceDocumentDB)
+	status, _ := types.Resolve(EvaluateDocdbClusterDeletionProtectionEnabled(inv))
+	return status
+}
+
+func EvaluateDocdbClusterDeletionProtectionEnabled(inv *inventory.Inventory) (string, types.Findings) {
+	var findings types.Findings
+	logger := logging.Control("DocumentDB.5", inv)
+
+	util.LogComplianceInfo(logger, compliance.SecurityHub(), "DocumentDB.5")
 	/* Description:
 	This control checks whether an Amazon DocumentDB cluster has deletion protection enabled. The control fails if the cluster doesn't have deletion protection enabled.
 	*/
 
-	// Create DocumentDB client
-	client := docdb.NewFromConfig(cfg)
+	if inv.DocumentDB == nil {
+		return types.NotCollected(logger, inv, inventory.ServiceDocumentDB, &findings), findings
+	}
 
-	// Describe DocumentDB clusters
-	log.Println("[*] Fetching DocumentDB clusters...")
-	input := &docdb.DescribeDBClustersInput{}
-	paginator := docdb.NewDescribeDBClustersPaginator(client, input)
+	if err := inv.DocumentDB.Errors.Find("DescribeDBClusters"); err != nil {
+		logger.Error("clusters not fully listed", "error", err)
+		findings.Error(inventory.ServiceDocumentDB, err)
+	}
 
 	clustersWithoutDeletionProtection := 0
 	totalClusters := 0
 
-	for paginator.HasMorePages() {
-		output, err := paginator.NextPage(context.TODO())
-		if err != nil {
-			log.Printf("└─[ERROR] Failed to describe DocumentDB clusters: %v", err)
-			return "NA"
+	for _, cluster := range inv.DocumentDB.Clusters {
+		totalClusters++
+		logger := logger.With("resource", cluster.Identifier)
+		logger.Debug("checking cluster")
+		if err := cluster.Errors.Find(); err != nil {
+			logger.Warn("cluster settings not resolved", "error", err)
+			findings.Error(cluster.Identifier, err)
+			continue
 		}
 
-		for _, cluster := range output.DBClusters {
-			totalClusters++
-			log.Printf("└─[*] Checking cluster: %s", aws.ToString(cluster.DBClusterIdentifier))
-
-			if cluster.DeletionProtection == nil || !*cluster.DeletionProtection {
-				log.Printf("  └─[FAIL] Cluster %s does not have deletion protection enabled", aws.ToString(cluster.DBClusterIdentifier))
-				clustersWithoutDeletionProtection++
-			} else {
-				log.Printf("  └─[PASS] Cluster %s has deletion protection enabled", aws.ToString(cluster.DBClusterIdentifier))
-			}
+		if !cluster.DeletionProtection {
+			logger.Info("cluster does not have deletion protection enabled", "status", "FAIL")
+			findings.Fail(cluster.Identifier, "Deletion protection is disabled")
+			clustersWithoutDeletionProtection++
+		} else {
+			logger.Debug("cluster has deletion protection enabled", "status", "PASS")
+			findings.Pass(cluster.Identifier, "Deletion protection is enabled")
 		}
 	}
 
 	if totalClusters == 0 {
-		log.Println("└─[*] No DocumentDB clusters found")
-		return "NA"
+		logger.Info("no DocumentDB clusters found")
+		return "NA", findings
 	}
 
 	if clustersWithoutDeletionProtection > 0 {
-		log.Printf("└─[FAIL] %d out of %d DocumentDB clusters do not have deletion protection enabled", clustersWithoutDeletionProtection, totalClusters)
-		return "FAIL"
+		logger.Debug("DocumentDB clusters do not have deletion protection enabled", "count", clustersWithoutDeletionProtection, "total", totalClusters)
+		return "FAIL", findings
 	}
 
-	log.Println("└─[PASS] All DocumentDB clusters have deletion protection enabled")
-	return "PASS"
+	logger.Debug("all DocumentDB clusters have deletion protection enabled")
+	return "PASS", findings
 }
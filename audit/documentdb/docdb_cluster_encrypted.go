@@ -2,60 +2,73 @@ package documentdb
 
 import (
 	"context"
-	"log"
+	"log/slog"
 
+	"aws-security-hub/compliance"
+	"aws-security-hub/inventory"
+	"aws-security-hub/logging"
+	"aws-security-hub/types"
 	"aws-security-hub/util"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/service/docdb"
 )
 
-func CheckDocdbClusterEncrypted(cfg aws.Config) string {
-	compliance, err := util.LoadComplianceData("compliance/aws_security_hub.json")
-	if err != nil {
-		log.Printf("└─[ERROR] Error loading compliance data: %v", err)
-		return "NA"
-	}
-	util.PrintComplianceInfo(compliance, "DocumentDB.1")
+func CheckDocdbClusterEncrypted(ctx context.Context, cfg aws.Config) string {
+	slog.Debug("fetching DocumentDB clusters", "control", "DocumentDB.1")
+	inv, _ := inventory.Collect(ctx, cfg, inventory.ServiceDocumentDB)
+	status, _ := types.Resolve(EvaluateDocdbClusterEncrypted(inv))
+	return status
+}
+
+func EvaluateDocdbClusterEncrypted(inv *inventory.Inventory) (string, types.Findings) {
+	var findings types.Findings
+	logger := logging.Control("DocumentDB.1", inv)
+
+	util.LogComplianceInfo(logger, compliance.SecurityHub(), "DocumentDB.1")
 	/* Description:
 	This control checks whether an Amazon DocumentDB cluster is encrypted at rest. The control fails if an Amazon DocumentDB cluster isn't encrypted at rest.
 	*/
 
-	// Create DocumentDB client
-	client := docdb.NewFromConfig(cfg)
+	if inv.DocumentDB == nil {
+		return types.NotCollected(logger, inv, inventory.ServiceDocumentDB, &findings), findings
+	}
 
-	// Describe DocumentDB clusters
-	log.Println("[*] Fetching DocumentDB clusters...")
-	input := &docdb.DescribeDBClustersInput{}
-	resp, err := client.DescribeDBClusters(context.TODO(), input)
-	if err != nil {
-		log.Printf("└─[ERROR] Failed to describe DocumentDB clusters: %v", err)
-		return "NA"
+	if err := inv.DocumentDB.Errors.Find("DescribeDBClusters"); err != nil {
+		logger.Error("clusters not fully listed", "error", err)
+		findings.Error(inventory.ServiceDocumentDB, err)
 	}
 
-	if len(resp.DBClusters) == 0 {
-		log.Println("└─[*] No DocumentDB clusters found")
-		return "NA"
+	if len(inv.DocumentDB.Clusters) == 0 {
+		logger.Info("no DocumentDB clusters found")
+		return "NA", findings
 	}
 
 	unencryptedClusters := 0
 
-	for _, cluster := range resp.DBClusters {
-		log.Printf("└─[*] Checking cluster: %s", aws.ToString(cluster.DBClusterIdentifier))
+	for _, cluster := range inv.DocumentDB.Clusters {
+		logger := logger.With("resource", cluster.Identifier)
+		logger.Debug("checking cluster")
+		if err := cluster.Errors.Find(); err != nil {
+			logger.Warn("cluster settings not resolved", "error", err)
+			findings.Error(cluster.Identifier, err)
+			continue
+		}
 
-		if cluster.StorageEncrypted == nil || !*cluster.StorageEncrypted {
-			log.Printf("  └─[FAIL] Cluster %s is not encrypted at rest", aws.ToString(cluster.DBClusterIdentifier))
+		if !cluster.StorageEncrypted {
+			logger.Info("cluster is not encrypted at rest", "status", "FAIL")
+			findings.Fail(cluster.Identifier, "Storage is not encrypted")
 			unencryptedClusters++
 		} else {
-			log.Printf("  └─[PASS] Cluster %s is encrypted at rest", aws.ToString(cluster.DBClusterIdentifier))
+			logger.Debug("cluster is encrypted at rest", "status", "PASS")
+			findings.Pass(cluster.Identifier, "Storage is encrypted")
 		}
 	}
 
 	if unencryptedClusters > 0 {
-		log.Printf("└─[FAIL] %d unencrypted DocumentDB clusters found", unencryptedClusters)
-		return "FAIL"
+		logger.Debug("unencrypted DocumentDB clusters found", "count", unencryptedClusters)
+		return "FAIL", findings
 	}
 
-	log.Println("└─[PASS] All DocumentDB clusters are encrypted at rest")
-	return "PASS"
+	logger.Debug("all DocumentDB clusters are encrypted at rest")
+	return "PASS", findings
 }
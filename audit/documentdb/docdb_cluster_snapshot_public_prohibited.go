@@ -2,86 +2,77 @@ package documentdb
 
 import (
 	"context"
-	"log"
+	"log/slog"
 
+	"aws-security-hub/compliance"
+	"aws-security-hub/inventory"
+	"aws-security-hub/logging"
+	"aws-security-hub/types"
 	"aws-security-hub/util"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/service/docdb"
 )
 
-func CheckDocdbClusterSnapshotPublicProhibited(cfg aws.Config) string {
-	compliance, err := util.LoadComplianceData("compliance/aws_security_hub.json")
-	if err != nil {
-		log.Printf("└─[ERROR] Error loading compliance data: %v", err)
-		return "NA"
-	}
+func CheckDocdbClusterSnapshotPublicProhibited(ctx context.Context, cfg aws.Config) string {
+	slog.Debug("fetching DocumentDB cluster snapshots", "control", "DocumentDB.3")
+	inv, _ := inventory.Collect(ctx, cfg, inventory.ServiceDocumentDB)
+	status, _ := types.Resolve(EvaluateDocdbClusterSnapshotPublicProhibited(inv))
+	return status
+}
 
-	util.PrintComplianceInfo(compliance, "DocumentDB.3")
+func EvaluateDocdbClusterSnapshotPublicProhibited(inv *inventory.Inventory) (string, types.Findings) {
+	var findings types.Findings
+	logger := logging.Control("DocumentDB.3", inv)
+
+	util.LogComplianceInfo(logger, compliance.SecurityHub(), "DocumentDB.3")
 	/* Description:
 	This control checks whether an Amazon DocumentDB manual cluster snapshot is public. The control fails if the manual cluster snapshot is public.
 	*/
 
-	// Create DocumentDB client
-	client := docdb.NewFromConfig(cfg)
-
-	// Describe DocumentDB cluster snapshots
-	log.Println("[*] Fetching DocumentDB cluster snapshots...")
-	input := &docdb.DescribeDBClusterSnapshotsInput{
-		SnapshotType: aws.String("manual"),
+	if inv.DocumentDB == nil {
+		return types.NotCollected(logger, inv, inventory.ServiceDocumentDB, &findings), findings
 	}
 
-	paginator := docdb.NewDescribeDBClusterSnapshotsPaginator(client, input)
+	if err := inv.DocumentDB.Errors.Find("DescribeDBClusterSnapshots"); err != nil {
+		logger.Error("cluster snapshots not fully listed", "error", err)
+		findings.Error(inventory.ServiceDocumentDB, err)
+	}
 
 	publicSnapshots := 0
 
-	for paginator.HasMorePages() {
-		output, err := paginator.NextPage(context.TODO())
-		if err != nil {
-			log.Printf("└─[ERROR] Failed to describe DocumentDB cluster snapshots: %v", err)
-			return "NA"
+	for _, snapshot := range inv.DocumentDB.ClusterSnapshots {
+		logger := logger.With("resource", snapshot.Identifier)
+		logger.Debug("checking snapshot")
+		if err := snapshot.Errors.Find(); err != nil {
+			logger.Warn("restore attribute not collected", "error", err)
+			findings.Error(snapshot.Identifier, err)
+			continue
 		}
 
-		for _, snapshot := range output.DBClusterSnapshots {
-			log.Printf("└─[*] Checking snapshot: %s", aws.ToString(snapshot.DBClusterSnapshotIdentifier))
-
-			// Check if the snapshot is public
-			attrInput := &docdb.DescribeDBClusterSnapshotAttributesInput{
-				DBClusterSnapshotIdentifier: snapshot.DBClusterSnapshotIdentifier,
-			}
-			attrOutput, err := client.DescribeDBClusterSnapshotAttributes(context.TODO(), attrInput)
-			if err != nil {
-				log.Printf("  └─[ERROR] Failed to describe snapshot attributes: %v", err)
-				continue
-			}
-
-			for _, attr := range attrOutput.DBClusterSnapshotAttributesResult.DBClusterSnapshotAttributes {
-				if aws.ToString(attr.AttributeName) == "restore" {
-					for _, value := range attr.AttributeValues {
-						if value == "all" {
-							log.Printf("  └─[FAIL] Snapshot %s is public", aws.ToString(snapshot.DBClusterSnapshotIdentifier))
-							publicSnapshots++
-							break
-						}
-					}
-				}
+		// Check if the snapshot is public
+		isPublic := false
+		for _, value := range snapshot.RestoreAttributeValues {
+			if value == "all" {
+				isPublic = true
+				break
 			}
+		}
 
-			if publicSnapshots == 0 {
-				log.Printf("  └─[PASS] Snapshot %s is not public", aws.ToString(snapshot.DBClusterSnapshotIdentifier))
-			}
+		if isPublic {
+			logger.Info("snapshot is public", "status", "FAIL")
+			findings.Fail(snapshot.Identifier, "Snapshot is shared with all accounts")
+			publicSnapshots++
+		} else {
+			logger.Debug("snapshot is not public", "status", "PASS")
+			findings.Pass(snapshot.Identifier, "Snapshot is not public")
 		}
 	}
 
 	if publicSnapshots > 0 {
-		log.Printf("└─[FAIL] %d public DocumentDB cluster snapshots found", publicSnapshots)
-		return "FAIL"
-	}
-
-	if publicSnapshots == 0 {
-		log.Println("└─[PASS] No public DocumentDB cluster snapshots found")
-		return "PASS"
+		logger.Debug("public DocumentDB cluster snapshots found", "count", publicSnapshots)
+		return "FAIL", findings
 	}
 
-	return "NA"
+	logger.Debug("no public DocumentDB cluster snapshots found")
+	return "PASS", findings
 }
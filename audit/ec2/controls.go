@@ -0,0 +1,13 @@
+package ec2
+
+import (
+	"aws-security-hub/types"
+)
+
+// GetControls returns all EC2 related controls
+func GetControls() []types.Control {
+	return []types.Control{
+		types.InventoryControl{ID: "EC2.1", Check: "ebs-snapshot-public-restorable-check", Func: EvaluateEbsSnapshotPublicRestorableCheck,
+			Actions: []string{"ec2:DescribeSnapshots", "ec2:DescribeSnapshotAttribute"}},
+	}
+}
@@ -0,0 +1,51 @@
+// audit/ec2/controls_test.go
+package ec2
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"aws-security-hub/inventory"
+	"aws-security-hub/inventory/inventorytest"
+)
+
+// snapshotsAPI answers DescribeSnapshots with two pages, the public snapshot on the last one
+func snapshotsAPI(r inventorytest.Request) (int, string) {
+	switch r.Action() {
+	case "DescribeSnapshots":
+		if r.Params.Get("NextToken") == "" {
+			return http.StatusOK, `<DescribeSnapshotsResponse><snapshotSet><item><snapshotId>snap-private</snapshotId></item></snapshotSet><nextToken>page-2</nextToken></DescribeSnapshotsResponse>`
+		}
+		return http.StatusOK, `<DescribeSnapshotsResponse><snapshotSet><item><snapshotId>snap-public</snapshotId></item></snapshotSet></DescribeSnapshotsResponse>`
+	case "DescribeSnapshotAttribute":
+		if r.Params.Get("SnapshotId") == "snap-public" {
+			return http.StatusOK, `<DescribeSnapshotAttributeResponse><snapshotId>snap-public</snapshotId><createVolumePermission><item><group>all</group></item></createVolumePermission></DescribeSnapshotAttributeResponse>`
+		}
+		return http.StatusOK, `<DescribeSnapshotAttributeResponse><snapshotId>snap-private</snapshotId><createVolumePermission/></DescribeSnapshotAttributeResponse>`
+	}
+	return inventorytest.NotFound(r)
+}
+
+func TestEbsSnapshotPublicRestorableCheckEveryPage(t *testing.T) {
+	cfg, _ := inventorytest.Config(snapshotsAPI)
+	inv, err := inventory.Collect(context.Background(), cfg, inventory.ServiceEC2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	status, findings := EvaluateEbsSnapshotPublicRestorableCheck(inv)
+	if status != "FAIL" {
+		t.Errorf("status = %s, want FAIL", status)
+	}
+	want := map[string]string{"snap-private": "PASS", "snap-public": "FAIL"}
+	for _, finding := range findings {
+		if want[finding.Resource] != finding.Status {
+			t.Errorf("%s = %s, want %s", finding.Resource, finding.Status, want[finding.Resource])
+		}
+		delete(want, finding.Resource)
+	}
+	if len(want) > 0 {
+		t.Errorf("resources missing from the findings: %v", want)
+	}
+}
@@ -2,74 +2,76 @@ package ec2
 
 import (
 	"context"
-	"log"
+	"log/slog"
 
+	"aws-security-hub/compliance"
+	"aws-security-hub/inventory"
+	"aws-security-hub/logging"
+	"aws-security-hub/types"
 	"aws-security-hub/util"
 
-	"github.com/aws/aws-sdk-go-v2/service/ec2"
-	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go-v2/aws"
 )
 
-func CheckEbsSnapshotPublicRestorableCheck(client *ec2.Client) string {
-	compliance, err := util.LoadComplianceData("compliance/aws_security_hub.json")
-	if err != nil {
-		log.Printf("└─[ERROR] Error loading compliance data: %v", err)
-		return "NA"
-	}
-	util.PrintComplianceInfo(compliance, "EC2.1")
+func CheckEbsSnapshotPublicRestorableCheck(ctx context.Context, cfg aws.Config) string {
+	slog.Debug("fetching EBS snapshots", "control", "EC2.1")
+	inv, _ := inventory.Collect(ctx, cfg, inventory.ServiceEC2)
+	status, _ := types.Resolve(EvaluateEbsSnapshotPublicRestorableCheck(inv))
+	return status
+}
+
+func EvaluateEbsSnapshotPublicRestorableCheck(inv *inventory.Inventory) (string, types.Findings) {
+	var findings types.Findings
+	logger := logging.Control("EC2.1", inv)
+
+	util.LogComplianceInfo(logger, compliance.SecurityHub(), "EC2.1")
 	/* Description:
 	This control checks whether Amazon Elastic Block Store snapshots are not public. The control fails if Amazon EBS snapshots are restorable by anyone.
 	*/
 
-	log.Println("[*] Fetching EBS snapshots...")
-	input := &ec2.DescribeSnapshotsInput{
-		OwnerIds: []string{"self"},
-	}
-	resp, err := client.DescribeSnapshots(context.TODO(), input)
-	if err != nil {
-		log.Printf("└─[ERROR] Failed to describe EBS snapshots: %v", err)
-		return "NA"
+	if inv.EC2 == nil {
+		return types.NotCollected(logger, inv, inventory.ServiceEC2, &findings), findings
 	}
 
-	if len(resp.Snapshots) == 0 {
-		log.Println("└─[*] No EBS snapshots found")
-		return "PASS"
+	if len(inv.EC2.Snapshots) == 0 {
+		logger.Info("no EBS snapshots found")
+		return "PASS", findings
 	}
 
 	publicSnapshots := 0
 
-	for _, snapshot := range resp.Snapshots {
-		log.Printf("└─[*] Checking snapshot: %s", *snapshot.SnapshotId)
-
-		attributeInput := &ec2.DescribeSnapshotAttributeInput{
-			Attribute:  types.SnapshotAttributeNameCreateVolumePermission,
-			SnapshotId: snapshot.SnapshotId,
-		}
-
-		attributeResp, err := client.DescribeSnapshotAttribute(context.TODO(), attributeInput)
-		if err != nil {
-			log.Printf("  └─[ERROR] Failed to describe snapshot attribute: %v", err)
+	for _, snapshot := range inv.EC2.Snapshots {
+		logger := logger.With("resource", snapshot.ID)
+		logger.Debug("checking snapshot")
+		if err := snapshot.Errors.Find(); err != nil {
+			logger.Warn("create volume permission not collected", "error", err)
+			findings.Error(snapshot.ID, err)
 			continue
 		}
 
-		for _, permission := range attributeResp.CreateVolumePermissions {
+		isPublic := false
+		for _, permission := range snapshot.CreateVolumePermissions {
 			if permission.Group == "all" {
-				log.Printf("  └─[FAIL] Snapshot %s is public", *snapshot.SnapshotId)
-				publicSnapshots++
+				isPublic = true
 				break
 			}
 		}
 
-		if publicSnapshots == 0 {
-			log.Printf("  └─[PASS] Snapshot %s is not public", *snapshot.SnapshotId)
+		if isPublic {
+			logger.Info("snapshot is public", "status", "FAIL")
+			findings.Fail(snapshot.ID, "Snapshot is publicly restorable")
+			publicSnapshots++
+		} else {
+			logger.Debug("snapshot is not public", "status", "PASS")
+			findings.Pass(snapshot.ID, "Snapshot is not public")
 		}
 	}
 
 	if publicSnapshots > 0 {
-		log.Printf("└─[FAIL] %d public snapshots found", publicSnapshots)
-		return "FAIL"
+		logger.Debug("public snapshots found", "count", publicSnapshots)
+		return "FAIL", findings
 	}
 
-	log.Println("└─[PASS] No public snapshots found")
-	return "PASS"
+	logger.Debug("no public snapshots found")
+	return "PASS", findings
 }
@@ -0,0 +1,80 @@
+// audit/frameworks.go
+package audit
+
+import (
+	"sort"
+	"strings"
+
+	"aws-security-hub/report"
+	"aws-security-hub/types"
+	"aws-security-hub/util"
+)
+
+// frameworks maps the framework names accepted in selections to the prefix of their requirements
+// in the RelatedRequirements of the compliance JSON
+var frameworks = map[string]string{
+	"cis":         "CIS AWS Foundations Benchmark",
+	"nist-800-53": "NIST.800-53.r5",
+	"pci-dss":     "PCI DSS",
+}
+
+// Frameworks returns the supported framework names in alphabetical order
+func Frameworks() []string {
+	names := make([]string, 0, len(frameworks))
+	for name := range frameworks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// IsFramework reports whether name is a supported framework
+func IsFramework(name string) bool {
+	_, ok := frameworks[strings.ToLower(name)]
+	return ok
+}
+
+// InFramework reports whether a requirement maps to a requirement of the framework
+func InFramework(requirement *util.Requirement, framework string) bool {
+	prefix, ok := frameworks[strings.ToLower(framework)]
+	if !ok || requirement == nil {
+		return false
+	}
+	for _, attribute := range requirement.Attributes {
+		for _, related := range strings.Split(attribute.RelatedRequirements, ",") {
+			if strings.HasPrefix(strings.TrimSpace(related), prefix) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// RequirementFrameworks returns the supported frameworks a requirement maps to, in alphabetical order
+func RequirementFrameworks(requirement *util.Requirement) []string {
+	var names []string
+	for _, name := range Frameworks() {
+		if InFramework(requirement, name) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// SelectFrameworks keeps the controls that map to any of the frameworks; no frameworks keeps every control
+func SelectFrameworks(controls []types.Control, compliance *util.Compliance, names []string) []types.Control {
+	if len(names) == 0 {
+		return controls
+	}
+	var selected []types.Control
+	for _, control := range controls {
+		requirement := report.Requirement(compliance, control)
+		for _, name := range names {
+			if InFramework(requirement, name) {
+				selected = append(selected, control)
+				break
+			}
+		}
+	}
+	return selected
+}
@@ -0,0 +1,148 @@
+// audit/registry.go
+package audit
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"aws-security-hub/audit/account"
+	"aws-security-hub/audit/apigateway"
+	"aws-security-hub/audit/cloudfront"
+	"aws-security-hub/audit/documentdb"
+	"aws-security-hub/audit/ec2"
+	"aws-security-hub/audit/s3"
+	"aws-security-hub/inventory"
+	"aws-security-hub/types"
+)
+
+// Controls returns all implemented controls in the order they are listed in the compliance JSON
+func Controls() []types.Control {
+	var controls []types.Control
+	controls = append(controls, account.GetControls()...)
+	controls = append(controls, apigateway.GetControls()...)
+	controls = append(controls, cloudfront.GetControls()...)
+	controls = append(controls, documentdb.GetControls()...)
+	controls = append(controls, ec2.GetControls()...)
+	controls = append(controls, s3.GetControls()...)
+	return controls
+}
+
+// CheckUnique returns an error when two controls share an ID, e.g. a rule or pack control that
+// reuses the ID of a built-in control
+func CheckUnique(controls []types.Control) error {
+	seen := make(map[string]bool)
+	for _, control := range controls {
+		id := control.Metadata().ID
+		if seen[id] {
+			return fmt.Errorf("control %s is defined twice", id)
+		}
+		seen[id] = true
+	}
+	return nil
+}
+
+// services maps the control ID prefix of each built-in service to its inventory service
+var services = map[string]string{
+	"Account":    inventory.ServiceAccount,
+	"APIGateway": inventory.ServiceAPIGateway,
+	"CloudFront": inventory.ServiceCloudFront,
+	"DocumentDB": inventory.ServiceDocumentDB,
+	"EC2":        inventory.ServiceEC2,
+	"S3":         inventory.ServiceS3,
+}
+
+// Service returns the inventory service a control is evaluated against, or "" for controls
+// outside the built-in services (such as user-authored rules)
+func Service(controlID string) string {
+	prefix, _, _ := strings.Cut(controlID, ".")
+	return services[prefix]
+}
+
+// ControlService returns the inventory service of a control: the one of its metadata (control packs),
+// otherwise the one of its ID
+func ControlService(control types.Control) string {
+	metadata := control.Metadata()
+	if metadata.Service != "" {
+		return metadata.Service
+	}
+	return Service(metadata.ID)
+}
+
+// Select filters controls by ID and by inventory service; empty filters select everything.
+// Unknown control IDs are an error.
+func Select(controls []types.Control, ids, services []string) ([]types.Control, error) {
+	wanted := make(map[string]bool)
+	for _, id := range ids {
+		wanted[id] = true
+	}
+	wantedServices := make(map[string]bool)
+	for _, service := range services {
+		wantedServices[service] = true
+	}
+
+	var selected []types.Control
+	for _, control := range controls {
+		id := control.Metadata().ID
+		if len(wanted) > 0 && !wanted[id] {
+			continue
+		}
+		if len(wantedServices) > 0 && !wantedServices[ControlService(control)] {
+			continue
+		}
+		delete(wanted, id)
+		selected = append(selected, control)
+	}
+
+	for id := range wanted {
+		return nil, fmt.Errorf("unknown control %s", id)
+	}
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("no controls match the selection")
+	}
+	return selected, nil
+}
+
+// Services returns the inventory services the controls need; all of them if any control is not tied to one
+func Services(controls []types.Control) []string {
+	seen := make(map[string]bool)
+	var result []string
+	for _, control := range controls {
+		service := ControlService(control)
+		if service == "" {
+			return inventory.Services
+		}
+		if !seen[service] {
+			seen[service] = true
+			result = append(result, service)
+		}
+	}
+	return result
+}
+
+// Permissions returns the sorted IAM actions the controls need. Controls that are not tied to a
+// service (such as user-authored rules) read every collected service, so they need the actions of
+// every built-in control.
+func Permissions(controls []types.Control) []string {
+	seen := make(map[string]bool)
+	add := func(control types.Control) {
+		for _, action := range control.Permissions() {
+			seen[action] = true
+		}
+	}
+	for _, control := range controls {
+		if ControlService(control) == "" {
+			for _, builtin := range Controls() {
+				add(builtin)
+			}
+		}
+		add(control)
+	}
+
+	actions := make([]string, 0, len(seen))
+	for action := range seen {
+		actions = append(actions, action)
+	}
+	sort.Strings(actions)
+	return actions
+}
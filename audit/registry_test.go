@@ -0,0 +1,27 @@
+// audit/registry_test.go
+package audit
+
+import (
+	"testing"
+
+	"aws-security-hub/types"
+)
+
+func TestCheckUnique(t *testing.T) {
+	tests := []struct {
+		name     string
+		controls []types.Control
+		wantErr  bool
+	}{
+		{"built-in controls", Controls(), false},
+		{"rule with a new ID", append(Controls(), types.InventoryControl{ID: "Custom.1"}), false},
+		{"rule reusing a built-in ID", append(Controls(), types.InventoryControl{ID: "S3.1"}), true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := CheckUnique(test.controls); (err != nil) != test.wantErr {
+				t.Errorf("CheckUnique() = %v, want error %v", err, test.wantErr)
+			}
+		})
+	}
+}
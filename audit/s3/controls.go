@@ -0,0 +1,13 @@
+package s3
+
+import (
+	"aws-security-hub/types"
+)
+
+// GetControls returns all S3 related controls
+func GetControls() []types.Control {
+	return []types.Control{
+		types.InventoryControl{ID: "S3.1", Check: "s3-account-level-public-access-blocks-periodic", Func: EvaluateS3AccountLevelPublicAccessBlocksPeriodic,
+			Actions: []string{"s3:ListAllMyBuckets", "s3:GetBucketPublicAccessBlock", "s3:GetBucketLocation"}},
+	}
+}
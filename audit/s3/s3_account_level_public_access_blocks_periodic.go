@@ -2,83 +2,75 @@ package s3
 
 import (
 	"context"
-	"log"
 
+	"aws-security-hub/compliance"
+	"aws-security-hub/inventory"
+	"aws-security-hub/logging"
+	"aws-security-hub/types"
 	"aws-security-hub/util"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/service/s3"
 )
 
-func CheckS3AccountLevelPublicAccessBlocksPeriodic(cfg aws.Config) string {
-	compliance, err := util.LoadComplianceData("compliance/aws_security_hub.json")
-	if err != nil {
-		log.Printf("└─[ERROR] Error loading compliance data: %v", err)
-		return "NA"
-	}
-	util.PrintComplianceInfo(compliance, "S3.1")
+func CheckS3AccountLevelPublicAccessBlocksPeriodic(ctx context.Context, cfg aws.Config) string {
+	inv, _ := inventory.Collect(ctx, cfg, inventory.ServiceS3)
+	status, _ := types.Resolve(EvaluateS3AccountLevelPublicAccessBlocksPeriodic(inv))
+	return status
+}
+
+func EvaluateS3AccountLevelPublicAccessBlocksPeriodic(inv *inventory.Inventory) (string, types.Findings) {
+	var findings types.Findings
+	logger := logging.Control("S3.1", inv)
 
-	// Create S3 client
-	client := s3.NewFromConfig(cfg)
+	util.LogComplianceInfo(logger, compliance.SecurityHub(), "S3.1")
 
-	// List buckets
-	listBucketsOutput, err := client.ListBuckets(context.TODO(), &s3.ListBucketsInput{})
-	if err != nil {
-		log.Printf("└─[ERROR] Failed to list S3 buckets: %v", err)
-		return "NA"
+	if inv.S3 == nil {
+		return types.NotCollected(logger, inv, inventory.ServiceS3, &findings), findings
 	}
 
-	if len(listBucketsOutput.Buckets) == 0 {
-		log.Println("└─[*] No S3 buckets found")
-		return "NA"
+	if len(inv.S3.Buckets) == 0 {
+		logger.Info("no S3 buckets found")
+		return "NA", findings
 	}
 
 	allBucketsCompliant := true
 
-	for _, bucket := range listBucketsOutput.Buckets {
-		log.Printf("└─[*] Checking bucket: %s", *bucket.Name)
-
-		publicAccessBlockOutput, err := client.GetPublicAccessBlock(context.TODO(), &s3.GetPublicAccessBlockInput{
-			Bucket: bucket.Name,
-		})
-
-		if err != nil {
-			log.Printf("  └─[ERROR] Failed to get public access block for bucket %s: %v", *bucket.Name, err)
-			allBucketsCompliant = false
+	for _, bucket := range inv.S3.Buckets {
+		logger := logger.With("resource", bucket.Name)
+		logger.Debug("checking bucket")
+		if err := bucket.Errors.Find(); err != nil {
+			logger.Warn("public access block not collected", "error", err)
+			findings.Error(bucket.Name, err)
 			continue
 		}
 
-		if publicAccessBlockOutput.PublicAccessBlockConfiguration == nil {
-			log.Printf("  └─[FAIL] Public access block not configured for bucket %s", *bucket.Name)
+		if bucket.PublicAccessBlock == nil {
+			logger.Info("public access block not configured", "status", "FAIL")
+			findings.Fail(bucket.Name, "Public access block not configured")
 			allBucketsCompliant = false
 			continue
 		}
 
-		config := publicAccessBlockOutput.PublicAccessBlockConfiguration
-
-		log.Println("  └─[*] Public Access Block Configuration:")
-		log.Printf("    └─[%s] Block new public ACLs: %t", getStatus(config.BlockPublicAcls), *config.BlockPublicAcls)
-		log.Printf("    └─[%s] Block public access via any ACLs: %t", getStatus(config.IgnorePublicAcls), *config.IgnorePublicAcls)
-		log.Printf("    └─[%s] Block new public bucket policies: %t", getStatus(config.BlockPublicPolicy), *config.BlockPublicPolicy)
-		log.Printf("    └─[%s] Block public and cross-account access via any bucket policy: %t", getStatus(config.RestrictPublicBuckets), *config.RestrictPublicBuckets)
+		config := bucket.PublicAccessBlock
+		compliant := config.BlockPublicAcls && config.IgnorePublicAcls && config.BlockPublicPolicy && config.RestrictPublicBuckets
 
-		if !*config.BlockPublicAcls || !*config.IgnorePublicAcls || !*config.BlockPublicPolicy || !*config.RestrictPublicBuckets {
+		if !compliant {
+			logger.Info("public access block settings are not all enabled", "status", "FAIL",
+				"block_public_acls", config.BlockPublicAcls, "ignore_public_acls", config.IgnorePublicAcls,
+				"block_public_policy", config.BlockPublicPolicy, "restrict_public_buckets", config.RestrictPublicBuckets)
+			findings.Fail(bucket.Name, "Public access block settings are not all enabled")
 			allBucketsCompliant = false
+		} else {
+			logger.Debug("all public access block settings are enabled", "status", "PASS")
+			findings.Pass(bucket.Name, "All public access block settings are enabled")
 		}
 	}
 
 	if allBucketsCompliant {
-		log.Println("└─[PASS] All S3 buckets have appropriate public access block settings")
-		return "PASS"
+		logger.Debug("all S3 buckets have appropriate public access block settings")
+		return "PASS", findings
 	} else {
-		log.Println("└─[FAIL] One or more S3 buckets do not have appropriate public access block settings")
-		return "FAIL"
-	}
-}
-
-func getStatus(value *bool) string {
-	if *value {
-		return "PASS"
+		logger.Debug("one or more S3 buckets do not have appropriate public access block settings")
+		return "FAIL", findings
 	}
-	return "FAIL"
 }
@@ -0,0 +1,209 @@
+// cmd/catalog.go
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"aws-security-hub/audit"
+	"aws-security-hub/compliance"
+	"aws-security-hub/coverage"
+	"aws-security-hub/inventory"
+	"aws-security-hub/logging"
+	"aws-security-hub/permissions"
+
+	"github.com/spf13/cobra"
+)
+
+// Browse the catalogue of controls
+var controlsCmd = &cobra.Command{
+	Use:   "controls",
+	Short: "List the Security Hub controls and show their metadata",
+}
+
+var controlsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List controls, implemented or not, filtered by service, severity and framework",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		var filter audit.Filter
+		filter.Services, _ = cmd.Flags().GetStringSlice("service")
+		filter.Severities, _ = cmd.Flags().GetStringSlice("severity")
+		filter.Frameworks, _ = cmd.Flags().GetStringSlice("framework")
+		filter.Implemented, _ = cmd.Flags().GetBool("implemented")
+		filter.Unimplemented, _ = cmd.Flags().GetBool("unimplemented")
+		for _, framework := range filter.Frameworks {
+			if !audit.IsFramework(framework) {
+				logging.Fatal("unknown framework", "framework", framework, "supported", audit.Frameworks())
+			}
+		}
+
+		var entries []audit.Entry
+		for _, entry := range catalog() {
+			if filter.Match(entry) {
+				entries = append(entries, entry)
+			}
+		}
+		writeCatalog(cmd, entries, func() error { return audit.WriteTable(os.Stdout, entries) })
+	},
+}
+
+var controlsShowCmd = &cobra.Command{
+	Use:   "show <control>",
+	Short: "Show the compliance attributes, parameters, IAM actions and framework mappings of a control",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		entry := audit.Find(catalog(), args[0])
+		if entry == nil {
+			logging.Fatal("unknown control", "control", args[0])
+		}
+		writeCatalog(cmd, entry, func() error { return audit.WriteDetails(os.Stdout, *entry) })
+	},
+}
+
+// catalog returns the catalogue of Security Hub controls, the compliance JSON and the implemented controls
+func catalog() []audit.Entry {
+	return audit.Catalog(controls(), compliance.SecurityHub(), compliance.CIS(), securityhubCatalogue())
+}
+
+// securityhubCatalogue returns the embedded list of every Security Hub control
+func securityhubCatalogue() coverage.Catalogue {
+	catalogue, err := coverage.Load()
+	if err != nil {
+		logging.Fatal("failed to load the control catalogue", "error", err)
+	}
+	return catalogue
+}
+
+// implementedIDs returns the IDs of the registry: built-in controls and rules
+func implementedIDs() []string {
+	var ids []string
+	for _, control := range controls() {
+		ids = append(ids, control.Metadata().ID)
+	}
+	return ids
+}
+
+// Compare the registry to the Security Hub catalogue
+var coverageCmd = &cobra.Command{
+	Use:   "coverage",
+	Short: "Show the percentage of Security Hub controls implemented per service and the missing ones",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		format, _ := cmd.Flags().GetString("format")
+		missing, _ := cmd.Flags().GetBool("missing")
+		result := coverage.Compute(securityhubCatalogue(), implementedIDs())
+
+		var err error
+		switch format {
+		case "table":
+			err = result.WriteTable(os.Stdout, missing)
+		case "markdown":
+			err = result.WriteMarkdown(os.Stdout, missing)
+		case "json":
+			err = result.WriteJSON(os.Stdout)
+		default:
+			logging.Fatal("unsupported format", "format", format, "supported", []string{"table", "markdown", "json"})
+		}
+		if err != nil {
+			logging.Fatal("failed to write coverage", "error", err)
+		}
+	},
+}
+
+var coverageReadmeCmd = &cobra.Command{
+	Use:   "readme [path]",
+	Short: "Check that the README checkboxes match the implemented controls (default README.md)",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		readmePath := "README.md"
+		if len(args) > 0 {
+			readmePath = args[0]
+		}
+		data, err := os.ReadFile(readmePath)
+		if err != nil {
+			logging.Fatal("failed to read README", "path", readmePath, "error", err)
+		}
+		implemented := implementedIDs()
+
+		if fix, _ := cmd.Flags().GetBool("fix"); fix {
+			fixed := coverage.FixReadme(string(data), implemented)
+			if fixed != string(data) {
+				if err := os.WriteFile(readmePath, []byte(fixed), 0644); err != nil {
+					logging.Fatal("failed to write README", "path", readmePath, "error", err)
+				}
+				slog.Info("updated the checkboxes", "path", readmePath)
+			}
+			data = []byte(fixed)
+		}
+
+		mismatches := coverage.CheckReadme(string(data), securityhubCatalogue(), implemented)
+		if len(mismatches) == 0 {
+			fmt.Printf("%s matches the %d implemented controls\n", readmePath, len(implemented))
+			return
+		}
+		fmt.Printf("%s disagrees with the registry:\n", readmePath)
+		for _, mismatch := range mismatches {
+			fmt.Printf("  └─[FAIL] %s\n", mismatch)
+		}
+		os.Exit(1)
+	},
+}
+
+// writeCatalog prints catalogue entries as JSON with --format json, otherwise through table
+func writeCatalog(cmd *cobra.Command, v interface{}, table func() error) {
+	format, _ := cmd.Flags().GetString("format")
+	var err error
+	switch format {
+	case "table":
+		err = table()
+	case "json":
+		err = audit.WriteJSON(os.Stdout, v)
+	default:
+		logging.Fatal("unsupported format", "format", format, "supported", []string{"table", "json"})
+	}
+	if err != nil {
+		logging.Fatal("failed to write controls", "error", err)
+	}
+}
+
+// Print the IAM policy the scanner needs
+var iamPolicyCmd = &cobra.Command{
+	Use:   "iam-policy [control]...",
+	Short: "Print the minimal read-only IAM policy for every control (or the given ones)",
+	Run: func(cmd *cobra.Command, args []string) {
+		services, _ := cmd.Flags().GetStringSlice("service")
+		_, selected := scanConfig(args, services)
+		if err := permissions.Policy(audit.Permissions(selected)).Write(os.Stdout); err != nil {
+			logging.Fatal("failed to write policy", "error", err)
+		}
+	},
+}
+
+func init() {
+	// Control catalogue
+	controlsListCmd.Flags().StringSlice("service", nil, "Only list controls of these services, e.g. cloudfront,s3")
+	controlsListCmd.Flags().StringSlice("severity", nil, "Only list controls of these severities, e.g. critical,high")
+	controlsListCmd.Flags().StringSlice("framework", nil, "Only list controls mapped to these frameworks: "+strings.Join(audit.Frameworks(), ", "))
+	controlsListCmd.Flags().Bool("implemented", false, "Only list implemented controls")
+	controlsListCmd.Flags().Bool("unimplemented", false, "Only list controls that are not implemented yet")
+	controlsListCmd.MarkFlagsMutuallyExclusive("implemented", "unimplemented")
+	for _, cmd := range []*cobra.Command{controlsListCmd, controlsShowCmd} {
+		cmd.Flags().String("format", "table", "Output format: table, json")
+		controlsCmd.AddCommand(cmd)
+	}
+	rootCmd.AddCommand(controlsCmd)
+
+	// Coverage of the Security Hub catalogue
+	coverageCmd.Flags().String("format", "table", "Output format: table, markdown, json")
+	coverageCmd.Flags().Bool("missing", false, "List the controls that are not implemented yet")
+	coverageReadmeCmd.Flags().Bool("fix", false, "Set the checkboxes to the implemented state before checking")
+	coverageCmd.AddCommand(coverageReadmeCmd)
+	rootCmd.AddCommand(coverageCmd)
+
+	// Permissions
+	iamPolicyCmd.Flags().StringSlice("service", nil, "Only include controls of these inventory services: "+strings.Join(inventory.Services, ", "))
+	rootCmd.AddCommand(iamPolicyCmd)
+}
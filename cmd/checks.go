@@ -0,0 +1,55 @@
+// cmd/checks.go
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"aws-security-hub/audit"
+	"aws-security-hub/inventory"
+	"aws-security-hub/logging"
+	"aws-security-hub/types"
+
+	"github.com/spf13/cobra"
+)
+
+// checkCmd returns the command that evaluates a single built-in control against the account, named
+// after its check (e.g. cloudfront-default-root-object-configured) with its lowercased ID as alias
+func checkCmd(metadata types.Metadata, title string) *cobra.Command {
+	return &cobra.Command{
+		Use:     metadata.Check,
+		Short:   title,
+		Aliases: []string{strings.ToLower(metadata.ID)},
+		Args:    cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			client, err := initAWSClient()
+			if err != nil {
+				logging.Fatal("failed to initialize AWS client", "error", err)
+			}
+			// Looked up in the registry rather than captured, so that rules and packs that reuse the
+			// ID of a built-in control are reported the same way as by the other commands
+			selected, err := audit.Select(controls(), []string{metadata.ID}, nil)
+			if err != nil {
+				logging.Fatal("failed to select the control", "error", err)
+			}
+			inv, err := inventory.Collect(cmd.Context(), client.Config, audit.ControlService(selected[0]))
+			if err != nil {
+				slog.Error("inventory is incomplete", "error", err)
+			}
+			status, _ := types.Resolve(selected[0].Evaluate(cmd.Context(), types.Clients{Inventory: inv, AWS: &client.Config}))
+			fmt.Printf("[%s] %s\n", metadata.ID, status)
+		},
+	}
+}
+
+func init() {
+	// A command per built-in control; rules and packs are only known once the flags are parsed and
+	// are evaluated with evaluate and all
+	catalogue := securityhubCatalogue()
+	for _, control := range audit.Controls() {
+		metadata := control.Metadata()
+		entry, _ := catalogue.Find(metadata.ID)
+		rootCmd.AddCommand(checkCmd(metadata, entry.Title))
+	}
+}
@@ -0,0 +1,175 @@
+// cmd/client.go
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+
+	"aws-security-hub/identity"
+	"aws-security-hub/logging"
+	"aws-security-hub/settings"
+	"aws-security-hub/throttle"
+	"aws-security-hub/types"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func initAWSClient() (*types.AWSClient, error) {
+	return newAWSClient(viper.GetString("aws.region"), nil)
+}
+
+// newAWSClient creates a client for a region, of a member account when one is given
+func newAWSClient(region string, account *settings.Account) (*types.AWSClient, error) {
+	options, err := credentialOptions()
+	if err != nil {
+		return nil, err
+	}
+	loadOptions := append(identity.LoadOptions(options), config.WithRegion(region))
+	// Keys from .env, which the SDK does not read itself; a profile takes precedence over them
+	if key, secret := viper.GetString("aws_access_key_id"), viper.GetString("aws_secret_access_key"); options.Profile == "" && key != "" && secret != "" {
+		loadOptions = append(loadOptions, config.WithCredentialsProvider(
+			credentials.NewStaticCredentialsProvider(key, secret, viper.GetString("aws_session_token"))))
+	}
+	cfg, err := config.LoadDefaultConfig(context.TODO(), loadOptions...)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load SDK config: %v", identity.Explain(err, options.Profile))
+	}
+
+	policy, err := sharedPolicy()
+	if err != nil {
+		return nil, err
+	}
+	policy.Apply(&cfg)
+
+	// Reuse the credentials of the first client, so that a role is assumed (and an MFA code asked) once per run
+	credentialsMu.Lock()
+	defer credentialsMu.Unlock()
+	if awsCredentials == nil {
+		identity.AssumeRole(&cfg, options)
+		awsCredentials = cfg.Credentials
+	}
+	cfg.Credentials = awsCredentials
+
+	if account != nil {
+		provider, ok := accountCredentials[account.RoleARN]
+		if !ok {
+			identity.AssumeRole(&cfg, identity.Options{RoleARN: account.RoleARN, ExternalID: account.ExternalID, SessionName: options.SessionName, Duration: options.Duration})
+			provider = cfg.Credentials
+			accountCredentials[account.RoleARN] = provider
+		}
+		cfg.Credentials = provider
+	}
+
+	return &types.AWSClient{Config: cfg}, nil
+}
+
+var (
+	credentialsMu sync.Mutex
+	// awsCredentials are the credentials shared by every client of the process
+	awsCredentials aws.CredentialsProvider
+	// accountCredentials are the credentials of the member accounts, by role ARN
+	accountCredentials = make(map[string]aws.CredentialsProvider)
+)
+
+// credentialOptions reads the aws section of the configuration
+func credentialOptions() (identity.Options, error) {
+	config, err := resolvedConfig()
+	if err != nil {
+		return identity.Options{}, err
+	}
+	return config.AWS.Credentials()
+}
+
+var (
+	policyMu sync.Mutex
+	// apiPolicy retries, paces and counts the AWS API calls of every client of the process
+	apiPolicy *throttle.Policy
+)
+
+// sharedPolicy returns the policy of the process, created by the first client so that clients of
+// concurrent scans (serve --workers, daemon groups) share its retryer, rate limits and stats
+func sharedPolicy() (*throttle.Policy, error) {
+	policyMu.Lock()
+	defer policyMu.Unlock()
+	if apiPolicy == nil {
+		policy, err := throttlePolicy()
+		if err != nil {
+			return nil, err
+		}
+		apiPolicy = policy
+	}
+	return apiPolicy, nil
+}
+
+// throttlePolicy reads the api section of the configuration
+func throttlePolicy() (*throttle.Policy, error) {
+	config, err := resolvedConfig()
+	if err != nil {
+		return nil, err
+	}
+	options, err := config.API.Throttle()
+	if err != nil {
+		return nil, err
+	}
+	return throttle.New(options)
+}
+
+// apiSummary returns the API calls made so far, or nil when the run did not call AWS
+func apiSummary() *throttle.Summary {
+	policyMu.Lock()
+	policy := apiPolicy
+	policyMu.Unlock()
+	if policy == nil {
+		return nil
+	}
+	summary := policy.Stats().Summary()
+	if summary.Calls == 0 {
+		return nil
+	}
+	return &summary
+}
+
+// Print the caller identity the scanner runs as
+var whoamiCmd = &cobra.Command{
+	Use:   "whoami",
+	Short: "Print the AWS account and principal the resolved credentials belong to",
+	Run: func(cmd *cobra.Command, args []string) {
+		client, err := initAWSClient()
+		if err != nil {
+			logging.Fatal("failed to initialize AWS client", "error", err)
+		}
+		// The profile the client was built with: --profile, AUDIT_AWS_PROFILE or aws.profile
+		options, err := credentialOptions()
+		if err != nil {
+			logging.Fatal("invalid configuration", "error", err)
+		}
+		caller, err := identity.Whoami(cmd.Context(), client.Config, options.Profile)
+		if err != nil {
+			logging.Fatal("failed to resolve the caller identity", "error", err)
+		}
+
+		if asJSON, _ := cmd.Flags().GetBool("json"); asJSON {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "    ")
+			if err := encoder.Encode(caller); err != nil {
+				logging.Fatal("failed to write identity", "error", err)
+			}
+			return
+		}
+		fmt.Printf("Account: %s\nArn:     %s\nUserId:  %s\nSource:  %s\nRegion:  %s\n",
+			caller.Account, caller.ARN, caller.UserID, caller.Source, client.Config.Region)
+	},
+}
+
+func init() {
+	// Caller identity
+	whoamiCmd.Flags().Bool("json", false, "Print the identity as JSON")
+	rootCmd.AddCommand(whoamiCmd)
+}
@@ -0,0 +1,163 @@
+// cmd/config.go
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"aws-security-hub/audit"
+	"aws-security-hub/compliance"
+	"aws-security-hub/logging"
+	"aws-security-hub/pack"
+	"aws-security-hub/rules"
+	"aws-security-hub/settings"
+	"aws-security-hub/types"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+// scanConfig validates the configuration and returns it with the controls to run: the given
+// control IDs and services, or the controls, services and frameworks of the configuration
+func scanConfig(ids, services []string) (*settings.Config, []types.Control) {
+	config, err := resolvedConfig()
+	if err != nil {
+		logging.Fatal("invalid configuration", "error", err)
+	}
+	all := controls()
+	if err := config.Validate(all); err != nil {
+		logging.Fatal("invalid configuration", "error", err)
+	}
+	for _, suppression := range config.Suppressions {
+		if suppression.Expired(time.Now()) {
+			slog.Warn("suppression expired", "control", suppression.Control, "resource", suppression.Resource, "expires", suppression.Expires)
+		}
+	}
+
+	explicit := len(ids) > 0
+	if !explicit && len(services) == 0 {
+		ids, services = config.Controls, config.Services
+	}
+	selected, err := audit.Select(all, ids, services)
+	if err != nil {
+		logging.Fatal("invalid controls", "error", err)
+	}
+	if !explicit && len(config.Frameworks) > 0 {
+		if selected = audit.SelectFrameworks(selected, compliance.SecurityHub(), config.Frameworks); len(selected) == 0 {
+			logging.Fatal("invalid controls: no controls map to the frameworks", "frameworks", config.Frameworks)
+		}
+	}
+	return config, selected
+}
+
+// Check the resolved configuration
+var configValidateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Check the configuration file merged with the environment and flags",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		_, selected := scanConfig(nil, nil)
+		source := "defaults, environment and flags (no configuration file)"
+		if configFile != nil {
+			source = configPath
+		}
+		fmt.Printf("Configuration from %s is valid: %d control(s) selected\n", source, len(selected))
+	},
+}
+
+// Print the resolved configuration
+var configPrintEffectiveCmd = &cobra.Command{
+	Use:   "print-effective",
+	Short: "Print the configuration merged from flags, environment, configuration file and defaults",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		config, err := resolvedConfig()
+		if err != nil {
+			logging.Fatal("invalid configuration", "error", err)
+		}
+		encoder := yaml.NewEncoder(os.Stdout)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(config); err != nil {
+			logging.Fatal("failed to write configuration", "error", err)
+		}
+	},
+}
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Validate or print the scan configuration",
+}
+
+// controls returns the built-in controls followed by the user-authored rules loaded from --rules
+func controls() []types.Control {
+	controls := audit.Controls()
+
+	if dir := viper.GetString("rules"); dir != "" {
+		userControls, err := rules.Controls(dir)
+		if err != nil {
+			logging.Fatal("failed to load rules", "error", err)
+		}
+		slog.Info("loaded rules", "count", len(userControls), "dir", dir)
+		controls = append(controls, userControls...)
+	}
+
+	paths := viper.GetStringSlice("packs")
+	for _, path := range paths {
+		controls = append(controls, loadPack(path).Controls()...)
+	}
+	if err := audit.CheckUnique(controls); err != nil {
+		logging.Fatal("invalid controls", "error", err)
+	}
+	// Parameters of pack controls are only known once their packs run
+	if len(paths) > 0 && configFile != nil {
+		if err := configFile.ValidateParameters(controls); err != nil {
+			logging.Fatal("invalid configuration", "path", configPath, "error", err)
+		}
+	}
+	return controls
+}
+
+// packs are the control packs started by the command, by path, closed once it completes. serve
+// and daemon list the controls from several goroutines.
+var (
+	packsMu sync.Mutex
+	packs   = make(map[string]*pack.Pack)
+)
+
+// loadPack starts a control pack, once per command
+func loadPack(path string) *pack.Pack {
+	packsMu.Lock()
+	defer packsMu.Unlock()
+	if p, ok := packs[path]; ok {
+		return p
+	}
+	p, err := pack.Load(path)
+	if err != nil {
+		logging.Fatal("failed to load control pack", "error", err)
+	}
+	slog.Info("loaded control pack", "pack", p.Name, "version", p.Version, "controls", len(p.Controls()), "path", path)
+	packs[path] = p
+	return p
+}
+
+// closePacks stops the control packs started by the command
+func closePacks() {
+	packsMu.Lock()
+	defer packsMu.Unlock()
+	for path, p := range packs {
+		if err := p.Close(); err != nil {
+			slog.Warn("control pack did not exit cleanly", "pack", p.Name, "error", err)
+		}
+		delete(packs, path)
+	}
+}
+
+func init() {
+	configCmd.AddCommand(configValidateCmd)
+	configCmd.AddCommand(configPrintEffectiveCmd)
+	rootCmd.AddCommand(configCmd)
+}
@@ -0,0 +1,128 @@
+// cmd/iac.go
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"aws-security-hub/audit"
+	"aws-security-hub/iac/cloudformation"
+	"aws-security-hub/iac/terraform"
+	"aws-security-hub/inventory"
+	"aws-security-hub/logging"
+	"aws-security-hub/report"
+	"aws-security-hub/snippets"
+	"aws-security-hub/types"
+
+	"github.com/spf13/cobra"
+)
+
+// Evaluate all controls against CloudFormation templates before deployment
+var cloudformationCmd = &cobra.Command{
+	Use:     "cloudformation <template>...",
+	Short:   "Evaluate all controls against CloudFormation YAML/JSON templates",
+	Aliases: []string{"cfn"},
+	Args:    cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		config, controls := scanConfig(nil, nil)
+		result := &report.Report{GeneratedAt: time.Now().UTC()}
+		for _, path := range args {
+			template, err := cloudformation.ParseTemplate(path)
+			if err != nil {
+				logging.Fatal("failed to load template", "path", path, "error", err)
+			}
+
+			slog.Info("evaluating template", "path", path, "resources", len(template.Resources))
+			inv := cloudformation.BuildInventory(template)
+			result.Add(report.Run(cmd.Context(), inv, path, controls, reportOptions(config)))
+		}
+
+		writeReport(cmd, config, result)
+		stopped(cmd)
+		if result.Failed() {
+			os.Exit(1)
+		}
+	},
+}
+
+// Evaluate all controls against Terraform plans before apply
+var terraformCmd = &cobra.Command{
+	Use:     "terraform <plan.json>...",
+	Short:   "Evaluate all controls against Terraform plans exported with `terraform show -json`",
+	Aliases: []string{"tf"},
+	Args:    cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		config, controls := scanConfig(nil, nil)
+		result := &report.Report{GeneratedAt: time.Now().UTC()}
+		for _, path := range args {
+			plan, err := terraform.LoadPlan(path)
+			if err != nil {
+				logging.Fatal("failed to load plan", "path", path, "error", err)
+			}
+
+			slog.Info("evaluating plan", "path", path, "resource_changes", len(plan.ResourceChanges))
+			inv := terraform.BuildInventory(plan)
+			result.Add(report.Run(cmd.Context(), inv, path, controls, reportOptions(config)))
+		}
+
+		writeReport(cmd, config, result)
+		stopped(cmd)
+		if result.Failed() {
+			os.Exit(1)
+		}
+	},
+}
+
+// Print Terraform and CloudFormation fixes for the failing resources of an inventory snapshot
+var fixSnippetsCmd = &cobra.Command{
+	Use:   "fix-snippets <inventory.json> [control]...",
+	Short: "Print infrastructure-as-code fixes for failing resources (" + strings.Join(snippets.ControlIDs(), ", ") + ")",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		inv, err := inventory.Load(args[0])
+		if err != nil {
+			logging.Fatal("failed to load inventory", "error", err)
+		}
+
+		config, err := resolvedConfig()
+		if err != nil {
+			logging.Fatal("invalid configuration", "error", err)
+		}
+		controlIDs := args[1:]
+		if len(controlIDs) == 0 {
+			controlIDs = snippets.ControlIDs()
+		}
+		selected, err := audit.Select(controls(), controlIDs, nil)
+		if err != nil {
+			logging.Fatal("invalid controls", "error", err)
+		}
+		kind, _ := cmd.Flags().GetString("iac")
+
+		// Snippets cover the resources that fail each control once the suppressions are applied
+		for _, control := range selected {
+			id := control.Metadata().ID
+			status, findings := types.Resolve(control.Evaluate(cmd.Context(), types.Clients{Inventory: inv}))
+			_, findings = types.Suppress(id, status, findings, config.Suppressions)
+			for _, snippet := range snippets.Generate(id, inv, findings) {
+				fmt.Printf("# [%s] %s\n", snippet.Control, snippet.Resource)
+				if kind == "terraform" || kind == "all" {
+					fmt.Printf("%s\n\n", snippet.Terraform)
+				}
+				if kind == "cloudformation" || kind == "all" {
+					fmt.Printf("%s\n\n", snippet.CloudFormation)
+				}
+			}
+		}
+	},
+}
+
+func init() {
+	// Pre-deployment scanning
+	rootCmd.AddCommand(cloudformationCmd)
+	rootCmd.AddCommand(terraformCmd)
+	fixSnippetsCmd.Flags().String("iac", "all", "Snippets to print: terraform, cloudformation, all")
+	rootCmd.AddCommand(fixSnippetsCmd)
+}
@@ -0,0 +1,147 @@
+// cmd/remediate.go
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"aws-security-hub/identity"
+	"aws-security-hub/inventory"
+	"aws-security-hub/logging"
+	"aws-security-hub/remediate"
+	"aws-security-hub/types"
+
+	"github.com/spf13/cobra"
+)
+
+// Fix the failing resources of a control, printing a dry-run plan unless --apply is given
+var remediateCmd = &cobra.Command{
+	Use:   "remediate <control>",
+	Short: "Plan and apply fixes for a control (" + strings.Join(remediate.ControlIDs(), ", ") + ")",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		remediation, ok := remediate.Get(args[0])
+		if !ok {
+			logging.Fatal("no remediation for control", "control", args[0], "supported", remediate.ControlIDs())
+		}
+
+		config, err := resolvedConfig()
+		if err != nil {
+			logging.Fatal("invalid configuration", "error", err)
+		}
+		var control types.Control
+		for _, candidate := range controls() {
+			if candidate.Metadata().ID == remediation.ControlID {
+				control = candidate
+			}
+		}
+		if control == nil {
+			logging.Fatal("control not found", "control", remediation.ControlID)
+		}
+
+		client, err := initAWSClient()
+		if err != nil {
+			logging.Fatal("failed to initialize AWS client", "error", err)
+		}
+
+		inv, err := inventory.Collect(cmd.Context(), client.Config, remediation.Service)
+		if err != nil {
+			logging.Fatal("failed to collect inventory", "error", err)
+		}
+		if inv.AccountID == "" {
+			logging.Fatal("failed to resolve the account to remediate")
+		}
+
+		// Resources whose failures are suppressed are left as they are
+		status, findings := control.Evaluate(cmd.Context(), types.Clients{Inventory: inv, AWS: &client.Config})
+		_, findings = types.Suppress(remediation.ControlID, status, findings, config.Suppressions)
+
+		changes := remediate.Unsuppressed(remediation.Plan(inv), findings)
+		remediate.WritePlan(os.Stdout, "Remediation plan for "+remediation.ControlID, changes)
+		runRemediation(cmd, client, inv.AccountID, changes)
+	},
+}
+
+// Undo the changes listed in a rollback record
+var remediateRollbackCmd = &cobra.Command{
+	Use:   "rollback <record.json>",
+	Short: "Roll back the changes recorded by a previous remediation",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		record, err := remediate.LoadRecord(args[0])
+		if err != nil {
+			logging.Fatal("failed to load record", "error", err)
+		}
+
+		client, err := initAWSClient()
+		if err != nil {
+			logging.Fatal("failed to initialize AWS client", "error", err)
+		}
+		options, err := credentialOptions()
+		if err != nil {
+			logging.Fatal("invalid configuration", "error", err)
+		}
+		caller, err := identity.Whoami(cmd.Context(), client.Config, options.Profile)
+		if err != nil {
+			logging.Fatal("failed to resolve the caller identity", "error", err)
+		}
+		if err := record.Verify(caller.Account, client.Config.Region); err != nil {
+			logging.Fatal("refusing to roll back", "error", err)
+		}
+
+		changes := record.Inverse()
+		remediate.WritePlan(os.Stdout, "Rollback plan for "+args[0], changes)
+		runRemediation(cmd, client, caller.Account, changes)
+	},
+}
+
+// runRemediation applies planned changes to the account after confirmation and writes a rollback record
+func runRemediation(cmd *cobra.Command, client *types.AWSClient, account string, changes []remediate.Change) {
+	apply, _ := cmd.Flags().GetBool("apply")
+	if !apply || len(changes) == 0 {
+		if len(changes) > 0 {
+			fmt.Println("Dry run: no changes made. Re-run with --apply to execute the plan.")
+		}
+		return
+	}
+
+	if yes, _ := cmd.Flags().GetBool("yes"); !yes {
+		fmt.Printf("Apply %d change(s) in account %s, %s? Type 'yes' to continue: ", len(changes), account, client.Config.Region)
+		answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+		if strings.TrimSpace(answer) != "yes" {
+			fmt.Println("Aborted: no changes made.")
+			return
+		}
+	}
+
+	record, applyErr := remediate.ApplyAll(cmd.Context(), client.Config, account, changes)
+
+	recordPath, _ := cmd.Flags().GetString("record")
+	if recordPath == "" {
+		recordPath = fmt.Sprintf("remediation-%s.json", record.AppliedAt.Format("20060102T150405Z"))
+	}
+	if len(record.Changes) > 0 {
+		if err := remediate.SaveRecord(record, recordPath); err != nil {
+			logging.Fatal("failed to save rollback record", "error", err)
+		}
+		slog.Info("rollback record written", "path", recordPath)
+	}
+
+	if applyErr != nil {
+		logging.Fatal("remediation stopped", "error", applyErr)
+	}
+}
+
+func init() {
+	// Remediation
+	for _, cmd := range []*cobra.Command{remediateCmd, remediateRollbackCmd} {
+		cmd.Flags().Bool("apply", false, "Execute the plan instead of only printing it")
+		cmd.Flags().Bool("yes", false, "Skip the confirmation prompt when applying")
+		cmd.Flags().String("record", "", "Path to write the rollback record to (default remediation-<timestamp>.json)")
+	}
+	remediateCmd.AddCommand(remediateRollbackCmd)
+	rootCmd.AddCommand(remediateCmd)
+}
@@ -0,0 +1,118 @@
+// cmd/report.go
+package cmd
+
+import (
+	"log/slog"
+	"os"
+	"strings"
+
+	"aws-security-hub/audit"
+	"aws-security-hub/compliance"
+	"aws-security-hub/history"
+	"aws-security-hub/logging"
+	"aws-security-hub/metrics"
+	"aws-security-hub/report"
+	"aws-security-hub/settings"
+
+	"github.com/spf13/cobra"
+)
+
+// reportOptions returns what the evaluating commands add to their results
+func reportOptions(config *settings.Config) report.Options {
+	return report.Options{Snippets: config.Outputs.Snippets, Suppressions: config.Suppressions}
+}
+
+// writeReport scores the report, renders it in --format to --output, or to stdout when no output is
+// given, and writes its metrics to --metrics-textfile when given (outputs in the configuration file).
+// With --history-dir, posture trends are computed against the last report of the command stored
+// there, and the report is stored in turn unless the command was interrupted.
+func writeReport(cmd *cobra.Command, config *settings.Config, result *report.Report) {
+	result.API = apiSummary()
+
+	historyDir := config.Outputs.HistoryDir
+	var previous *report.Report
+	if historyDir != "" {
+		var err error
+		if previous, err = history.Latest(historyDir, cmd.Name()); err != nil {
+			slog.Error("failed to load previous report", "error", err)
+		}
+	}
+	result.Posture = report.NewPosture(result, controlServices(), controlFrameworks(), previous)
+
+	if textfile := config.Outputs.MetricsTextfile; textfile != "" {
+		recorder := metrics.New()
+		recorder.ObserveReport(result)
+		if err := recorder.WriteTextfile(textfile); err != nil {
+			logging.Fatal("failed to write metrics", "error", err)
+		}
+		slog.Info("metrics written", "path", textfile)
+	}
+
+	format, output := config.Outputs.Format, config.Outputs.Path
+
+	w := os.Stdout
+	if output != "" {
+		file, err := os.Create(output)
+		if err != nil {
+			logging.Fatal("failed to create report", "error", err)
+		}
+		defer file.Close()
+		w = file
+	}
+
+	if err := report.Write(w, format, result); err != nil {
+		logging.Fatal("failed to write report", "error", err)
+	}
+	if output != "" {
+		slog.Info("report written", "path", output)
+	}
+
+	if historyDir == "" {
+		return
+	}
+	// A partial report would read as a drop of the posture score
+	if cmd.Context().Err() != nil {
+		slog.Warn("command stopped before it completed, report not stored")
+		return
+	}
+	path, err := history.Save(historyDir, cmd.Name(), result)
+	if err != nil {
+		logging.Fatal("failed to store report", "error", err)
+	}
+	slog.Info("report stored", "path", path)
+}
+
+// controlServices returns the inventory service each control evaluates, by control ID
+func controlServices() func(id string) string {
+	services := make(map[string]string)
+	for _, control := range controls() {
+		services[control.Metadata().ID] = audit.ControlService(control)
+	}
+	return func(id string) string {
+		return services[id]
+	}
+}
+
+// controlFrameworks returns the frameworks each control maps to, by control ID
+func controlFrameworks() func(id string) []string {
+	frameworks := make(map[string][]string)
+	for _, control := range controls() {
+		frameworks[control.Metadata().ID] = audit.RequirementFrameworks(report.Requirement(compliance.SecurityHub(), control))
+	}
+	return func(id string) []string {
+		return frameworks[id]
+	}
+}
+
+func init() {
+	defaults := settings.Defaults()
+
+	// Reports
+	for _, cmd := range []*cobra.Command{evaluateCmd, allCmd, cloudformationCmd, terraformCmd} {
+		cmd.Flags().String("format", defaults.Outputs.Format, "Report format: "+strings.Join(report.Formats, ", "))
+		cmd.Flags().StringP("output", "o", "", "Path to write the report to (default stdout)")
+		cmd.Flags().Bool("snippets", false, "Attach Terraform/CloudFormation fix snippets to failing results")
+		cmd.Flags().String("metrics-textfile", "", "Path to write Prometheus metrics to for the node_exporter textfile collector")
+		cmd.Flags().String("history-dir", "", "Directory to store reports in and to compute posture trends against the last one of the command")
+	}
+}
@@ -0,0 +1,257 @@
+// cmd/root.go
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"aws-security-hub/audit"
+	"aws-security-hub/logging"
+	"aws-security-hub/settings"
+	"aws-security-hub/types"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var rootCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Audit your AWS resources",
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		bindFlags(cmd)
+		setupLogging()
+		withTimeout(cmd)
+	},
+	PersistentPostRun: func(cmd *cobra.Command, args []string) {
+		cancelTimeout()
+		closePacks()
+		if summary := apiSummary(); summary != nil {
+			slog.Info("AWS API usage", "summary", summary.String())
+		}
+	},
+}
+
+var (
+	// configFile is the configuration file that was loaded, nil without one
+	configFile *settings.Config
+	configPath string
+)
+
+// loadConfig merges the configuration file (--config, AUDIT_CONFIG, or audit.yaml when present) into viper
+func loadConfig() {
+	path, _ := rootCmd.PersistentFlags().GetString("config")
+	if path == "" {
+		path = os.Getenv("AUDIT_CONFIG")
+	}
+	if path == "" {
+		if _, err := os.Stat(defaultConfigFile); err != nil {
+			return
+		}
+		path = defaultConfigFile
+	}
+
+	file, values, err := settings.Load(path)
+	if err != nil {
+		logging.Fatal("invalid configuration", "path", path, "error", err)
+	}
+	// With control packs, controls validates the parameters once the packs have listed theirs
+	if len(file.Packs) == 0 {
+		if err := file.ValidateParameters(audit.Controls()); err != nil {
+			logging.Fatal("invalid configuration", "path", path, "error", err)
+		}
+	}
+	if err := viper.MergeConfigMap(values); err != nil {
+		logging.Fatal("failed to merge configuration", "path", path, "error", err)
+	}
+	configFile, configPath = file, path
+	types.SetParameters(file.Parameters)
+}
+
+const defaultConfigFile = "audit.yaml"
+
+// flagKeys maps flags to the configuration keys they override
+var flagKeys = map[string]string{
+	"region":                  "aws.region",
+	"profile":                 "aws.profile",
+	"role-arn":                "aws.role_arn",
+	"external-id":             "aws.external_id",
+	"mfa-serial":              "aws.mfa_serial",
+	"role-session-name":       "aws.role_session_name",
+	"role-duration":           "aws.role_duration",
+	"web-identity-token-file": "aws.web_identity_token_file",
+	"retry-mode":              "api.retry_mode",
+	"max-attempts":            "api.max_attempts",
+	"max-backoff":             "api.max_backoff",
+	"rate-limit":              "api.rate_limits",
+	"rules":                   "rules",
+	"pack":                    "packs",
+	"suppressions-file":       "suppressions_file",
+	"concurrency":             "concurrency",
+	"format":                  "outputs.format",
+	"output":                  "outputs.path",
+	"snippets":                "outputs.snippets",
+	"metrics-textfile":        "outputs.metrics_textfile",
+	"history-dir":             "outputs.history_dir",
+	"timeout":                 "timeout",
+	"control-timeout":         "control_timeout",
+	"log-level":               "log.level",
+	"log-format":              "log.format",
+}
+
+// bindFlags lets the flags of the running command override the configuration. Only report commands
+// bind --output, which names the inventory file of collect.
+func bindFlags(cmd *cobra.Command) {
+	for name, key := range flagKeys {
+		if name == "output" && cmd.Flags().Lookup("format") == nil {
+			continue
+		}
+		if flag := cmd.Flags().Lookup(name); flag != nil {
+			viper.BindPFlag(key, flag)
+		}
+	}
+}
+
+// setupLogging writes diagnostics to stderr at --log-level in --log-format, keeping stdout for reports
+func setupLogging() {
+	if err := logging.Setup(os.Stderr, viper.GetString("log.level"), viper.GetString("log.format")); err != nil {
+		logging.Fatal("invalid logging configuration", "error", err)
+	}
+}
+
+// untimed annotates the commands that run until they are stopped, which --timeout does not apply to
+const untimed = "untimed"
+
+// cancelTimeout releases the deadline --timeout put on the running command
+var cancelTimeout context.CancelFunc = func() {}
+
+// withTimeout bounds the context of the running command by --timeout
+func withTimeout(cmd *cobra.Command) {
+	if cmd.Annotations[untimed] != "" {
+		return
+	}
+	config, err := resolvedConfig()
+	if err != nil {
+		logging.Fatal("invalid configuration", "error", err)
+	}
+	timeout, _, err := config.Timeouts()
+	if err != nil {
+		logging.Fatal("invalid configuration", "error", err)
+	}
+	if timeout > 0 {
+		ctx, cancel := context.WithTimeout(cmd.Context(), timeout)
+		cmd.SetContext(ctx)
+		cancelTimeout = cancel
+	}
+}
+
+// stopped logs why the context of a command ended early and exits with 1. Reports of the controls
+// that completed must be written before.
+func stopped(cmd *cobra.Command) {
+	switch err := cmd.Context().Err(); err {
+	case nil:
+		return
+	case context.DeadlineExceeded:
+		slog.Error("stopped by --timeout before every control completed", "timeout", viper.GetString("timeout"))
+	default:
+		slog.Error("interrupted before every control completed")
+	}
+	os.Exit(1)
+}
+
+// resolvedConfig returns the configuration merged from flags, environment, configuration file and defaults
+func resolvedConfig() (*settings.Config, error) {
+	config := &settings.Config{}
+	if err := viper.Unmarshal(config, func(decoder *mapstructure.DecoderConfig) { decoder.TagName = "yaml" }); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %v", err)
+	}
+	if configFile != nil {
+		config.Parameters = configFile.Parameters
+	}
+	if config.SuppressionsFile != "" {
+		suppressions, err := settings.LoadSuppressions(config.SuppressionsFile)
+		if err != nil {
+			return nil, fmt.Errorf("invalid suppressions file %s: %v", config.SuppressionsFile, err)
+		}
+		config.Suppressions = append(config.Suppressions, suppressions...)
+	}
+	return config, nil
+}
+
+func init() {
+	// Every key of the configuration file, with its default (the region defaults to South Korea, ap-northeast-2)
+	defaults := settings.Defaults()
+	for key, value := range settings.Keys(defaults) {
+		viper.SetDefault(key, value)
+	}
+	// AUDIT_ environment variables override the configuration file, e.g. AUDIT_OUTPUTS_FORMAT for outputs.format
+	viper.SetEnvPrefix("audit")
+	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	viper.AutomaticEnv()
+
+	viper.BindEnv("aws_access_key_id", "AWS_ACCESS_KEY_ID")
+	viper.BindEnv("aws_secret_access_key", "AWS_SECRET_ACCESS_KEY")
+	viper.BindEnv("aws_session_token", "AWS_SESSION_TOKEN")
+	viper.BindEnv("aws.region", "AUDIT_AWS_REGION", "AWS_REGION")
+
+	viper.SetConfigFile(".env")
+	err := viper.ReadInConfig()
+	if err != nil {
+		slog.Debug("no .env file found, using environment variables")
+	} else if region := viper.GetString("aws_region"); region != "" {
+		// The region of .env is overridden by the configuration file
+		viper.SetDefault("aws.region", region)
+	}
+
+	// Configuration file, loaded once the flags are parsed
+	rootCmd.PersistentFlags().String("config", "", "Configuration file (default "+defaultConfigFile+" when present, or AUDIT_CONFIG)")
+	cobra.OnInitialize(loadConfig)
+
+	// User-authored rules
+	rootCmd.PersistentFlags().String("rules", defaults.Rules, "Directory of rule files to evaluate alongside the built-in controls")
+	rootCmd.PersistentFlags().StringSlice("pack", defaults.Packs, "Control pack executable to evaluate alongside the built-in controls (repeatable)")
+	rootCmd.PersistentFlags().String("suppressions-file", defaults.SuppressionsFile, "YAML file of suppressions added to those of the configuration; tui appends to it")
+
+	// AWS API retries and rate limits
+	rootCmd.PersistentFlags().String("retry-mode", defaults.API.RetryMode, "Retry mode of AWS API calls: standard, adaptive")
+	rootCmd.PersistentFlags().Int("max-attempts", defaults.API.MaxAttempts, "Attempts per AWS API call, including the first one")
+	rootCmd.PersistentFlags().String("max-backoff", defaults.API.MaxBackoff, "Maximum delay between attempts of an AWS API call")
+	rootCmd.PersistentFlags().StringToString("rate-limit", nil, "Requests per second by service, e.g. ec2=10,apigateway=5")
+
+	// Timeouts; Ctrl-C stops the controls in progress the same way
+	rootCmd.PersistentFlags().String("log-level", defaults.Log.Level, "Level of the diagnostics written to stderr: "+strings.Join(logging.Levels, ", "))
+	rootCmd.PersistentFlags().String("log-format", defaults.Log.Format, "Format of the diagnostics written to stderr: "+strings.Join(logging.Formats, ", "))
+	rootCmd.PersistentFlags().String("timeout", "", "Maximum duration of the command, e.g. 10m; the report lists the controls that completed (default none)")
+
+	// AWS credentials
+	rootCmd.PersistentFlags().String("region", defaults.AWS.Region, "AWS region to scan (default AWS_REGION)")
+	rootCmd.PersistentFlags().String("profile", "", "Shared config profile to use, including SSO and assume-role profiles (default AWS_PROFILE)")
+	rootCmd.PersistentFlags().String("role-arn", "", "IAM role to assume before scanning, e.g. to audit another account")
+	rootCmd.PersistentFlags().String("external-id", "", "External ID required by the trust policy of --role-arn")
+	rootCmd.PersistentFlags().String("mfa-serial", "", "MFA device required by the trust policy of --role-arn; the code is read from stdin")
+	rootCmd.PersistentFlags().String("role-session-name", "", "Session name of the assumed role (default aws-security-hub-<unix time>)")
+	rootCmd.PersistentFlags().String("role-duration", "", "Lifetime of the assumed role credentials, e.g. 1h (default 15m)")
+	rootCmd.PersistentFlags().String("web-identity-token-file", "", "OIDC token file to exchange for --role-arn instead of the profile credentials")
+}
+
+// Execute runs the command given on the command line. The first Ctrl-C cancels the context of the
+// command, which stops the controls in progress and still writes the report of the completed ones;
+// the second one exits at once.
+func Execute() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ctx.Done()
+		stop()
+		slog.Warn("interrupted: stopping the controls in progress (press Ctrl-C again to exit now)")
+	}()
+
+	if err := rootCmd.ExecuteContext(ctx); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,403 @@
+// cmd/scan.go
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"aws-security-hub/audit"
+	"aws-security-hub/compliance"
+	"aws-security-hub/history"
+	"aws-security-hub/inventory"
+	"aws-security-hub/logging"
+	"aws-security-hub/notify"
+	"aws-security-hub/permissions"
+	"aws-security-hub/report"
+	"aws-security-hub/settings"
+	"aws-security-hub/tui"
+	"aws-security-hub/types"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// Collect a normalized inventory snapshot for offline evaluation
+var collectCmd = &cobra.Command{
+	Use:   "collect",
+	Short: "Collect an inventory snapshot of the resources inspected by the controls",
+	Run: func(cmd *cobra.Command, args []string) {
+		output, _ := cmd.Flags().GetString("output")
+
+		client, err := initAWSClient()
+		if err != nil {
+			logging.Fatal("failed to initialize AWS client", "error", err)
+		}
+		preflight(cmd, client, controls())
+
+		slog.Info("collecting inventory")
+		inv, err := inventory.Collect(cmd.Context(), client.Config)
+		if err != nil {
+			slog.Error("inventory is incomplete", "error", err)
+		}
+
+		if err := inventory.Save(inv, output); err != nil {
+			logging.Fatal("failed to save inventory", "error", err)
+		}
+		slog.Info("inventory written", "path", output)
+		stopped(cmd)
+	},
+}
+
+// Evaluate all controls against an inventory snapshot
+var evaluateCmd = &cobra.Command{
+	Use:   "evaluate <inventory.json>",
+	Short: "Evaluate all controls against an inventory snapshot",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		inv, err := inventory.Load(args[0])
+		if err != nil {
+			logging.Fatal("failed to load inventory", "error", err)
+		}
+
+		config, selected := scanConfig(nil, nil)
+		writeReport(cmd, config, report.Run(cmd.Context(), inv, args[0], selected, reportOptions(config)))
+		stopped(cmd)
+	},
+}
+
+// Run every control against AWS, sharing one inventory cache between the controls of each account and region
+var allCmd = &cobra.Command{
+	Use:   "all [control]...",
+	Short: "Run every control (or the given ones) against AWS, fetching each resource once for all controls",
+	Run: func(cmd *cobra.Command, args []string) {
+		config, selected := scanConfig(args, nil)
+		targets := scanTargets(config)
+		clients := scanClients(cmd, targets, selected)
+		result := scanAll(cmd.Context(), config, targets, clients, selected, reportOptions(config))
+
+		writeReport(cmd, config, result)
+		notifyFailures(config, result)
+		stopped(cmd)
+		if result.Failed() {
+			os.Exit(1)
+		}
+	},
+}
+
+// Browse the results of a scan, an inventory snapshot or a report in a full-screen terminal UI
+var tuiCmd = &cobra.Command{
+	Use:   "tui [control]...",
+	Short: "Browse results in a full-screen terminal UI while the scan runs, and mark accepted failures as suppressions",
+	Run: func(cmd *cobra.Command, args []string) {
+		reportPath, _ := cmd.Flags().GetString("report")
+		inventoryPath, _ := cmd.Flags().GetString("inventory")
+		config, selected := scanConfig(args, nil)
+		uiOptions := tui.Options{Compliance: compliance.SecurityHub(), SuppressionsFile: config.SuppressionsFile}
+		var scan func(options report.Options) error
+		switch {
+		case reportPath != "":
+			previous, err := history.Load(reportPath)
+			if err != nil {
+				logging.Fatal("failed to load report", "path", reportPath, "error", err)
+			}
+			uiOptions.Title = reportPath
+			scan = func(options report.Options) error {
+				for _, result := range previous.Results {
+					options.Progress(result)
+				}
+				return nil
+			}
+		case inventoryPath != "":
+			inv, err := inventory.Load(inventoryPath)
+			if err != nil {
+				logging.Fatal("failed to load inventory", "error", err)
+			}
+			uiOptions.Title, uiOptions.Controls, uiOptions.Runs = inventoryPath, selected, 1
+			scan = func(options report.Options) error {
+				report.Run(cmd.Context(), inv, inventoryPath, selected, options)
+				return context.Cause(cmd.Context())
+			}
+		default:
+			// The clients are set up before the UI takes over the terminal, since MFA codes are read from stdin
+			targets := scanTargets(config)
+			clients := scanClients(cmd, targets, selected)
+			uiOptions.Title, uiOptions.Controls, uiOptions.Runs = "scan of "+scanSummary(targets), selected, len(targets)
+			scan = func(options report.Options) error {
+				scanAll(cmd.Context(), config, targets, clients, selected, options)
+				return context.Cause(cmd.Context())
+			}
+		}
+		ui := tui.New(uiOptions)
+
+		// Diagnostics would garble the screen: the log view shows them instead
+		if err := logging.Setup(ui.Logs(), viper.GetString("log.level"), "text"); err != nil {
+			logging.Fatal("invalid logging configuration", "error", err)
+		}
+		options := reportOptions(config)
+		options.Progress = ui.Add
+		go func() {
+			ui.Done(scan(options))
+		}()
+		err := ui.Run(cmd.Context())
+		setupLogging()
+		if err != nil {
+			logging.Fatal("failed to start the terminal UI", "error", err)
+		}
+	},
+}
+
+// scanSummary names the targets of a scan, e.g. "ap-northeast-2" or "3 accounts and regions"
+func scanSummary(targets []target) string {
+	if len(targets) == 1 && targets[0].Account == nil {
+		return targets[0].Region
+	}
+	return fmt.Sprintf("%d accounts and regions", len(targets))
+}
+
+// target is an account and region scanned by all
+type target struct {
+	Region  string
+	Account *settings.Account // nil for the account of the caller
+}
+
+func (t target) String() string {
+	if t.Account == nil {
+		return "region " + t.Region
+	}
+	name := t.Account.RoleARN
+	if t.Account.Name != "" {
+		name = t.Account.Name
+	} else if t.Account.ID != "" {
+		name = t.Account.ID
+	}
+	return fmt.Sprintf("account %s in region %s", name, t.Region)
+}
+
+// scanTargets returns every configured account in every configured region
+func scanTargets(config *settings.Config) []target {
+	regions := config.Regions
+	if len(regions) == 0 {
+		regions = []string{config.AWS.Region}
+	}
+	var targets []target
+	for _, region := range regions {
+		if len(config.Accounts) == 0 {
+			targets = append(targets, target{Region: region})
+		}
+		for i := range config.Accounts {
+			targets = append(targets, target{Region: region, Account: &config.Accounts[i]})
+		}
+	}
+	return targets
+}
+
+// scanClients initializes the AWS client of every target, assuming the roles of member accounts, and
+// checks their permissions with --preflight
+func scanClients(cmd *cobra.Command, targets []target, selected []types.Control) []*types.AWSClient {
+	clients := make([]*types.AWSClient, len(targets))
+	for i, target := range targets {
+		client, err := newAWSClient(target.Region, target.Account)
+		if err != nil {
+			logging.Fatal("failed to initialize AWS client", "target", target, "error", err)
+		}
+		preflight(cmd, client, selected)
+		clients[i] = client
+	}
+	return clients
+}
+
+// scanAll scans the targets, config.Concurrency at a time, and merges their reports
+func scanAll(ctx context.Context, config *settings.Config, targets []target, clients []*types.AWSClient, selected []types.Control, options report.Options) *report.Report {
+	_, controlTimeout, err := config.Timeouts()
+	if err != nil {
+		logging.Fatal("invalid configuration", "error", err)
+	}
+
+	var (
+		wg            sync.WaitGroup
+		mu            sync.Mutex
+		hits, misses  int
+		reports       = make([]*report.Report, len(targets))
+		slots         = make(chan struct{}, config.Concurrency)
+		logEachTarget = len(targets) > 1 || len(config.Accounts) > 0
+	)
+	for i, target := range targets {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+			if logEachTarget {
+				slog.Info("scanning", "target", target)
+			}
+			result, targetHits, targetMisses := scanTarget(ctx, clients[i], controlTimeout, target, selected, options)
+			mu.Lock()
+			reports[i], hits, misses = result, hits+targetHits, misses+targetMisses
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	result := &report.Report{GeneratedAt: time.Now().UTC()}
+	for _, targetReport := range reports {
+		result.Add(targetReport)
+	}
+	slog.Info("controls evaluated", "count", len(result.Results), "fetched", misses, "cached", hits)
+	return result
+}
+
+// targetCollector collects inventories from the accounts and regions of the configuration, the ones scan
+// covers, config.Concurrency at a time. The clients are set up once, so that roles are assumed (and MFA
+// codes read) before serve and daemon start.
+func targetCollector(cmd *cobra.Command, config *settings.Config) func(ctx context.Context, services []string) ([]report.Target, error) {
+	targets := scanTargets(config)
+	clients := scanClients(cmd, targets, controls())
+	slog.Info("collecting inventories", "targets", scanSummary(targets))
+
+	return func(ctx context.Context, services []string) ([]report.Target, error) {
+		var (
+			wg        sync.WaitGroup
+			mu        sync.Mutex
+			errs      []error
+			collected = make([]report.Target, len(targets))
+			slots     = make(chan struct{}, config.Concurrency)
+		)
+		for i, target := range targets {
+			wg.Add(1)
+			slots <- struct{}{}
+			go func() {
+				defer wg.Done()
+				defer func() { <-slots }()
+				inv, err := inventory.Collect(ctx, clients[i].Config, services...)
+				mu.Lock()
+				defer mu.Unlock()
+				if err != nil {
+					errs = append(errs, fmt.Errorf("%s: %w", target, err))
+				}
+				collected[i] = report.Target{Inventory: inv, AWS: &clients[i].Config}
+			}()
+		}
+		wg.Wait()
+
+		var kept []report.Target
+		for _, target := range collected {
+			if target.Inventory != nil {
+				kept = append(kept, target)
+			}
+		}
+		return kept, errors.Join(errs...)
+	}
+}
+
+// scanTarget runs the controls against one account and region, and returns the inventory cache hits and misses.
+// Each control gets the control timeout to collect what it needs; once the command is interrupted or
+// times out, the control in progress and the remaining ones are left out of the report.
+func scanTarget(ctx context.Context, client *types.AWSClient, controlTimeout time.Duration, target target, selected []types.Control, options report.Options) (*report.Report, int, int) {
+	cache := inventory.NewCache()
+	result := &report.Report{}
+	options.AWS = &client.Config
+	for i, control := range selected {
+		var services []string
+		if service := audit.ControlService(control); service != "" {
+			services = append(services, service)
+		}
+
+		// The control timeout bounds both the collection and the evaluation, which calls AWS or a
+		// control pack for some controls
+		controlCtx, cancel := controlContext(ctx, controlTimeout)
+		inv, err := cache.Collect(controlCtx, client.Config, services...)
+		if ctx.Err() != nil {
+			cancel()
+			slog.Warn("controls not evaluated", "count", len(selected)-i, "target", target, "cause", context.Cause(ctx))
+			break
+		}
+		if err != nil {
+			slog.Error("inventory is incomplete", "control", control.Metadata().ID, "target", target, "error", err)
+		}
+		result.Add(report.Run(controlCtx, inv, "aws", []types.Control{control}, options))
+		cancel()
+	}
+	hits, misses := cache.Stats()
+	return result, hits, misses
+}
+
+// controlContext bounds a control by the control timeout, when there is one
+func controlContext(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// notifyFailures sends the failing controls of a run to the notifiers of the configuration
+func notifyFailures(config *settings.Config, result *report.Report) {
+	failures := notify.Failures(result)
+	if len(failures) == 0 {
+		return
+	}
+	event := notify.Event{Group: "all", Time: time.Now().UTC(), Failures: failures}
+	for _, notifierConfig := range config.Notifiers {
+		notifier, err := notify.New(notifierConfig, notify.OnFailures)
+		if err != nil {
+			slog.Error("invalid notifier", "error", err)
+			continue
+		}
+		if err := notifier.Notify(event); err != nil {
+			slog.Error("failed to notify", "notifier", notifierConfig.Type, "error", err)
+		}
+	}
+}
+
+// preflight simulates the IAM actions of the controls for the caller when --preflight is given,
+// and stops before scanning when any of them is denied
+func preflight(cmd *cobra.Command, client *types.AWSClient, controls []types.Control) {
+	if enabled, _ := cmd.Flags().GetBool("preflight"); !enabled {
+		return
+	}
+
+	actions := audit.Permissions(controls)
+	slog.Info("simulating IAM actions before scanning", "count", len(actions))
+	principal, decisions, err := permissions.Simulate(cmd.Context(), client.Config, actions)
+	if err != nil {
+		slog.Warn("preflight skipped", "error", err)
+		return
+	}
+
+	denied := 0
+	for _, decision := range decisions {
+		if !decision.Allowed() {
+			slog.Warn("action denied", "action", decision.Action, "decision", decision.Decision)
+			denied++
+		}
+	}
+	if denied > 0 {
+		logging.Fatal("preflight failed: run 'iam-policy' for the policy the scanner needs", "principal", principal, "denied", denied, "actions", len(actions))
+	}
+	slog.Info("preflight passed: every action is allowed", "principal", principal)
+}
+
+func init() {
+	defaults := settings.Defaults()
+
+	// Offline inventory snapshot
+	collectCmd.Flags().StringP("output", "o", "inventory.json", "Path to write the inventory snapshot to")
+	rootCmd.AddCommand(collectCmd)
+	rootCmd.AddCommand(evaluateCmd)
+	rootCmd.AddCommand(allCmd)
+	rootCmd.AddCommand(tuiCmd)
+	tuiCmd.Flags().String("report", "", "JSON report to browse instead of scanning")
+	tuiCmd.Flags().String("inventory", "", "Inventory snapshot to evaluate instead of scanning AWS")
+	tuiCmd.MarkFlagsMutuallyExclusive("report", "inventory")
+	for _, cmd := range []*cobra.Command{allCmd, tuiCmd} {
+		cmd.Flags().Int("concurrency", defaults.Concurrency, "Accounts and regions of the configuration scanned at the same time")
+		cmd.Flags().String("control-timeout", "", "Maximum duration of each control, including collecting its resources, e.g. 30s; a control that exceeds it is reported as ERROR (default none)")
+	}
+	for _, cmd := range []*cobra.Command{collectCmd, allCmd, tuiCmd} {
+		cmd.Flags().Bool("preflight", false, "Simulate the IAM actions of the controls before scanning and stop if any is denied")
+	}
+}
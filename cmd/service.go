@@ -0,0 +1,175 @@
+// cmd/service.go
+package cmd
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"aws-security-hub/daemon"
+	"aws-security-hub/history"
+	"aws-security-hub/logging"
+	"aws-security-hub/metrics"
+	"aws-security-hub/notify"
+	"aws-security-hub/report"
+	"aws-security-hub/server"
+	"aws-security-hub/tickets"
+
+	"github.com/spf13/cobra"
+)
+
+// Run the auditor as a service with a REST API for triggering and querying scans
+var serveCmd = &cobra.Command{
+	Use:         "serve",
+	Short:       "Serve a REST API to list controls, queue scans and fetch their results",
+	Args:        cobra.NoArgs,
+	Annotations: map[string]string{untimed: "true"},
+	Run: func(cmd *cobra.Command, args []string) {
+		addr, _ := cmd.Flags().GetString("addr")
+		workers, _ := cmd.Flags().GetInt("workers")
+		queueSize, _ := cmd.Flags().GetInt("queue-size")
+		history, _ := cmd.Flags().GetInt("history")
+
+		config, _ := scanConfig(nil, nil)
+		collect := targetCollector(cmd, config)
+		options := reportOptions(config)
+		options.Snippets = true
+		srv := server.New(controls, collect, server.Options{
+			Workers:   workers,
+			QueueSize: queueSize,
+			History:   history,
+			Metrics:   metrics.New(),
+			Report:    options,
+		})
+		srv.Start(cmd.Context())
+
+		httpServer := &http.Server{Addr: addr, Handler: srv.Handler()}
+		go func() {
+			<-cmd.Context().Done()
+			httpServer.Shutdown(context.Background())
+		}()
+		slog.Info("listening", "addr", addr)
+		if err := httpServer.ListenAndServe(); err != http.ErrServerClosed {
+			logging.Fatal("server stopped", "error", err)
+		}
+		slog.Info("server stopped")
+	},
+}
+
+// Run control groups on cron schedules, keeping history and notifying status transitions
+var daemonCmd = &cobra.Command{
+	Use:         "daemon",
+	Short:       "Run control groups on cron schedules and notify on PASS/FAIL transitions",
+	Args:        cobra.NoArgs,
+	Annotations: map[string]string{untimed: "true"},
+	Run: func(cmd *cobra.Command, args []string) {
+		schedulePath, _ := cmd.Flags().GetString("schedule")
+		runNow, _ := cmd.Flags().GetBool("run-now")
+		metricsAddr, _ := cmd.Flags().GetString("metrics-addr")
+
+		config, err := daemon.LoadConfig(schedulePath)
+		if err != nil {
+			logging.Fatal("failed to load schedule", "error", err)
+		}
+
+		auditConfig, _ := scanConfig(nil, nil)
+		collect := targetCollector(cmd, auditConfig)
+
+		notifiers := []notify.Notifier{notify.Log{}}
+		if config.WebhookURL != "" {
+			notifiers = append(notifiers, notify.Filter{Notifier: notify.Webhook{URL: config.WebhookURL}, On: notify.OnTransitions})
+		}
+		for _, notifierConfig := range config.Notifiers {
+			notifier, err := notify.New(notifierConfig, notify.OnTransitions)
+			if err != nil {
+				logging.Fatal("invalid notifier", "error", err)
+			}
+			notifiers = append(notifiers, notifier)
+		}
+
+		var recorder *metrics.Metrics
+		if metricsAddr != "" {
+			recorder = metrics.New()
+			mux := http.NewServeMux()
+			mux.Handle("GET /metrics", recorder.Handler())
+			go func() {
+				slog.Info("serving metrics", "addr", metricsAddr, "path", "/metrics")
+				logging.Fatal("metrics server stopped", "error", http.ListenAndServe(metricsAddr, mux))
+			}()
+		}
+
+		d, err := daemon.New(config, controls, collect, reportOptions(auditConfig), recorder, notifiers...)
+		if err != nil {
+			logging.Fatal("invalid schedule", "error", err)
+		}
+
+		if err := d.Run(cmd.Context(), runNow); err != nil {
+			logging.Fatal("daemon stopped", "error", err)
+		}
+	},
+}
+
+// Open, update and close tickets for the failing resources of JSON reports
+var ticketsCmd = &cobra.Command{
+	Use:   "tickets <report.json>...",
+	Short: "Open a ticket per failing resource, comment when its failure changes and close it once it passes (GitHub Issues, Jira)",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		config := tickets.Config{}
+		config.Tracker, _ = cmd.Flags().GetString("tracker")
+		config.BaseURL, _ = cmd.Flags().GetString("base-url")
+		config.Repository, _ = cmd.Flags().GetString("repository")
+		config.Project, _ = cmd.Flags().GetString("project")
+		config.IssueType, _ = cmd.Flags().GetString("issue-type")
+		config.User, _ = cmd.Flags().GetString("user")
+		config.TokenEnv, _ = cmd.Flags().GetString("token-env")
+		config.Label, _ = cmd.Flags().GetString("label")
+
+		tracker, err := tickets.New(config)
+		if err != nil {
+			logging.Fatal("invalid tracker", "error", err)
+		}
+
+		// Each report is compared with the one before it, so that open tickets are only commented on
+		// when their failure changed
+		var previous *report.Report
+		for _, path := range args {
+			result, err := history.Load(path)
+			if err != nil {
+				logging.Fatal("failed to load report", "path", path, "error", err)
+			}
+
+			slog.Info("syncing tickets", "path", path)
+			summary, err := tickets.Sync(tracker, previous, result)
+			if err != nil {
+				logging.Fatal("failed to sync tickets", "error", err)
+			}
+			slog.Info("tickets synced", "opened", summary.Created, "commented", summary.Commented, "closed", summary.Closed, "skipped", summary.Skipped)
+			previous = result
+		}
+	},
+}
+
+func init() {
+	// Service mode
+	serveCmd.Flags().String("addr", ":8080", "Address to listen on")
+	serveCmd.Flags().Int("workers", 1, "Number of scans that run at the same time")
+	serveCmd.Flags().Int("queue-size", 10, "Number of scans that may wait for a worker before new ones are rejected")
+	serveCmd.Flags().Int("history", 100, "Number of finished scans kept for polling")
+	rootCmd.AddCommand(serveCmd)
+	daemonCmd.Flags().String("schedule", "schedule.yaml", "Path to the schedule file with the control groups")
+	daemonCmd.Flags().Bool("run-now", false, "Run every group once at startup before following the schedule")
+	daemonCmd.Flags().String("metrics-addr", "", "Address to serve Prometheus metrics on, e.g. :9090 (disabled by default)")
+	rootCmd.AddCommand(daemonCmd)
+
+	// Ticketing
+	ticketsCmd.Flags().String("tracker", "github", "Issue tracker: github, jira")
+	ticketsCmd.Flags().String("base-url", "", "API root of the tracker (default https://api.github.com for GitHub)")
+	ticketsCmd.Flags().String("repository", "", "GitHub repository as owner/name")
+	ticketsCmd.Flags().String("project", "", "Jira project key")
+	ticketsCmd.Flags().String("issue-type", "Task", "Jira issue type")
+	ticketsCmd.Flags().String("user", "", "Jira Cloud account email; without it the token is sent as a bearer token")
+	ticketsCmd.Flags().String("token-env", "", "Environment variable holding the token (default GITHUB_TOKEN or JIRA_TOKEN)")
+	ticketsCmd.Flags().String("label", "security-hub", "Label of the tickets managed by the auditor")
+	rootCmd.AddCommand(ticketsCmd)
+}
@@ -0,0 +1,45 @@
+// compliance/compliance.go
+package compliance
+
+import (
+	_ "embed"
+	"sync"
+
+	"aws-security-hub/util"
+)
+
+// securityHub holds the requirements of the Security Hub controls, with their descriptions, severities
+// and related framework requirements
+//
+//go:embed aws_security_hub.json
+var securityHub []byte
+
+// cis holds the requirements of the CIS AWS Foundations Benchmark, for the titles of the CIS mappings
+//
+//go:embed cis_amazon_web_services_foundations_benchmark_v3.0.0.json
+var cis []byte
+
+var (
+	securityHubData = sync.OnceValue(func() *util.Compliance { return mustParse("aws_security_hub.json", securityHub) })
+	cisData         = sync.OnceValue(func() *util.Compliance { return mustParse("CIS benchmark", cis) })
+)
+
+// SecurityHub returns the embedded requirements of the Security Hub controls, parsed on first use
+func SecurityHub() *util.Compliance {
+	return securityHubData()
+}
+
+// CIS returns the embedded requirements of the CIS AWS Foundations Benchmark, parsed on first use
+func CIS() *util.Compliance {
+	return cisData()
+}
+
+// mustParse panics on an invalid file: the data is built into the binary, so it is a build defect
+// rather than something a run could recover from
+func mustParse(name string, data []byte) *util.Compliance {
+	compliance, err := util.ParseComplianceData(data)
+	if err != nil {
+		panic(name + ": " + err.Error())
+	}
+	return compliance
+}
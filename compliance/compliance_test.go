@@ -0,0 +1,32 @@
+// compliance/compliance_test.go
+package compliance
+
+import "testing"
+
+func TestEmbeddedData(t *testing.T) {
+	tests := []struct {
+		name string
+		data func() []byte
+		id   string
+	}{
+		{"aws_security_hub.json", func() []byte { return securityHub }, "DocumentDB.1"},
+		{"cis_amazon_web_services_foundations_benchmark_v3.0.0.json", func() []byte { return cis }, "1.1"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			compliance := mustParse(test.name, test.data())
+			for _, requirement := range compliance.Requirements {
+				if requirement.Id == test.id {
+					return
+				}
+			}
+			t.Errorf("requirement %s not found among %d", test.id, len(compliance.Requirements))
+		})
+	}
+}
+
+func TestSecurityHubIsParsedOnce(t *testing.T) {
+	if SecurityHub() != SecurityHub() {
+		t.Error("SecurityHub() parsed the data again")
+	}
+}
@@ -0,0 +1,180 @@
+// coverage/coverage.go
+package coverage
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// securityhubControls is a snapshot of every control of the Security Hub controls reference.
+// Refresh it when AWS adds controls; the compliance JSON stays the source of the implemented ones.
+//
+//go:embed securityhub_controls.json
+var securityhubControls []byte
+
+// Control is a Security Hub control of the catalogue
+type Control struct {
+	ID       string `json:"ID"`
+	Title    string `json:"Title"`
+	Severity string `json:"Severity"`
+}
+
+// Catalogue is the embedded list of Security Hub controls
+type Catalogue struct {
+	Source   string    `json:"Source"`
+	Updated  string    `json:"Updated"` // month the snapshot was taken, YYYY-MM
+	Controls []Control `json:"Controls"`
+}
+
+// Load returns the embedded catalogue
+func Load() (Catalogue, error) {
+	var catalogue Catalogue
+	if err := json.Unmarshal(securityhubControls, &catalogue); err != nil {
+		return catalogue, fmt.Errorf("failed to parse the embedded control catalogue: %v", err)
+	}
+	return catalogue, nil
+}
+
+// Find returns the control of an ID, ignoring case
+func (c Catalogue) Find(id string) (Control, bool) {
+	for _, control := range c.Controls {
+		if strings.EqualFold(control.ID, id) {
+			return control, true
+		}
+	}
+	return Control{}, false
+}
+
+// Service is the coverage of the controls sharing an ID prefix, e.g. CloudFront
+type Service struct {
+	Service     string    `json:"Service"`
+	Implemented int       `json:"Implemented"`
+	Total       int       `json:"Total"`
+	Percent     float64   `json:"Percent"`
+	Missing     []Control `json:"Missing,omitempty"`
+}
+
+// Report compares the registry to the catalogue
+type Report struct {
+	Updated     string    `json:"Updated"`
+	Implemented int       `json:"Implemented"`
+	Total       int       `json:"Total"`
+	Percent     float64   `json:"Percent"`
+	Services    []Service `json:"Services"`
+	Unknown     []string  `json:"Unknown,omitempty"` // implemented IDs missing from the catalogue, e.g. custom rules
+}
+
+// Compute returns the coverage of the implemented control IDs, per service in catalogue order
+func Compute(catalogue Catalogue, implemented []string) Report {
+	registry := make(map[string]bool)
+	for _, id := range implemented {
+		registry[id] = true
+	}
+
+	result := Report{Updated: catalogue.Updated}
+	index := make(map[string]int)
+	listed := make(map[string]bool)
+	for _, control := range catalogue.Controls {
+		name := ServiceOf(control.ID)
+		i, ok := index[name]
+		if !ok {
+			i = len(result.Services)
+			index[name] = i
+			result.Services = append(result.Services, Service{Service: name})
+		}
+		service := &result.Services[i]
+		service.Total++
+		result.Total++
+		listed[control.ID] = true
+		if registry[control.ID] {
+			service.Implemented++
+			result.Implemented++
+		} else {
+			service.Missing = append(service.Missing, control)
+		}
+	}
+	for i := range result.Services {
+		result.Services[i].Percent = percent(result.Services[i].Implemented, result.Services[i].Total)
+	}
+	result.Percent = percent(result.Implemented, result.Total)
+
+	for _, id := range implemented {
+		if !listed[id] {
+			result.Unknown = append(result.Unknown, id)
+		}
+	}
+	return result
+}
+
+// ServiceOf returns the ID prefix of a control, e.g. CloudFront for CloudFront.1
+func ServiceOf(id string) string {
+	service, _, _ := strings.Cut(id, ".")
+	return service
+}
+
+func percent(part, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(int(float64(part)*1000/float64(total)+0.5)) / 10
+}
+
+// WriteJSON renders the report as indented JSON
+func (r Report) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "    ")
+	return encoder.Encode(r)
+}
+
+// WriteTable renders one line per service, then the missing controls when missing is set
+func (r Report) WriteTable(w io.Writer, missing bool) error {
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "SERVICE\tIMPLEMENTED\tTOTAL\tCOVERAGE")
+	for _, service := range r.Services {
+		fmt.Fprintf(table, "%s\t%d\t%d\t%.1f%%\n", service.Service, service.Implemented, service.Total, service.Percent)
+	}
+	fmt.Fprintf(table, "TOTAL\t%d\t%d\t%.1f%%\n", r.Implemented, r.Total, r.Percent)
+	if err := table.Flush(); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "\nCatalogue snapshot: %s\n", r.Updated)
+	if len(r.Unknown) > 0 {
+		fmt.Fprintf(w, "Not in the catalogue: %s\n", strings.Join(r.Unknown, ", "))
+	}
+
+	if !missing {
+		return nil
+	}
+	fmt.Fprintln(w, "\nMissing controls:")
+	table = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, service := range r.Services {
+		for _, control := range service.Missing {
+			fmt.Fprintf(table, "%s\t%s\t%s\n", control.ID, control.Severity, control.Title)
+		}
+	}
+	return table.Flush()
+}
+
+// WriteMarkdown renders the report as a Markdown table, e.g. for a pull request comment
+func (r Report) WriteMarkdown(w io.Writer, missing bool) error {
+	fmt.Fprintf(w, "| Service | Implemented | Total | Coverage |\n|---|---:|---:|---:|\n")
+	for _, service := range r.Services {
+		fmt.Fprintf(w, "| %s | %d | %d | %.1f%% |\n", service.Service, service.Implemented, service.Total, service.Percent)
+	}
+	fmt.Fprintf(w, "| **Total** | %d | %d | %.1f%% |\n", r.Implemented, r.Total, r.Percent)
+
+	if missing {
+		fmt.Fprintln(w, "\n### Missing controls")
+		for _, service := range r.Services {
+			for _, control := range service.Missing {
+				fmt.Fprintf(w, "- [%s] %s (%s)\n", control.ID, control.Title, control.Severity)
+			}
+		}
+	}
+	_, err := fmt.Fprintf(w, "\nCatalogue snapshot: %s\n", r.Updated)
+	return err
+}
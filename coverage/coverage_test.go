@@ -0,0 +1,80 @@
+// coverage/coverage_test.go
+package coverage
+
+import (
+	"reflect"
+	"testing"
+)
+
+var testCatalogue = Catalogue{
+	Updated: "2024-05",
+	Controls: []Control{
+		{ID: "CloudFront.1", Severity: "High"},
+		{ID: "CloudFront.3", Severity: "Medium"},
+		{ID: "CloudFront.4", Severity: "Low"},
+		{ID: "S3.1", Severity: "Medium"},
+		{ID: "CloudFront.5", Severity: "Medium"},
+	},
+}
+
+func TestCompute(t *testing.T) {
+	got := Compute(testCatalogue, []string{"CloudFront.1", "S3.1", "Custom.1"})
+
+	want := Report{
+		Updated:     "2024-05",
+		Implemented: 2,
+		Total:       5,
+		Percent:     40,
+		// Services are listed in the order they first appear in the catalogue
+		Services: []Service{
+			{Service: "CloudFront", Implemented: 1, Total: 4, Percent: 25, Missing: []Control{
+				{ID: "CloudFront.3", Severity: "Medium"},
+				{ID: "CloudFront.4", Severity: "Low"},
+				{ID: "CloudFront.5", Severity: "Medium"},
+			}},
+			{Service: "S3", Implemented: 1, Total: 1, Percent: 100},
+		},
+		Unknown: []string{"Custom.1"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Compute() = %+v, want %+v", got, want)
+	}
+}
+
+func TestPercent(t *testing.T) {
+	tests := []struct {
+		part, total int
+		want        float64
+	}{
+		{0, 0, 0},
+		{1, 3, 33.3},
+		{2, 3, 66.7},
+		{1, 8, 12.5},
+		{3, 3, 100},
+	}
+	for _, test := range tests {
+		if got := percent(test.part, test.total); got != test.want {
+			t.Errorf("percent(%d, %d) = %v, want %v", test.part, test.total, got, test.want)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	catalogue, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if catalogue.Updated == "" || len(catalogue.Controls) == 0 {
+		t.Fatalf("catalogue = updated %q, %d controls", catalogue.Updated, len(catalogue.Controls))
+	}
+	seen := make(map[string]bool)
+	for _, control := range catalogue.Controls {
+		if seen[control.ID] {
+			t.Errorf("%s is listed twice", control.ID)
+		}
+		seen[control.ID] = true
+	}
+	if _, ok := catalogue.Find("cloudfront.1"); !ok {
+		t.Error("Find() ignores case")
+	}
+}
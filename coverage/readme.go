@@ -0,0 +1,83 @@
+// coverage/readme.go
+package coverage
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// checkbox matches the control lines of the README feature list, e.g. "  - [x] [EC2.1] Title"
+var checkbox = regexp.MustCompile(`^(\s*- \[)([ xX])(\] \[([A-Za-z0-9]+\.[0-9]+)\])`)
+
+// Mismatch is a README control line that disagrees with the registry
+type Mismatch struct {
+	Line    int    `json:"Line"` // 0 when the control is not listed
+	ID      string `json:"ID"`
+	Problem string `json:"Problem"`
+}
+
+func (m Mismatch) String() string {
+	if m.Line == 0 {
+		return fmt.Sprintf("%s: %s", m.ID, m.Problem)
+	}
+	return fmt.Sprintf("line %d: %s: %s", m.Line, m.ID, m.Problem)
+}
+
+// CheckReadme compares the checkboxes of a README with the implemented control IDs. A control is
+// checked if and only if it is implemented, and every implemented catalogue control must be listed.
+func CheckReadme(readme string, catalogue Catalogue, implemented []string) []Mismatch {
+	registry := make(map[string]bool)
+	for _, id := range implemented {
+		registry[id] = true
+	}
+
+	var mismatches []Mismatch
+	listed := make(map[string]bool)
+	for i, line := range strings.Split(readme, "\n") {
+		match := checkbox.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		id, checked := match[4], match[2] != " "
+		listed[id] = true
+		switch {
+		case checked && !registry[id]:
+			mismatches = append(mismatches, Mismatch{Line: i + 1, ID: id, Problem: "checked but not implemented"})
+		case !checked && registry[id]:
+			mismatches = append(mismatches, Mismatch{Line: i + 1, ID: id, Problem: "implemented but not checked"})
+		}
+		if _, ok := catalogue.Find(id); !ok {
+			mismatches = append(mismatches, Mismatch{Line: i + 1, ID: id, Problem: "not a Security Hub control of the catalogue"})
+		}
+	}
+	for _, id := range implemented {
+		if _, ok := catalogue.Find(id); ok && !listed[id] {
+			mismatches = append(mismatches, Mismatch{ID: id, Problem: "implemented but not listed"})
+		}
+	}
+	return mismatches
+}
+
+// FixReadme sets every checkbox to the state of the registry. Controls that are not listed at all are
+// left to the author, who knows which section they belong to.
+func FixReadme(readme string, implemented []string) string {
+	registry := make(map[string]bool)
+	for _, id := range implemented {
+		registry[id] = true
+	}
+
+	lines := strings.Split(readme, "\n")
+	for i, line := range lines {
+		match := checkbox.FindStringSubmatchIndex(line)
+		if match == nil {
+			continue
+		}
+		state := " "
+		if registry[line[match[8]:match[9]]] {
+			state = "x"
+		}
+		lines[i] = line[:match[4]] + state + line[match[5]:]
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,53 @@
+// coverage/readme_test.go
+package coverage
+
+import (
+	"reflect"
+	"testing"
+)
+
+const testReadme = `## Controls
+
+- CloudFront
+  - [x] [CloudFront.1] Default root object
+  - [ ] [CloudFront.3] Viewer protocol policy
+  - [x] [CloudFront.4] Origin failover
+- Other
+  - [x] [Custom.1] Not a catalogue control
+`
+
+func TestCheckReadme(t *testing.T) {
+	got := CheckReadme(testReadme, testCatalogue, []string{"CloudFront.1", "CloudFront.3", "S3.1", "Custom.1"})
+
+	want := []Mismatch{
+		{Line: 5, ID: "CloudFront.3", Problem: "implemented but not checked"},
+		{Line: 6, ID: "CloudFront.4", Problem: "checked but not implemented"},
+		{Line: 8, ID: "Custom.1", Problem: "not a Security Hub control of the catalogue"},
+		{ID: "S3.1", Problem: "implemented but not listed"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("CheckReadme() = %v, want %v", got, want)
+	}
+}
+
+func TestFixReadme(t *testing.T) {
+	implemented := []string{"CloudFront.1", "CloudFront.3", "S3.1"}
+	fixed := FixReadme(testReadme, implemented)
+
+	want := `## Controls
+
+- CloudFront
+  - [x] [CloudFront.1] Default root object
+  - [x] [CloudFront.3] Viewer protocol policy
+  - [ ] [CloudFront.4] Origin failover
+- Other
+  - [ ] [Custom.1] Not a catalogue control
+`
+	if fixed != want {
+		t.Errorf("FixReadme() = %q, want %q", fixed, want)
+	}
+	// Unlisted controls are left to the author
+	if mismatches := CheckReadme(fixed, testCatalogue, implemented); len(mismatches) != 2 {
+		t.Errorf("mismatches after fixing = %v, want the unknown Custom.1 and the unlisted S3.1", mismatches)
+	}
+}
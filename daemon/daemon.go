@@ -0,0 +1,212 @@
+// daemon/daemon.go
+package daemon
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"aws-security-hub/audit"
+	"aws-security-hub/history"
+	"aws-security-hub/metrics"
+	"aws-security-hub/notify"
+	"aws-security-hub/report"
+	"aws-security-hub/tickets"
+	"aws-security-hub/types"
+
+	"github.com/robfig/cron/v3"
+	"gopkg.in/yaml.v3"
+)
+
+// Group is a set of controls that runs on its own schedule
+type Group struct {
+	Name     string   `yaml:"name"`
+	Schedule string   `yaml:"schedule"` // cron expression ("0 */6 * * *") or descriptor ("@hourly", "@every 30m")
+	Controls []string `yaml:"controls"` // control IDs; empty selects all controls of Services
+	Services []string `yaml:"services"` // inventory services; empty selects all services
+}
+
+// Config is the schedule file of the daemon
+type Config struct {
+	HistoryDir string          `yaml:"history_dir"`
+	WebhookURL string          `yaml:"webhook_url"` // optional; posts transitions only. Transitions are always logged.
+	Notifiers  []notify.Config `yaml:"notifiers"`
+	Tickets    *tickets.Config `yaml:"tickets"` // optional; opens and closes a ticket per failing resource
+	Groups     []Group         `yaml:"groups"`
+}
+
+// LoadConfig reads a schedule file, rejecting unknown keys and invalid schedules
+func LoadConfig(filePath string) (*Config, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schedule: %v", err)
+	}
+
+	config := &Config{HistoryDir: "history"}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(config); err != nil {
+		return nil, fmt.Errorf("failed to parse schedule: %v", err)
+	}
+
+	if len(config.Groups) == 0 {
+		return nil, fmt.Errorf("schedule has no groups")
+	}
+	seen := make(map[string]bool)
+	for _, group := range config.Groups {
+		if group.Name == "" {
+			return nil, fmt.Errorf("group is missing name")
+		}
+		if seen[group.Name] {
+			return nil, fmt.Errorf("group %s is defined twice", group.Name)
+		}
+		seen[group.Name] = true
+		if _, err := cron.ParseStandard(group.Schedule); err != nil {
+			return nil, fmt.Errorf("group %s: invalid schedule %q: %v", group.Name, group.Schedule, err)
+		}
+	}
+	for i, notifier := range config.Notifiers {
+		if _, err := notify.New(notifier, notify.OnTransitions); err != nil {
+			return nil, fmt.Errorf("notifier %d: %v", i+1, err)
+		}
+		for _, name := range notifier.Groups {
+			if !seen[name] {
+				return nil, fmt.Errorf("notifier %d: unknown group %s", i+1, name)
+			}
+		}
+	}
+	if config.Tickets != nil {
+		if _, err := tickets.New(*config.Tickets); err != nil {
+			return nil, fmt.Errorf("tickets: %v", err)
+		}
+	}
+	return config, nil
+}
+
+// Collector fetches an inventory of the given services for every account and region scanned, giving up
+// once ctx is done. The error reports what failed to collect alongside the inventories that were collected.
+type Collector func(ctx context.Context, services []string) ([]report.Target, error)
+
+// Daemon runs control groups on their schedules
+type Daemon struct {
+	config    *Config
+	controls  func() []types.Control
+	collect   Collector
+	options   report.Options
+	metrics   *metrics.Metrics
+	tracker   tickets.Tracker
+	notifiers []notify.Notifier
+}
+
+// New validates the control selection of every group and creates a daemon, which applies options
+// (e.g. the suppressions of the configuration) to every run. Metrics may be nil when they are not exported.
+func New(config *Config, controls func() []types.Control, collect Collector, options report.Options, recorder *metrics.Metrics, notifiers ...notify.Notifier) (*Daemon, error) {
+	for _, group := range config.Groups {
+		if _, err := audit.Select(controls(), group.Controls, group.Services); err != nil {
+			return nil, fmt.Errorf("group %s: %v", group.Name, err)
+		}
+	}
+	d := &Daemon{config: config, controls: controls, collect: collect, options: options, metrics: recorder, notifiers: notifiers}
+	if config.Tickets != nil {
+		tracker, err := tickets.New(*config.Tickets)
+		if err != nil {
+			return nil, fmt.Errorf("tickets: %v", err)
+		}
+		d.tracker = tracker
+	}
+	return d, nil
+}
+
+// Run schedules every group and blocks until the context is cancelled, which also stops the groups
+// that are running. With runNow, every group also runs once at startup.
+func (d *Daemon) Run(ctx context.Context, runNow bool) error {
+	scheduler := cron.New(cron.WithChain(cron.SkipIfStillRunning(cron.DiscardLogger)))
+	for _, group := range d.config.Groups {
+		group := group
+		if _, err := scheduler.AddFunc(group.Schedule, func() { d.RunGroup(ctx, group) }); err != nil {
+			return fmt.Errorf("group %s: %v", group.Name, err)
+		}
+		slog.Info("scheduled group", "group", group.Name, "schedule", group.Schedule)
+	}
+
+	if runNow {
+		for _, group := range d.config.Groups {
+			d.RunGroup(ctx, group)
+		}
+	}
+
+	scheduler.Start()
+	<-ctx.Done()
+	slog.Info("stopping scheduler, waiting for running groups to finish")
+	<-scheduler.Stop().Done()
+	return nil
+}
+
+// RunGroup scans a group once, stores the report in history and notifies failures and status transitions.
+// A scan stopped by ctx is dropped, since its partial report would read as transitions.
+func (d *Daemon) RunGroup(ctx context.Context, group Group) {
+	logger := slog.With("group", group.Name)
+	logger.Info("running group")
+
+	controls, err := audit.Select(d.controls(), group.Controls, group.Services)
+	if err != nil {
+		logger.Error("failed to select controls", "error", err)
+		return
+	}
+
+	targets, err := d.collect(ctx, audit.Services(controls))
+	if err != nil {
+		logger.Error("failed to collect inventory", "error", err)
+	}
+	if len(targets) == 0 {
+		d.metrics.ObserveFailure()
+		return
+	}
+
+	current := report.RunTargets(ctx, targets, "group "+group.Name, controls, d.options)
+	if ctx.Err() != nil {
+		logger.Warn("group stopped before it completed, report not stored")
+		return
+	}
+	d.metrics.ObserveReport(current)
+	previous, err := history.Latest(d.config.HistoryDir, group.Name)
+	if err != nil {
+		logger.Error("failed to load previous report", "error", err)
+	}
+	settled, err := history.Settled(d.config.HistoryDir, group.Name, current)
+	if err != nil {
+		logger.Error("failed to load previous reports", "error", err)
+	}
+	path, err := history.Save(d.config.HistoryDir, group.Name, current)
+	if err != nil {
+		logger.Error("failed to store report", "error", err)
+	} else {
+		logger.Info("report stored", "path", path)
+	}
+
+	if d.tracker != nil {
+		summary, err := tickets.Sync(d.tracker, previous, current)
+		if err != nil {
+			logger.Error("failed to sync tickets", "error", err)
+		}
+		logger.Info("tickets synced", "opened", summary.Created, "commented", summary.Commented, "closed", summary.Closed, "skipped", summary.Skipped)
+	}
+
+	event := notify.Event{
+		Group:       group.Name,
+		Time:        time.Now().UTC(),
+		Failures:    notify.Failures(current),
+		Transitions: history.Transitions(settled, current),
+	}
+	if len(event.Failures) == 0 && len(event.Transitions) == 0 {
+		return
+	}
+	for _, notifier := range d.notifiers {
+		if err := notifier.Notify(event); err != nil {
+			logger.Error("failed to notify", "error", err)
+		}
+	}
+}
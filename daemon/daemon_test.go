@@ -0,0 +1,214 @@
+// daemon/daemon_test.go
+package daemon
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"aws-security-hub/history"
+	"aws-security-hub/inventory"
+	"aws-security-hub/metrics"
+	"aws-security-hub/notify"
+	"aws-security-hub/report"
+	"aws-security-hub/types"
+	"aws-security-hub/util"
+)
+
+func TestLoadConfig(t *testing.T) {
+	config, err := LoadConfig("schedule.example.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.HistoryDir != "history" || len(config.Groups) != 3 {
+		t.Errorf("config = %+v", config)
+	}
+
+	tests := []struct {
+		name     string
+		schedule string
+		err      string
+	}{
+		{"no groups", "history_dir: history\n", "schedule has no groups"},
+		{"missing name", "groups:\n  - schedule: \"@hourly\"\n", "group is missing name"},
+		{"duplicate group", "groups:\n  - {name: edge, schedule: \"@hourly\"}\n  - {name: edge, schedule: \"@daily\"}\n", "group edge is defined twice"},
+		{"invalid schedule", "groups:\n  - {name: edge, schedule: \"every hour\"}\n", `group edge: invalid schedule "every hour"`},
+		{"unknown key", "groups:\n  - {name: edge, schedule: \"@hourly\", control: [S3.1]}\n", "field control not found"},
+		{"notifier of an unknown group", "notifiers:\n  - {type: webhook, url: \"https://hooks.example.com\", groups: [core]}\ngroups:\n  - {name: edge, schedule: \"@hourly\"}\n", "notifier 1: unknown group core"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "schedule.yaml")
+			if err := os.WriteFile(path, []byte(test.schedule), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := LoadConfig(path); err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("LoadConfig() = %v, want an error containing %q", err, test.err)
+			}
+		})
+	}
+}
+
+func TestNewRejectsUnknownControls(t *testing.T) {
+	config := &Config{Groups: []Group{{Name: "edge", Schedule: "@hourly", Controls: []string{"Missing.1"}}}}
+	if _, err := New(config, testControls("PASS"), nil, report.Options{}, nil); err == nil || !strings.HasPrefix(err.Error(), "group edge:") {
+		t.Errorf("New() = %v, want an error of group edge", err)
+	}
+}
+
+// testControls returns a single control that reports status, failing the orders cluster when status is FAIL
+func testControls(status string) func() []types.Control {
+	control := types.InventoryControl{
+		ID:      "Example.1",
+		Check:   "example",
+		Service: inventory.ServiceDocumentDB,
+		Func: func(inv *inventory.Inventory) (string, types.Findings) {
+			var findings types.Findings
+			switch status {
+			case "FAIL":
+				findings.Fail("orders", "Storage is not encrypted")
+			case "PASS":
+				findings.Pass("orders", "Storage is encrypted")
+			}
+			return status, findings
+		},
+		Requirement: &util.Requirement{Id: "Example.1", Description: "Example control", Attributes: []util.Attribute{{Severity: "High"}}},
+	}
+	return func() []types.Control { return []types.Control{control} }
+}
+
+// recorder keeps the events it is notified of
+type recorder struct{ events *[]notify.Event }
+
+func (r recorder) Notify(event notify.Event) error {
+	*r.events = append(*r.events, event)
+	return nil
+}
+
+// targets collects an empty inventory for each of two regions
+func targets(ctx context.Context, services []string) ([]report.Target, error) {
+	return []report.Target{
+		{Inventory: &inventory.Inventory{Region: "ap-northeast-2"}},
+		{Inventory: &inventory.Inventory{Region: "us-east-1"}},
+	}, nil
+}
+
+func TestRunGroup(t *testing.T) {
+	tests := []struct {
+		name        string
+		stored      []string // statuses of the earlier runs, oldest first
+		status      string
+		failures    int // failing results notified, one per region
+		transitions []history.Transition
+	}{
+		{"first run", nil, "FAIL", 2, nil},
+		{"still passing", []string{"PASS"}, "PASS", 0, nil},
+		{"regression", []string{"PASS"}, "FAIL", 2, []history.Transition{
+			{ID: "Example.1", Severity: "High", From: "PASS", To: "FAIL"},
+			{ID: "Example.1", Severity: "High", From: "PASS", To: "FAIL"},
+		}},
+		{"fixed", []string{"FAIL"}, "PASS", 0, []history.Transition{
+			{ID: "Example.1", Severity: "High", From: "FAIL", To: "PASS"},
+			{ID: "Example.1", Severity: "High", From: "FAIL", To: "PASS"},
+		}},
+		// A run that could not evaluate the control is no transition, nor is the run after it
+		{"error", []string{"PASS"}, "ERROR", 0, nil},
+		{"after an error", []string{"PASS", "ERROR"}, "FAIL", 2, []history.Transition{
+			{ID: "Example.1", Severity: "High", From: "PASS", To: "FAIL"},
+			{ID: "Example.1", Severity: "High", From: "PASS", To: "FAIL"},
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for i, status := range test.stored {
+				stored := &report.Report{
+					GeneratedAt: time.Date(2024, 5, 1, i, 0, 0, 0, time.UTC),
+					Results:     []report.Result{{ID: "Example.1", Severity: "High", Status: status}},
+				}
+				if _, err := history.Save(dir, "edge", stored); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			var events []notify.Event
+			group := Group{Name: "edge", Schedule: "@hourly", Controls: []string{"Example.1"}}
+			d, err := New(&Config{HistoryDir: dir, Groups: []Group{group}}, testControls(test.status), targets, report.Options{}, nil, recorder{&events})
+			if err != nil {
+				t.Fatal(err)
+			}
+			d.RunGroup(context.Background(), group)
+
+			latest, err := history.Latest(dir, "edge")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(latest.Results) != 2 || latest.Results[0].Status != test.status || latest.Results[1].Region != "us-east-1" {
+				t.Errorf("stored report = %+v, want the %s results of both regions", latest.Results, test.status)
+			}
+
+			if test.failures == 0 && len(test.transitions) == 0 {
+				if len(events) != 0 {
+					t.Errorf("events = %+v, want none", events)
+				}
+				return
+			}
+			if len(events) != 1 {
+				t.Fatalf("got %d events, want 1", len(events))
+			}
+			if events[0].Group != "edge" || len(events[0].Failures) != test.failures || !reflect.DeepEqual(events[0].Transitions, test.transitions) {
+				t.Errorf("event = %+v, want %d failures and transitions %+v", events[0], test.failures, test.transitions)
+			}
+		})
+	}
+}
+
+func TestRunGroupWithoutInventory(t *testing.T) {
+	dir := t.TempDir()
+	recorder := metrics.New()
+	collect := func(ctx context.Context, services []string) ([]report.Target, error) {
+		return nil, errors.New("access denied")
+	}
+	group := Group{Name: "edge", Schedule: "@hourly"}
+	d, err := New(&Config{HistoryDir: dir, Groups: []Group{group}}, testControls("FAIL"), collect, report.Options{}, recorder)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.RunGroup(context.Background(), group)
+
+	if paths, _ := history.List(dir, "edge"); len(paths) != 0 {
+		t.Errorf("stored %v, want nothing", paths)
+	}
+	var out bytes.Buffer
+	if err := recorder.Write(&out); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "_scan_failures_total 1\n") {
+		t.Errorf("metrics lack the failed scan:\n%s", out.String())
+	}
+}
+
+func TestRunGroupStopped(t *testing.T) {
+	dir := t.TempDir()
+	var events []notify.Event
+	group := Group{Name: "edge", Schedule: "@hourly"}
+	d, err := New(&Config{HistoryDir: dir, Groups: []Group{group}}, testControls("FAIL"), targets, report.Options{}, nil, recorder{&events})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A partial report would read as transitions on the next run, so it is neither stored nor notified
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	d.RunGroup(ctx, group)
+
+	if paths, _ := history.List(dir, "edge"); len(paths) != 0 || len(events) != 0 {
+		t.Errorf("stored %v and notified %+v, want nothing", paths, events)
+	}
+}
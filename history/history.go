@@ -0,0 +1,132 @@
+// history/history.go
+package history
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"aws-security-hub/report"
+)
+
+const timestampFormat = "20060102T150405Z"
+
+// Save stores a report as <dir>/<group>/<timestamp>.json and returns its path
+func Save(dir, group string, result *report.Report) (string, error) {
+	groupDir := filepath.Join(dir, group)
+	if err := os.MkdirAll(groupDir, 0o700); err != nil {
+		return "", fmt.Errorf("failed to create history directory: %v", err)
+	}
+
+	bytes, err := json.MarshalIndent(result, "", "    ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal report: %v", err)
+	}
+
+	filePath := filepath.Join(groupDir, result.GeneratedAt.UTC().Format(timestampFormat)+".json")
+	if err := os.WriteFile(filePath, bytes, 0o600); err != nil {
+		return "", fmt.Errorf("failed to write report: %v", err)
+	}
+	return filePath, nil
+}
+
+// List returns the stored report paths of a group, oldest first
+func List(dir, group string) ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(dir, group))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history directory: %v", err)
+	}
+
+	var paths []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".json") {
+			paths = append(paths, filepath.Join(dir, group, entry.Name()))
+		}
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// Latest returns the most recent stored report of a group, or nil if there is none
+func Latest(dir, group string) (*report.Report, error) {
+	paths, err := List(dir, group)
+	if err != nil || len(paths) == 0 {
+		return nil, err
+	}
+	return Load(paths[len(paths)-1])
+}
+
+// Load reads a stored report
+func Load(filePath string) (*report.Report, error) {
+	bytes, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read report: %v", err)
+	}
+
+	var result report.Report
+	if err := json.Unmarshal(bytes, &result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal report: %v", err)
+	}
+	return &result, nil
+}
+
+// Transition is a change of status of a control between two runs
+type Transition struct {
+	ID       string `json:"ID"`
+	Severity string `json:"Severity"`
+	From     string `json:"From"`
+	To       string `json:"To"`
+}
+
+// Settled returns the last status other than ERROR of every control of current in the stored reports
+// of a group, by control ID. Reports are read from the newest until every control has one, so a run
+// that could not evaluate a control does not hide what it was before.
+func Settled(dir, group string, current *report.Report) (map[string]string, error) {
+	paths, err := List(dir, group)
+	if err != nil {
+		return nil, err
+	}
+
+	pending := make(map[string]bool)
+	for _, result := range current.Results {
+		pending[result.ID] = true
+	}
+	settled := make(map[string]string)
+	for i := len(paths) - 1; i >= 0 && len(pending) > 0; i-- {
+		previous, err := Load(paths[i])
+		if err != nil {
+			return settled, err
+		}
+		statuses := make(map[string]string)
+		for _, result := range previous.Results {
+			if pending[result.ID] && result.Status != "ERROR" {
+				statuses[result.ID] = result.Status
+			}
+		}
+		for id, status := range statuses {
+			settled[id] = status
+			delete(pending, id)
+		}
+	}
+	return settled, nil
+}
+
+// Transitions compares a report with the settled statuses of its controls and returns the controls that
+// went from PASS to FAIL or from FAIL to PASS, e.g. PASS, then ERROR, then FAIL. Controls without a
+// settled status have nothing to compare with, so they have no transitions.
+func Transitions(settled map[string]string, current *report.Report) []Transition {
+	var transitions []Transition
+	for _, result := range current.Results {
+		from := settled[result.ID]
+		if (from == "PASS" && result.Status == "FAIL") || (from == "FAIL" && result.Status == "PASS") {
+			transitions = append(transitions, Transition{ID: result.ID, Severity: result.Severity, From: from, To: result.Status})
+		}
+	}
+	return transitions
+}
@@ -0,0 +1,75 @@
+// history/history_test.go
+package history
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"aws-security-hub/report"
+)
+
+func run(at int, statuses ...string) *report.Report {
+	result := &report.Report{GeneratedAt: time.Date(2026, 1, 1, at, 0, 0, 0, time.UTC)}
+	for i, status := range statuses {
+		result.Results = append(result.Results, report.Result{ID: []string{"DocumentDB.1", "DocumentDB.5"}[i], Status: status})
+	}
+	return result
+}
+
+func TestTransitionsSkipErrorRuns(t *testing.T) {
+	tests := []struct {
+		name    string
+		history []*report.Report
+		current *report.Report
+		want    []Transition
+	}{
+		{
+			name:    "no history",
+			current: run(3, "FAIL", "PASS"),
+		},
+		{
+			name:    "pass to fail",
+			history: []*report.Report{run(1, "PASS", "PASS")},
+			current: run(2, "FAIL", "PASS"),
+			want:    []Transition{{ID: "DocumentDB.1", From: "PASS", To: "FAIL"}},
+		},
+		{
+			name:    "pass, error, then fail",
+			history: []*report.Report{run(1, "PASS", "FAIL"), run(2, "ERROR", "ERROR")},
+			current: run(3, "FAIL", "PASS"),
+			want: []Transition{
+				{ID: "DocumentDB.1", From: "PASS", To: "FAIL"},
+				{ID: "DocumentDB.5", From: "FAIL", To: "PASS"},
+			},
+		},
+		{
+			name:    "fail, error, then fail again",
+			history: []*report.Report{run(1, "FAIL", "PASS"), run(2, "ERROR", "PASS")},
+			current: run(3, "FAIL", "PASS"),
+		},
+		{
+			name:    "error is not a transition",
+			history: []*report.Report{run(1, "PASS", "FAIL")},
+			current: run(2, "ERROR", "ERROR"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for _, previous := range test.history {
+				if _, err := Save(dir, "hourly", previous); err != nil {
+					t.Fatal(err)
+				}
+			}
+			settled, err := Settled(dir, "hourly", test.current)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := Transitions(settled, test.current); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("transitions = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
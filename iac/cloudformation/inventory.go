@@ -0,0 +1,300 @@
+// iac/cloudformation/inventory.go
+package cloudformation
+
+import (
+	"fmt"
+	"time"
+
+	"aws-security-hub/inventory"
+
+	"gopkg.in/yaml.v3"
+)
+
+// BuildInventory maps the supported resources of a template to an inventory.
+// Resources are identified by logical ID and template line so evaluator output points back into the template.
+func BuildInventory(template *Template) *inventory.Inventory {
+	inv := &inventory.Inventory{
+		Version:     inventory.Version,
+		CollectedAt: time.Now().UTC(),
+		APIGateway:  &inventory.APIGateway{},
+		CloudFront:  &inventory.CloudFront{},
+		DocumentDB:  &inventory.DocumentDB{},
+		S3:          &inventory.S3{},
+	}
+
+	restAPIs := make(map[string]*inventory.RestAPI)
+	apis := make(map[string]*inventory.API)
+	var restAPIOrder, apiOrder []string
+
+	restAPI := func(id string) *inventory.RestAPI {
+		if _, ok := restAPIs[id]; !ok {
+			name := id
+			if resource := template.Resource(id); resource != nil {
+				if value := str(lookup(resource.Properties, "Name")); value != "" {
+					name = value
+				}
+				name = fmt.Sprintf("%s [%s]", name, resource.Location())
+			}
+			restAPIs[id] = &inventory.RestAPI{ID: id, Name: name}
+			restAPIOrder = append(restAPIOrder, id)
+		}
+		return restAPIs[id]
+	}
+
+	api := func(id string) *inventory.API {
+		if _, ok := apis[id]; !ok {
+			name := id
+			protocolType := ""
+			if resource := template.Resource(id); resource != nil {
+				if value := str(lookup(resource.Properties, "Name")); value != "" {
+					name = value
+				}
+				name = fmt.Sprintf("%s [%s]", name, resource.Location())
+				protocolType = str(lookup(resource.Properties, "ProtocolType"))
+			}
+			apis[id] = &inventory.API{ID: id, Name: name, ProtocolType: protocolType}
+			apiOrder = append(apiOrder, id)
+		}
+		return apis[id]
+	}
+
+	for _, resource := range template.Resources {
+		properties := resource.Properties
+
+		switch resource.Type {
+		case "AWS::CloudFront::Distribution":
+			inv.CloudFront.Distributions = append(inv.CloudFront.Distributions, buildDistribution(template, resource))
+
+		case "AWS::DocDB::DBCluster":
+			values := &resolver{operation: "DescribeDBClusters"}
+			backupRetentionPeriod := int32(1) // CloudFormation default
+			if node := lookup(properties, "BackupRetentionPeriod"); node != nil {
+				backupRetentionPeriod = values.integer("BackupRetentionPeriod", node)
+			}
+			var logExports []string
+			exports := lookup(properties, "EnableCloudwatchLogsExports")
+			if isIntrinsic(exports) {
+				values.unresolved("EnableCloudwatchLogsExports", exports)
+			}
+			for _, item := range items(exports) {
+				logExports = append(logExports, values.value("EnableCloudwatchLogsExports", item))
+			}
+			inv.DocumentDB.Clusters = append(inv.DocumentDB.Clusters, inventory.DocDBCluster{
+				Identifier:                   resource.Location(),
+				StorageEncrypted:             values.boolean("StorageEncrypted", lookup(properties, "StorageEncrypted")),
+				BackupRetentionPeriod:        backupRetentionPeriod,
+				EnabledCloudwatchLogsExports: logExports,
+				DeletionProtection:           values.boolean("DeletionProtection", lookup(properties, "DeletionProtection")),
+				Errors:                       values.errors,
+			})
+
+		case "AWS::ApiGateway::RestApi":
+			restAPI(resource.LogicalID)
+
+		case "AWS::ApiGateway::Stage":
+			values := &resolver{operation: "GetStages", resource: resource.LogicalID}
+			stage := inventory.RestStage{
+				StageName:           resource.Location(),
+				ClientCertificateID: str(lookup(properties, "ClientCertificateId")),
+				TracingEnabled:      values.boolean("TracingEnabled", lookup(properties, "TracingEnabled")),
+				CacheClusterEnabled: values.boolean("CacheClusterEnabled", lookup(properties, "CacheClusterEnabled")),
+				CacheClusterSize:    str(lookup(properties, "CacheClusterSize")),
+				MethodSettings:      make(map[string]inventory.MethodSetting),
+			}
+			settings := lookup(properties, "MethodSettings")
+			if isIntrinsic(settings) {
+				values.unresolved("MethodSettings", settings)
+			}
+			for _, item := range items(settings) {
+				path := str(lookup(item, "ResourcePath")) + "/" + str(lookup(item, "HttpMethod"))
+				stage.MethodSettings[path] = inventory.MethodSetting{
+					LoggingLevel:       values.value("MethodSettings.LoggingLevel", lookup(item, "LoggingLevel")),
+					CacheDataEncrypted: values.boolean("MethodSettings.CacheDataEncrypted", lookup(item, "CacheDataEncrypted")),
+				}
+			}
+			parent := restAPI(ref(lookup(properties, "RestApiId")))
+			parent.Stages = append(parent.Stages, stage)
+			parent.Errors = append(parent.Errors, values.errors...)
+
+		case "AWS::ApiGatewayV2::Api":
+			api(resource.LogicalID)
+
+		case "AWS::ApiGatewayV2::Stage":
+			values := &resolver{operation: "GetStages", resource: resource.LogicalID}
+			parent := api(ref(lookup(properties, "ApiId")))
+			parent.Stages = append(parent.Stages, inventory.Stage{
+				StageName:                resource.Location(),
+				DefaultRouteLoggingLevel: values.value("DefaultRouteSettings.LoggingLevel", lookupPath(properties, "DefaultRouteSettings", "LoggingLevel")),
+				AccessLogDestinationARN:  str(lookupPath(properties, "AccessLogSettings", "DestinationArn")),
+			})
+			parent.Errors = append(parent.Errors, values.errors...)
+
+		case "AWS::ApiGatewayV2::Route":
+			values := &resolver{operation: "GetRoutes", resource: resource.LogicalID}
+			authorizationType := values.value("AuthorizationType", lookup(properties, "AuthorizationType"))
+			if authorizationType == "" {
+				authorizationType = "NONE" // CloudFormation default
+			}
+			parent := api(ref(lookup(properties, "ApiId")))
+			parent.Routes = append(parent.Routes, inventory.Route{
+				RouteKey:          fmt.Sprintf("%s [%s]", str(lookup(properties, "RouteKey")), resource.Location()),
+				AuthorizationType: authorizationType,
+			})
+			parent.Errors = append(parent.Errors, values.errors...)
+
+		case "AWS::S3::Bucket":
+			values := &resolver{operation: "GetPublicAccessBlock"}
+			bucket := inventory.Bucket{Name: resource.Location()}
+			config := lookup(properties, "PublicAccessBlockConfiguration")
+			switch {
+			case config == nil:
+				// Since April 2023, S3 turns all four settings on for new buckets unless the template turns them off
+				bucket.PublicAccessBlock = &inventory.PublicAccessBlock{
+					BlockPublicAcls:       true,
+					IgnorePublicAcls:      true,
+					BlockPublicPolicy:     true,
+					RestrictPublicBuckets: true,
+				}
+			case isIntrinsic(config):
+				values.unresolved("PublicAccessBlockConfiguration", config)
+			default:
+				bucket.PublicAccessBlock = &inventory.PublicAccessBlock{
+					BlockPublicAcls:       values.boolean("BlockPublicAcls", lookup(config, "BlockPublicAcls")),
+					IgnorePublicAcls:      values.boolean("IgnorePublicAcls", lookup(config, "IgnorePublicAcls")),
+					BlockPublicPolicy:     values.boolean("BlockPublicPolicy", lookup(config, "BlockPublicPolicy")),
+					RestrictPublicBuckets: values.boolean("RestrictPublicBuckets", lookup(config, "RestrictPublicBuckets")),
+				}
+			}
+			bucket.Errors = values.errors
+			inv.S3.Buckets = append(inv.S3.Buckets, bucket)
+		}
+	}
+
+	for _, id := range restAPIOrder {
+		inv.APIGateway.RestAPIs = append(inv.APIGateway.RestAPIs, *restAPIs[id])
+	}
+	for _, id := range apiOrder {
+		inv.APIGateway.APIs = append(inv.APIGateway.APIs, *apis[id])
+	}
+
+	return inv
+}
+
+func buildDistribution(template *Template, resource Resource) inventory.Distribution {
+	config := lookup(resource.Properties, "DistributionConfig")
+	values := &resolver{operation: "GetDistribution"}
+
+	distribution := inventory.Distribution{
+		ID:                   resource.Location(),
+		DefaultRootObject:    str(lookup(config, "DefaultRootObject")),
+		ViewerProtocolPolicy: values.value("DefaultCacheBehavior.ViewerProtocolPolicy", lookupPath(config, "DefaultCacheBehavior", "ViewerProtocolPolicy")),
+		Tags:                 make(map[string]string),
+	}
+
+	for _, item := range items(lookup(config, "CacheBehaviors")) {
+		distribution.CacheBehaviors = append(distribution.CacheBehaviors, inventory.CacheBehavior{
+			PathPattern:          str(lookup(item, "PathPattern")),
+			ViewerProtocolPolicy: values.value("CacheBehaviors.ViewerProtocolPolicy", lookup(item, "ViewerProtocolPolicy")),
+		})
+	}
+
+	for _, item := range items(lookup(config, "Origins")) {
+		distribution.Origins = append(distribution.Origins, inventory.Origin{
+			ID:                    str(lookup(item, "Id")),
+			DomainName:            originDomainName(template, lookup(item, "DomainName")),
+			OriginAccessControlID: str(lookup(item, "OriginAccessControlId")),
+		})
+	}
+
+	for _, item := range items(lookupPath(config, "OriginGroups", "Items")) {
+		group := inventory.OriginGroup{ID: str(lookup(item, "Id"))}
+		for _, member := range items(lookupPath(item, "Members", "Items")) {
+			group.Members = append(group.Members, str(lookup(member, "OriginId")))
+		}
+		distribution.OriginGroups = append(distribution.OriginGroups, group)
+	}
+
+	// A Logging block enables standard logging; it has no separate Enabled property
+	if logging := lookup(config, "Logging"); isIntrinsic(logging) {
+		values.unresolved("Logging", logging)
+	} else if logging != nil {
+		distribution.Logging = inventory.Logging{
+			Enabled: true,
+			Bucket:  str(lookup(logging, "Bucket")),
+			Prefix:  str(lookup(logging, "Prefix")),
+		}
+	}
+
+	for _, tag := range items(lookup(resource.Properties, "Tags")) {
+		distribution.Tags[str(lookup(tag, "Key"))] = str(lookup(tag, "Value"))
+	}
+
+	distribution.Errors = values.errors
+	return distribution
+}
+
+// resolver reads the settings of one resource that controls compare against, and records those that
+// are intrinsic functions as Unresolved errors of the API operation that would return them, so that
+// controls report the resource as ERROR instead of judging a placeholder value
+type resolver struct {
+	operation string
+	// resource names the template resource when the errors are kept on a parent, e.g. a stage on its API
+	resource string
+	errors   inventory.APIErrors
+}
+
+func (r *resolver) unresolved(property string, node *yaml.Node) {
+	if r.resource != "" {
+		property = r.resource + "." + property
+	}
+	r.errors = append(r.errors, inventory.APIError{
+		Operation: r.operation,
+		Code:      "Unresolved",
+		Message:   fmt.Sprintf("%s is %s, which cannot be resolved from the template", property, str(node)),
+	})
+}
+
+func (r *resolver) boolean(property string, node *yaml.Node) bool {
+	if isIntrinsic(node) {
+		r.unresolved(property, node)
+	}
+	return boolean(node)
+}
+
+func (r *resolver) integer(property string, node *yaml.Node) int32 {
+	if isIntrinsic(node) {
+		r.unresolved(property, node)
+	}
+	return integer(node)
+}
+
+// value returns a literal string whose value, not only its presence, decides a control
+func (r *resolver) value(property string, node *yaml.Node) string {
+	if isIntrinsic(node) {
+		r.unresolved(property, node)
+		return ""
+	}
+	return str(node)
+}
+
+// originDomainName resolves !GetAtt Bucket.DomainName and Bucket.RegionalDomainName of a bucket
+// declared in the same template to an S3 domain, so S3 origin controls apply to it
+func originDomainName(template *Template, node *yaml.Node) string {
+	logicalID, attribute, ok := getAtt(node)
+	if !ok {
+		return str(node)
+	}
+
+	bucket := template.Resource(logicalID)
+	if bucket == nil || bucket.Type != "AWS::S3::Bucket" {
+		return str(node)
+	}
+
+	switch attribute {
+	case "DomainName", "RegionalDomainName":
+		return logicalID + ".s3.amazonaws.com"
+	case "WebsiteURL":
+		return logicalID + ".s3-website-region.amazonaws.com"
+	}
+	return str(node)
+}
@@ -0,0 +1,133 @@
+// iac/cloudformation/inventory_test.go
+package cloudformation
+
+import (
+	"reflect"
+	"testing"
+
+	"aws-security-hub/inventory"
+)
+
+func buildFixture(t *testing.T, path string) *inventory.Inventory {
+	t.Helper()
+	template, err := ParseTemplate(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return BuildInventory(template)
+}
+
+func TestBuildInventoryDocumentDB(t *testing.T) {
+	inv := buildFixture(t, "testdata/template.yaml")
+
+	tests := []struct {
+		name  string
+		index int
+		want  inventory.DocDBCluster
+	}{
+		{"literal settings", 0, inventory.DocDBCluster{
+			Identifier:                   "Cluster (template.yaml:9)",
+			StorageEncrypted:             true,
+			BackupRetentionPeriod:        1, // CloudFormation default
+			EnabledCloudwatchLogsExports: []string{"audit"},
+			DeletionProtection:           true,
+		}},
+		{"parameter reference", 1, inventory.DocDBCluster{
+			Identifier:            "ParameterizedCluster (template.yaml:16)",
+			BackupRetentionPeriod: 7,
+			Errors: inventory.APIErrors{{
+				Operation: "DescribeDBClusters",
+				Code:      "Unresolved",
+				Message:   "StorageEncrypted is !Ref Encrypt, which cannot be resolved from the template",
+			}},
+		}},
+	}
+	if len(inv.DocumentDB.Clusters) != len(tests) {
+		t.Fatalf("got %d clusters, want %d", len(inv.DocumentDB.Clusters), len(tests))
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := inv.DocumentDB.Clusters[test.index]; !reflect.DeepEqual(got, test.want) {
+				t.Errorf("cluster = %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestBuildInventoryS3(t *testing.T) {
+	allBlocked := &inventory.PublicAccessBlock{BlockPublicAcls: true, IgnorePublicAcls: true, BlockPublicPolicy: true, RestrictPublicBuckets: true}
+
+	tests := []struct {
+		path  string
+		index int
+		want  inventory.Bucket
+	}{
+		{"testdata/template.yaml", 0, inventory.Bucket{Name: "DefaultBucket (template.yaml:21)", PublicAccessBlock: allBlocked}},
+		{"testdata/template.yaml", 1, inventory.Bucket{
+			Name:              "OpenBucket (template.yaml:23)",
+			PublicAccessBlock: &inventory.PublicAccessBlock{IgnorePublicAcls: true, BlockPublicPolicy: true, RestrictPublicBuckets: true},
+		}},
+		{"testdata/template.json", 0, inventory.Bucket{
+			Name: "Bucket (template.json:3)",
+			Errors: inventory.APIErrors{{
+				Operation: "GetPublicAccessBlock",
+				Code:      "Unresolved",
+				Message:   "PublicAccessBlockConfiguration is Fn::If, which cannot be resolved from the template",
+			}},
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.want.Name, func(t *testing.T) {
+			inv := buildFixture(t, test.path)
+			if got := inv.S3.Buckets[test.index]; !reflect.DeepEqual(got, test.want) {
+				t.Errorf("bucket = %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestBuildInventoryRouteDefaultsToNoAuthorization(t *testing.T) {
+	inv := buildFixture(t, "testdata/template.yaml")
+
+	if len(inv.APIGateway.APIs) != 1 {
+		t.Fatalf("got %d APIs, want 1", len(inv.APIGateway.APIs))
+	}
+	api := inv.APIGateway.APIs[0]
+	if api.ID != "Api" || api.Name != "orders [Api (template.yaml:31)]" || api.ProtocolType != "HTTP" {
+		t.Errorf("api = %+v", api)
+	}
+	want := []inventory.Route{{RouteKey: "GET /orders [Route (template.yaml:36)]", AuthorizationType: "NONE"}}
+	if !reflect.DeepEqual(api.Routes, want) {
+		t.Errorf("routes = %+v, want %+v", api.Routes, want)
+	}
+}
+
+func TestBuildInventoryDistribution(t *testing.T) {
+	inv := buildFixture(t, "testdata/template.yaml")
+
+	if len(inv.CloudFront.Distributions) != 1 {
+		t.Fatalf("got %d distributions, want 1", len(inv.CloudFront.Distributions))
+	}
+	distribution := inv.CloudFront.Distributions[0]
+	if distribution.ID != "Distribution (template.yaml:41)" {
+		t.Errorf("ID = %s", distribution.ID)
+	}
+
+	// The bucket declared in the template resolves to an S3 origin; other domains are kept as written
+	var domains []string
+	for _, origin := range distribution.Origins {
+		domains = append(domains, origin.DomainName)
+	}
+	if want := []string{"DefaultBucket.s3.amazonaws.com", "api.example.com"}; !reflect.DeepEqual(domains, want) {
+		t.Errorf("origin domains = %v, want %v", domains, want)
+	}
+
+	// The conditional viewer protocol policy makes the distribution ERROR instead of passing or failing
+	err := distribution.Errors.Find("GetDistribution")
+	if err == nil || err.Code != "Unresolved" {
+		t.Fatalf("errors = %+v, want an Unresolved GetDistribution error", distribution.Errors)
+	}
+	if want := "DefaultCacheBehavior.ViewerProtocolPolicy is !If, which cannot be resolved from the template"; err.Message != want {
+		t.Errorf("message = %q, want %q", err.Message, want)
+	}
+}
@@ -0,0 +1,214 @@
+// iac/cloudformation/template.go
+package cloudformation
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Resource is a resource declared in the Resources section of a template
+type Resource struct {
+	LogicalID  string
+	Type       string
+	Properties *yaml.Node
+	File       string
+	Line       int
+}
+
+// Location identifies the resource by logical ID and template position for reporting
+func (r Resource) Location() string {
+	return fmt.Sprintf("%s (%s:%d)", r.LogicalID, r.File, r.Line)
+}
+
+// Template is a parsed CloudFormation template
+type Template struct {
+	File      string
+	Resources []Resource
+}
+
+// Resource returns the resource with the given logical ID, or nil if it is not declared
+func (t *Template) Resource(logicalID string) *Resource {
+	for i := range t.Resources {
+		if t.Resources[i].LogicalID == logicalID {
+			return &t.Resources[i]
+		}
+	}
+	return nil
+}
+
+var leadingTabs = regexp.MustCompile(`(?m)^\t+`)
+
+// ParseTemplate parses a YAML or JSON CloudFormation template, keeping the line of each resource
+func ParseTemplate(filePath string) (*Template, error) {
+	bytes, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read template: %v", err)
+	}
+
+	// JSON is parsed as YAML, which does not allow tab indentation
+	if strings.EqualFold(filepath.Ext(filePath), ".json") {
+		bytes = leadingTabs.ReplaceAllFunc(bytes, func(tabs []byte) []byte {
+			return []byte(strings.Repeat("    ", len(tabs)))
+		})
+	}
+
+	var document yaml.Node
+	if err := yaml.Unmarshal(bytes, &document); err != nil {
+		return nil, fmt.Errorf("failed to parse template: %v", err)
+	}
+	if len(document.Content) == 0 {
+		return nil, fmt.Errorf("template is empty")
+	}
+
+	resources := lookup(document.Content[0], "Resources")
+	if resources == nil || resources.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("template has no Resources section")
+	}
+
+	template := &Template{File: filePath}
+	for i := 0; i+1 < len(resources.Content); i += 2 {
+		key, value := resources.Content[i], resources.Content[i+1]
+		template.Resources = append(template.Resources, Resource{
+			LogicalID:  key.Value,
+			Type:       str(lookup(value, "Type")),
+			Properties: lookup(value, "Properties"),
+			File:       filepath.Base(filePath),
+			Line:       key.Line,
+		})
+	}
+
+	return template, nil
+}
+
+// lookup returns the value of a key in a mapping node, or nil
+func lookup(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// lookupPath follows a chain of keys through nested mapping nodes
+func lookupPath(node *yaml.Node, keys ...string) *yaml.Node {
+	for _, key := range keys {
+		node = lookup(node, key)
+	}
+	return node
+}
+
+// items returns the elements of a sequence node
+func items(node *yaml.Node) []*yaml.Node {
+	if node == nil || node.Kind != yaml.SequenceNode {
+		return nil
+	}
+	return node.Content
+}
+
+// isIntrinsic reports whether the node is an intrinsic function in short (!Ref) or long (Ref:, Fn::) form
+func isIntrinsic(node *yaml.Node) bool {
+	if node == nil {
+		return false
+	}
+	if strings.HasPrefix(node.Tag, "!") && !strings.HasPrefix(node.Tag, "!!") {
+		return true
+	}
+	if node.Kind == yaml.MappingNode && len(node.Content) == 2 {
+		key := node.Content[0].Value
+		return key == "Ref" || strings.HasPrefix(key, "Fn::")
+	}
+	return false
+}
+
+// str returns the literal value of a scalar, or a placeholder describing an intrinsic function.
+// It is meant for settings where only the presence of a value matters.
+func str(node *yaml.Node) string {
+	if node == nil {
+		return ""
+	}
+	if isIntrinsic(node) {
+		if node.Kind == yaml.ScalarNode {
+			return strings.TrimSpace(node.Tag + " " + node.Value)
+		}
+		if node.Kind == yaml.MappingNode {
+			return node.Content[0].Value
+		}
+		return node.Tag
+	}
+	if node.Kind != yaml.ScalarNode {
+		return ""
+	}
+	return node.Value
+}
+
+// boolean returns the value of a literal boolean, or false for an intrinsic function
+func boolean(node *yaml.Node) bool {
+	if node == nil || isIntrinsic(node) {
+		return false
+	}
+	value, _ := strconv.ParseBool(node.Value)
+	return value
+}
+
+// integer returns the value of a literal integer, or zero for an intrinsic function
+func integer(node *yaml.Node) int32 {
+	if node == nil || isIntrinsic(node) {
+		return 0
+	}
+	value, _ := strconv.ParseInt(node.Value, 10, 32)
+	return int32(value)
+}
+
+// ref returns the logical ID referenced by !Ref or { "Ref": ... }, or the literal value
+func ref(node *yaml.Node) string {
+	if node == nil {
+		return ""
+	}
+	if node.Kind == yaml.ScalarNode {
+		return node.Value
+	}
+	if target := lookup(node, "Ref"); target != nil {
+		return target.Value
+	}
+	return ""
+}
+
+// getAtt returns the logical ID and attribute of a !GetAtt or { "Fn::GetAtt": ... } function
+func getAtt(node *yaml.Node) (string, string, bool) {
+	if node == nil {
+		return "", "", false
+	}
+
+	var parts []string
+	switch {
+	case node.Tag == "!GetAtt" && node.Kind == yaml.ScalarNode:
+		parts = strings.SplitN(node.Value, ".", 2)
+	case node.Tag == "!GetAtt" && node.Kind == yaml.SequenceNode:
+		for _, item := range node.Content {
+			parts = append(parts, item.Value)
+		}
+	case lookup(node, "Fn::GetAtt") != nil:
+		target := lookup(node, "Fn::GetAtt")
+		if target.Kind == yaml.ScalarNode {
+			parts = strings.SplitN(target.Value, ".", 2)
+		}
+		for _, item := range items(target) {
+			parts = append(parts, item.Value)
+		}
+	}
+
+	if len(parts) != 2 {
+		return "", "", false
+	}
+	return parts[0], parts[1], true
+}
@@ -0,0 +1,361 @@
+// iac/terraform/inventory.go
+package terraform
+
+import (
+	"fmt"
+	"time"
+
+	"aws-security-hub/inventory"
+)
+
+// BuildInventory maps the supported planned resources to an inventory.
+// Resources are identified by Terraform address so evaluator output points back into the configuration.
+func BuildInventory(plan *Plan) *inventory.Inventory {
+	inv := &inventory.Inventory{
+		Version:     inventory.Version,
+		CollectedAt: time.Now().UTC(),
+		APIGateway:  &inventory.APIGateway{},
+		CloudFront:  &inventory.CloudFront{},
+		DocumentDB:  &inventory.DocumentDB{},
+		EC2:         &inventory.EC2{},
+		S3:          &inventory.S3{},
+	}
+
+	for _, change := range plan.Planned("aws_cloudfront_distribution") {
+		inv.CloudFront.Distributions = append(inv.CloudFront.Distributions, buildDistribution(plan, change))
+	}
+
+	for _, change := range plan.Planned("aws_docdb_cluster") {
+		values, afterUnknown := change.Change.After, change.Change.AfterUnknown
+		resolved := &resolver{operation: "DescribeDBClusters"}
+		var logExports []string
+		if unknown(afterUnknown, "enabled_cloudwatch_logs_exports") {
+			resolved.unresolved("enabled_cloudwatch_logs_exports")
+		}
+		exports, _ := values["enabled_cloudwatch_logs_exports"].([]interface{})
+		for _, item := range exports {
+			if value, ok := item.(string); ok {
+				logExports = append(logExports, value)
+			}
+		}
+		inv.DocumentDB.Clusters = append(inv.DocumentDB.Clusters, inventory.DocDBCluster{
+			Identifier:                   change.Address,
+			StorageEncrypted:             resolved.boolean(values, afterUnknown, "storage_encrypted"),
+			BackupRetentionPeriod:        resolved.integer(values, afterUnknown, "backup_retention_period"),
+			EnabledCloudwatchLogsExports: logExports,
+			DeletionProtection:           resolved.boolean(values, afterUnknown, "deletion_protection"),
+			Errors:                       resolved.errors,
+		})
+	}
+
+	buildAPIGateway(plan, inv.APIGateway)
+	buildSnapshots(plan, inv.EC2)
+	buildBuckets(plan, inv.S3)
+
+	return inv
+}
+
+func buildDistribution(plan *Plan, change ResourceChange) inventory.Distribution {
+	values, afterUnknown := change.Change.After, change.Change.AfterUnknown
+	expression := plan.expression(change.Address)
+	resolved := &resolver{operation: "GetDistribution"}
+
+	distribution := inventory.Distribution{
+		ID:                change.Address,
+		DefaultRootObject: str(values, "default_root_object"),
+		Tags:              make(map[string]string),
+	}
+
+	if defaultBehavior := blocks(values, "default_cache_behavior"); len(defaultBehavior) > 0 {
+		distribution.ViewerProtocolPolicy = resolved.value(defaultBehavior[0], unknownBlock(afterUnknown, "default_cache_behavior", 0), "viewer_protocol_policy")
+	}
+
+	for i, item := range blocks(values, "ordered_cache_behavior") {
+		distribution.CacheBehaviors = append(distribution.CacheBehaviors, inventory.CacheBehavior{
+			PathPattern:          str(item, "path_pattern"),
+			ViewerProtocolPolicy: resolved.value(item, unknownBlock(afterUnknown, "ordered_cache_behavior", i), "viewer_protocol_policy"),
+		})
+	}
+
+	// origin is a set, so planned origins are matched to their configuration by origin_id rather than position
+	originConfig := make(map[string]map[string]interface{})
+	for _, item := range blocks(expression, "origin") {
+		originConfig[constant(item, "origin_id")] = item
+	}
+
+	for i, item := range blocks(values, "origin") {
+		originID := str(item, "origin_id")
+		distribution.Origins = append(distribution.Origins, inventory.Origin{
+			ID:                    originID,
+			DomainName:            originDomainName(change.Address, item, unknownBlock(afterUnknown, "origin", i), originConfig[originID]),
+			OriginAccessControlID: presence(item, unknownBlock(afterUnknown, "origin", i), "origin_access_control_id"),
+		})
+	}
+
+	for _, item := range blocks(values, "origin_group") {
+		group := inventory.OriginGroup{ID: str(item, "origin_id")}
+		for _, member := range blocks(item, "member") {
+			group.Members = append(group.Members, str(member, "origin_id"))
+		}
+		distribution.OriginGroups = append(distribution.OriginGroups, group)
+	}
+
+	// A logging_config block enables standard logging; it has no separate enabled argument
+	if logging := blocks(values, "logging_config"); len(logging) > 0 {
+		distribution.Logging = inventory.Logging{
+			Enabled: true,
+			Bucket:  presence(logging[0], unknownBlock(afterUnknown, "logging_config", 0), "bucket"),
+			Prefix:  str(logging[0], "prefix"),
+		}
+	}
+
+	// tags_all includes provider default_tags
+	tags, _ := values["tags_all"].(map[string]interface{})
+	if tags == nil {
+		tags, _ = values["tags"].(map[string]interface{})
+	}
+	for key, value := range tags {
+		distribution.Tags[key], _ = value.(string)
+	}
+
+	distribution.Errors = resolved.errors
+	return distribution
+}
+
+// originDomainName resolves references to the domain attributes of an aws_s3_bucket to an S3 domain,
+// so S3 origin controls apply to buckets created in the same plan
+func originDomainName(address string, values, afterUnknown, expression map[string]interface{}) string {
+	if domainName := str(values, "domain_name"); domainName != "" {
+		return domainName
+	}
+
+	bucket, attribute := reference(address, expression, "domain_name", "aws_s3_bucket")
+	switch {
+	case bucket != "" && (attribute == "bucket_regional_domain_name" || attribute == "bucket_domain_name"):
+		return bucket + ".s3.amazonaws.com"
+	case bucket != "" && attribute == "website_endpoint":
+		return bucket + ".s3-website-region.amazonaws.com"
+	}
+	return presence(values, afterUnknown, "domain_name")
+}
+
+func buildAPIGateway(plan *Plan, result *inventory.APIGateway) {
+	restAPIs := make(map[string]*inventory.RestAPI)
+	apis := make(map[string]*inventory.API)
+	var restAPIOrder, apiOrder []string
+
+	restAPI := func(id, name string) *inventory.RestAPI {
+		if _, ok := restAPIs[id]; !ok {
+			restAPIs[id] = &inventory.RestAPI{ID: id, Name: id}
+			restAPIOrder = append(restAPIOrder, id)
+		}
+		if name != "" {
+			restAPIs[id].Name = fmt.Sprintf("%s [%s]", name, id)
+		}
+		return restAPIs[id]
+	}
+
+	api := func(id, name, protocolType string) *inventory.API {
+		if _, ok := apis[id]; !ok {
+			apis[id] = &inventory.API{ID: id, Name: id}
+			apiOrder = append(apiOrder, id)
+		}
+		if name != "" {
+			apis[id].Name = fmt.Sprintf("%s [%s]", name, id)
+			apis[id].ProtocolType = protocolType
+		}
+		return apis[id]
+	}
+
+	for _, change := range plan.Planned("aws_api_gateway_rest_api") {
+		restAPI(change.Address, str(change.Change.After, "name"))
+	}
+
+	// Stages are keyed by address and by REST API and stage name, which aws_api_gateway_method_settings may use instead
+	type stageRef struct {
+		restAPI string
+		index   int
+	}
+	stages := make(map[string]stageRef)
+
+	for _, change := range plan.Planned("aws_api_gateway_stage") {
+		values, afterUnknown := change.Change.After, change.Change.AfterUnknown
+		resolved := &resolver{operation: "GetStages", resource: change.Address}
+		parentID := plan.key(change, "rest_api_id", "aws_api_gateway_rest_api")
+		parent := restAPI(parentID, "")
+		parent.Stages = append(parent.Stages, inventory.RestStage{
+			StageName:           change.Address,
+			ClientCertificateID: presence(values, afterUnknown, "client_certificate_id"),
+			TracingEnabled:      resolved.boolean(values, afterUnknown, "xray_tracing_enabled"),
+			CacheClusterEnabled: resolved.boolean(values, afterUnknown, "cache_cluster_enabled"),
+			CacheClusterSize:    str(values, "cache_cluster_size"),
+			MethodSettings:      make(map[string]inventory.MethodSetting),
+		})
+		parent.Errors = append(parent.Errors, resolved.errors...)
+		stages[change.Address] = stageRef{restAPI: parentID, index: len(parent.Stages) - 1}
+		stages[parentID+"/"+str(values, "stage_name")] = stages[change.Address]
+	}
+
+	for _, change := range plan.Planned("aws_api_gateway_method_settings") {
+		values := change.Change.After
+		stage, ok := stages[plan.key(change, "stage_name", "aws_api_gateway_stage")]
+		if !ok {
+			stage, ok = stages[plan.key(change, "rest_api_id", "aws_api_gateway_rest_api")+"/"+str(values, "stage_name")]
+		}
+		settings := blocks(values, "settings")
+		if !ok || len(settings) == 0 {
+			continue
+		}
+		resolved := &resolver{operation: "GetStages", resource: change.Address}
+		settingsUnknown := unknownBlock(change.Change.AfterUnknown, "settings", 0)
+		parent := restAPIs[stage.restAPI]
+		parent.Stages[stage.index].MethodSettings[str(values, "method_path")] = inventory.MethodSetting{
+			LoggingLevel:       resolved.value(settings[0], settingsUnknown, "logging_level"),
+			CacheDataEncrypted: resolved.boolean(settings[0], settingsUnknown, "cache_data_encrypted"),
+		}
+		parent.Errors = append(parent.Errors, resolved.errors...)
+	}
+
+	for _, change := range plan.Planned("aws_apigatewayv2_api") {
+		api(change.Address, str(change.Change.After, "name"), str(change.Change.After, "protocol_type"))
+	}
+
+	for _, change := range plan.Planned("aws_apigatewayv2_stage") {
+		values, afterUnknown := change.Change.After, change.Change.AfterUnknown
+		resolved := &resolver{operation: "GetStages", resource: change.Address}
+		stage := inventory.Stage{StageName: change.Address}
+		if settings := blocks(values, "default_route_settings"); len(settings) > 0 {
+			stage.DefaultRouteLoggingLevel = resolved.value(settings[0], unknownBlock(afterUnknown, "default_route_settings", 0), "logging_level")
+		}
+		if settings := blocks(values, "access_log_settings"); len(settings) > 0 {
+			stage.AccessLogDestinationARN = presence(settings[0], unknownBlock(afterUnknown, "access_log_settings", 0), "destination_arn")
+		}
+		parent := api(plan.key(change, "api_id", "aws_apigatewayv2_api"), "", "")
+		parent.Stages = append(parent.Stages, stage)
+		parent.Errors = append(parent.Errors, resolved.errors...)
+	}
+
+	for _, change := range plan.Planned("aws_apigatewayv2_route") {
+		values := change.Change.After
+		resolved := &resolver{operation: "GetRoutes", resource: change.Address}
+		authorizationType := resolved.value(values, change.Change.AfterUnknown, "authorization_type")
+		if authorizationType == "" {
+			authorizationType = "NONE" // provider default
+		}
+		parent := api(plan.key(change, "api_id", "aws_apigatewayv2_api"), "", "")
+		parent.Routes = append(parent.Routes, inventory.Route{
+			RouteKey:          fmt.Sprintf("%s [%s]", str(values, "route_key"), change.Address),
+			AuthorizationType: authorizationType,
+		})
+		parent.Errors = append(parent.Errors, resolved.errors...)
+	}
+
+	for _, id := range restAPIOrder {
+		result.RestAPIs = append(result.RestAPIs, *restAPIs[id])
+	}
+	for _, id := range apiOrder {
+		result.APIs = append(result.APIs, *apis[id])
+	}
+}
+
+// buildSnapshots maps aws_ebs_snapshot and the account grants of aws_snapshot_create_volume_permission
+func buildSnapshots(plan *Plan, result *inventory.EC2) {
+	index := make(map[string]int)
+	for _, change := range plan.Planned("aws_ebs_snapshot") {
+		index[change.Address] = len(result.Snapshots)
+		result.Snapshots = append(result.Snapshots, inventory.EBSSnapshot{ID: change.Address})
+	}
+
+	for _, change := range plan.Planned("aws_snapshot_create_volume_permission") {
+		i, ok := index[plan.key(change, "snapshot_id", "aws_ebs_snapshot")]
+		if !ok {
+			continue
+		}
+		result.Snapshots[i].CreateVolumePermissions = append(result.Snapshots[i].CreateVolumePermissions, inventory.CreateVolumePermission{
+			UserID: presence(change.Change.After, change.Change.AfterUnknown, "account_id"),
+		})
+	}
+}
+
+// buildBuckets maps aws_s3_bucket together with its aws_s3_bucket_public_access_block.
+// A public access block for a bucket outside the plan is reported on its own.
+func buildBuckets(plan *Plan, result *inventory.S3) {
+	index := make(map[string]int)
+	for _, change := range plan.Planned("aws_s3_bucket") {
+		index[change.Address] = len(result.Buckets)
+		if name := str(change.Change.After, "bucket"); name != "" {
+			index[name] = len(result.Buckets)
+		}
+		// Since April 2023, S3 turns all four settings on for new buckets unless a public access block turns them off
+		result.Buckets = append(result.Buckets, inventory.Bucket{
+			Name: change.Address,
+			PublicAccessBlock: &inventory.PublicAccessBlock{
+				BlockPublicAcls:       true,
+				IgnorePublicAcls:      true,
+				BlockPublicPolicy:     true,
+				RestrictPublicBuckets: true,
+			},
+		})
+	}
+
+	for _, change := range plan.Planned("aws_s3_bucket_public_access_block") {
+		values, afterUnknown := change.Change.After, change.Change.AfterUnknown
+		resolved := &resolver{operation: "GetPublicAccessBlock", resource: change.Address}
+		publicAccessBlock := &inventory.PublicAccessBlock{
+			BlockPublicAcls:       resolved.boolean(values, afterUnknown, "block_public_acls"),
+			IgnorePublicAcls:      resolved.boolean(values, afterUnknown, "ignore_public_acls"),
+			BlockPublicPolicy:     resolved.boolean(values, afterUnknown, "block_public_policy"),
+			RestrictPublicBuckets: resolved.boolean(values, afterUnknown, "restrict_public_buckets"),
+		}
+
+		if i, ok := index[plan.key(change, "bucket", "aws_s3_bucket")]; ok {
+			result.Buckets[i].PublicAccessBlock = publicAccessBlock
+			result.Buckets[i].Errors = append(result.Buckets[i].Errors, resolved.errors...)
+			continue
+		}
+		result.Buckets = append(result.Buckets, inventory.Bucket{Name: change.Address, PublicAccessBlock: publicAccessBlock, Errors: resolved.errors})
+	}
+}
+
+// resolver reads the attributes that controls compare against, and records those known only after
+// apply as Unresolved errors of the API operation that would return them, so that controls report the
+// resource as ERROR instead of judging a zero value
+type resolver struct {
+	operation string
+	// resource names the planned resource when the errors are kept on another one, e.g. a stage on its API
+	resource string
+	errors   inventory.APIErrors
+}
+
+func (r *resolver) unresolved(attribute string) {
+	if r.resource != "" {
+		attribute = r.resource + "." + attribute
+	}
+	r.errors = append(r.errors, inventory.APIError{
+		Operation: r.operation,
+		Code:      "Unresolved",
+		Message:   fmt.Sprintf("%s is %s, which cannot be resolved from the plan", attribute, knownAfterApply),
+	})
+}
+
+func (r *resolver) boolean(values, afterUnknown map[string]interface{}, key string) bool {
+	if unknown(afterUnknown, key) {
+		r.unresolved(key)
+	}
+	return boolean(values, key)
+}
+
+func (r *resolver) integer(values, afterUnknown map[string]interface{}, key string) int32 {
+	if unknown(afterUnknown, key) {
+		r.unresolved(key)
+	}
+	return integer(values, key)
+}
+
+// value returns a string attribute whose value, not only its presence, decides a control
+func (r *resolver) value(values, afterUnknown map[string]interface{}, key string) string {
+	if unknown(afterUnknown, key) {
+		r.unresolved(key)
+	}
+	return str(values, key)
+}
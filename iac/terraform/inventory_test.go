@@ -0,0 +1,143 @@
+// iac/terraform/inventory_test.go
+package terraform
+
+import (
+	"reflect"
+	"testing"
+
+	"aws-security-hub/inventory"
+)
+
+func buildFixture(t *testing.T) *inventory.Inventory {
+	t.Helper()
+	plan, err := LoadPlan("testdata/plan.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return BuildInventory(plan)
+}
+
+func unresolved(operation, message string) inventory.APIErrors {
+	return inventory.APIErrors{{Operation: operation, Code: "Unresolved", Message: message}}
+}
+
+func TestBuildInventoryDocumentDB(t *testing.T) {
+	inv := buildFixture(t)
+
+	want := []inventory.DocDBCluster{
+		{
+			Identifier:                   "aws_docdb_cluster.main",
+			StorageEncrypted:             true,
+			BackupRetentionPeriod:        7,
+			EnabledCloudwatchLogsExports: []string{"audit"},
+			DeletionProtection:           true,
+		},
+		{
+			Identifier:            "aws_docdb_cluster.restored",
+			BackupRetentionPeriod: 1,
+			Errors:                unresolved("DescribeDBClusters", "storage_encrypted is (known after apply), which cannot be resolved from the plan"),
+		},
+	}
+	if !reflect.DeepEqual(inv.DocumentDB.Clusters, want) {
+		t.Errorf("clusters = %+v, want %+v", inv.DocumentDB.Clusters, want)
+	}
+}
+
+func TestBuildInventoryS3(t *testing.T) {
+	inv := buildFixture(t)
+
+	allBlocked := &inventory.PublicAccessBlock{BlockPublicAcls: true, IgnorePublicAcls: true, BlockPublicPolicy: true, RestrictPublicBuckets: true}
+	tests := []struct {
+		name string
+		want inventory.Bucket
+	}{
+		{"module.cdn.aws_s3_bucket.assets", inventory.Bucket{Name: "module.cdn.aws_s3_bucket.assets", PublicAccessBlock: allBlocked}},
+		{"aws_s3_bucket.logs", inventory.Bucket{Name: "aws_s3_bucket.logs", PublicAccessBlock: allBlocked}},
+		{"aws_s3_bucket.site", inventory.Bucket{
+			Name:              "aws_s3_bucket.site",
+			PublicAccessBlock: &inventory.PublicAccessBlock{BlockPublicAcls: true, IgnorePublicAcls: true, RestrictPublicBuckets: true},
+			Errors: unresolved("GetPublicAccessBlock",
+				"aws_s3_bucket_public_access_block.site.block_public_policy is (known after apply), which cannot be resolved from the plan"),
+		}},
+	}
+	if len(inv.S3.Buckets) != len(tests) {
+		t.Fatalf("got %d buckets, want %d", len(inv.S3.Buckets), len(tests))
+	}
+	for i, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := inv.S3.Buckets[i]; !reflect.DeepEqual(got, test.want) {
+				t.Errorf("bucket = %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestBuildInventoryMatchesMethodSettingsToStages(t *testing.T) {
+	inv := buildFixture(t)
+
+	if len(inv.APIGateway.RestAPIs) != 1 {
+		t.Fatalf("got %d REST APIs, want 1", len(inv.APIGateway.RestAPIs))
+	}
+	api := inv.APIGateway.RestAPIs[0]
+	if api.ID != "aws_api_gateway_rest_api.orders" || api.Name != "orders [aws_api_gateway_rest_api.orders]" {
+		t.Errorf("api = %s %q", api.ID, api.Name)
+	}
+
+	tests := []struct {
+		name           string
+		stage          string
+		tracing        bool
+		methodSettings map[string]inventory.MethodSetting
+	}{
+		// matched through the reference to the stage resource
+		{"by stage reference", "aws_api_gateway_stage.prod", true, map[string]inventory.MethodSetting{
+			"*/*": {LoggingLevel: "INFO", CacheDataEncrypted: true},
+		}},
+		// matched through the REST API reference and the literal stage name
+		{"by REST API and stage name", "aws_api_gateway_stage.dev", false, map[string]inventory.MethodSetting{
+			"orders/GET": {LoggingLevel: "ERROR"},
+		}},
+	}
+	if len(api.Stages) != len(tests) {
+		t.Fatalf("got %d stages, want %d", len(api.Stages), len(tests))
+	}
+	for i, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			stage := api.Stages[i]
+			if stage.StageName != test.stage || stage.TracingEnabled != test.tracing {
+				t.Errorf("stage = %s tracing %v, want %s tracing %v", stage.StageName, stage.TracingEnabled, test.stage, test.tracing)
+			}
+			if !reflect.DeepEqual(stage.MethodSettings, test.methodSettings) {
+				t.Errorf("method settings = %+v, want %+v", stage.MethodSettings, test.methodSettings)
+			}
+		})
+	}
+
+	// X-Ray tracing of the dev stage comes from a variable, so the API is reported as ERROR
+	want := unresolved("GetStages", "aws_api_gateway_stage.dev.xray_tracing_enabled is (known after apply), which cannot be resolved from the plan")
+	if !reflect.DeepEqual(api.Errors, want) {
+		t.Errorf("errors = %+v, want %+v", api.Errors, want)
+	}
+}
+
+func TestBuildInventoryMatchesOriginsByID(t *testing.T) {
+	inv := buildFixture(t)
+
+	if len(inv.CloudFront.Distributions) != 1 {
+		t.Fatalf("got %d distributions, want 1", len(inv.CloudFront.Distributions))
+	}
+	distribution := inv.CloudFront.Distributions[0]
+	if distribution.ViewerProtocolPolicy != "redirect-to-https" || len(distribution.Errors) != 0 {
+		t.Errorf("distribution = %+v", distribution)
+	}
+
+	// The planned origins are in a different order than configured; the bucket reference inside the
+	// module resolves to the module's bucket
+	want := []inventory.Origin{
+		{ID: "api", DomainName: "api.example.com"},
+		{ID: "assets", DomainName: "module.cdn.aws_s3_bucket.assets.s3.amazonaws.com"},
+	}
+	if !reflect.DeepEqual(distribution.Origins, want) {
+		t.Errorf("origins = %+v, want %+v", distribution.Origins, want)
+	}
+}
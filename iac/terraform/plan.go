@@ -0,0 +1,211 @@
+// iac/terraform/plan.go
+package terraform
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// Plan is the subset of `terraform show -json` output used to build an inventory
+type Plan struct {
+	FormatVersion   string           `json:"format_version"`
+	ResourceChanges []ResourceChange `json:"resource_changes"`
+	Configuration   struct {
+		RootModule ConfigModule `json:"root_module"`
+	} `json:"configuration"`
+
+	// expressions indexes resource configuration expressions by resource address (without instance keys)
+	expressions map[string]map[string]interface{}
+}
+
+// ResourceChange is a planned change to a single resource instance
+type ResourceChange struct {
+	Address string `json:"address"`
+	Mode    string `json:"mode"`
+	Type    string `json:"type"`
+	Name    string `json:"name"`
+	Change  struct {
+		Actions      []string               `json:"actions"`
+		After        map[string]interface{} `json:"after"`
+		AfterUnknown map[string]interface{} `json:"after_unknown"`
+	} `json:"change"`
+}
+
+// ConfigModule is a module in the plan configuration
+type ConfigModule struct {
+	Resources []struct {
+		Address     string                 `json:"address"`
+		Expressions map[string]interface{} `json:"expressions"`
+	} `json:"resources"`
+	ModuleCalls map[string]struct {
+		Module ConfigModule `json:"module"`
+	} `json:"module_calls"`
+}
+
+// LoadPlan reads a plan exported with `terraform show -json`
+func LoadPlan(filePath string) (*Plan, error) {
+	bytes, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read plan: %v", err)
+	}
+
+	var plan Plan
+	if err := json.Unmarshal(bytes, &plan); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal plan: %v", err)
+	}
+	if plan.FormatVersion == "" {
+		return nil, fmt.Errorf("not a Terraform JSON plan (missing format_version)")
+	}
+
+	plan.expressions = make(map[string]map[string]interface{})
+	plan.indexModule("", plan.Configuration.RootModule)
+
+	return &plan, nil
+}
+
+func (p *Plan) indexModule(prefix string, module ConfigModule) {
+	for _, resource := range module.Resources {
+		p.expressions[prefix+resource.Address] = resource.Expressions
+	}
+	for name, call := range module.ModuleCalls {
+		p.indexModule(prefix+"module."+name+".", call.Module)
+	}
+}
+
+// Planned returns the managed resources of the given type that exist after the plan is applied
+func (p *Plan) Planned(resourceType string) []ResourceChange {
+	var changes []ResourceChange
+	for _, change := range p.ResourceChanges {
+		if change.Mode != "managed" || change.Type != resourceType || change.Change.After == nil {
+			continue
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+var instanceKey = regexp.MustCompile(`\[[^\]]*\]`)
+
+// expression returns the configuration expressions of a resource instance
+func (p *Plan) expression(address string) map[string]interface{} {
+	return p.expressions[instanceKey.ReplaceAllString(address, "")]
+}
+
+// constant returns the literal value of an attribute expression, or "" when it is computed
+func constant(expression map[string]interface{}, key string) string {
+	attribute, _ := expression[key].(map[string]interface{})
+	value, _ := attribute["constant_value"].(string)
+	return value
+}
+
+// references returns what an attribute expression refers to, e.g. "aws_s3_bucket.site.bucket_regional_domain_name".
+// Module-local references are qualified with the module path of the referring resource.
+func references(address string, expression map[string]interface{}, key string) []string {
+	attribute, _ := expression[key].(map[string]interface{})
+	refs, _ := attribute["references"].([]interface{})
+
+	prefix := modulePath(address)
+	var result []string
+	for _, ref := range refs {
+		if value, ok := ref.(string); ok {
+			result = append(result, prefix+value)
+		}
+	}
+	return result
+}
+
+// modulePath returns the "module.<name>." segments of an address, e.g. "module.cdn." for "module.cdn.aws_s3_bucket.logs"
+func modulePath(address string) string {
+	parts := strings.Split(address, ".")
+	prefix := ""
+	for i := 0; i+1 < len(parts) && parts[i] == "module"; i += 2 {
+		prefix += "module." + parts[i+1] + "."
+	}
+	return prefix
+}
+
+// reference returns the first referenced resource of the given type and the referenced attribute,
+// e.g. "aws_s3_bucket.site" and "bucket_regional_domain_name", or "" when there is none
+func reference(address string, expression map[string]interface{}, key, resourceType string) (string, string) {
+	for _, ref := range references(address, expression, key) {
+		parts := strings.Split(instanceKey.ReplaceAllString(ref, ""), ".")
+		for i := 0; i+1 < len(parts); i++ {
+			if parts[i] == resourceType {
+				return strings.Join(parts[:i+2], "."), strings.Join(parts[i+2:], ".")
+			}
+		}
+	}
+	return "", ""
+}
+
+// key identifies the resource an attribute points at: the referenced resource of the given type when
+// the attribute refers to one, otherwise the planned value. Values known only after apply are never
+// used on their own, so resources created in the same plan can still be matched together.
+func (p *Plan) key(change ResourceChange, attribute, resourceType string) string {
+	if target, _ := reference(change.Address, p.expression(change.Address), attribute, resourceType); target != "" {
+		return target
+	}
+	return presence(change.Change.After, change.Change.AfterUnknown, attribute)
+}
+
+// str returns a string attribute; values known only after apply are returned as ""
+func str(values map[string]interface{}, key string) string {
+	if value, ok := values[key].(string); ok {
+		return value
+	}
+	return ""
+}
+
+// boolean returns a boolean attribute, or false when it is null or known only after apply
+func boolean(values map[string]interface{}, key string) bool {
+	value, _ := values[key].(bool)
+	return value
+}
+
+// integer returns a numeric attribute, or zero when it is null or known only after apply
+func integer(values map[string]interface{}, key string) int32 {
+	value, _ := values[key].(float64)
+	return int32(value)
+}
+
+// blocks returns the elements of a nested block or list attribute
+func blocks(values map[string]interface{}, key string) []map[string]interface{} {
+	list, _ := values[key].([]interface{})
+	var result []map[string]interface{}
+	for _, item := range list {
+		if block, ok := item.(map[string]interface{}); ok {
+			result = append(result, block)
+		}
+	}
+	return result
+}
+
+// unknown reports whether an attribute of the given values is known only after apply
+func unknown(afterUnknown map[string]interface{}, key string) bool {
+	value, _ := afterUnknown[key].(bool)
+	return value
+}
+
+// unknownBlock returns the after_unknown markers of the index-th element of a nested block
+func unknownBlock(afterUnknown map[string]interface{}, key string, index int) map[string]interface{} {
+	list, _ := afterUnknown[key].([]interface{})
+	if index >= len(list) {
+		return nil
+	}
+	block, _ := list[index].(map[string]interface{})
+	return block
+}
+
+// knownAfterApply is reported for attributes whose value is computed during apply
+const knownAfterApply = "(known after apply)"
+
+// presence returns a string attribute that is only checked for being set, treating computed values as set
+func presence(values, afterUnknown map[string]interface{}, key string) string {
+	if unknown(afterUnknown, key) {
+		return knownAfterApply
+	}
+	return str(values, key)
+}
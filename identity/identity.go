@@ -0,0 +1,151 @@
+// identity/identity.go
+package identity
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials/ssocreds"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+)
+
+// Options selects the credentials the scanner runs with. Without any of them the SDK default chain is
+// used: environment variables, AWS_PROFILE, web identity from AWS_WEB_IDENTITY_TOKEN_FILE, then the
+// container or instance role.
+type Options struct {
+	Profile              string        // shared config profile, including SSO and assume-role profiles
+	RoleARN              string        // role to assume on top of the profile credentials
+	ExternalID           string        // external ID required by the trust policy of RoleARN
+	MFASerial            string        // MFA device required by the trust policy of RoleARN
+	SessionName          string        // session name of the assumed role, visible in CloudTrail
+	Duration             time.Duration // lifetime of the assumed role credentials
+	WebIdentityTokenFile string        // OIDC token exchanged for RoleARN instead of the profile credentials
+}
+
+// Validate rejects options that only make sense together with a role
+func (o Options) Validate() error {
+	if o.RoleARN == "" {
+		switch {
+		case o.ExternalID != "":
+			return fmt.Errorf("an external ID requires a role ARN")
+		case o.MFASerial != "":
+			return fmt.Errorf("an MFA serial requires a role ARN")
+		case o.WebIdentityTokenFile != "":
+			return fmt.Errorf("a web identity token file requires a role ARN")
+		}
+	}
+	if o.WebIdentityTokenFile != "" && (o.ExternalID != "" || o.MFASerial != "") {
+		return fmt.Errorf("a web identity token cannot be combined with an external ID or an MFA serial")
+	}
+	return nil
+}
+
+// LoadOptions returns the options of config.LoadDefaultConfig selecting the profile. Profiles that
+// assume a role with mfa_serial prompt for the code on stderr.
+func LoadOptions(o Options) []func(*config.LoadOptions) error {
+	options := []func(*config.LoadOptions) error{
+		config.WithAssumeRoleCredentialOptions(func(options *stscreds.AssumeRoleOptions) {
+			options.TokenProvider = tokenPrompt(aws.ToString(options.SerialNumber))
+		}),
+	}
+	if o.Profile != "" {
+		options = append(options, config.WithSharedConfigProfile(o.Profile))
+	}
+	return options
+}
+
+// AssumeRole replaces the credentials of cfg with those of the role of the options, if any. The
+// role is assumed lazily, on the first API call, and refreshed before it expires.
+func AssumeRole(cfg *aws.Config, o Options) {
+	if o.RoleARN == "" {
+		return
+	}
+	client := sts.NewFromConfig(*cfg)
+	sessionName := o.SessionName
+	if sessionName == "" {
+		sessionName = fmt.Sprintf("aws-security-hub-%d", time.Now().Unix())
+	}
+
+	if o.WebIdentityTokenFile != "" {
+		cfg.Credentials = aws.NewCredentialsCache(stscreds.NewWebIdentityRoleProvider(client, o.RoleARN,
+			stscreds.IdentityTokenFile(o.WebIdentityTokenFile), func(options *stscreds.WebIdentityRoleOptions) {
+				options.RoleSessionName = sessionName
+				options.Duration = o.Duration
+			}))
+		return
+	}
+	cfg.Credentials = aws.NewCredentialsCache(stscreds.NewAssumeRoleProvider(client, o.RoleARN, func(options *stscreds.AssumeRoleOptions) {
+		options.RoleSessionName = sessionName
+		options.Duration = o.Duration
+		if o.ExternalID != "" {
+			options.ExternalID = aws.String(o.ExternalID)
+		}
+		if o.MFASerial != "" {
+			options.SerialNumber = aws.String(o.MFASerial)
+			options.TokenProvider = tokenPrompt(o.MFASerial)
+		}
+	}))
+}
+
+// tokenPrompt reads an MFA code from stdin. The prompt goes to stderr so that it does not end up in
+// reports written to stdout.
+func tokenPrompt(serial string) func() (string, error) {
+	return func() (string, error) {
+		if serial == "" {
+			fmt.Fprint(os.Stderr, "Enter MFA code: ")
+		} else {
+			fmt.Fprintf(os.Stderr, "Enter MFA code for %s: ", serial)
+		}
+		code, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && code == "" {
+			return "", fmt.Errorf("failed to read the MFA code: %v", err)
+		}
+		return strings.TrimSpace(code), nil
+	}
+}
+
+// Identity is the principal the scanner runs as
+type Identity struct {
+	Account string `json:"Account"`
+	ARN     string `json:"Arn"`
+	UserID  string `json:"UserId"`
+	Source  string `json:"Source"` // credentials provider, e.g. SSOProvider or AssumeRoleProvider
+}
+
+// Whoami resolves the credentials of cfg and returns the caller they belong to
+func Whoami(ctx context.Context, cfg aws.Config, profile string) (Identity, error) {
+	credentials, err := cfg.Credentials.Retrieve(ctx)
+	if err != nil {
+		return Identity{}, Explain(err, profile)
+	}
+	output, err := sts.NewFromConfig(cfg).GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+	if err != nil {
+		return Identity{}, Explain(err, profile)
+	}
+	return Identity{
+		Account: aws.ToString(output.Account),
+		ARN:     aws.ToString(output.Arn),
+		UserID:  aws.ToString(output.UserId),
+		Source:  credentials.Source,
+	}, nil
+}
+
+// Explain adds how to recover to credential errors that have a known fix
+func Explain(err error, profile string) error {
+	var ssoErr *ssocreds.InvalidTokenError
+	if errors.As(err, &ssoErr) {
+		if profile == "" {
+			return fmt.Errorf("%v (run 'aws sso login')", err)
+		}
+		return fmt.Errorf("%v (run 'aws sso login --profile %s')", err, profile)
+	}
+	return err
+}
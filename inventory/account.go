@@ -0,0 +1,52 @@
+// inventory/account.go
+package inventory
+
+import (
+	"context"
+	"errors"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/account"
+	"github.com/aws/aws-sdk-go-v2/service/account/types"
+)
+
+// Account holds account-level settings
+type Account struct {
+	// SecurityContact is nil when no security alternate contact is configured
+	SecurityContact *AlternateContact `json:"SecurityContact"`
+}
+
+// AlternateContact is an account alternate contact
+type AlternateContact struct {
+	Name         string `json:"Name"`
+	Title        string `json:"Title"`
+	EmailAddress string `json:"EmailAddress"`
+	PhoneNumber  string `json:"PhoneNumber"`
+}
+
+func collectAccount(ctx context.Context, cfg aws.Config) (*Account, error) {
+	client := account.NewFromConfig(cfg)
+
+	contact, err := client.GetAlternateContact(ctx, &account.GetAlternateContactInput{
+		AlternateContactType: types.AlternateContactTypeSecurity,
+	})
+	if err != nil {
+		var notFound *types.ResourceNotFoundException
+		if errors.As(err, &notFound) {
+			return &Account{}, nil
+		}
+		return nil, err
+	}
+
+	result := &Account{}
+	if contact.AlternateContact != nil {
+		result.SecurityContact = &AlternateContact{
+			Name:         aws.ToString(contact.AlternateContact.Name),
+			Title:        aws.ToString(contact.AlternateContact.Title),
+			EmailAddress: aws.ToString(contact.AlternateContact.EmailAddress),
+			PhoneNumber:  aws.ToString(contact.AlternateContact.PhoneNumber),
+		}
+	}
+
+	return result, nil
+}
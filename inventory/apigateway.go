@@ -0,0 +1,272 @@
+// inventory/apigateway.go
+package inventory
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/apigateway"
+	"github.com/aws/aws-sdk-go-v2/service/apigatewayv2"
+	apigatewayv2types "github.com/aws/aws-sdk-go-v2/service/apigatewayv2/types"
+	"github.com/aws/aws-sdk-go-v2/service/wafv2"
+	wafv2types "github.com/aws/aws-sdk-go-v2/service/wafv2/types"
+)
+
+// APIGateway holds REST APIs (v1), HTTP and WebSocket APIs (v2) and the regional web ACLs protecting them
+type APIGateway struct {
+	RestAPIs []RestAPI `json:"RestAPIs"`
+	APIs     []API     `json:"APIs"`
+	// WebACLs is nil when the regional web ACLs could not be collected
+	WebACLs []WebACL `json:"WebACLs"`
+	// Errors holds a failed ListWebACLs call
+	Errors APIErrors `json:"Errors,omitempty"`
+}
+
+// RestAPI is an API Gateway REST API
+type RestAPI struct {
+	ID     string      `json:"ID"`
+	Name   string      `json:"Name"`
+	Stages []RestStage `json:"Stages"`
+	Errors APIErrors   `json:"Errors,omitempty"` // a failed GetStages call leaves Stages empty
+}
+
+// RestStage is a stage of a REST API
+type RestStage struct {
+	StageName           string `json:"StageName"`
+	ClientCertificateID string `json:"ClientCertificateID"`
+	TracingEnabled      bool   `json:"TracingEnabled"`
+	CacheClusterEnabled bool   `json:"CacheClusterEnabled"`
+	CacheClusterSize    string `json:"CacheClusterSize"`
+	// MethodSettings is keyed by method path, e.g. "*/*" for all methods
+	MethodSettings map[string]MethodSetting `json:"MethodSettings"`
+}
+
+// MethodSetting holds the settings applied to a REST API method
+type MethodSetting struct {
+	LoggingLevel       string `json:"LoggingLevel"`
+	CacheDataEncrypted bool   `json:"CacheDataEncrypted"`
+}
+
+// API is an API Gateway v2 (HTTP or WebSocket) API
+type API struct {
+	ID           string    `json:"ID"`
+	Name         string    `json:"Name"`
+	ProtocolType string    `json:"ProtocolType"`
+	Stages       []Stage   `json:"Stages"`
+	Routes       []Route   `json:"Routes"`
+	Errors       APIErrors `json:"Errors,omitempty"` // failed GetStages and GetRoutes calls
+}
+
+// Stage is a stage of a v2 API
+type Stage struct {
+	StageName string `json:"StageName"`
+	// DefaultRouteLoggingLevel is empty when no default route settings are configured
+	DefaultRouteLoggingLevel string `json:"DefaultRouteLoggingLevel"`
+	AccessLogDestinationARN  string `json:"AccessLogDestinationARN"`
+}
+
+// Route is a route of a v2 API
+type Route struct {
+	RouteKey          string `json:"RouteKey"`
+	AuthorizationType string `json:"AuthorizationType"`
+}
+
+// WebACL is a regional WAF web ACL and the API Gateway resources associated with it
+type WebACL struct {
+	Name         string    `json:"Name"`
+	ARN          string    `json:"ARN"`
+	ResourceARNs []string  `json:"ResourceARNs"`
+	Errors       APIErrors `json:"Errors,omitempty"` // a failed ListResourcesForWebACL call
+}
+
+func collectAPIGateway(ctx context.Context, cfg aws.Config) (*APIGateway, error) {
+	result := &APIGateway{}
+
+	restAPIs, err := collectRestAPIs(ctx, apigateway.NewFromConfig(cfg))
+	if err != nil {
+		return nil, err
+	}
+	result.RestAPIs = restAPIs
+
+	apis, err := collectAPIs(ctx, apigatewayv2.NewFromConfig(cfg))
+	if err != nil {
+		return nil, err
+	}
+	result.APIs = apis
+
+	webACLs, err := collectWebACLs(ctx, wafv2.NewFromConfig(cfg))
+	if err != nil {
+		result.Errors = append(result.Errors, NewAPIError(err))
+	} else {
+		result.WebACLs = webACLs
+	}
+
+	return result, nil
+}
+
+func collectRestAPIs(ctx context.Context, client *apigateway.Client) ([]RestAPI, error) {
+	var restAPIs []RestAPI
+
+	paginator := apigateway.NewGetRestApisPaginator(client, &apigateway.GetRestApisInput{
+		Limit: aws.Int32(500), // Maximum allowed value
+	})
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, api := range output.Items {
+			restAPI := RestAPI{
+				ID:   aws.ToString(api.Id),
+				Name: aws.ToString(api.Name),
+			}
+
+			// GetStages returns every stage of the API in one response
+			stages, err := client.GetStages(ctx, &apigateway.GetStagesInput{
+				RestApiId: api.Id,
+			})
+			if err != nil {
+				restAPI.Errors = append(restAPI.Errors, NewAPIError(err))
+				restAPIs = append(restAPIs, restAPI)
+				continue
+			}
+
+			for _, stage := range stages.Item {
+				restStage := RestStage{
+					StageName:           aws.ToString(stage.StageName),
+					ClientCertificateID: aws.ToString(stage.ClientCertificateId),
+					TracingEnabled:      stage.TracingEnabled,
+					CacheClusterEnabled: stage.CacheClusterEnabled,
+					CacheClusterSize:    string(stage.CacheClusterSize),
+					MethodSettings:      make(map[string]MethodSetting),
+				}
+				for path, settings := range stage.MethodSettings {
+					restStage.MethodSettings[path] = MethodSetting{
+						LoggingLevel:       aws.ToString(settings.LoggingLevel),
+						CacheDataEncrypted: settings.CacheDataEncrypted,
+					}
+				}
+				restAPI.Stages = append(restAPI.Stages, restStage)
+			}
+
+			restAPIs = append(restAPIs, restAPI)
+		}
+	}
+
+	return restAPIs, nil
+}
+
+func collectAPIs(ctx context.Context, client *apigatewayv2.Client) ([]API, error) {
+	items, err := pages(func(token *string) ([]apigatewayv2types.Api, *string, error) {
+		output, err := client.GetApis(ctx, &apigatewayv2.GetApisInput{
+			MaxResults: aws.String("100"), // Maximum allowed value as a string
+			NextToken:  token,
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		return output.Items, output.NextToken, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var apis []API
+	for _, item := range items {
+		api := API{
+			ID:           aws.ToString(item.ApiId),
+			Name:         aws.ToString(item.Name),
+			ProtocolType: string(item.ProtocolType),
+		}
+
+		stages, err := pages(func(token *string) ([]apigatewayv2types.Stage, *string, error) {
+			output, err := client.GetStages(ctx, &apigatewayv2.GetStagesInput{
+				ApiId:     item.ApiId,
+				NextToken: token,
+			})
+			if err != nil {
+				return nil, nil, err
+			}
+			return output.Items, output.NextToken, nil
+		})
+		if err != nil {
+			api.Errors = append(api.Errors, NewAPIError(err))
+		}
+		for _, stage := range stages {
+			apiStage := Stage{
+				StageName: aws.ToString(stage.StageName),
+			}
+			if stage.DefaultRouteSettings != nil {
+				apiStage.DefaultRouteLoggingLevel = string(stage.DefaultRouteSettings.LoggingLevel)
+			}
+			if stage.AccessLogSettings != nil {
+				apiStage.AccessLogDestinationARN = aws.ToString(stage.AccessLogSettings.DestinationArn)
+			}
+			api.Stages = append(api.Stages, apiStage)
+		}
+
+		routes, err := pages(func(token *string) ([]apigatewayv2types.Route, *string, error) {
+			output, err := client.GetRoutes(ctx, &apigatewayv2.GetRoutesInput{
+				ApiId:     item.ApiId,
+				NextToken: token,
+			})
+			if err != nil {
+				return nil, nil, err
+			}
+			return output.Items, output.NextToken, nil
+		})
+		if err != nil {
+			api.Errors = append(api.Errors, NewAPIError(err))
+		}
+		for _, route := range routes {
+			api.Routes = append(api.Routes, Route{
+				RouteKey:          aws.ToString(route.RouteKey),
+				AuthorizationType: string(route.AuthorizationType),
+			})
+		}
+
+		apis = append(apis, api)
+	}
+
+	return apis, nil
+}
+
+func collectWebACLs(ctx context.Context, client *wafv2.Client) ([]WebACL, error) {
+	summaries, err := pages(func(token *string) ([]wafv2types.WebACLSummary, *string, error) {
+		output, err := client.ListWebACLs(ctx, &wafv2.ListWebACLsInput{
+			Scope:      wafv2types.ScopeRegional,
+			Limit:      aws.Int32(100), // Maximum allowed value
+			NextMarker: token,
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		return output.WebACLs, output.NextMarker, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	webACLs := make([]WebACL, 0, len(summaries))
+	for _, summary := range summaries {
+		webACL := WebACL{
+			Name: aws.ToString(summary.Name),
+			ARN:  aws.ToString(summary.ARN),
+		}
+
+		resources, err := client.ListResourcesForWebACL(ctx, &wafv2.ListResourcesForWebACLInput{
+			WebACLArn:    summary.ARN,
+			ResourceType: wafv2types.ResourceTypeApiGateway,
+		})
+		if err != nil {
+			webACL.Errors = append(webACL.Errors, NewAPIError(err))
+		} else {
+			webACL.ResourceARNs = resources.ResourceArns
+		}
+
+		webACLs = append(webACLs, webACL)
+	}
+
+	return webACLs, nil
+}
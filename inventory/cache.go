@@ -0,0 +1,184 @@
+// inventory/cache.go
+package inventory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// Cache shares the resources fetched during one run, so that controls asking for the same service
+// (or collectors asking for the same resource) trigger a single round of API calls. Entries are keyed
+// by account/region/service/resource, where an empty resource stands for the whole service section.
+// Errors are cached as well, so a failing service is not retried by every control of the run. Only a
+// collection whose context ended (a control timeout or an interruption) is not cached, since its
+// Timeout and Canceled errors belong to its caller: the next caller collects the service again.
+// A Cache is meant to live for one run; it never expires entries.
+type Cache struct {
+	mu      sync.Mutex
+	entries map[string]*cacheEntry
+	hits    int
+	misses  int
+}
+
+type cacheEntry struct {
+	mu     sync.Mutex
+	loaded bool
+	value  interface{}
+	err    error
+}
+
+// NewCache creates an empty cache
+func NewCache() *Cache {
+	return &Cache{entries: make(map[string]*cacheEntry)}
+}
+
+// Stats returns the number of lookups served from the cache and the number that called AWS
+func (c *Cache) Stats() (hits, misses int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.hits, c.misses
+}
+
+// Collect works like the package-level Collect, taking every service section from the cache when
+// it was already collected for the account and region of cfg
+func (c *Cache) Collect(ctx context.Context, cfg aws.Config, services ...string) (*Inventory, error) {
+	if len(services) == 0 {
+		services = Services
+	}
+
+	account, _ := cached(ctx, c, cacheKey("", cfg.Region, "sts", "GetCallerIdentity"), func() (string, error) {
+		return accountID(ctx, cfg), nil
+	})
+	inv := &Inventory{
+		Version:     Version,
+		CollectedAt: time.Now().UTC(),
+		Region:      cfg.Region,
+		AccountID:   account,
+	}
+
+	var errs []error
+	for _, service := range services {
+		key := cacheKey(account, cfg.Region, service, "")
+		var err error
+		switch service {
+		case ServiceAccount:
+			inv.Account, err = cached(ctx, c, key, func() (*Account, error) { return collectAccount(ctx, cfg) })
+		case ServiceAPIGateway:
+			inv.APIGateway, err = cached(ctx, c, key, func() (*APIGateway, error) { return collectAPIGateway(ctx, cfg) })
+		case ServiceCloudFront:
+			inv.CloudFront, err = cached(ctx, c, key, func() (*CloudFront, error) { return collectCloudFront(ctx, cfg, c, account) })
+		case ServiceDocumentDB:
+			inv.DocumentDB, err = cached(ctx, c, key, func() (*DocumentDB, error) { return collectDocumentDB(ctx, cfg) })
+		case ServiceEC2:
+			inv.EC2, err = cached(ctx, c, key, func() (*EC2, error) { return collectEC2(ctx, cfg) })
+		case ServiceS3:
+			inv.S3, err = cached(ctx, c, key, func() (*S3, error) { return collectS3(ctx, cfg) })
+		default:
+			err = fmt.Errorf("unknown service")
+		}
+		if err != nil {
+			if inv.Errors == nil {
+				inv.Errors = make(map[string]APIError)
+			}
+			inv.Errors[service] = NewAPIError(err)
+			errs = append(errs, &ServiceError{Service: service, Err: err})
+		}
+	}
+
+	return inv, errors.Join(errs...)
+}
+
+// bucketExists reports whether a bucket exists, asking S3 once per bucket whatever the region scanned,
+// since bucket names are global. A bucket of another region is asked again in its region. Errors
+// other than NotFound (such as Forbidden for a bucket of another account) leave the question open.
+func (c *Cache) bucketExists(ctx context.Context, cfg aws.Config, account, bucket string) (bool, error) {
+	return cached(ctx, c, cacheKey(account, "", ServiceS3, "HeadBucket/"+bucket), func() (bool, error) {
+		client := s3.NewFromConfig(cfg)
+		input := &s3.HeadBucketInput{Bucket: aws.String(bucket)}
+		_, err := client.HeadBucket(ctx, input)
+		if region, moved := bucketRegion(ctx, client, bucket, err); moved {
+			_, err = s3.NewFromConfig(cfg, func(o *s3.Options) { o.Region = region }).HeadBucket(ctx, input)
+		}
+		var notFound *s3types.NotFound
+		if errors.As(err, &notFound) {
+			return false, nil
+		}
+		return err == nil, err
+	})
+}
+
+// bucketRegion returns the region of a bucket that S3 answered with a redirect to its region: the
+// x-amz-bucket-region header of the response, or GetBucketLocation when the header is missing
+func bucketRegion(ctx context.Context, client *s3.Client, bucket string, err error) (string, bool) {
+	var responseErr *awshttp.ResponseError
+	if !errors.As(err, &responseErr) || responseErr.HTTPStatusCode() != http.StatusMovedPermanently {
+		return "", false
+	}
+	if region := responseErr.Response.Header.Get("X-Amz-Bucket-Region"); region != "" {
+		return region, true
+	}
+	output, err := client.GetBucketLocation(ctx, &s3.GetBucketLocationInput{Bucket: aws.String(bucket)})
+	if err != nil {
+		return "", false
+	}
+	switch region := string(output.LocationConstraint); region {
+	case "":
+		return "us-east-1", true
+	case "EU":
+		return "eu-west-1", true
+	default:
+		return region, true
+	}
+}
+
+func cacheKey(account, region, service, resource string) string {
+	return strings.Join([]string{account, region, service, resource}, "/")
+}
+
+// cached returns the value stored under key, calling load the first time the key is requested.
+// Concurrent lookups of the same key wait for the first load instead of calling AWS again. A load
+// that ends with ctx done (the context load calls AWS with) is returned but not stored, so that the
+// next lookup loads the key again with its own context.
+func cached[T any](ctx context.Context, c *Cache, key string, load func() (T, error)) (T, error) {
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	if !ok {
+		entry = &cacheEntry{}
+		c.entries[key] = entry
+	}
+	c.mu.Unlock()
+
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+	c.count(entry.loaded)
+	if !entry.loaded {
+		value, err := load()
+		if ctx.Err() != nil || errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+			return value, err
+		}
+		entry.loaded, entry.value, entry.err = true, value, err
+	}
+	value, _ := entry.value.(T)
+	return value, entry.err
+}
+
+// count records a lookup served from the cache, or one that calls AWS
+func (c *Cache) count(hit bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if hit {
+		c.hits++
+	} else {
+		c.misses++
+	}
+}
@@ -0,0 +1,98 @@
+// inventory/cache_test.go
+package inventory
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"aws-security-hub/inventory/inventorytest"
+)
+
+func TestCachedContextErrors(t *testing.T) {
+	c := NewCache()
+	loads := 0
+	load := func(ctx context.Context) func() (string, error) {
+		return func() (string, error) {
+			loads++
+			if err := ctx.Err(); err != nil {
+				return "", err
+			}
+			return "value", nil
+		}
+	}
+
+	// A control whose timeout ran out does not leave its Timeout error to the next control
+	expired, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := cached(expired, c, "key", load(expired)); !errors.Is(err, context.Canceled) {
+		t.Fatalf("error = %v, want Canceled", err)
+	}
+	value, err := cached(context.Background(), c, "key", load(context.Background()))
+	if err != nil || value != "value" {
+		t.Fatalf("value, error = %q, %v, want value, nil", value, err)
+	}
+	if _, err := cached(context.Background(), c, "key", load(context.Background())); err != nil || loads != 2 {
+		t.Errorf("loads = %d, error = %v, want the second load cached", loads, err)
+	}
+	if hits, misses := c.Stats(); hits != 1 || misses != 2 {
+		t.Errorf("hits, misses = %d, %d, want 1, 2", hits, misses)
+	}
+}
+
+func TestCachedErrors(t *testing.T) {
+	c := NewCache()
+	loads := 0
+	denied := errors.New("AccessDenied")
+	for i := 0; i < 2; i++ {
+		_, err := cached(context.Background(), c, "key", func() (string, error) {
+			loads++
+			return "", denied
+		})
+		if !errors.Is(err, denied) {
+			t.Fatalf("error = %v, want %v", err, denied)
+		}
+	}
+	if loads != 1 {
+		t.Errorf("loads = %d, want other errors cached", loads)
+	}
+}
+
+func TestBucketExistsInAnotherRegion(t *testing.T) {
+	cfg, api := inventorytest.Config(func(r inventorytest.Request) (int, string) {
+		switch {
+		case r.Host == "origin.s3.ap-northeast-2.amazonaws.com" && r.Params.Has("location"):
+			return http.StatusOK, `<LocationConstraint xmlns="http://s3.amazonaws.com/doc/2006-03-01/">eu-west-1</LocationConstraint>`
+		case r.Host == "origin.s3.ap-northeast-2.amazonaws.com":
+			return http.StatusMovedPermanently, ""
+		case r.Host == "origin.s3.eu-west-1.amazonaws.com":
+			return http.StatusOK, ""
+		case r.Host == "deleted.s3.ap-northeast-2.amazonaws.com":
+			return http.StatusNotFound, ""
+		}
+		return inventorytest.NotFound(r)
+	})
+
+	tests := []struct {
+		bucket string
+		want   bool
+	}{
+		{bucket: "origin", want: true},
+		{bucket: "deleted", want: false},
+	}
+	for _, test := range tests {
+		t.Run(test.bucket, func(t *testing.T) {
+			exists, err := NewCache().bucketExists(context.Background(), cfg, "123456789012", test.bucket)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if exists != test.want {
+				t.Errorf("exists = %v, want %v", exists, test.want)
+			}
+		})
+	}
+	if calls := api.Count(func(r inventorytest.Request) bool { return r.Host == "origin.s3.eu-west-1.amazonaws.com" }); calls != 1 {
+		t.Errorf("HeadBucket calls in eu-west-1 = %d, want 1", calls)
+	}
+}
@@ -0,0 +1,179 @@
+// inventory/cloudfront.go
+package inventory
+
+import (
+	"context"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudfront"
+	cloudfronttypes "github.com/aws/aws-sdk-go-v2/service/cloudfront/types"
+)
+
+// CloudFront holds CloudFront distributions
+type CloudFront struct {
+	Distributions []Distribution `json:"Distributions"`
+}
+
+// Distribution is the configuration of a CloudFront distribution
+type Distribution struct {
+	ID                   string          `json:"ID"`
+	ARN                  string          `json:"ARN"`
+	DefaultRootObject    string          `json:"DefaultRootObject"`
+	ViewerProtocolPolicy string          `json:"ViewerProtocolPolicy"` // Default cache behavior
+	CacheBehaviors       []CacheBehavior `json:"CacheBehaviors"`
+	Origins              []Origin        `json:"Origins"`
+	OriginGroups         []OriginGroup   `json:"OriginGroups"`
+	Logging              Logging         `json:"Logging"`
+	// Tags is nil when the tags could not be collected
+	Tags map[string]string `json:"Tags"`
+	// Errors holds failed GetDistribution (leaving only ID and ARN set) and ListTagsForResource calls
+	Errors APIErrors `json:"Errors,omitempty"`
+}
+
+// CacheBehavior is an additional (non-default) cache behavior
+type CacheBehavior struct {
+	PathPattern          string `json:"PathPattern"`
+	ViewerProtocolPolicy string `json:"ViewerProtocolPolicy"`
+}
+
+// Origin is a distribution origin
+type Origin struct {
+	ID                    string `json:"ID"`
+	DomainName            string `json:"DomainName"`
+	OriginAccessControlID string `json:"OriginAccessControlID"`
+	// BucketExists is only set for S3 bucket origins whose bucket could be looked up
+	BucketExists *bool     `json:"BucketExists,omitempty"`
+	Errors       APIErrors `json:"Errors,omitempty"`
+}
+
+// OriginGroup is a failover group of origins
+type OriginGroup struct {
+	ID      string   `json:"ID"`
+	Members []string `json:"Members"` // Origin IDs
+}
+
+// Logging is the standard access logging configuration
+type Logging struct {
+	Enabled bool   `json:"Enabled"`
+	Bucket  string `json:"Bucket"`
+	Prefix  string `json:"Prefix"`
+}
+
+// IsS3BucketOrigin reports whether the origin is an S3 bucket (not a website endpoint)
+func (o Origin) IsS3BucketOrigin() bool {
+	return strings.Contains(o.DomainName, ".s3.") && !strings.Contains(o.DomainName, ".s3-website-")
+}
+
+// BucketName returns the bucket name of an S3 bucket origin
+func (o Origin) BucketName() string {
+	return strings.Split(o.DomainName, ".s3.")[0]
+}
+
+func collectCloudFront(ctx context.Context, cfg aws.Config, cache *Cache, account string) (*CloudFront, error) {
+	// CloudFront requires us-east-1 region
+	cloudfrontCfg := cfg.Copy()
+	cloudfrontCfg.Region = "us-east-1"
+
+	client := cloudfront.NewFromConfig(cloudfrontCfg)
+
+	var summaries []cloudfronttypes.DistributionSummary
+	paginator := cloudfront.NewListDistributionsPaginator(client, &cloudfront.ListDistributionsInput{})
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if output.DistributionList != nil {
+			summaries = append(summaries, output.DistributionList.Items...)
+		}
+	}
+
+	result := &CloudFront{}
+	for _, summary := range summaries {
+		output, err := client.GetDistribution(ctx, &cloudfront.GetDistributionInput{
+			Id: summary.Id,
+		})
+		if err != nil {
+			result.Distributions = append(result.Distributions, Distribution{
+				ID:     aws.ToString(summary.Id),
+				ARN:    aws.ToString(summary.ARN),
+				Errors: APIErrors{NewAPIError(err)},
+			})
+			continue
+		}
+
+		distribution := Distribution{
+			ID:  aws.ToString(output.Distribution.Id),
+			ARN: aws.ToString(output.Distribution.ARN),
+		}
+
+		config := output.Distribution.DistributionConfig
+		distribution.DefaultRootObject = aws.ToString(config.DefaultRootObject)
+		if config.DefaultCacheBehavior != nil {
+			distribution.ViewerProtocolPolicy = string(config.DefaultCacheBehavior.ViewerProtocolPolicy)
+		}
+		if config.CacheBehaviors != nil {
+			for _, behavior := range config.CacheBehaviors.Items {
+				distribution.CacheBehaviors = append(distribution.CacheBehaviors, CacheBehavior{
+					PathPattern:          aws.ToString(behavior.PathPattern),
+					ViewerProtocolPolicy: string(behavior.ViewerProtocolPolicy),
+				})
+			}
+		}
+		if config.Origins != nil {
+			for _, item := range config.Origins.Items {
+				origin := Origin{
+					ID:                    aws.ToString(item.Id),
+					DomainName:            aws.ToString(item.DomainName),
+					OriginAccessControlID: aws.ToString(item.OriginAccessControlId),
+				}
+				if origin.IsS3BucketOrigin() {
+					exists, err := cache.bucketExists(ctx, cfg, account, origin.BucketName())
+					if err != nil {
+						origin.Errors = append(origin.Errors, NewAPIError(err))
+					} else {
+						origin.BucketExists = aws.Bool(exists)
+					}
+				}
+				distribution.Origins = append(distribution.Origins, origin)
+			}
+		}
+		if config.OriginGroups != nil {
+			for _, item := range config.OriginGroups.Items {
+				group := OriginGroup{ID: aws.ToString(item.Id)}
+				if item.Members != nil {
+					for _, member := range item.Members.Items {
+						group.Members = append(group.Members, aws.ToString(member.OriginId))
+					}
+				}
+				distribution.OriginGroups = append(distribution.OriginGroups, group)
+			}
+		}
+		if config.Logging != nil {
+			distribution.Logging = Logging{
+				Enabled: aws.ToBool(config.Logging.Enabled),
+				Bucket:  aws.ToString(config.Logging.Bucket),
+				Prefix:  aws.ToString(config.Logging.Prefix),
+			}
+		}
+
+		tags, err := client.ListTagsForResource(ctx, &cloudfront.ListTagsForResourceInput{
+			Resource: output.Distribution.ARN,
+		})
+		if err != nil {
+			distribution.Errors = append(distribution.Errors, NewAPIError(err))
+		} else {
+			distribution.Tags = make(map[string]string)
+			if tags.Tags != nil {
+				for _, tag := range tags.Tags.Items {
+					distribution.Tags[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+				}
+			}
+		}
+
+		result.Distributions = append(result.Distributions, distribution)
+	}
+
+	return result, nil
+}
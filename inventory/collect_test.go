@@ -0,0 +1,176 @@
+// inventory/collect_test.go
+package inventory
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"aws-security-hub/inventory/inventorytest"
+
+	"github.com/aws/aws-sdk-go-v2/service/apigatewayv2"
+)
+
+// docdbAPI answers the DocumentDB calls with two pages of clusters and two pages of snapshots
+func docdbAPI(r inventorytest.Request) (int, string) {
+	marker := r.Params.Get("Marker")
+	switch r.Action() {
+	case "DescribeDBClusters":
+		if marker == "" {
+			return http.StatusOK, `<DescribeDBClustersResponse><DescribeDBClustersResult><DBClusters>
+<DBCluster><DBClusterIdentifier>first</DBClusterIdentifier><StorageEncrypted>true</StorageEncrypted></DBCluster>
+</DBClusters><Marker>clusters-2</Marker></DescribeDBClustersResult></DescribeDBClustersResponse>`
+		}
+		return http.StatusOK, `<DescribeDBClustersResponse><DescribeDBClustersResult><DBClusters>
+<DBCluster><DBClusterIdentifier>last</DBClusterIdentifier><StorageEncrypted>false</StorageEncrypted></DBCluster>
+</DBClusters></DescribeDBClustersResult></DescribeDBClustersResponse>`
+	case "DescribeDBClusterSnapshots":
+		name, next := "snapshot-1", "<Marker>snapshots-2</Marker>"
+		if marker != "" {
+			name, next = "snapshot-2", ""
+		}
+		return http.StatusOK, fmt.Sprintf(`<DescribeDBClusterSnapshotsResponse><DescribeDBClusterSnapshotsResult><DBClusterSnapshots>
+<DBClusterSnapshot><DBClusterSnapshotIdentifier>%s</DBClusterSnapshotIdentifier></DBClusterSnapshot>
+</DBClusterSnapshots>%s</DescribeDBClusterSnapshotsResult></DescribeDBClusterSnapshotsResponse>`, name, next)
+	case "DescribeDBClusterSnapshotAttributes":
+		return http.StatusOK, `<DescribeDBClusterSnapshotAttributesResponse><DescribeDBClusterSnapshotAttributesResult><DBClusterSnapshotAttributesResult>
+<DBClusterSnapshotAttributes><DBClusterSnapshotAttribute><AttributeName>restore</AttributeName>
+<AttributeValues><AttributeValue>all</AttributeValue></AttributeValues></DBClusterSnapshotAttribute></DBClusterSnapshotAttributes>
+</DBClusterSnapshotAttributesResult></DescribeDBClusterSnapshotAttributesResult></DescribeDBClusterSnapshotAttributesResponse>`
+	}
+	return inventorytest.NotFound(r)
+}
+
+func TestCollectDocumentDBPages(t *testing.T) {
+	cfg, api := inventorytest.Config(docdbAPI)
+	documentDB, err := collectDocumentDB(context.Background(), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var clusters, snapshots []string
+	for _, cluster := range documentDB.Clusters {
+		clusters = append(clusters, cluster.Identifier)
+	}
+	for _, snapshot := range documentDB.ClusterSnapshots {
+		snapshots = append(snapshots, snapshot.Identifier)
+		if !reflect.DeepEqual(snapshot.RestoreAttributeValues, []string{"all"}) {
+			t.Errorf("%s: restore attribute = %v, want [all]", snapshot.Identifier, snapshot.RestoreAttributeValues)
+		}
+	}
+	if want := []string{"first", "last"}; !reflect.DeepEqual(clusters, want) {
+		t.Errorf("clusters = %v, want %v", clusters, want)
+	}
+	if want := []string{"snapshot-1", "snapshot-2"}; !reflect.DeepEqual(snapshots, want) {
+		t.Errorf("snapshots = %v, want %v", snapshots, want)
+	}
+	if calls := api.Count(func(r inventorytest.Request) bool { return r.Action() == "DescribeDBClusters" }); calls != 2 {
+		t.Errorf("DescribeDBClusters calls = %d, want 2", calls)
+	}
+	if len(documentDB.Errors) > 0 {
+		t.Errorf("errors = %v, want none", documentDB.Errors)
+	}
+}
+
+func TestCollectDocumentDBErrorPartway(t *testing.T) {
+	cfg, _ := inventorytest.Config(func(r inventorytest.Request) (int, string) {
+		if r.Action() == "DescribeDBClusters" && r.Params.Get("Marker") != "" {
+			return http.StatusForbidden, `<ErrorResponse><Error><Type>Sender</Type><Code>AccessDenied</Code><Message>denied</Message></Error></ErrorResponse>`
+		}
+		return docdbAPI(r)
+	})
+	documentDB, err := collectDocumentDB(context.Background(), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(documentDB.Clusters) != 1 || documentDB.Clusters[0].Identifier != "first" {
+		t.Errorf("clusters = %v, want the first page", documentDB.Clusters)
+	}
+	if apiErr := documentDB.Errors.Find("DescribeDBClusters"); apiErr == nil || apiErr.Code != "AccessDenied" {
+		t.Errorf("DescribeDBClusters error = %v, want AccessDenied", apiErr)
+	}
+}
+
+// apigatewayv2API answers the API Gateway v2 calls with two pages of APIs, each with two pages of stages
+func apigatewayv2API(r inventorytest.Request) (int, string) {
+	next := r.Params.Get("nextToken")
+	switch {
+	case r.Path == "/v2/apis":
+		if next == "" {
+			return http.StatusOK, `{"items":[{"apiId":"a1","name":"first","protocolType":"HTTP"}],"nextToken":"apis-2"}`
+		}
+		return http.StatusOK, `{"items":[{"apiId":"a2","name":"last","protocolType":"WEBSOCKET"}]}`
+	case strings.HasSuffix(r.Path, "/stages"):
+		if next == "" {
+			return http.StatusOK, `{"items":[{"stageName":"dev"}],"nextToken":"stages-2"}`
+		}
+		return http.StatusOK, `{"items":[{"stageName":"prod","accessLogSettings":{"destinationArn":"arn:aws:logs:ap-northeast-2:123456789012:log-group:api"}}]}`
+	case strings.HasSuffix(r.Path, "/routes"):
+		return http.StatusOK, `{"items":[{"routeKey":"GET /","authorizationType":"NONE"}]}`
+	}
+	return inventorytest.NotFound(r)
+}
+
+func TestCollectAPIsPages(t *testing.T) {
+	cfg, _ := inventorytest.Config(apigatewayv2API)
+	apis, err := collectAPIs(context.Background(), apigatewayv2.NewFromConfig(cfg))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(apis) != 2 || apis[1].ID != "a2" {
+		t.Fatalf("apis = %+v, want a1 and a2", apis)
+	}
+	for _, api := range apis {
+		var stages []string
+		for _, stage := range api.Stages {
+			stages = append(stages, stage.StageName)
+		}
+		if want := []string{"dev", "prod"}; !reflect.DeepEqual(stages, want) {
+			t.Errorf("%s: stages = %v, want %v", api.ID, stages, want)
+		}
+		if len(api.Errors) > 0 {
+			t.Errorf("%s: errors = %v, want none", api.ID, api.Errors)
+		}
+	}
+}
+
+func TestCollectS3BucketsOfOtherRegions(t *testing.T) {
+	const block = `<PublicAccessBlockConfiguration><BlockPublicAcls>true</BlockPublicAcls><IgnorePublicAcls>true</IgnorePublicAcls>
+<BlockPublicPolicy>true</BlockPublicPolicy><RestrictPublicBuckets>true</RestrictPublicBuckets></PublicAccessBlockConfiguration>`
+	cfg, _ := inventorytest.Config(func(r inventorytest.Request) (int, string) {
+		switch {
+		case r.Host == "s3.ap-northeast-2.amazonaws.com" && r.Path == "/":
+			return http.StatusOK, `<ListAllMyBucketsResult><Buckets>
+<Bucket><Name>local</Name></Bucket><Bucket><Name>remote</Name></Bucket>
+</Buckets></ListAllMyBucketsResult>`
+		case r.Host == "local.s3.ap-northeast-2.amazonaws.com" && r.Params.Has("publicAccessBlock"):
+			return http.StatusOK, block
+		case r.Host == "remote.s3.ap-northeast-2.amazonaws.com" && r.Params.Has("location"):
+			return http.StatusOK, `<LocationConstraint>eu-west-1</LocationConstraint>`
+		case r.Host == "remote.s3.ap-northeast-2.amazonaws.com":
+			return http.StatusMovedPermanently, ""
+		case r.Host == "remote.s3.eu-west-1.amazonaws.com" && r.Params.Has("publicAccessBlock"):
+			return http.StatusOK, block
+		}
+		return inventorytest.NotFound(r)
+	})
+
+	result, err := collectS3(context.Background(), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Buckets) != 2 {
+		t.Fatalf("buckets = %+v, want local and remote", result.Buckets)
+	}
+	for _, bucket := range result.Buckets {
+		if len(bucket.Errors) > 0 {
+			t.Errorf("%s: errors = %v, want none", bucket.Name, bucket.Errors)
+		}
+		if bucket.PublicAccessBlock == nil || !bucket.PublicAccessBlock.RestrictPublicBuckets {
+			t.Errorf("%s: public access block = %+v, want every block", bucket.Name, bucket.PublicAccessBlock)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+// inventory/documentdb.go
+package inventory
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/docdb"
+)
+
+// DocumentDB holds DocumentDB clusters and manual cluster snapshots
+type DocumentDB struct {
+	Clusters         []DocDBCluster         `json:"Clusters"`
+	ClusterSnapshots []DocDBClusterSnapshot `json:"ClusterSnapshots"`
+	// Errors holds failed DescribeDBClusters and DescribeDBClusterSnapshots calls, which leave the
+	// clusters or snapshots listed so far, so that each needs only its own permission
+	Errors APIErrors `json:"Errors,omitempty"`
+}
+
+// DocDBCluster is a DocumentDB cluster
+type DocDBCluster struct {
+	Identifier                   string   `json:"Identifier"`
+	ARN                          string   `json:"ARN"`
+	StorageEncrypted             bool     `json:"StorageEncrypted"`
+	BackupRetentionPeriod        int32    `json:"BackupRetentionPeriod"` // Days
+	EnabledCloudwatchLogsExports []string `json:"EnabledCloudwatchLogsExports"`
+	DeletionProtection           bool     `json:"DeletionProtection"`
+	// Errors holds the settings of an IaC cluster that cannot be resolved from its source; live scans
+	// read every setting from DescribeDBClusters and leave it empty
+	Errors APIErrors `json:"Errors,omitempty"`
+}
+
+// DocDBClusterSnapshot is a manual DocumentDB cluster snapshot
+type DocDBClusterSnapshot struct {
+	Identifier string `json:"Identifier"`
+	ARN        string `json:"ARN"`
+	// RestoreAttributeValues are the account IDs (or "all") allowed to restore the snapshot
+	RestoreAttributeValues []string  `json:"RestoreAttributeValues"`
+	Errors                 APIErrors `json:"Errors,omitempty"`
+}
+
+func collectDocumentDB(ctx context.Context, cfg aws.Config) (*DocumentDB, error) {
+	client := docdb.NewFromConfig(cfg)
+	result := &DocumentDB{}
+	var clustersErr error
+
+	clusters := docdb.NewDescribeDBClustersPaginator(client, &docdb.DescribeDBClustersInput{})
+	for clusters.HasMorePages() {
+		output, err := clusters.NextPage(ctx)
+		if err != nil {
+			clustersErr = err
+			result.Errors = append(result.Errors, NewAPIError(err))
+			break
+		}
+
+		for _, cluster := range output.DBClusters {
+			result.Clusters = append(result.Clusters, DocDBCluster{
+				Identifier:                   aws.ToString(cluster.DBClusterIdentifier),
+				ARN:                          aws.ToString(cluster.DBClusterArn),
+				StorageEncrypted:             aws.ToBool(cluster.StorageEncrypted),
+				BackupRetentionPeriod:        aws.ToInt32(cluster.BackupRetentionPeriod),
+				EnabledCloudwatchLogsExports: cluster.EnabledCloudwatchLogsExports,
+				DeletionProtection:           aws.ToBool(cluster.DeletionProtection),
+			})
+		}
+	}
+
+	snapshots := docdb.NewDescribeDBClusterSnapshotsPaginator(client, &docdb.DescribeDBClusterSnapshotsInput{
+		SnapshotType: aws.String("manual"),
+	})
+	for snapshots.HasMorePages() {
+		output, err := snapshots.NextPage(ctx)
+		if err != nil {
+			if clustersErr != nil {
+				// Nothing could be listed: the service failed as a whole
+				return nil, clustersErr
+			}
+			result.Errors = append(result.Errors, NewAPIError(err))
+			break
+		}
+
+		for _, snapshot := range output.DBClusterSnapshots {
+			attributes, err := client.DescribeDBClusterSnapshotAttributes(ctx, &docdb.DescribeDBClusterSnapshotAttributesInput{
+				DBClusterSnapshotIdentifier: snapshot.DBClusterSnapshotIdentifier,
+			})
+			clusterSnapshot := DocDBClusterSnapshot{
+				Identifier: aws.ToString(snapshot.DBClusterSnapshotIdentifier),
+				ARN:        aws.ToString(snapshot.DBClusterSnapshotArn),
+			}
+			if err != nil {
+				clusterSnapshot.Errors = append(clusterSnapshot.Errors, NewAPIError(err))
+			} else if attributes.DBClusterSnapshotAttributesResult != nil {
+				for _, attr := range attributes.DBClusterSnapshotAttributesResult.DBClusterSnapshotAttributes {
+					if aws.ToString(attr.AttributeName) == "restore" {
+						clusterSnapshot.RestoreAttributeValues = append(clusterSnapshot.RestoreAttributeValues, attr.AttributeValues...)
+					}
+				}
+			}
+			result.ClusterSnapshots = append(result.ClusterSnapshots, clusterSnapshot)
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,68 @@
+// inventory/ec2.go
+package inventory
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+// EC2 holds EBS snapshots owned by the account
+type EC2 struct {
+	Snapshots []EBSSnapshot `json:"Snapshots"`
+}
+
+// EBSSnapshot is an EBS snapshot and its createVolumePermission attribute
+type EBSSnapshot struct {
+	ID                      string                   `json:"ID"`
+	CreateVolumePermissions []CreateVolumePermission `json:"CreateVolumePermissions"`
+	Errors                  APIErrors                `json:"Errors,omitempty"`
+}
+
+// CreateVolumePermission grants a group ("all") or an account permission to restore a snapshot
+type CreateVolumePermission struct {
+	Group  string `json:"Group,omitempty"`
+	UserID string `json:"UserID,omitempty"`
+}
+
+func collectEC2(ctx context.Context, cfg aws.Config) (*EC2, error) {
+	client := ec2.NewFromConfig(cfg)
+
+	var items []types.Snapshot
+	paginator := ec2.NewDescribeSnapshotsPaginator(client, &ec2.DescribeSnapshotsInput{
+		OwnerIds: []string{"self"},
+	})
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, output.Snapshots...)
+	}
+
+	result := &EC2{}
+	for _, item := range items {
+		attribute, err := client.DescribeSnapshotAttribute(ctx, &ec2.DescribeSnapshotAttributeInput{
+			Attribute:  types.SnapshotAttributeNameCreateVolumePermission,
+			SnapshotId: item.SnapshotId,
+		})
+		snapshot := EBSSnapshot{ID: aws.ToString(item.SnapshotId)}
+		if err != nil {
+			snapshot.Errors = append(snapshot.Errors, NewAPIError(err))
+			result.Snapshots = append(result.Snapshots, snapshot)
+			continue
+		}
+
+		for _, permission := range attribute.CreateVolumePermissions {
+			snapshot.CreateVolumePermissions = append(snapshot.CreateVolumePermissions, CreateVolumePermission{
+				Group:  string(permission.Group),
+				UserID: aws.ToString(permission.UserId),
+			})
+		}
+		result.Snapshots = append(result.Snapshots, snapshot)
+	}
+
+	return result, nil
+}
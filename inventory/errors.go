@@ -0,0 +1,74 @@
+// inventory/errors.go
+package inventory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/aws/smithy-go"
+)
+
+// APIError is a failed AWS API call, kept on the resource (or service) it was made for so that controls
+// report the resource as ERROR instead of mistaking it for a non-compliant or missing one
+type APIError struct {
+	Operation string `json:"Operation"`
+	Code      string `json:"Code"` // AWS error code, e.g. AccessDenied; Timeout or Canceled when the context ended the call, Unknown for other failures before AWS answered, Unresolved for IaC settings that cannot be resolved from their source
+	Message   string `json:"Message"`
+}
+
+func (e APIError) Error() string {
+	if e.Operation == "" {
+		return fmt.Sprintf("%s: %s", e.Code, e.Message)
+	}
+	return fmt.Sprintf("%s: %s: %s", e.Operation, e.Code, e.Message)
+}
+
+// LogValue logs the error as a group, so that log records carry the code as error.code
+func (e APIError) LogValue() slog.Value {
+	attrs := []slog.Attr{slog.String("code", e.Code), slog.String("message", e.Message)}
+	if e.Operation != "" {
+		attrs = append([]slog.Attr{slog.String("operation", e.Operation)}, attrs...)
+	}
+	return slog.GroupValue(attrs...)
+}
+
+// NewAPIError extracts the operation and the AWS error code of an SDK error
+func NewAPIError(err error) APIError {
+	result := APIError{Code: "Unknown", Message: err.Error()}
+	var operationErr *smithy.OperationError
+	if errors.As(err, &operationErr) {
+		result.Operation = operationErr.OperationName
+		result.Message = operationErr.Err.Error()
+	}
+	var apiErr smithy.APIError
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		result.Code = "Timeout"
+	case errors.Is(err, context.Canceled):
+		result.Code = "Canceled"
+	case errors.As(err, &apiErr):
+		result.Code = apiErr.ErrorCode()
+		result.Message = apiErr.ErrorMessage()
+	}
+	return result
+}
+
+// APIErrors are the failed calls made for one resource
+type APIErrors []APIError
+
+// Find returns the first error of one of the operations, or of any operation when none are given, or nil
+func (e APIErrors) Find(operations ...string) *APIError {
+	for i := range e {
+		if len(operations) == 0 {
+			return &e[i]
+		}
+		for _, operation := range operations {
+			if e[i].Operation == operation {
+				return &e[i]
+			}
+		}
+	}
+	return nil
+}
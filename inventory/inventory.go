@@ -0,0 +1,119 @@
+// inventory/inventory.go
+package inventory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+)
+
+// Version is the inventory snapshot format version written by Save and accepted by Load
+const Version = "1"
+
+// Services that can be collected into an inventory
+const (
+	ServiceAccount    = "account"
+	ServiceAPIGateway = "apigateway"
+	ServiceCloudFront = "cloudfront"
+	ServiceDocumentDB = "documentdb"
+	ServiceEC2        = "ec2"
+	ServiceS3         = "s3"
+)
+
+// Services lists every collectable service in collection order
+var Services = []string{
+	ServiceAccount,
+	ServiceAPIGateway,
+	ServiceCloudFront,
+	ServiceDocumentDB,
+	ServiceEC2,
+	ServiceS3,
+}
+
+// Inventory is a normalized snapshot of the resources inspected by the controls.
+// A nil service section means the service was not collected.
+type Inventory struct {
+	Version     string      `json:"Version"`
+	CollectedAt time.Time   `json:"CollectedAt"`
+	Region      string      `json:"Region"`
+	AccountID   string      `json:"AccountID,omitempty"` // empty when the caller identity could not be resolved
+	Account     *Account    `json:"Account,omitempty"`
+	APIGateway  *APIGateway `json:"APIGateway,omitempty"`
+	CloudFront  *CloudFront `json:"CloudFront,omitempty"`
+	DocumentDB  *DocumentDB `json:"DocumentDB,omitempty"`
+	EC2         *EC2        `json:"EC2,omitempty"`
+	S3          *S3         `json:"S3,omitempty"`
+	// Errors holds the failure of every service that could not be collected, by service
+	Errors map[string]APIError `json:"Errors,omitempty"`
+}
+
+// Collect fetches the given services (all services if none are given) into a new inventory.
+// A service that fails to collect is left nil, its failure is kept in Errors and returned alongside the
+// partial inventory. ctx bounds every API call: calls it ends fail with a Timeout or Canceled error.
+// Use a Cache to share the collected services between several calls of one run.
+func Collect(ctx context.Context, cfg aws.Config, services ...string) (*Inventory, error) {
+	return NewCache().Collect(ctx, cfg, services...)
+}
+
+// ServiceError is the failure to collect one service
+type ServiceError struct {
+	Service string
+	Err     error
+}
+
+func (e *ServiceError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Service, e.Err)
+}
+
+func (e *ServiceError) Unwrap() error {
+	return e.Err
+}
+
+// accountID resolves the account of the credentials, which labels the inventory and keys the cache
+func accountID(ctx context.Context, cfg aws.Config) string {
+	identity, err := sts.NewFromConfig(cfg).GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+	if err != nil {
+		return ""
+	}
+	return aws.ToString(identity.Account)
+}
+
+// Save writes the inventory to a JSON file
+func Save(inv *Inventory, filePath string) error {
+	bytes, err := json.MarshalIndent(inv, "", "    ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal inventory: %v", err)
+	}
+
+	if err := os.WriteFile(filePath, bytes, 0o600); err != nil {
+		return fmt.Errorf("failed to write inventory file: %v", err)
+	}
+
+	return nil
+}
+
+// Load reads an inventory from a JSON file written by Save
+func Load(filePath string) (*Inventory, error) {
+	bytes, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read inventory file: %v", err)
+	}
+
+	var inv Inventory
+	if err := json.Unmarshal(bytes, &inv); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal inventory: %v", err)
+	}
+
+	if inv.Version != Version {
+		return nil, fmt.Errorf("unsupported inventory version %q (expected %q)", inv.Version, Version)
+	}
+
+	slog.Info("loaded inventory", "collected_at", inv.CollectedAt.Format(time.RFC3339), "region", inv.Region)
+	return &inv, nil
+}
@@ -0,0 +1,111 @@
+// inventory/inventorytest/inventorytest.go
+
+// Package inventorytest fakes AWS APIs for tests of the collectors and the controls, so that they run
+// the real SDK clients and paginators without network access.
+package inventorytest
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+)
+
+// Request is a call to a fake API
+type Request struct {
+	Host   string // e.g. rds.ap-northeast-2.amazonaws.com
+	Method string
+	Path   string
+	// Params merges the query string and the form body, which carries the Action of Query APIs
+	// (EC2, RDS/DocumentDB, STS)
+	Params url.Values
+	// Body is the JSON or XML body of REST APIs
+	Body string
+}
+
+// Action returns the Action of a Query API request
+func (r Request) Action() string {
+	return r.Params.Get("Action")
+}
+
+// Handler answers a request with a status code and an XML or JSON body
+type Handler func(r Request) (int, string)
+
+// API records the requests it answers
+type API struct {
+	handler Handler
+
+	mu       sync.Mutex
+	Requests []Request
+}
+
+// Do answers a request of an SDK client with the handler
+func (a *API) Do(req *http.Request) (*http.Response, error) {
+	request := Request{Host: req.URL.Host, Method: req.Method, Path: req.URL.Path, Params: req.URL.Query()}
+	if req.Body != nil {
+		body, err := io.ReadAll(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		if strings.HasPrefix(req.Header.Get("Content-Type"), "application/x-www-form-urlencoded") {
+			form, err := url.ParseQuery(string(body))
+			if err != nil {
+				return nil, err
+			}
+			for key, values := range form {
+				request.Params[key] = append(request.Params[key], values...)
+			}
+		} else {
+			request.Body = string(body)
+		}
+	}
+	a.mu.Lock()
+	a.Requests = append(a.Requests, request)
+	a.mu.Unlock()
+
+	status, body := a.handler(request)
+	contentType := "application/json"
+	if strings.HasPrefix(body, "<") {
+		contentType = "text/xml"
+	}
+	return &http.Response{
+		StatusCode: status,
+		Header:     http.Header{"Content-Type": []string{contentType}},
+		Body:       io.NopCloser(strings.NewReader(body)),
+		Request:    req,
+	}, nil
+}
+
+// Count returns the number of requests the predicate matches
+func (a *API) Count(match func(r Request) bool) int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	count := 0
+	for _, r := range a.Requests {
+		if match(r) {
+			count++
+		}
+	}
+	return count
+}
+
+// Config returns the configuration of SDK clients answered by handler, in ap-northeast-2 without
+// retries, and the API recording their requests
+func Config(handler Handler) (aws.Config, *API) {
+	api := &API{handler: handler}
+	return aws.Config{
+		Region:      "ap-northeast-2",
+		Credentials: credentials.NewStaticCredentialsProvider("AKIDEXAMPLE", "SECRET", ""),
+		HTTPClient:  api,
+		Retryer:     func() aws.Retryer { return aws.NopRetryer{} },
+	}, api
+}
+
+// NotFound answers requests the test does not expect
+func NotFound(r Request) (int, string) {
+	return http.StatusNotFound, `{"message":"unexpected request ` + r.Method + " " + r.Host + r.Path + " " + r.Action() + `"}`
+}
@@ -0,0 +1,24 @@
+// inventory/paginate.go
+package inventory
+
+// Collectors list resources with the SDK paginators (New...Paginator) where the SDK provides one.
+// pages covers the token-paginated APIs that have none, so no listing stops at its first page.
+
+// pages calls fetch with the token of each page, starting with nil, until no next token is returned,
+// and returns the items of every page. A repeated token ends the listing instead of looping forever.
+func pages[T any](fetch func(token *string) ([]T, *string, error)) ([]T, error) {
+	var items []T
+	var token *string
+	for {
+		page, next, err := fetch(token)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, page...)
+
+		if next == nil || *next == "" || (token != nil && *next == *token) {
+			return items, nil
+		}
+		token = next
+	}
+}
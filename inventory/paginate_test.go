@@ -0,0 +1,113 @@
+// inventory/paginate_test.go
+package inventory
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// page is what a fake API returns for a token
+type page struct {
+	items []string
+	next  *string
+	err   error
+}
+
+func token(value string) *string {
+	return &value
+}
+
+func TestPages(t *testing.T) {
+	failure := errors.New("AccessDenied")
+	tests := []struct {
+		name    string
+		pages   map[string]page // by token, "" for the first request
+		want    []string
+		calls   int
+		wantErr error
+	}{
+		{
+			name:  "single page",
+			pages: map[string]page{"": {items: []string{"a", "b"}}},
+			want:  []string{"a", "b"},
+			calls: 1,
+		},
+		{
+			name: "multiple pages",
+			pages: map[string]page{
+				"":   {items: []string{"a"}, next: token("p2")},
+				"p2": {items: []string{"b"}, next: token("p3")},
+				"p3": {items: []string{"c"}},
+			},
+			want:  []string{"a", "b", "c"},
+			calls: 3,
+		},
+		{
+			name: "empty next token ends the listing",
+			pages: map[string]page{
+				"":   {items: []string{"a"}, next: token("p2")},
+				"p2": {items: []string{"b"}, next: token("")},
+			},
+			want:  []string{"a", "b"},
+			calls: 2,
+		},
+		{
+			name: "repeated token ends the listing",
+			pages: map[string]page{
+				"":   {items: []string{"a"}, next: token("p2")},
+				"p2": {items: []string{"b"}, next: token("p2")},
+			},
+			want:  []string{"a", "b"},
+			calls: 2,
+		},
+		{
+			name: "empty page in the middle",
+			pages: map[string]page{
+				"":   {items: nil, next: token("p2")},
+				"p2": {items: []string{"b"}},
+			},
+			want:  []string{"b"},
+			calls: 2,
+		},
+		{
+			name: "error partway",
+			pages: map[string]page{
+				"":   {items: []string{"a"}, next: token("p2")},
+				"p2": {err: failure},
+			},
+			calls:   2,
+			wantErr: failure,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			calls := 0
+			items, err := pages(func(token *string) ([]string, *string, error) {
+				calls++
+				key := ""
+				if token != nil {
+					key = *token
+				}
+				p, ok := test.pages[key]
+				if !ok {
+					t.Fatalf("unexpected token %q", key)
+				}
+				return p.items, p.next, p.err
+			})
+			if !errors.Is(err, test.wantErr) {
+				t.Fatalf("error = %v, want %v", err, test.wantErr)
+			}
+			if test.wantErr != nil && items != nil {
+				t.Errorf("items = %v, want none on error", items)
+			}
+			if !reflect.DeepEqual(items, test.want) {
+				t.Errorf("items = %v, want %v", items, test.want)
+			}
+			if calls != test.calls {
+				t.Errorf("calls = %d, want %d", calls, test.calls)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+// inventory/s3.go
+package inventory
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// S3 holds S3 general purpose buckets
+type S3 struct {
+	Buckets []Bucket `json:"Buckets"`
+}
+
+// Bucket is an S3 bucket and its public access block configuration
+type Bucket struct {
+	Name string `json:"Name"`
+	// PublicAccessBlock is nil when no public access block is configured
+	PublicAccessBlock *PublicAccessBlock `json:"PublicAccessBlock"`
+	Errors            APIErrors          `json:"Errors,omitempty"`
+}
+
+// PublicAccessBlock is an S3 block public access configuration
+type PublicAccessBlock struct {
+	BlockPublicAcls       bool `json:"BlockPublicAcls"`
+	IgnorePublicAcls      bool `json:"IgnorePublicAcls"`
+	BlockPublicPolicy     bool `json:"BlockPublicPolicy"`
+	RestrictPublicBuckets bool `json:"RestrictPublicBuckets"`
+}
+
+func collectS3(ctx context.Context, cfg aws.Config) (*S3, error) {
+	client := s3.NewFromConfig(cfg)
+
+	var items []types.Bucket
+	paginator := s3.NewListBucketsPaginator(client, &s3.ListBucketsInput{})
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, output.Buckets...)
+	}
+
+	// ListBuckets lists the buckets of every region; those of another region are asked in their region
+	regional := map[string]*s3.Client{cfg.Region: client}
+	result := &S3{}
+	for _, item := range items {
+		bucket := Bucket{Name: aws.ToString(item.Name)}
+
+		input := &s3.GetPublicAccessBlockInput{Bucket: item.Name}
+		publicAccessBlock, err := client.GetPublicAccessBlock(ctx, input)
+		if region, moved := bucketRegion(ctx, client, bucket.Name, err); moved {
+			if regional[region] == nil {
+				regional[region] = s3.NewFromConfig(cfg, func(o *s3.Options) { o.Region = region })
+			}
+			publicAccessBlock, err = regional[region].GetPublicAccessBlock(ctx, input)
+		}
+		if err != nil {
+			// A bucket without a configuration answers NoSuchPublicAccessBlockConfiguration
+			if apiErr := NewAPIError(err); apiErr.Code != "NoSuchPublicAccessBlockConfiguration" {
+				bucket.Errors = append(bucket.Errors, apiErr)
+			}
+		} else if config := publicAccessBlock.PublicAccessBlockConfiguration; config != nil {
+			bucket.PublicAccessBlock = &PublicAccessBlock{
+				BlockPublicAcls:       aws.ToBool(config.BlockPublicAcls),
+				IgnorePublicAcls:      aws.ToBool(config.IgnorePublicAcls),
+				BlockPublicPolicy:     aws.ToBool(config.BlockPublicPolicy),
+				RestrictPublicBuckets: aws.ToBool(config.RestrictPublicBuckets),
+			}
+		}
+
+		result.Buckets = append(result.Buckets, bucket)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,89 @@
+// logging/logging.go
+package logging
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"aws-security-hub/inventory"
+)
+
+// Levels and Formats list the values of --log-level and --log-format
+var (
+	Levels  = []string{"debug", "info", "warn", "error"}
+	Formats = []string{"text", "json"}
+)
+
+// ParseLevel returns the slog level of a --log-level value
+func ParseLevel(level string) (slog.Level, error) {
+	switch strings.ToLower(level) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "info", "":
+		return slog.LevelInfo, nil
+	case "warn":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	}
+	return slog.LevelInfo, fmt.Errorf("unsupported log level %q (supported: %s)", level, strings.Join(Levels, ", "))
+}
+
+// Validate checks a level and a format without installing them
+func Validate(level, format string) error {
+	if _, err := ParseLevel(level); err != nil {
+		return err
+	}
+	if _, err := handler(io.Discard, slog.LevelInfo, format); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Setup makes the default logger write records of level and above to w, as logfmt text or as one
+// JSON object per line. Output of the standard log package goes through it too.
+func Setup(w io.Writer, level, format string) error {
+	parsed, err := ParseLevel(level)
+	if err != nil {
+		return err
+	}
+	h, err := handler(w, parsed, format)
+	if err != nil {
+		return err
+	}
+	slog.SetDefault(slog.New(h))
+	return nil
+}
+
+func handler(w io.Writer, level slog.Level, format string) (slog.Handler, error) {
+	options := &slog.HandlerOptions{Level: level}
+	switch strings.ToLower(format) {
+	case "text", "":
+		return slog.NewTextHandler(w, options), nil
+	case "json":
+		return slog.NewJSONHandler(w, options), nil
+	}
+	return nil, fmt.Errorf("unsupported log format %q (supported: %s)", format, strings.Join(Formats, ", "))
+}
+
+// Control returns the logger of a control evaluated against an inventory, carrying the control ID and
+// the account and region of the inventory when known (templates and plans have neither)
+func Control(id string, inv *inventory.Inventory) *slog.Logger {
+	args := []any{"control", id}
+	if inv != nil && inv.AccountID != "" {
+		args = append(args, "account", inv.AccountID)
+	}
+	if inv != nil && inv.Region != "" {
+		args = append(args, "region", inv.Region)
+	}
+	return slog.With(args...)
+}
+
+// Fatal logs an error and exits with status 1
+func Fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
@@ -0,0 +1,122 @@
+// logging/logging_test.go
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"aws-security-hub/inventory"
+)
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		level   string
+		want    slog.Level
+		wantErr bool
+	}{
+		{"debug", slog.LevelDebug, false},
+		{"", slog.LevelInfo, false},
+		{"INFO", slog.LevelInfo, false},
+		{"warn", slog.LevelWarn, false},
+		{"error", slog.LevelError, false},
+		{"trace", slog.LevelInfo, true},
+	}
+	for _, test := range tests {
+		t.Run(test.level, func(t *testing.T) {
+			got, err := ParseLevel(test.level)
+			if got != test.want || (err != nil) != test.wantErr {
+				t.Errorf("ParseLevel() = %v, %v; want %v, error %v", got, err, test.want, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name          string
+		level, format string
+		wantErr       bool
+	}{
+		{"defaults", "", "", false},
+		{"json", "debug", "JSON", false},
+		{"unsupported level", "verbose", "text", true},
+		{"unsupported format", "info", "xml", true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := Validate(test.level, test.format); (err != nil) != test.wantErr {
+				t.Errorf("Validate() = %v, want error %v", err, test.wantErr)
+			}
+		})
+	}
+}
+
+// setup installs a default logger writing to a buffer, and restores the previous one after the test
+func setup(t *testing.T, level, format string) *bytes.Buffer {
+	t.Helper()
+	previous := slog.Default()
+	t.Cleanup(func() { slog.SetDefault(previous) })
+
+	var out bytes.Buffer
+	if err := Setup(&out, level, format); err != nil {
+		t.Fatal(err)
+	}
+	return &out
+}
+
+func TestSetupLevel(t *testing.T) {
+	out := setup(t, "warn", "text")
+	slog.Info("skipped")
+	slog.Warn("kept", "count", 2)
+
+	if got := out.String(); strings.Contains(got, "skipped") || !strings.Contains(got, `level=WARN msg=kept count=2`) {
+		t.Errorf("output = %q", got)
+	}
+}
+
+func TestSetupJSON(t *testing.T) {
+	out := setup(t, "debug", "json")
+	slog.Debug("checking cluster", "resource", "orders")
+
+	var record map[string]any
+	if err := json.Unmarshal(out.Bytes(), &record); err != nil {
+		t.Fatalf("output is not one JSON object: %q", out.String())
+	}
+	if record["level"] != "DEBUG" || record["msg"] != "checking cluster" || record["resource"] != "orders" {
+		t.Errorf("record = %v", record)
+	}
+}
+
+func TestSetupRejectsUnsupportedValues(t *testing.T) {
+	previous := slog.Default()
+	if err := Setup(&bytes.Buffer{}, "info", "xml"); err == nil {
+		t.Error("Setup() accepted an unsupported format")
+	}
+	if slog.Default() != previous {
+		t.Error("Setup() replaced the default logger despite the error")
+	}
+}
+
+func TestControl(t *testing.T) {
+	tests := []struct {
+		name string
+		inv  *inventory.Inventory
+		want string
+	}{
+		{"scanned inventory", &inventory.Inventory{AccountID: "123456789012", Region: "ap-northeast-2"}, `msg="control evaluated" control=S3.1 account=123456789012 region=ap-northeast-2`},
+		{"template", &inventory.Inventory{}, `msg="control evaluated" control=S3.1`},
+		{"no inventory", nil, `msg="control evaluated" control=S3.1`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			out := setup(t, "info", "text")
+			Control("S3.1", test.inv).Info("control evaluated")
+			if got := out.String(); !strings.HasSuffix(got, test.want+"\n") {
+				t.Errorf("output = %q, want it to end with %q", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,235 @@
+// metrics/metrics.go
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"aws-security-hub/report"
+	"aws-security-hub/types"
+)
+
+const namespace = "aws_security_hub"
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// Metrics holds the posture and scan health of the most recent runs in the Prometheus text format.
+// A nil *Metrics records nothing, so callers can pass one around without checking.
+type Metrics struct {
+	mu           sync.Mutex
+	failing      map[string]sample // control, severity, account, region
+	status       map[string]sample // control, account, region
+	duration     map[string]sample // control
+	lastSuccess  float64
+	apiErrors    map[string]sample // service, code
+	scansTotal   float64
+	scanFailures float64
+}
+
+type sample struct {
+	labels []string
+	value  float64
+}
+
+// New creates an empty set of metrics
+func New() *Metrics {
+	return &Metrics{
+		failing:   make(map[string]sample),
+		status:    make(map[string]sample),
+		duration:  make(map[string]sample),
+		apiErrors: make(map[string]sample),
+	}
+}
+
+// ObserveFailure counts a scan that produced no report
+func (m *Metrics) ObserveFailure() {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.scansTotal++
+	m.scanFailures++
+}
+
+// ObserveReport replaces the posture of the controls in the report, counts the failed API calls its
+// ERROR findings carry and marks the scan as successful. Results of the same control, account and
+// region (e.g. several templates) are added up.
+func (m *Metrics) ObserveReport(result *report.Report) {
+	if m == nil || result == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Drop the previous series of the reported controls, their severity may have changed
+	for _, r := range result.Results {
+		for key, s := range m.failing {
+			if s.labels[0] == r.ID && s.labels[2] == r.Account && s.labels[3] == r.Region {
+				delete(m.failing, key)
+			}
+		}
+		delete(m.status, key(r.ID, r.Account, r.Region))
+		delete(m.duration, key(r.ID))
+	}
+
+	for _, r := range result.Results {
+		failing := float64(len(r.Findings.Failed()))
+		if failing == 0 && r.Status == "FAIL" {
+			failing = 1
+		}
+		m.add(m.failing, failing, r.ID, r.Severity, r.Account, r.Region)
+		m.add(m.duration, r.Duration, r.ID)
+
+		status := key(r.ID, r.Account, r.Region)
+		if previous, ok := m.status[status]; !ok || statusRank[statusValue(r.Status)] > statusRank[previous.value] {
+			m.status[status] = sample{labels: []string{r.ID, r.Account, r.Region}, value: statusValue(r.Status)}
+		}
+	}
+
+	m.observeAPIErrors(result)
+	m.scansTotal++
+	m.lastSuccess = float64(result.GeneratedAt.Unix())
+}
+
+// observeAPIErrors counts the failed API calls of a report by service and AWS error code, from the
+// services that failed to collect down to single resources. A resource reported by several controls
+// counts once.
+func (m *Metrics) observeAPIErrors(result *report.Report) {
+	seen := make(map[string]bool)
+	for _, r := range result.Results {
+		prefix, _, _ := strings.Cut(r.ID, ".")
+		service := strings.ToLower(prefix)
+		for _, finding := range r.Findings.Errored() {
+			if finding.ErrorCode == "" || finding.ErrorCode == types.ConditionErrorCode {
+				continue
+			}
+			call := key(r.Account, r.Region, service, finding.Resource, finding.ErrorCode)
+			if seen[call] {
+				continue
+			}
+			seen[call] = true
+			m.add(m.apiErrors, 1, service, finding.ErrorCode)
+		}
+	}
+}
+
+func (m *Metrics) add(series map[string]sample, value float64, labels ...string) {
+	k := key(labels...)
+	series[k] = sample{labels: labels, value: series[k].value + value}
+}
+
+func key(labels ...string) string {
+	return strings.Join(labels, "\xff")
+}
+
+// statusRank orders the status values when results are combined: a FAIL wins over an ERROR,
+// an ERROR over a PASS and a PASS over NA
+var statusRank = map[float64]int{-1: 0, 1: 1, -2: 2, 0: 3}
+
+// statusValue maps a control status to 1 (PASS), 0 (FAIL), -2 (ERROR) or -1 (NA)
+func statusValue(status string) float64 {
+	switch status {
+	case "PASS":
+		return 1
+	case "FAIL":
+		return 0
+	case "ERROR":
+		return -2
+	}
+	return -1
+}
+
+// Write renders the metrics in the Prometheus text exposition format
+func (m *Metrics) Write(w io.Writer) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var b strings.Builder
+	family(&b, "failing_resources", "gauge", "Resources failing a control in the latest scan", []string{"control", "severity", "account", "region"}, m.failing)
+	family(&b, "control_status", "gauge", "Status of a control in the latest scan: 1 PASS, 0 FAIL, -1 NA, -2 ERROR", []string{"control", "account", "region"}, m.status)
+	family(&b, "control_duration_seconds", "gauge", "Time spent evaluating a control in the latest scan", []string{"control"}, m.duration)
+
+	family(&b, "api_errors_total", "counter", "Failed AWS API calls reported by the scans, by service and AWS error code", []string{"service", "code"}, m.apiErrors)
+
+	if m.lastSuccess > 0 {
+		family(&b, "last_successful_scan_timestamp_seconds", "gauge", "Unix time of the latest scan that produced a report", nil,
+			map[string]sample{"": {value: m.lastSuccess}})
+	}
+	family(&b, "scans_total", "counter", "Scans run since startup", nil, map[string]sample{"": {value: m.scansTotal}})
+	family(&b, "scan_failures_total", "counter", "Scans that produced no report since startup", nil, map[string]sample{"": {value: m.scanFailures}})
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// family writes the HELP and TYPE lines of a metric followed by its samples, sorted by labels
+func family(b *strings.Builder, name, kind, help string, labelNames []string, series map[string]sample) {
+	if len(series) == 0 {
+		return
+	}
+	name = namespace + "_" + name
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+
+	keys := make([]string, 0, len(series))
+	for key := range series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		s := series[key]
+		var pairs []string
+		for i, label := range labelNames {
+			pairs = append(pairs, fmt.Sprintf(`%s="%s"`, label, labelEscaper.Replace(s.labels[i])))
+		}
+		value := strconv.FormatFloat(s.value, 'f', -1, 64)
+		if len(pairs) > 0 {
+			fmt.Fprintf(b, "%s{%s} %s\n", name, strings.Join(pairs, ","), value)
+		} else {
+			fmt.Fprintf(b, "%s %s\n", name, value)
+		}
+	}
+}
+
+// Handler serves the metrics for Prometheus to scrape
+func (m *Metrics) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		if err := m.Write(w); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
+
+// WriteTextfile writes the metrics for the node_exporter textfile collector. The file is written
+// next to its destination and renamed, so the collector never reads a partial file.
+func (m *Metrics) WriteTextfile(filePath string) error {
+	temp, err := os.CreateTemp(filepath.Dir(filePath), filepath.Base(filePath)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create metrics file: %v", err)
+	}
+	defer os.Remove(temp.Name())
+
+	if err := m.Write(temp); err != nil {
+		temp.Close()
+		return fmt.Errorf("failed to write metrics: %v", err)
+	}
+	if err := temp.Close(); err != nil {
+		return fmt.Errorf("failed to write metrics: %v", err)
+	}
+	if err := os.Chmod(temp.Name(), 0o644); err != nil {
+		return fmt.Errorf("failed to write metrics: %v", err)
+	}
+	if err := os.Rename(temp.Name(), filePath); err != nil {
+		return fmt.Errorf("failed to write metrics: %v", err)
+	}
+	return nil
+}
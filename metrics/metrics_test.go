@@ -0,0 +1,50 @@
+// metrics/metrics_test.go
+package metrics
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"aws-security-hub/report"
+	"aws-security-hub/types"
+)
+
+func TestObserveReportCountsAPIErrors(t *testing.T) {
+	result := &report.Report{GeneratedAt: time.Unix(1700000000, 0), Results: []report.Result{
+		{ID: "DocumentDB.1", Status: "ERROR", Account: "123456789012", Region: "ap-northeast-2", Findings: types.Findings{
+			{Resource: "orders", Status: "ERROR", ErrorCode: "AccessDenied"},
+			{Resource: "users", Status: "PASS"},
+		}},
+		// the same resource reported by another control counts once
+		{ID: "DocumentDB.5", Status: "ERROR", Account: "123456789012", Region: "ap-northeast-2", Findings: types.Findings{
+			{Resource: "orders", Status: "ERROR", ErrorCode: "AccessDenied"},
+		}},
+		{ID: "CloudFront.1", Status: "ERROR", Account: "123456789012", Region: "ap-northeast-2", Findings: types.Findings{
+			{Resource: "cloudfront", Status: "ERROR", ErrorCode: "Throttling"},
+		}},
+		{ID: "Custom.1", Status: "ERROR", Findings: types.Findings{
+			{Resource: "orders", Status: "ERROR", ErrorCode: types.ConditionErrorCode},
+		}},
+	}}
+
+	m := New()
+	m.ObserveReport(result)
+	m.ObserveReport(result)
+	var b strings.Builder
+	if err := m.Write(&b); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{
+		`aws_security_hub_api_errors_total{service="cloudfront",code="Throttling"} 2`,
+		`aws_security_hub_api_errors_total{service="documentdb",code="AccessDenied"} 2`,
+	} {
+		if !strings.Contains(b.String(), want+"\n") {
+			t.Errorf("metrics lack %s:\n%s", want, b.String())
+		}
+	}
+	if strings.Contains(b.String(), types.ConditionErrorCode) {
+		t.Errorf("metrics count condition errors as API errors:\n%s", b.String())
+	}
+}
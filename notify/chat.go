@@ -0,0 +1,64 @@
+// notify/chat.go
+package notify
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"text/template"
+)
+
+// Slack posts events to a Slack incoming webhook
+type Slack struct {
+	URL      string
+	Template *template.Template // nil uses DefaultTemplate
+	Client   *http.Client
+}
+
+// Notify posts the rendered message
+func (s Slack) Notify(event Event) error {
+	message, err := render(s.Template, event)
+	if err != nil {
+		return err
+	}
+	body, err := json.Marshal(map[string]string{"text": message})
+	if err != nil {
+		return fmt.Errorf("failed to marshal Slack message: %v", err)
+	}
+	return post(s.Client, s.URL, "application/json", body)
+}
+
+// Teams posts events to a Microsoft Teams incoming webhook or Workflows URL as an Adaptive Card
+type Teams struct {
+	URL      string
+	Template *template.Template // nil uses DefaultTemplate
+	Client   *http.Client
+}
+
+// Notify posts the rendered message
+func (t Teams) Notify(event Event) error {
+	message, err := render(t.Template, event)
+	if err != nil {
+		return err
+	}
+
+	card := map[string]interface{}{
+		"type":    "AdaptiveCard",
+		"$schema": "http://adaptivecards.io/schemas/adaptive-card.json",
+		"version": "1.4",
+		"body": []map[string]interface{}{
+			{"type": "TextBlock", "text": "AWS Security Hub: " + event.Group, "weight": "Bolder", "size": "Medium"},
+			{"type": "TextBlock", "text": message, "wrap": true, "fontType": "Monospace"},
+		},
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"type": "message",
+		"attachments": []map[string]interface{}{
+			{"contentType": "application/vnd.microsoft.card.adaptive", "content": card},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal Teams message: %v", err)
+	}
+	return post(t.Client, t.URL, "application/json", body)
+}
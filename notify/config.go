@@ -0,0 +1,160 @@
+// notify/config.go
+package notify
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+
+	"aws-security-hub/history"
+)
+
+// Notifier types
+const (
+	TypeWebhook = "webhook"
+	TypeSlack   = "slack"
+	TypeTeams   = "teams"
+)
+
+// Notification triggers
+const (
+	OnFailures    = "failures"    // every run with failing controls, and runs where a control changed status
+	OnTransitions = "transitions" // only runs where a control went from PASS to FAIL or back; the default of the daemon
+)
+
+// Severities from least to most severe, as written in the compliance JSON
+var Severities = []string{"Informational", "Low", "Medium", "High", "Critical"}
+
+// Config is a notifier entry of the schedule file
+type Config struct {
+	Type        string   `yaml:"type"` // webhook, slack or teams
+	URL         string   `yaml:"url"`
+	On          string   `yaml:"on"`           // failures or transitions; empty uses the default of the command
+	MinSeverity string   `yaml:"min_severity"` // controls below this severity are left out; empty keeps all
+	Groups      []string `yaml:"groups"`       // control groups to notify about; empty notifies about all
+	Template    string   `yaml:"template"`     // Go text/template of the message; empty uses DefaultTemplate
+}
+
+// New validates a notifier entry and creates its notifier, which fires on defaultOn unless the entry
+// sets a trigger: OnTransitions for the daemon, which compares runs, and OnFailures for one-shot scans
+func New(config Config, defaultOn string) (Notifier, error) {
+	if config.URL == "" {
+		return nil, fmt.Errorf("%s notifier is missing url", config.Type)
+	}
+
+	on := config.On
+	if on == "" {
+		on = defaultOn
+	}
+	if on != OnFailures && on != OnTransitions {
+		return nil, fmt.Errorf("unsupported trigger %q (supported: %s, %s)", config.On, OnFailures, OnTransitions)
+	}
+
+	minRank := 0
+	if config.MinSeverity != "" {
+		minRank = severityRank(config.MinSeverity)
+		if minRank < 0 {
+			return nil, fmt.Errorf("unknown severity %q (supported: %s)", config.MinSeverity, strings.Join(Severities, ", "))
+		}
+	}
+
+	var tmpl *template.Template
+	if config.Template != "" {
+		var err error
+		if tmpl, err = ParseTemplate(config.Template); err != nil {
+			return nil, fmt.Errorf("invalid template: %v", err)
+		}
+	}
+
+	var notifier Notifier
+	switch config.Type {
+	case TypeWebhook:
+		notifier = Webhook{URL: config.URL, Template: tmpl}
+	case TypeSlack:
+		notifier = Slack{URL: config.URL, Template: tmpl}
+	case TypeTeams:
+		notifier = Teams{URL: config.URL, Template: tmpl}
+	default:
+		return nil, fmt.Errorf("unsupported notifier type %q (supported: %s, %s, %s)", config.Type, TypeWebhook, TypeSlack, TypeTeams)
+	}
+
+	return Filter{Notifier: notifier, On: on, MinSeverity: minRank, Groups: config.Groups}, nil
+}
+
+// Filter passes on the part of an event a notifier is interested in, and nothing when that part is empty
+type Filter struct {
+	Notifier    Notifier
+	On          string   // OnFailures or OnTransitions
+	MinSeverity int      // index in Severities
+	Groups      []string // empty passes every group
+}
+
+// Notify filters the event by group, severity and trigger before passing it on
+func (f Filter) Notify(event Event) error {
+	if len(f.Groups) > 0 && !contains(f.Groups, event.Group) {
+		return nil
+	}
+
+	filtered := Event{Group: event.Group, Time: event.Time}
+	for _, transition := range event.Transitions {
+		if f.passes(transition.Severity) {
+			filtered.Transitions = append(filtered.Transitions, transition)
+		}
+	}
+	if f.On == OnFailures {
+		for _, failure := range event.Failures {
+			if f.passes(failure.Severity) {
+				filtered.Failures = append(filtered.Failures, failure)
+			}
+		}
+	} else {
+		filtered.Failures = failuresOf(filtered.Transitions, event.Failures)
+	}
+
+	if len(filtered.Failures) == 0 && len(filtered.Transitions) == 0 {
+		return nil
+	}
+	return f.Notifier.Notify(filtered)
+}
+
+// passes reports whether a severity reaches the threshold; unknown severities only pass without one
+func (f Filter) passes(severity string) bool {
+	return f.MinSeverity == 0 || severityRank(severity) >= f.MinSeverity
+}
+
+// failuresOf returns the failures of the controls that went to FAIL
+func failuresOf(transitions []history.Transition, failures []Failure) []Failure {
+	var result []Failure
+	for _, failure := range failures {
+		for _, transition := range transitions {
+			if transition.ID == failure.ID && transition.To == "FAIL" {
+				result = append(result, failure)
+				break
+			}
+		}
+	}
+	return result
+}
+
+// severityRank returns the index of a severity in Severities, ignoring case, or -1.
+// Controls without a severity (e.g. user-authored rules without attributes) rank as Informational.
+func severityRank(severity string) int {
+	if severity == "" {
+		return 0
+	}
+	for i, s := range Severities {
+		if strings.EqualFold(s, severity) {
+			return i
+		}
+	}
+	return -1
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,55 @@
+// notify/config_test.go
+package notify
+
+import (
+	"testing"
+
+	"aws-security-hub/history"
+)
+
+// recorder keeps the events it is notified of
+type recorder struct{ events *[]Event }
+
+func (r recorder) Notify(event Event) error {
+	*r.events = append(*r.events, event)
+	return nil
+}
+
+func TestNewDefaultTrigger(t *testing.T) {
+	failing := Event{Group: "hourly", Failures: []Failure{{ID: "DocumentDB.1", Severity: "Medium"}}}
+	regressed := Event{
+		Group:       "hourly",
+		Failures:    []Failure{{ID: "DocumentDB.1", Severity: "Medium"}},
+		Transitions: []history.Transition{{ID: "DocumentDB.1", Severity: "Medium", From: "PASS", To: "FAIL"}},
+	}
+	tests := []struct {
+		name      string
+		on        string
+		defaultOn string
+		event     Event
+		want      int
+	}{
+		{name: "daemon skips runs that still fail", defaultOn: OnTransitions, event: failing, want: 0},
+		{name: "daemon notifies transitions", defaultOn: OnTransitions, event: regressed, want: 1},
+		{name: "daemon opts in to failures", on: OnFailures, defaultOn: OnTransitions, event: failing, want: 1},
+		{name: "one-shot scans notify failures", defaultOn: OnFailures, event: failing, want: 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			notifier, err := New(Config{Type: TypeWebhook, URL: "https://hooks.example.com", On: test.on}, test.defaultOn)
+			if err != nil {
+				t.Fatal(err)
+			}
+			filter := notifier.(Filter)
+			var events []Event
+			filter.Notifier = recorder{&events}
+			if err := filter.Notify(test.event); err != nil {
+				t.Fatal(err)
+			}
+			if len(events) != test.want {
+				t.Errorf("notifications = %d, want %d", len(events), test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+// notify/notify.go
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"text/template"
+	"time"
+
+	"aws-security-hub/history"
+	"aws-security-hub/report"
+	"aws-security-hub/types"
+)
+
+// Event reports the failing controls and the status transitions of a scheduled run
+type Event struct {
+	Group       string               `json:"Group"`
+	Time        time.Time            `json:"Time"`
+	Failures    []Failure            `json:"Failures"`
+	Transitions []history.Transition `json:"Transitions"`
+}
+
+// Failure is a failing control with its failing resources
+type Failure struct {
+	ID          string         `json:"ID"`
+	Severity    string         `json:"Severity"`
+	Description string         `json:"Description"`
+	Resources   types.Findings `json:"Resources"`
+}
+
+// Failures returns the failing controls of a report
+func Failures(result *report.Report) []Failure {
+	var failures []Failure
+	for _, r := range result.Results {
+		if r.Status != "FAIL" {
+			continue
+		}
+		failures = append(failures, Failure{
+			ID:          r.ID,
+			Severity:    r.Severity,
+			Description: r.Description,
+			Resources:   r.Findings.Failed(),
+		})
+	}
+	return failures
+}
+
+// Notifier delivers events
+type Notifier interface {
+	Notify(event Event) error
+}
+
+// Log writes events to the log
+type Log struct{}
+
+// Notify logs each transition of the event
+func (Log) Notify(event Event) error {
+	for _, transition := range event.Transitions {
+		slog.Info("status changed", "group", event.Group, "control", transition.ID, "from", transition.From, "to", transition.To)
+	}
+	return nil
+}
+
+// Webhook posts events as JSON to a URL, with the rendered message alongside
+type Webhook struct {
+	URL      string
+	Template *template.Template // nil uses DefaultTemplate
+	Client   *http.Client
+}
+
+// Notify posts the event
+func (w Webhook) Notify(event Event) error {
+	message, err := render(w.Template, event)
+	if err != nil {
+		return err
+	}
+	body, err := json.Marshal(struct {
+		Event
+		Message string `json:"Message"`
+	}{event, message})
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %v", err)
+	}
+	return post(w.Client, w.URL, "application/json", body)
+}
+
+func post(client *http.Client, url, contentType string, body []byte) error {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	response, err := client.Post(url, contentType, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode >= 300 {
+		return fmt.Errorf("%s responded with %s", url, response.Status)
+	}
+	return nil
+}
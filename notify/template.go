@@ -0,0 +1,38 @@
+// notify/template.go
+package notify
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// DefaultTemplate renders the message of chat notifications and the Message field of webhooks.
+// Templates see the Event: .Group, .Time, .Failures (ID, Severity, Description, Resources) and
+// .Transitions (ID, Severity, From, To).
+const DefaultTemplate = `[{{.Group}}] {{len .Failures}} failing control(s) at {{.Time.Format "2006-01-02 15:04 MST"}}
+{{range .Failures}}
+{{.ID}} ({{.Severity}}): {{.Description}}
+{{range .Resources}}  - {{.Resource}}{{if .Reason}}: {{.Reason}}{{end}}
+{{end}}{{end}}{{if .Transitions}}
+Changes since the previous run:
+{{range .Transitions}}  - {{.ID}}: {{.From}} -> {{.To}}
+{{end}}{{end}}`
+
+var defaultTemplate = template.Must(ParseTemplate(DefaultTemplate))
+
+// ParseTemplate parses a message template
+func ParseTemplate(text string) (*template.Template, error) {
+	return template.New("message").Parse(text)
+}
+
+func render(tmpl *template.Template, event Event) (string, error) {
+	if tmpl == nil {
+		tmpl = defaultTemplate
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, event); err != nil {
+		return "", fmt.Errorf("failed to render message: %v", err)
+	}
+	return strings.TrimSpace(b.String()), nil
+}
@@ -0,0 +1,311 @@
+// pack/client.go
+package pack
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"aws-security-hub/inventory"
+	"aws-security-hub/logging"
+	"aws-security-hub/types"
+)
+
+const (
+	describeTimeout = 30 * time.Second // for a pack to start and list its controls
+	closeTimeout    = 5 * time.Second  // for a pack to exit once its stdin is closed
+)
+
+// Pack is a running control pack
+type Pack struct {
+	Path    string
+	Name    string
+	Version string
+
+	cmd      *exec.Cmd
+	stdin    io.WriteCloser
+	writeMu  sync.Mutex
+	encoder  *json.Encoder
+	controls []ControlInfo
+
+	mu      sync.Mutex
+	nextID  int64
+	pending map[int64]chan response // calls waiting for their response, by request ID
+	err     error                   // why the pack stopped answering
+	stopped chan struct{}           // closed once the pack stopped answering
+}
+
+// Load starts a control pack and asks for its controls. The pack keeps running until Close.
+func Load(path string) (*Pack, error) {
+	cmd := exec.Command(path)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("control pack %s: %v", path, err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("control pack %s: %v", path, err)
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return nil, fmt.Errorf("control pack %s: %v", path, err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start control pack %s: %v", path, err)
+	}
+
+	p := &Pack{
+		Path:    path,
+		Name:    filepath.Base(path),
+		cmd:     cmd,
+		stdin:   stdin,
+		encoder: json.NewEncoder(stdin),
+		pending: make(map[int64]chan response),
+		stopped: make(chan struct{}),
+	}
+	go p.read(stdout)
+	go p.log(stderr)
+
+	ctx, cancel := context.WithTimeout(context.Background(), describeTimeout)
+	defer cancel()
+	var described DescribeResult
+	if err := p.call(ctx, MethodDescribe, DescribeParams{Protocol: ProtocolVersion}, &described); err != nil {
+		p.kill()
+		return nil, fmt.Errorf("control pack %s: %s: %v", path, MethodDescribe, err)
+	}
+	if err := p.describe(described); err != nil {
+		p.kill()
+		return nil, fmt.Errorf("control pack %s: %v", path, err)
+	}
+	return p, nil
+}
+
+// describe checks the answer to describe and keeps the controls it lists
+func (p *Pack) describe(described DescribeResult) error {
+	if described.Protocol != ProtocolVersion {
+		return fmt.Errorf("unsupported protocol version %d (supported: %d)", described.Protocol, ProtocolVersion)
+	}
+	seen := make(map[string]bool)
+	for i, info := range described.Controls {
+		id := info.Requirement.Id
+		switch {
+		case id == "":
+			return fmt.Errorf("control %d has no Id", i+1)
+		case seen[id]:
+			return fmt.Errorf("control %s is listed twice", id)
+		case info.Service != "" && !slices.Contains(inventory.Services, info.Service):
+			return fmt.Errorf("control %s: unknown service %q", id, info.Service)
+		}
+		seen[id] = true
+	}
+	if described.Name != "" {
+		p.Name = described.Name
+	}
+	p.Version = described.Version
+	p.controls = described.Controls
+	return nil
+}
+
+// Controls returns the controls of the pack, evaluated by the pack process
+func (p *Pack) Controls() []types.Control {
+	var controls []types.Control
+	for _, info := range p.controls {
+		controls = append(controls, control{pack: p, info: info})
+	}
+	return controls
+}
+
+// Close closes the stdin of the pack and waits for it to exit, killing it if it does not
+func (p *Pack) Close() error {
+	p.stdin.Close()
+	exited := make(chan error, 1)
+	go func() { exited <- p.cmd.Wait() }()
+	select {
+	case err := <-exited:
+		return err
+	case <-time.After(closeTimeout):
+		p.cmd.Process.Kill()
+		<-exited
+		return fmt.Errorf("control pack %s did not exit within %s and was killed", p.Name, closeTimeout)
+	}
+}
+
+func (p *Pack) kill() {
+	p.cmd.Process.Kill()
+	p.cmd.Wait()
+}
+
+// call sends a request and waits for its response, decoding its result into result
+func (p *Pack) call(ctx context.Context, method string, params, result any) error {
+	raw, err := json.Marshal(params)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s parameters: %v", method, err)
+	}
+
+	responses := make(chan response, 1)
+	p.mu.Lock()
+	p.nextID++
+	id := p.nextID
+	p.pending[id] = responses
+	p.mu.Unlock()
+	defer func() {
+		p.mu.Lock()
+		delete(p.pending, id)
+		p.mu.Unlock()
+	}()
+
+	// A pack that stops reading its stdin blocks the write, and every write queued behind it: the
+	// call gives up with its context, and a request whose call gave up is never sent
+	sent := make(chan error, 1)
+	go func() {
+		p.writeMu.Lock()
+		defer p.writeMu.Unlock()
+		if err := ctx.Err(); err != nil {
+			sent <- err
+			return
+		}
+		sent <- p.encoder.Encode(request{JSONRPC: "2.0", ID: id, Method: method, Params: raw})
+	}()
+	select {
+	case err := <-sent:
+		if err != nil {
+			return fmt.Errorf("failed to send %s: %v", method, err)
+		}
+	case <-p.stopped:
+		return p.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	var resp response
+	select {
+	case resp = <-responses:
+	case <-p.stopped:
+		select {
+		case resp = <-responses:
+		default:
+			return p.err
+		}
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	if resp.Error != nil {
+		return resp.Error
+	}
+	if err := json.Unmarshal(resp.Result, result); err != nil {
+		return fmt.Errorf("invalid %s result: %v", method, err)
+	}
+	return nil
+}
+
+// read hands the responses of the pack to the calls waiting for them, until the pack stops
+// answering
+func (p *Pack) read(stdout io.Reader) {
+	decoder := json.NewDecoder(stdout)
+	for {
+		var resp response
+		if err := decoder.Decode(&resp); err != nil {
+			if errors.Is(err, io.EOF) {
+				err = errors.New("control pack exited")
+			} else {
+				err = fmt.Errorf("invalid response: %v", err)
+			}
+			p.mu.Lock()
+			p.err = err
+			p.mu.Unlock()
+			close(p.stopped)
+			return
+		}
+		p.mu.Lock()
+		responses, ok := p.pending[resp.ID]
+		p.mu.Unlock()
+		if ok {
+			responses <- resp
+		}
+	}
+}
+
+// log logs what the pack writes to stderr, a record per line
+func (p *Pack) log(stderr io.Reader) {
+	scanner := bufio.NewScanner(stderr)
+	for scanner.Scan() {
+		slog.Info("control pack output", "pack", p.Path, "line", scanner.Text())
+	}
+}
+
+// control is a control of a pack
+type control struct {
+	pack *Pack
+	info ControlInfo
+}
+
+// Metadata describes the control as the pack listed it
+func (c control) Metadata() types.Metadata {
+	requirement := c.info.Requirement
+	check := requirement.Id
+	if len(requirement.Checks) > 0 {
+		check = requirement.Checks[0]
+	}
+	var parameters []types.Parameter
+	for _, parameter := range c.info.Parameters {
+		parameters = append(parameters, types.Parameter{Name: parameter.Name, Description: parameter.Description, Default: parameter.Default})
+	}
+	return types.Metadata{ID: requirement.Id, Check: check, Service: c.info.Service, Parameters: parameters, Requirement: &requirement}
+}
+
+// Permissions are the IAM actions the pack listed for the control
+func (c control) Permissions() []string {
+	return c.info.Permissions
+}
+
+// Evaluate has the pack evaluate the control. When the pack fails to, the control is an ERROR with a
+// finding naming the pack.
+func (c control) Evaluate(ctx context.Context, clients types.Clients) (string, types.Findings) {
+	metadata := c.Metadata()
+	params := EvaluateParams{Control: metadata.ID, Inventory: clients.Inventory}
+	if len(metadata.Parameters) > 0 {
+		params.Parameters = make(map[string]string)
+		for _, parameter := range metadata.Parameters {
+			params.Parameters[parameter.Name] = parameter.Value(metadata.ID)
+		}
+	}
+	if clients.AWS != nil && clients.AWS.Credentials != nil {
+		credentials, err := clients.AWS.Credentials.Retrieve(ctx)
+		if err != nil {
+			return c.failed(clients.Inventory, err)
+		}
+		params.AWS = &AWS{
+			Region:          clients.AWS.Region,
+			AccessKeyID:     credentials.AccessKeyID,
+			SecretAccessKey: credentials.SecretAccessKey,
+			SessionToken:    credentials.SessionToken,
+		}
+	}
+
+	var result EvaluateResult
+	if err := c.pack.call(ctx, MethodEvaluate, params, &result); err != nil {
+		return c.failed(clients.Inventory, err)
+	}
+	if !statuses[result.Status] {
+		return c.failed(clients.Inventory, fmt.Errorf("invalid status %q", result.Status))
+	}
+	return result.Status, result.Findings
+}
+
+func (c control) failed(inv *inventory.Inventory, err error) (string, types.Findings) {
+	logging.Control(c.info.Requirement.Id, inv).Error("control pack failed to evaluate the control", "pack", c.pack.Name, "error", err)
+	apiErr := inventory.NewAPIError(err)
+	apiErr.Operation = MethodEvaluate
+	var findings types.Findings
+	findings.Error(c.pack.Name, &apiErr)
+	return "ERROR", findings
+}
@@ -0,0 +1,54 @@
+// pack/client_test.go
+package pack
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestMain runs the test binary as a control pack that answers describe and then stops reading its
+// stdin, when the tests start it as one
+func TestMain(m *testing.M) {
+	if os.Getenv("PACK_TEST_STUCK") == "1" {
+		var req request
+		if err := json.NewDecoder(bufio.NewReader(os.Stdin)).Decode(&req); err != nil {
+			os.Exit(1)
+		}
+		result, _ := json.Marshal(DescribeResult{Protocol: ProtocolVersion, Name: "stuck"})
+		json.NewEncoder(os.Stdout).Encode(response{JSONRPC: "2.0", ID: req.ID, Result: result})
+		time.Sleep(time.Minute)
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+func TestCallGivesUpWhenThePackStopsReading(t *testing.T) {
+	t.Setenv("PACK_TEST_STUCK", "1")
+	p, err := Load(os.Args[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.kill()
+
+	// Larger than a pipe buffer, so that the write blocks
+	params := map[string]string{"Inventory": strings.Repeat("x", 1<<20)}
+	for i := 0; i < 2; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+		started := time.Now()
+		var result EvaluateResult
+		err := p.call(ctx, MethodEvaluate, params, &result)
+		cancel()
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("call %d: error = %v, want %v", i+1, err, context.DeadlineExceeded)
+		}
+		if elapsed := time.Since(started); elapsed > 2*time.Second {
+			t.Errorf("call %d returned after %s, want once its context is done", i+1, elapsed)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+// pack/example/main.go
+
+// Command example is a control pack with a single control, Example.1, checking that DocumentDB cluster
+// names end with the environment they belong to. Build it and pass it to --pack:
+//
+//	go build -o example-pack ./pack/example
+//	audit all --pack ./example-pack
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"aws-security-hub/inventory"
+	"aws-security-hub/logging"
+	"aws-security-hub/pack"
+	"aws-security-hub/types"
+	"aws-security-hub/util"
+)
+
+var environment = types.Parameter{
+	Name:        "environment",
+	Description: "Suffix every DocumentDB cluster name should end with",
+	Default:     "prod",
+}
+
+func main() {
+	// stdout belongs to the protocol: logs go to stderr, which the runner logs
+	err := pack.Serve("example", "1.0.0", types.InventoryControl{
+		ID:         "Example.1",
+		Check:      "docdb-cluster-environment-suffix",
+		Service:    inventory.ServiceDocumentDB,
+		Func:       evaluateClusterEnvironmentSuffix,
+		Actions:    []string{"rds:DescribeDBClusters"},
+		Parameters: []types.Parameter{environment},
+		Requirement: &util.Requirement{
+			Id:          "Example.1",
+			Description: "DocumentDB cluster names should end with their environment",
+			Checks:      []string{"docdb-cluster-environment-suffix"},
+			Attributes:  []util.Attribute{{Section: "Example", Category: "Identify > Inventory", Severity: "Low"}},
+		},
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func evaluateClusterEnvironmentSuffix(inv *inventory.Inventory) (string, types.Findings) {
+	var findings types.Findings
+	logger := logging.Control("Example.1", inv)
+
+	if inv.DocumentDB == nil {
+		return types.NotCollected(logger, inv, inventory.ServiceDocumentDB, &findings), findings
+	}
+	if err := inv.DocumentDB.Errors.Find("DescribeDBClusters"); err != nil {
+		findings.Error(inventory.ServiceDocumentDB, err)
+	}
+	if len(inv.DocumentDB.Clusters) == 0 {
+		return "NA", findings
+	}
+
+	suffix := "-" + environment.Value("Example.1")
+	status := "PASS"
+	for _, cluster := range inv.DocumentDB.Clusters {
+		if strings.HasSuffix(cluster.Identifier, suffix) {
+			findings.Pass(cluster.Identifier, fmt.Sprintf("Name ends with %s", suffix))
+		} else {
+			logger.Info("cluster name lacks the environment", "resource", cluster.Identifier, "status", "FAIL")
+			findings.Fail(cluster.Identifier, fmt.Sprintf("Name does not end with %s", suffix))
+			status = "FAIL"
+		}
+	}
+	return status, findings
+}
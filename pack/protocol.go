@@ -0,0 +1,122 @@
+// pack/protocol.go
+package pack
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"aws-security-hub/inventory"
+	"aws-security-hub/types"
+	"aws-security-hub/util"
+)
+
+// ProtocolVersion is the version of the control pack protocol. A pack answers describe with the
+// version it speaks, and is rejected when it differs; the version changes only when an existing
+// method or field changes meaning, new optional fields leave it as is.
+//
+// A control pack is an executable that reads JSON-RPC 2.0 requests on stdin and writes its responses
+// on stdout, one JSON object per line. Its stderr is logged. The methods are:
+//
+//	describe  DescribeParams -> DescribeResult  once, after the pack starts
+//	evaluate  EvaluateParams -> EvaluateResult  for each control, account and region evaluated
+//
+// Stdin is closed when the pack is no longer needed, after which it should exit.
+const ProtocolVersion = 1
+
+// Methods of the protocol
+const (
+	MethodDescribe = "describe"
+	MethodEvaluate = "evaluate"
+)
+
+// JSON-RPC 2.0 error codes used by Serve
+const (
+	CodeParseError     = -32700
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternalError  = -32603
+)
+
+// request is a JSON-RPC 2.0 request
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      int64           `json:"id"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+// response is a JSON-RPC 2.0 response, carrying either a result or an error
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      int64           `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+}
+
+// Error is a JSON-RPC 2.0 error
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s (code %d)", e.Message, e.Code)
+}
+
+// DescribeParams are the parameters of describe
+type DescribeParams struct {
+	Protocol int `json:"Protocol"` // ProtocolVersion of the runner
+}
+
+// DescribeResult lists the controls of a pack
+type DescribeResult struct {
+	Protocol int           `json:"Protocol"` // ProtocolVersion the pack speaks
+	Name     string        `json:"Name"`
+	Version  string        `json:"Version,omitempty"`
+	Controls []ControlInfo `json:"Controls"`
+}
+
+// ControlInfo describes a control of a pack. Its requirement uses the fields of the compliance JSON,
+// so that its description and severity show in every report.
+type ControlInfo struct {
+	Requirement util.Requirement `json:"Requirement"`
+	// Service is the inventory service to collect for the control (e.g. documentdb); empty collects
+	// every service
+	Service     string          `json:"Service,omitempty"`
+	Permissions []string        `json:"Permissions,omitempty"` // IAM actions the control needs
+	Parameters  []ParameterInfo `json:"Parameters,omitempty"`
+}
+
+// ParameterInfo describes a parameter of a pack control
+type ParameterInfo struct {
+	Name        string `json:"Name"`
+	Description string `json:"Description,omitempty"`
+	Default     string `json:"Default,omitempty"`
+}
+
+// EvaluateParams are the parameters of evaluate
+type EvaluateParams struct {
+	Control    string               `json:"Control"`              // ID of the control
+	Parameters map[string]string    `json:"Parameters,omitempty"` // configured values, defaults included
+	Inventory  *inventory.Inventory `json:"Inventory"`            // resources collected for the control
+	// AWS is the account and region scanned, for controls that call AWS themselves; nil when
+	// evaluating a snapshot, template or plan
+	AWS *AWS `json:"AWS,omitempty"`
+}
+
+// AWS carries the region and the temporary credentials of the scan
+type AWS struct {
+	Region          string `json:"Region"`
+	AccessKeyID     string `json:"AccessKeyID"`
+	SecretAccessKey string `json:"SecretAccessKey"`
+	SessionToken    string `json:"SessionToken,omitempty"`
+}
+
+// EvaluateResult is the outcome of a control
+type EvaluateResult struct {
+	Status   string         `json:"Status"` // PASS, FAIL, ERROR or NA
+	Findings types.Findings `json:"Findings"`
+}
+
+// statuses are the valid statuses of EvaluateResult
+var statuses = map[string]bool{"PASS": true, "FAIL": true, "ERROR": true, "NA": true}
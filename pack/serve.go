@@ -0,0 +1,112 @@
+// pack/serve.go
+package pack
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"aws-security-hub/types"
+	"aws-security-hub/util"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+)
+
+// Serve answers the requests of the runner on stdin and stdout until stdin is closed, making a Go
+// program a control pack of the given controls. Diagnostics should go to stderr, which the runner
+// logs; stdout belongs to the protocol.
+func Serve(name, version string, controls ...types.Control) error {
+	return serve(os.Stdin, os.Stdout, name, version, controls)
+}
+
+func serve(r io.Reader, w io.Writer, name, version string, controls []types.Control) error {
+	byID := make(map[string]types.Control)
+	for _, control := range controls {
+		byID[control.Metadata().ID] = control
+	}
+
+	decoder := json.NewDecoder(r)
+	encoder := json.NewEncoder(w)
+	for {
+		var req request
+		if err := decoder.Decode(&req); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			encoder.Encode(response{JSONRPC: "2.0", Error: &Error{Code: CodeParseError, Message: err.Error()}})
+			return fmt.Errorf("invalid request: %v", err)
+		}
+
+		resp := response{JSONRPC: "2.0", ID: req.ID}
+		result, rpcErr := handle(req, name, version, controls, byID)
+		if rpcErr == nil {
+			if raw, err := json.Marshal(result); err != nil {
+				rpcErr = &Error{Code: CodeInternalError, Message: err.Error()}
+			} else {
+				resp.Result = raw
+			}
+		}
+		resp.Error = rpcErr
+		if err := encoder.Encode(resp); err != nil {
+			return fmt.Errorf("failed to write response: %v", err)
+		}
+	}
+}
+
+// handle answers a request with its result, or a JSON-RPC error
+func handle(req request, name, version string, controls []types.Control, byID map[string]types.Control) (any, *Error) {
+	switch req.Method {
+	case MethodDescribe:
+		described := DescribeResult{Protocol: ProtocolVersion, Name: name, Version: version, Controls: []ControlInfo{}}
+		for _, control := range controls {
+			described.Controls = append(described.Controls, describe(control))
+		}
+		return described, nil
+
+	case MethodEvaluate:
+		var params EvaluateParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, &Error{Code: CodeInvalidParams, Message: err.Error()}
+		}
+		control, ok := byID[params.Control]
+		if !ok {
+			return nil, &Error{Code: CodeInvalidParams, Message: fmt.Sprintf("unknown control %s", params.Control)}
+		}
+		if params.Inventory == nil {
+			return nil, &Error{Code: CodeInvalidParams, Message: "missing Inventory"}
+		}
+		types.SetParameters(map[string]map[string]string{params.Control: params.Parameters})
+
+		clients := types.Clients{Inventory: params.Inventory}
+		if params.AWS != nil {
+			clients.AWS = &aws.Config{
+				Region:      params.AWS.Region,
+				Credentials: credentials.NewStaticCredentialsProvider(params.AWS.AccessKeyID, params.AWS.SecretAccessKey, params.AWS.SessionToken),
+			}
+		}
+		status, findings := control.Evaluate(context.Background(), clients)
+		if findings == nil {
+			findings = types.Findings{}
+		}
+		return EvaluateResult{Status: status, Findings: findings}, nil
+	}
+	return nil, &Error{Code: CodeMethodNotFound, Message: fmt.Sprintf("unknown method %s", req.Method)}
+}
+
+// describe lists a control the way the runner expects it
+func describe(control types.Control) ControlInfo {
+	metadata := control.Metadata()
+	requirement := util.Requirement{Id: metadata.ID, Checks: []string{metadata.Check}}
+	if metadata.Requirement != nil {
+		requirement = *metadata.Requirement
+	}
+	info := ControlInfo{Requirement: requirement, Service: metadata.Service, Permissions: control.Permissions()}
+	for _, parameter := range metadata.Parameters {
+		info.Parameters = append(info.Parameters, ParameterInfo{Name: parameter.Name, Description: parameter.Description, Default: parameter.Default})
+	}
+	return info
+}
@@ -0,0 +1,42 @@
+// permissions/policy.go
+package permissions
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// Document is an IAM policy document
+type Document struct {
+	Version   string      `json:"Version"`
+	Statement []Statement `json:"Statement"`
+}
+
+// Statement is a statement of an IAM policy document
+type Statement struct {
+	Sid      string   `json:"Sid"`
+	Effect   string   `json:"Effect"`
+	Action   []string `json:"Action"`
+	Resource string   `json:"Resource"`
+}
+
+// Policy returns the read-only policy allowing the actions. The scanner lists whole services,
+// so the actions are granted on every resource.
+func Policy(actions []string) Document {
+	return Document{
+		Version: "2012-10-17",
+		Statement: []Statement{{
+			Sid:      "SecurityHubAudit",
+			Effect:   "Allow",
+			Action:   actions,
+			Resource: "*",
+		}},
+	}
+}
+
+// Write renders the policy as indented JSON
+func (d Document) Write(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "    ")
+	return encoder.Encode(d)
+}
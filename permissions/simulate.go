@@ -0,0 +1,77 @@
+// permissions/simulate.go
+package permissions
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+)
+
+// Decision is the outcome of simulating one action: allowed, implicitDeny or explicitDeny
+type Decision struct {
+	Action   string
+	Decision string
+}
+
+// Allowed reports whether the principal may call the action
+func (d Decision) Allowed() bool {
+	return d.Decision == "allowed"
+}
+
+// Simulate asks IAM whether the caller of cfg may call each action, through SimulatePrincipalPolicy.
+// The caller needs iam:SimulatePrincipalPolicy on itself, and iam:GetRole on its role when it assumed
+// one. Root users cannot be simulated.
+func Simulate(ctx context.Context, cfg aws.Config, actions []string) (principal string, decisions []Decision, err error) {
+	identity, err := sts.NewFromConfig(cfg).GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to resolve the caller: %v", err)
+	}
+
+	client := iam.NewFromConfig(cfg)
+	principal, err = principalARN(ctx, client, aws.ToString(identity.Arn))
+	if err != nil {
+		return "", nil, err
+	}
+
+	paginator := iam.NewSimulatePrincipalPolicyPaginator(client, &iam.SimulatePrincipalPolicyInput{
+		PolicySourceArn: aws.String(principal),
+		ActionNames:     actions,
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return principal, nil, fmt.Errorf("failed to simulate the policies of %s: %v", principal, err)
+		}
+		for _, result := range page.EvaluationResults {
+			decisions = append(decisions, Decision{Action: aws.ToString(result.EvalActionName), Decision: string(result.EvalDecision)})
+		}
+	}
+	return principal, decisions, nil
+}
+
+// principalARN maps the caller identity to the IAM user or role that policies are attached to. The
+// session ARN of an assumed role lacks the path of the role, so the role ARN comes from GetRole.
+func principalARN(ctx context.Context, client *iam.Client, callerARN string) (string, error) {
+	parts := strings.SplitN(callerARN, ":", 6)
+	if len(parts) != 6 {
+		return "", fmt.Errorf("unexpected caller ARN %q", callerARN)
+	}
+	resource := parts[5]
+
+	switch {
+	case strings.HasPrefix(resource, "assumed-role/"):
+		name := strings.Split(resource, "/")[1]
+		role, err := client.GetRole(ctx, &iam.GetRoleInput{RoleName: aws.String(name)})
+		if err != nil {
+			return "", fmt.Errorf("failed to resolve the role %s: %v", name, err)
+		}
+		return aws.ToString(role.Role.Arn), nil
+	case strings.HasPrefix(resource, "user/"), strings.HasPrefix(resource, "role/"):
+		return callerARN, nil
+	}
+	return "", fmt.Errorf("cannot simulate the policies of %s", callerARN)
+}
@@ -0,0 +1,54 @@
+// permissions/simulate_test.go
+package permissions
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"aws-security-hub/inventory/inventorytest"
+)
+
+func TestSimulateRoleWithPath(t *testing.T) {
+	cfg, api := inventorytest.Config(func(r inventorytest.Request) (int, string) {
+		switch r.Action() {
+		case "GetCallerIdentity":
+			return http.StatusOK, `<GetCallerIdentityResponse><GetCallerIdentityResult>
+<Arn>arn:aws:sts::123456789012:assumed-role/scanner/session</Arn><Account>123456789012</Account>
+</GetCallerIdentityResult></GetCallerIdentityResponse>`
+		case "GetRole":
+			return http.StatusOK, `<GetRoleResponse><GetRoleResult><Role>
+<RoleName>scanner</RoleName><Path>/security/</Path><Arn>arn:aws:iam::123456789012:role/security/scanner</Arn>
+</Role></GetRoleResult></GetRoleResponse>`
+		case "SimulatePrincipalPolicy":
+			if r.Params.Get("PolicySourceArn") != "arn:aws:iam::123456789012:role/security/scanner" {
+				return http.StatusBadRequest, `<ErrorResponse><Error><Type>Sender</Type><Code>NoSuchEntity</Code><Message>unknown principal</Message></Error></ErrorResponse>`
+			}
+			if r.Params.Get("Marker") == "" {
+				return http.StatusOK, `<SimulatePrincipalPolicyResponse><SimulatePrincipalPolicyResult><EvaluationResults>
+<member><EvalActionName>rds:DescribeDBClusters</EvalActionName><EvalDecision>allowed</EvalDecision></member>
+</EvaluationResults><IsTruncated>true</IsTruncated><Marker>page-2</Marker></SimulatePrincipalPolicyResult></SimulatePrincipalPolicyResponse>`
+			}
+			return http.StatusOK, `<SimulatePrincipalPolicyResponse><SimulatePrincipalPolicyResult><EvaluationResults>
+<member><EvalActionName>s3:GetBucketPolicy</EvalActionName><EvalDecision>implicitDeny</EvalDecision></member>
+</EvaluationResults><IsTruncated>false</IsTruncated></SimulatePrincipalPolicyResult></SimulatePrincipalPolicyResponse>`
+		}
+		return inventorytest.NotFound(r)
+	})
+
+	principal, decisions, err := Simulate(context.Background(), cfg, []string{"rds:DescribeDBClusters", "s3:GetBucketPolicy"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "arn:aws:iam::123456789012:role/security/scanner"; principal != want {
+		t.Errorf("principal = %s, want %s", principal, want)
+	}
+	want := []Decision{{"rds:DescribeDBClusters", "allowed"}, {"s3:GetBucketPolicy", "implicitDeny"}}
+	if !reflect.DeepEqual(decisions, want) {
+		t.Errorf("decisions = %v, want %v", decisions, want)
+	}
+	if calls := api.Count(func(r inventorytest.Request) bool { return r.Action() == "SimulatePrincipalPolicy" }); calls != 2 {
+		t.Errorf("SimulatePrincipalPolicy calls = %d, want 2", calls)
+	}
+}
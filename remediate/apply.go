@@ -0,0 +1,106 @@
+// remediate/apply.go
+package remediate
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/apigateway"
+	apigatewayTypes "github.com/aws/aws-sdk-go-v2/service/apigateway/types"
+	"github.com/aws/aws-sdk-go-v2/service/docdb"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2Types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3Types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// Apply executes a single action
+func Apply(ctx context.Context, cfg aws.Config, action Action) error {
+	p := action.Parameters
+	var err error
+
+	switch action.Service + ":" + action.Operation {
+	case "docdb:ModifyDBCluster":
+		_, err = docdb.NewFromConfig(cfg).ModifyDBCluster(ctx, &docdb.ModifyDBClusterInput{
+			DBClusterIdentifier: aws.String(str(p, "DBClusterIdentifier")),
+			DeletionProtection:  aws.Bool(boolean(p, "DeletionProtection")),
+			ApplyImmediately:    aws.Bool(boolean(p, "ApplyImmediately")),
+		})
+
+	case "docdb:ModifyDBClusterSnapshotAttribute":
+		_, err = docdb.NewFromConfig(cfg).ModifyDBClusterSnapshotAttribute(ctx, &docdb.ModifyDBClusterSnapshotAttributeInput{
+			DBClusterSnapshotIdentifier: aws.String(str(p, "DBClusterSnapshotIdentifier")),
+			AttributeName:               aws.String(str(p, "AttributeName")),
+			ValuesToAdd:                 strs(p, "ValuesToAdd"),
+			ValuesToRemove:              strs(p, "ValuesToRemove"),
+		})
+
+	case "ec2:ModifySnapshotAttribute":
+		_, err = ec2.NewFromConfig(cfg).ModifySnapshotAttribute(ctx, &ec2.ModifySnapshotAttributeInput{
+			SnapshotId:    aws.String(str(p, "SnapshotId")),
+			Attribute:     ec2Types.SnapshotAttributeName(str(p, "Attribute")),
+			OperationType: ec2Types.OperationType(str(p, "OperationType")),
+			GroupNames:    strs(p, "GroupNames"),
+		})
+
+	case "apigateway:UpdateStage":
+		_, err = apigateway.NewFromConfig(cfg).UpdateStage(ctx, &apigateway.UpdateStageInput{
+			RestApiId: aws.String(str(p, "RestApiId")),
+			StageName: aws.String(str(p, "StageName")),
+			PatchOperations: []apigatewayTypes.PatchOperation{{
+				Op:    apigatewayTypes.Op(str(p, "Op")),
+				Path:  aws.String(str(p, "Path")),
+				Value: aws.String(str(p, "Value")),
+			}},
+		})
+
+	case "s3:PutPublicAccessBlock":
+		_, err = s3.NewFromConfig(cfg).PutPublicAccessBlock(ctx, &s3.PutPublicAccessBlockInput{
+			Bucket: aws.String(str(p, "Bucket")),
+			PublicAccessBlockConfiguration: &s3Types.PublicAccessBlockConfiguration{
+				BlockPublicAcls:       aws.Bool(boolean(p, "BlockPublicAcls")),
+				IgnorePublicAcls:      aws.Bool(boolean(p, "IgnorePublicAcls")),
+				BlockPublicPolicy:     aws.Bool(boolean(p, "BlockPublicPolicy")),
+				RestrictPublicBuckets: aws.Bool(boolean(p, "RestrictPublicBuckets")),
+			},
+		})
+
+	case "s3:DeletePublicAccessBlock":
+		_, err = s3.NewFromConfig(cfg).DeletePublicAccessBlock(ctx, &s3.DeletePublicAccessBlockInput{
+			Bucket: aws.String(str(p, "Bucket")),
+		})
+
+	default:
+		return fmt.Errorf("unsupported action %s:%s", action.Service, action.Operation)
+	}
+
+	return err
+}
+
+func str(parameters map[string]interface{}, key string) string {
+	value, _ := parameters[key].(string)
+	return value
+}
+
+func boolean(parameters map[string]interface{}, key string) bool {
+	value, _ := parameters[key].(bool)
+	return value
+}
+
+// strs accepts both []string (planned) and []interface{} (loaded from a record)
+func strs(parameters map[string]interface{}, key string) []string {
+	switch value := parameters[key].(type) {
+	case []string:
+		return value
+	case []interface{}:
+		var result []string
+		for _, item := range value {
+			if s, ok := item.(string); ok {
+				result = append(result, s)
+			}
+		}
+		return result
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+// remediate/apply_test.go
+package remediate
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"aws-security-hub/inventory"
+	"aws-security-hub/inventory/inventorytest"
+)
+
+// acceptAll answers every remediation call with an empty success
+func acceptAll(r inventorytest.Request) (int, string) {
+	if action := r.Action(); action != "" {
+		return http.StatusOK, "<" + action + "Response><" + action + "Result/></" + action + "Response>"
+	}
+	return http.StatusOK, "{}"
+}
+
+func TestApplyParameters(t *testing.T) {
+	tests := []struct {
+		name   string
+		action Action
+		method string
+		path   string
+		params map[string]string // query or form parameters of the request
+		body   []string          // fragments of the JSON or XML body
+	}{
+		{
+			name:   "docdb:ModifyDBCluster",
+			action: modifyDBCluster("cluster-1", true),
+			method: http.MethodPost,
+			params: map[string]string{"Action": "ModifyDBCluster", "DBClusterIdentifier": "cluster-1", "DeletionProtection": "true", "ApplyImmediately": "true"},
+		},
+		{
+			name:   "docdb:ModifyDBClusterSnapshotAttribute",
+			action: modifyDBClusterSnapshotAttribute("snapshot-1", "ValuesToRemove"),
+			method: http.MethodPost,
+			params: map[string]string{"Action": "ModifyDBClusterSnapshotAttribute", "DBClusterSnapshotIdentifier": "snapshot-1", "AttributeName": "restore", "ValuesToRemove.AttributeValue.1": "all"},
+		},
+		{
+			name:   "ec2:ModifySnapshotAttribute",
+			action: modifySnapshotAttribute("snap-1", "add"),
+			method: http.MethodPost,
+			params: map[string]string{"Action": "ModifySnapshotAttribute", "SnapshotId": "snap-1", "Attribute": "createVolumePermission", "OperationType": "add", "UserGroup.1": "all"},
+		},
+		{
+			name:   "apigateway:UpdateStage",
+			action: updateStageTracing("a1", "prod", "true"),
+			method: http.MethodPatch,
+			path:   "/restapis/a1/stages/prod",
+			body:   []string{`"op":"replace"`, `"path":"/tracingEnabled"`, `"value":"true"`},
+		},
+		{
+			name:   "s3:PutPublicAccessBlock",
+			action: putPublicAccessBlock("logs", inventory.PublicAccessBlock{BlockPublicAcls: true, RestrictPublicBuckets: true}),
+			method: http.MethodPut,
+			params: map[string]string{"publicAccessBlock": ""},
+			body: []string{
+				"<BlockPublicAcls>true</BlockPublicAcls>", "<IgnorePublicAcls>false</IgnorePublicAcls>",
+				"<BlockPublicPolicy>false</BlockPublicPolicy>", "<RestrictPublicBuckets>true</RestrictPublicBuckets>",
+			},
+		},
+		{
+			name:   "s3:DeletePublicAccessBlock",
+			action: Action{Service: "s3", Operation: "DeletePublicAccessBlock", Parameters: map[string]interface{}{"Bucket": "logs"}},
+			method: http.MethodDelete,
+			params: map[string]string{"publicAccessBlock": ""},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// Apply actions the way a rollback reads them back from a record
+			bytes, err := json.Marshal(test.action)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var action Action
+			if err := json.Unmarshal(bytes, &action); err != nil {
+				t.Fatal(err)
+			}
+
+			cfg, api := inventorytest.Config(acceptAll)
+			if err := Apply(context.Background(), cfg, action); err != nil {
+				t.Fatal(err)
+			}
+			if len(api.Requests) != 1 {
+				t.Fatalf("got %d requests, want 1", len(api.Requests))
+			}
+			request := api.Requests[0]
+
+			if request.Method != test.method {
+				t.Errorf("method = %s, want %s", request.Method, test.method)
+			}
+			if test.path != "" && request.Path != test.path {
+				t.Errorf("path = %s, want %s", request.Path, test.path)
+			}
+			for key, want := range test.params {
+				if !request.Params.Has(key) || request.Params.Get(key) != want {
+					t.Errorf("%s = %q, want %q (params %v)", key, request.Params.Get(key), want, request.Params)
+				}
+			}
+			for _, fragment := range test.body {
+				if !strings.Contains(request.Body, fragment) {
+					t.Errorf("body lacks %s: %s", fragment, request.Body)
+				}
+			}
+		})
+	}
+}
+
+func TestApplyUnsupportedAction(t *testing.T) {
+	cfg, api := inventorytest.Config(acceptAll)
+	if err := Apply(context.Background(), cfg, Action{Service: "s3", Operation: "DeleteBucket"}); err == nil {
+		t.Error("Apply() succeeded for an unsupported action")
+	}
+	if len(api.Requests) != 0 {
+		t.Errorf("got %d requests, want none", len(api.Requests))
+	}
+}
@@ -0,0 +1,91 @@
+// remediate/record.go
+package remediate
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+// Record lists the changes that were applied so they can be rolled back
+type Record struct {
+	AppliedAt time.Time `json:"AppliedAt"`
+	Account   string    `json:"Account"`
+	Region    string    `json:"Region"`
+	Changes   []Change  `json:"Changes"`
+}
+
+// SaveRecord writes a rollback record as indented JSON
+func SaveRecord(record *Record, filePath string) error {
+	bytes, err := json.MarshalIndent(record, "", "    ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal record: %v", err)
+	}
+	if err := os.WriteFile(filePath, bytes, 0o600); err != nil {
+		return fmt.Errorf("failed to write record: %v", err)
+	}
+	return nil
+}
+
+// LoadRecord reads a rollback record
+func LoadRecord(filePath string) (*Record, error) {
+	bytes, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read record: %v", err)
+	}
+
+	var record Record
+	if err := json.Unmarshal(bytes, &record); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal record: %v", err)
+	}
+	return &record, nil
+}
+
+// Verify returns an error unless the record was applied in the account and region a rollback runs
+// against. Records written before the account was recorded are only checked for the region.
+func (r *Record) Verify(account, region string) error {
+	if r.Account != "" && r.Account != account {
+		return fmt.Errorf("record was applied in account %s, not %s", r.Account, account)
+	}
+	if r.Region != "" && r.Region != region {
+		return fmt.Errorf("record was applied in region %s, not %s", r.Region, region)
+	}
+	return nil
+}
+
+// Inverse returns the changes that undo a record, in reverse order
+func (r *Record) Inverse() []Change {
+	var changes []Change
+	for i := len(r.Changes) - 1; i >= 0; i-- {
+		change := r.Changes[i]
+		changes = append(changes, Change{
+			Control:  change.Control,
+			Resource: change.Resource,
+			Action:   change.Rollback,
+			Rollback: change.Action,
+		})
+	}
+	return changes
+}
+
+// ApplyAll executes changes in order in the account of cfg and records the ones that succeeded.
+// It stops at the first failure, or when ctx is done, so the record always matches what was changed.
+func ApplyAll(ctx context.Context, cfg aws.Config, account string, changes []Change) (*Record, error) {
+	record := &Record{AppliedAt: time.Now().UTC(), Account: account, Region: cfg.Region}
+	for _, change := range changes {
+		logger := slog.With("control", change.Control, "resource", change.Resource, "action", change.Action.String())
+		logger.Info("applying change")
+		if err := Apply(ctx, cfg, change.Action); err != nil {
+			logger.Error("change failed", "error", err)
+			return record, fmt.Errorf("%s: %v", change.Resource, err)
+		}
+		logger.Info("change applied")
+		record.Changes = append(record.Changes, change)
+	}
+	return record, nil
+}
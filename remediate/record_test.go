@@ -0,0 +1,88 @@
+// remediate/record_test.go
+package remediate
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRecordRoundTrip(t *testing.T) {
+	record := &Record{
+		AppliedAt: time.Date(2024, 5, 1, 9, 30, 0, 0, time.UTC),
+		Account:   "123456789012",
+		Region:    "ap-northeast-2",
+		Changes: []Change{{
+			Control:  "EC2.1",
+			Resource: "snap-public",
+			Action:   modifySnapshotAttribute("snap-public", "remove"),
+			Rollback: modifySnapshotAttribute("snap-public", "add"),
+		}},
+	}
+
+	path := filepath.Join(t.TempDir(), "record.json")
+	if err := SaveRecord(record, path); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadRecord(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if loaded.AppliedAt != record.AppliedAt || loaded.Account != record.Account || loaded.Region != record.Region {
+		t.Errorf("record = %s %s %s, want %s %s %s", loaded.AppliedAt, loaded.Account, loaded.Region, record.AppliedAt, record.Account, record.Region)
+	}
+	if len(loaded.Changes) != 1 {
+		t.Fatalf("got %d changes, want 1", len(loaded.Changes))
+	}
+
+	// String lists come back from JSON as []interface{}, which Apply must read the same way
+	parameters := loaded.Changes[0].Rollback.Parameters
+	if _, ok := parameters["GroupNames"].([]interface{}); !ok {
+		t.Fatalf("GroupNames = %T, want []interface{}", parameters["GroupNames"])
+	}
+	if got := strs(parameters, "GroupNames"); !reflect.DeepEqual(got, []string{"all"}) {
+		t.Errorf("strs() = %v, want [all]", got)
+	}
+	for _, key := range []string{"SnapshotId", "Attribute", "OperationType"} {
+		if str(parameters, key) != str(record.Changes[0].Rollback.Parameters, key) {
+			t.Errorf("%s = %v, want %v", key, parameters[key], record.Changes[0].Rollback.Parameters[key])
+		}
+	}
+}
+
+func TestRecordInverse(t *testing.T) {
+	first := Change{Control: "DocumentDB.5", Resource: "first", Action: modifyDBCluster("first", true), Rollback: modifyDBCluster("first", false)}
+	second := Change{Control: "DocumentDB.5", Resource: "second", Action: modifyDBCluster("second", true), Rollback: modifyDBCluster("second", false)}
+	record := &Record{Changes: []Change{first, second}}
+
+	// Changes are undone last first, each with its rollback
+	want := []Change{
+		{Control: "DocumentDB.5", Resource: "second", Action: second.Rollback, Rollback: second.Action},
+		{Control: "DocumentDB.5", Resource: "first", Action: first.Rollback, Rollback: first.Action},
+	}
+	if got := record.Inverse(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Inverse() = %+v, want %+v", got, want)
+	}
+}
+
+func TestRecordVerify(t *testing.T) {
+	tests := []struct {
+		name    string
+		record  Record
+		wantErr bool
+	}{
+		{"same account and region", Record{Account: "123456789012", Region: "ap-northeast-2"}, false},
+		{"another account", Record{Account: "210987654321", Region: "ap-northeast-2"}, true},
+		{"another region", Record{Account: "123456789012", Region: "us-east-1"}, true},
+		{"record without account", Record{Region: "ap-northeast-2"}, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := test.record.Verify("123456789012", "ap-northeast-2"); (err != nil) != test.wantErr {
+				t.Errorf("Verify() = %v, want error %v", err, test.wantErr)
+			}
+		})
+	}
+}
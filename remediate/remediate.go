@@ -0,0 +1,288 @@
+// remediate/remediate.go
+package remediate
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"sort"
+
+	"aws-security-hub/inventory"
+	"aws-security-hub/types"
+)
+
+// Action is a single AWS API call. Parameters only hold strings, booleans and string lists
+// so an action survives the JSON round trip of a rollback record unchanged.
+type Action struct {
+	Service    string                 `json:"Service"`   // SDK service name, e.g. docdb
+	Operation  string                 `json:"Operation"` // API operation, e.g. ModifyDBCluster
+	Parameters map[string]interface{} `json:"Parameters"`
+}
+
+// String renders the action as it is shown in a plan
+func (a Action) String() string {
+	parameters, _ := json.Marshal(a.Parameters)
+	return fmt.Sprintf("%s:%s %s", a.Service, a.Operation, parameters)
+}
+
+// Change fixes one resource and knows how to undo itself
+type Change struct {
+	Control  string `json:"Control"`
+	Resource string `json:"Resource"`
+	Action   Action `json:"Action"`
+	Rollback Action `json:"Rollback"`
+}
+
+// Remediation plans the changes that fix the failing resources of a control
+type Remediation struct {
+	ControlID string
+	Service   string // inventory service the plan is built from
+	Plan      func(inv *inventory.Inventory) []Change
+}
+
+var remediations = map[string]Remediation{
+	"APIGateway.3": {ControlID: "APIGateway.3", Service: inventory.ServiceAPIGateway, Plan: planRestStageTracing},
+	"DocumentDB.3": {ControlID: "DocumentDB.3", Service: inventory.ServiceDocumentDB, Plan: planDocdbSnapshotRestore},
+	"DocumentDB.5": {ControlID: "DocumentDB.5", Service: inventory.ServiceDocumentDB, Plan: planDocdbDeletionProtection},
+	"EC2.1":        {ControlID: "EC2.1", Service: inventory.ServiceEC2, Plan: planEbsSnapshotRestore},
+	"S3.1":         {ControlID: "S3.1", Service: inventory.ServiceS3, Plan: planS3PublicAccessBlock},
+}
+
+// Get returns the remediation of a control
+func Get(controlID string) (Remediation, bool) {
+	remediation, ok := remediations[controlID]
+	return remediation, ok
+}
+
+// ControlIDs lists the controls that can be remediated
+func ControlIDs() []string {
+	var ids []string
+	for id := range remediations {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Unsuppressed drops the changes of resources whose findings of the control are suppressed, so that
+// a remediation leaves alone the resources the configuration accepts as they are
+func Unsuppressed(changes []Change, findings types.Findings) []Change {
+	suppressed := make(map[string]bool)
+	for _, finding := range findings {
+		if finding.Status == "SUPPRESSED" {
+			suppressed[finding.Resource] = true
+		}
+	}
+
+	var result []Change
+	for _, change := range changes {
+		if suppressed[change.Resource] {
+			slog.Info("change skipped: finding suppressed", "control", change.Control, "resource", change.Resource)
+			continue
+		}
+		result = append(result, change)
+	}
+	return result
+}
+
+// WritePlan renders the exact API calls of each change
+func WritePlan(w io.Writer, title string, changes []Change) {
+	fmt.Fprintf(w, "%s (%d change(s))\n", title, len(changes))
+	if len(changes) == 0 {
+		fmt.Fprintln(w, "└─ Nothing to change")
+		return
+	}
+	for _, change := range changes {
+		fmt.Fprintf(w, "└─[PLAN] %s\n", change.Resource)
+		fmt.Fprintf(w, "  └─ %s\n", change.Action)
+	}
+}
+
+// DocumentDB.5: enable deletion protection
+func planDocdbDeletionProtection(inv *inventory.Inventory) []Change {
+	var changes []Change
+	for _, cluster := range inv.DocumentDB.Clusters {
+		if cluster.DeletionProtection {
+			continue
+		}
+		changes = append(changes, Change{
+			Control:  "DocumentDB.5",
+			Resource: cluster.Identifier,
+			Action:   modifyDBCluster(cluster.Identifier, true),
+			Rollback: modifyDBCluster(cluster.Identifier, false),
+		})
+	}
+	return changes
+}
+
+func modifyDBCluster(identifier string, deletionProtection bool) Action {
+	return Action{
+		Service:   "docdb",
+		Operation: "ModifyDBCluster",
+		Parameters: map[string]interface{}{
+			"DBClusterIdentifier": identifier,
+			"DeletionProtection":  deletionProtection,
+			"ApplyImmediately":    true,
+		},
+	}
+}
+
+// DocumentDB.3: remove "all" from the restore attribute of manual cluster snapshots
+func planDocdbSnapshotRestore(inv *inventory.Inventory) []Change {
+	var changes []Change
+	for _, snapshot := range inv.DocumentDB.ClusterSnapshots {
+		if !contains(snapshot.RestoreAttributeValues, "all") {
+			continue
+		}
+		changes = append(changes, Change{
+			Control:  "DocumentDB.3",
+			Resource: snapshot.Identifier,
+			Action:   modifyDBClusterSnapshotAttribute(snapshot.Identifier, "ValuesToRemove"),
+			Rollback: modifyDBClusterSnapshotAttribute(snapshot.Identifier, "ValuesToAdd"),
+		})
+	}
+	return changes
+}
+
+func modifyDBClusterSnapshotAttribute(identifier, operation string) Action {
+	return Action{
+		Service:   "docdb",
+		Operation: "ModifyDBClusterSnapshotAttribute",
+		Parameters: map[string]interface{}{
+			"DBClusterSnapshotIdentifier": identifier,
+			"AttributeName":               "restore",
+			operation:                     []string{"all"},
+		},
+	}
+}
+
+// EC2.1: remove the "all" group from the createVolumePermission of EBS snapshots
+func planEbsSnapshotRestore(inv *inventory.Inventory) []Change {
+	var changes []Change
+	for _, snapshot := range inv.EC2.Snapshots {
+		public := false
+		for _, permission := range snapshot.CreateVolumePermissions {
+			if permission.Group == "all" {
+				public = true
+			}
+		}
+		if !public {
+			continue
+		}
+		changes = append(changes, Change{
+			Control:  "EC2.1",
+			Resource: snapshot.ID,
+			Action:   modifySnapshotAttribute(snapshot.ID, "remove"),
+			Rollback: modifySnapshotAttribute(snapshot.ID, "add"),
+		})
+	}
+	return changes
+}
+
+func modifySnapshotAttribute(snapshotID, operationType string) Action {
+	return Action{
+		Service:   "ec2",
+		Operation: "ModifySnapshotAttribute",
+		Parameters: map[string]interface{}{
+			"SnapshotId":    snapshotID,
+			"Attribute":     "createVolumePermission",
+			"OperationType": operationType,
+			"GroupNames":    []string{"all"},
+		},
+	}
+}
+
+// APIGateway.3: enable X-Ray tracing on REST API stages
+func planRestStageTracing(inv *inventory.Inventory) []Change {
+	var changes []Change
+	for _, restAPI := range inv.APIGateway.RestAPIs {
+		for _, stage := range restAPI.Stages {
+			if stage.TracingEnabled {
+				continue
+			}
+			changes = append(changes, Change{
+				Control:  "APIGateway.3",
+				Resource: fmt.Sprintf("%s/%s", restAPI.ID, stage.StageName),
+				Action:   updateStageTracing(restAPI.ID, stage.StageName, "true"),
+				Rollback: updateStageTracing(restAPI.ID, stage.StageName, "false"),
+			})
+		}
+	}
+	return changes
+}
+
+func updateStageTracing(restAPIID, stageName, value string) Action {
+	return Action{
+		Service:   "apigateway",
+		Operation: "UpdateStage",
+		Parameters: map[string]interface{}{
+			"RestApiId": restAPIID,
+			"StageName": stageName,
+			"Op":        "replace",
+			"Path":      "/tracingEnabled",
+			"Value":     value,
+		},
+	}
+}
+
+// S3.1: enable every block public access setting on buckets.
+// The rollback restores the previous configuration, or deletes it if the bucket had none.
+func planS3PublicAccessBlock(inv *inventory.Inventory) []Change {
+	var changes []Change
+	for _, bucket := range inv.S3.Buckets {
+		// Without the previous configuration there would be nothing to roll back to
+		if len(bucket.Errors) > 0 {
+			continue
+		}
+		previous := bucket.PublicAccessBlock
+		if previous != nil && previous.BlockPublicAcls && previous.IgnorePublicAcls && previous.BlockPublicPolicy && previous.RestrictPublicBuckets {
+			continue
+		}
+
+		rollback := Action{
+			Service:    "s3",
+			Operation:  "DeletePublicAccessBlock",
+			Parameters: map[string]interface{}{"Bucket": bucket.Name},
+		}
+		if previous != nil {
+			rollback = putPublicAccessBlock(bucket.Name, *previous)
+		}
+
+		changes = append(changes, Change{
+			Control:  "S3.1",
+			Resource: bucket.Name,
+			Action: putPublicAccessBlock(bucket.Name, inventory.PublicAccessBlock{
+				BlockPublicAcls:       true,
+				IgnorePublicAcls:      true,
+				BlockPublicPolicy:     true,
+				RestrictPublicBuckets: true,
+			}),
+			Rollback: rollback,
+		})
+	}
+	return changes
+}
+
+func putPublicAccessBlock(bucket string, config inventory.PublicAccessBlock) Action {
+	return Action{
+		Service:   "s3",
+		Operation: "PutPublicAccessBlock",
+		Parameters: map[string]interface{}{
+			"Bucket":                bucket,
+			"BlockPublicAcls":       config.BlockPublicAcls,
+			"IgnorePublicAcls":      config.IgnorePublicAcls,
+			"BlockPublicPolicy":     config.BlockPublicPolicy,
+			"RestrictPublicBuckets": config.RestrictPublicBuckets,
+		},
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,116 @@
+// remediate/remediate_test.go
+package remediate
+
+import (
+	"reflect"
+	"testing"
+
+	"aws-security-hub/inventory"
+	"aws-security-hub/types"
+)
+
+func TestPlan(t *testing.T) {
+	inv := &inventory.Inventory{
+		APIGateway: &inventory.APIGateway{RestAPIs: []inventory.RestAPI{{
+			ID: "a1",
+			Stages: []inventory.RestStage{
+				{StageName: "prod", TracingEnabled: true},
+				{StageName: "dev"},
+			},
+		}}},
+		DocumentDB: &inventory.DocumentDB{
+			Clusters: []inventory.DocDBCluster{
+				{Identifier: "protected", DeletionProtection: true},
+				{Identifier: "unprotected"},
+			},
+			ClusterSnapshots: []inventory.DocDBClusterSnapshot{
+				{Identifier: "shared", RestoreAttributeValues: []string{"123456789012"}},
+				{Identifier: "public", RestoreAttributeValues: []string{"123456789012", "all"}},
+			},
+		},
+		EC2: &inventory.EC2{Snapshots: []inventory.EBSSnapshot{
+			{ID: "snap-private", CreateVolumePermissions: []inventory.CreateVolumePermission{{UserID: "123456789012"}}},
+			{ID: "snap-public", CreateVolumePermissions: []inventory.CreateVolumePermission{{Group: "all"}}},
+		}},
+		S3: &inventory.S3{Buckets: []inventory.Bucket{
+			{Name: "blocked", PublicAccessBlock: &inventory.PublicAccessBlock{BlockPublicAcls: true, IgnorePublicAcls: true, BlockPublicPolicy: true, RestrictPublicBuckets: true}},
+			{Name: "partial", PublicAccessBlock: &inventory.PublicAccessBlock{BlockPublicAcls: true}},
+			{Name: "none"},
+			{Name: "unknown", Errors: inventory.APIErrors{{Operation: "GetPublicAccessBlock", Code: "AccessDenied"}}},
+		}},
+	}
+
+	blockFor := func(bucket string) Action {
+		return putPublicAccessBlock(bucket, inventory.PublicAccessBlock{BlockPublicAcls: true, IgnorePublicAcls: true, BlockPublicPolicy: true, RestrictPublicBuckets: true})
+	}
+
+	tests := []struct {
+		control string
+		want    []Change
+	}{
+		{"APIGateway.3", []Change{{
+			Control:  "APIGateway.3",
+			Resource: "a1/dev",
+			Action:   updateStageTracing("a1", "dev", "true"),
+			Rollback: updateStageTracing("a1", "dev", "false"),
+		}}},
+		{"DocumentDB.3", []Change{{
+			Control:  "DocumentDB.3",
+			Resource: "public",
+			Action:   modifyDBClusterSnapshotAttribute("public", "ValuesToRemove"),
+			Rollback: modifyDBClusterSnapshotAttribute("public", "ValuesToAdd"),
+		}}},
+		{"DocumentDB.5", []Change{{
+			Control:  "DocumentDB.5",
+			Resource: "unprotected",
+			Action:   modifyDBCluster("unprotected", true),
+			Rollback: modifyDBCluster("unprotected", false),
+		}}},
+		{"EC2.1", []Change{{
+			Control:  "EC2.1",
+			Resource: "snap-public",
+			Action:   modifySnapshotAttribute("snap-public", "remove"),
+			Rollback: modifySnapshotAttribute("snap-public", "add"),
+		}}},
+		// The rollback restores the previous settings, or deletes those of a bucket that had none;
+		// buckets whose settings could not be read are left alone
+		{"S3.1", []Change{
+			{
+				Control:  "S3.1",
+				Resource: "partial",
+				Action:   blockFor("partial"),
+				Rollback: putPublicAccessBlock("partial", inventory.PublicAccessBlock{BlockPublicAcls: true}),
+			},
+			{
+				Control:  "S3.1",
+				Resource: "none",
+				Action:   blockFor("none"),
+				Rollback: Action{Service: "s3", Operation: "DeletePublicAccessBlock", Parameters: map[string]interface{}{"Bucket": "none"}},
+			},
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.control, func(t *testing.T) {
+			remediation, ok := Get(test.control)
+			if !ok {
+				t.Fatalf("no remediation for %s", test.control)
+			}
+			if got := remediation.Plan(inv); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("plan = %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestUnsuppressed(t *testing.T) {
+	changes := []Change{{Control: "S3.1", Resource: "public-on-purpose"}, {Control: "S3.1", Resource: "logs"}}
+	findings := types.Findings{
+		{Resource: "public-on-purpose", Status: "SUPPRESSED"},
+		{Resource: "logs", Status: "FAIL"},
+	}
+
+	want := []Change{{Control: "S3.1", Resource: "logs"}}
+	if got := Unsuppressed(changes, findings); !reflect.DeepEqual(got, want) {
+		t.Errorf("Unsuppressed() = %+v, want %+v", got, want)
+	}
+}
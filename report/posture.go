@@ -0,0 +1,186 @@
+// report/posture.go
+package report
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// SeverityWeights weigh the results of a control by its Severity in the compliance JSON, ignoring
+// case. Controls without a severity (e.g. user-authored rules without attributes) rank as
+// Informational, which leaves them out of the score.
+var SeverityWeights = map[string]float64{
+	"critical": 10,
+	"high":     5,
+	"medium":   2,
+	"low":      1,
+}
+
+// Trends of a score compared with the previous report
+const (
+	TrendUp   = "up"
+	TrendDown = "down"
+	TrendSame = "same"
+)
+
+// Posture scores a report, overall and per service and framework
+type Posture struct {
+	Overall    Score   `json:"Overall"`
+	Services   []Score `json:"Services"`
+	Frameworks []Score `json:"Frameworks,omitempty"`
+}
+
+// Score is the weight of the results that passed over the weight of the results evaluated, from 0 to
+// 100. NA results and results without weight are left out; ERROR results count as not passing, since
+// the resources that could not be evaluated might be non-compliant.
+type Score struct {
+	Name      string   `json:"Name,omitempty"` // service or framework; empty for the overall score
+	Score     float64  `json:"Score"`
+	Passed    int      `json:"Passed"`             // weighted results that passed
+	Evaluated int      `json:"Evaluated"`          // weighted results that passed, failed or errored
+	Previous  *float64 `json:"Previous,omitempty"` // score of the previous report, when it scored the same service or framework
+	Trend     string   `json:"Trend,omitempty"`    // up, down or same compared with Previous
+}
+
+// Arrow returns the trend of the score as an arrow, or "" without a previous score
+func (s Score) Arrow() string {
+	switch s.Trend {
+	case TrendUp:
+		return "↑"
+	case TrendDown:
+		return "↓"
+	case TrendSame:
+		return "→"
+	}
+	return ""
+}
+
+// Change describes the trend of the score, e.g. "↑ +4.2", or "" without a previous score
+func (s Score) Change() string {
+	if s.Previous == nil {
+		return ""
+	}
+	return fmt.Sprintf("%s %+.1f", s.Arrow(), s.Score-*s.Previous)
+}
+
+// tally adds up the results of a service, a framework or the whole report
+type tally struct {
+	passed, evaluated   int
+	passedWeight, total float64
+}
+
+func (t *tally) add(result Result) {
+	if result.Status != "PASS" && result.Status != "FAIL" && result.Status != "ERROR" {
+		return
+	}
+	weight := SeverityWeights[strings.ToLower(result.Severity)]
+	if weight == 0 {
+		return
+	}
+	t.evaluated++
+	t.total += weight
+	if result.Status == "PASS" {
+		t.passed++
+		t.passedWeight += weight
+	}
+}
+
+// score returns the score of the tally, or false when no weighted result was evaluated
+func (t *tally) score(name string) (Score, bool) {
+	if t.total == 0 {
+		return Score{}, false
+	}
+	return Score{Name: name, Score: math.Round(1000*t.passedWeight/t.total) / 10, Passed: t.passed, Evaluated: t.evaluated}, true
+}
+
+// OtherService groups the results of controls without an inventory service, such as user-authored rules
+const OtherService = "other"
+
+// NewPosture scores the results of a report, or returns nil when none of them weighs anything.
+// services returns the inventory service a control evaluates (e.g. documentdb), from its metadata;
+// frameworks, when not nil, returns the frameworks a control maps to. With a previous report, each
+// score gets the trend since then.
+func NewPosture(report *Report, services func(id string) string, frameworks func(id string) []string, previous *Report) *Posture {
+	posture := score(report, services, frameworks)
+	if posture == nil || previous == nil {
+		return posture
+	}
+	before := score(previous, services, frameworks)
+	if before == nil {
+		return posture
+	}
+	trend(&posture.Overall, []Score{before.Overall})
+	for i := range posture.Services {
+		trend(&posture.Services[i], before.Services)
+	}
+	for i := range posture.Frameworks {
+		trend(&posture.Frameworks[i], before.Frameworks)
+	}
+	return posture
+}
+
+func score(report *Report, services func(id string) string, frameworks func(id string) []string) *Posture {
+	var overall tally
+	byService := make(map[string]*tally)
+	byFramework := make(map[string]*tally)
+	for _, result := range report.Results {
+		overall.add(result)
+		service := services(result.ID)
+		if service == "" {
+			service = OtherService
+		}
+		add(byService, service, result)
+		if frameworks != nil {
+			for _, framework := range frameworks(result.ID) {
+				add(byFramework, framework, result)
+			}
+		}
+	}
+
+	score, ok := overall.score("")
+	if !ok {
+		return nil
+	}
+	return &Posture{Overall: score, Services: scores(byService), Frameworks: scores(byFramework)}
+}
+
+func add(tallies map[string]*tally, name string, result Result) {
+	if tallies[name] == nil {
+		tallies[name] = &tally{}
+	}
+	tallies[name].add(result)
+}
+
+// scores returns the scores of the tallies that weigh anything, by name
+func scores(tallies map[string]*tally) []Score {
+	var result []Score
+	for name, t := range tallies {
+		if score, ok := t.score(name); ok {
+			result = append(result, score)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+// trend compares a score with the previous score of the same name
+func trend(current *Score, previous []Score) {
+	for _, before := range previous {
+		if before.Name != current.Name {
+			continue
+		}
+		value := before.Score
+		current.Previous = &value
+		switch {
+		case current.Score > value:
+			current.Trend = TrendUp
+		case current.Score < value:
+			current.Trend = TrendDown
+		default:
+			current.Trend = TrendSame
+		}
+		return
+	}
+}
@@ -0,0 +1,119 @@
+// report/posture_test.go
+package report
+
+import (
+	"reflect"
+	"testing"
+)
+
+// services and frameworks of the controls of the test reports
+var (
+	testServices = map[string]string{
+		"CloudFront.1":     "cloudfront",
+		"CloudFront.3":     "cloudfront",
+		"DocumentDB.1":     "documentdb",
+		"Org.CloudFront.1": "cloudfront", // a control pack control named after its organization
+	}
+	testFrameworks = map[string][]string{
+		"CloudFront.1": {"CIS"},
+		"DocumentDB.1": {"CIS", "PCI"},
+	}
+)
+
+func service(id string) string      { return testServices[id] }
+func frameworks(id string) []string { return testFrameworks[id] }
+
+func TestNewPosture(t *testing.T) {
+	current := &Report{Results: []Result{
+		{ID: "CloudFront.1", Severity: "High", Status: "PASS"},
+		{ID: "CloudFront.3", Severity: "medium", Status: "FAIL"},
+		{ID: "DocumentDB.1", Severity: "Medium", Status: "ERROR"},
+		{ID: "Org.CloudFront.1", Severity: "Low", Status: "PASS"},
+		// Neither informational nor NA results count, in the score or in the counts
+		{ID: "Custom.1", Severity: "Informational", Status: "FAIL"},
+		{ID: "CloudFront.5", Severity: "High", Status: "NA"},
+	}}
+
+	got := NewPosture(current, service, frameworks, nil)
+	want := &Posture{
+		// (5 + 1) / (5 + 2 + 2 + 1)
+		Overall: Score{Score: 60, Passed: 2, Evaluated: 4},
+		Services: []Score{
+			{Name: "cloudfront", Score: 75, Passed: 2, Evaluated: 3},
+			{Name: "documentdb", Score: 0, Passed: 0, Evaluated: 1},
+		},
+		Frameworks: []Score{
+			{Name: "CIS", Score: 71.4, Passed: 1, Evaluated: 2},
+			{Name: "PCI", Score: 0, Passed: 0, Evaluated: 1},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("NewPosture() = %+v, want %+v", got, want)
+	}
+}
+
+func TestNewPostureWithoutWeightedResults(t *testing.T) {
+	current := &Report{Results: []Result{{ID: "Custom.1", Status: "FAIL"}, {ID: "CloudFront.1", Severity: "High", Status: "NA"}}}
+	if got := NewPosture(current, service, frameworks, nil); got != nil {
+		t.Errorf("NewPosture() = %+v, want nil", got)
+	}
+}
+
+func TestNewPostureGroupsControlsWithoutServiceAsOther(t *testing.T) {
+	current := &Report{Results: []Result{{ID: "Custom.1", Severity: "Low", Status: "PASS"}}}
+	got := NewPosture(current, service, nil, nil)
+	if want := []Score{{Name: OtherService, Score: 100, Passed: 1, Evaluated: 1}}; !reflect.DeepEqual(got.Services, want) {
+		t.Errorf("services = %+v, want %+v", got.Services, want)
+	}
+}
+
+func TestNewPostureTrend(t *testing.T) {
+	previous := &Report{Results: []Result{
+		{ID: "CloudFront.1", Severity: "High", Status: "FAIL"},
+		{ID: "CloudFront.3", Severity: "Medium", Status: "PASS"},
+		{ID: "DocumentDB.1", Severity: "Medium", Status: "FAIL"},
+	}}
+	current := &Report{Results: []Result{
+		{ID: "CloudFront.1", Severity: "High", Status: "PASS"},
+		{ID: "CloudFront.3", Severity: "Medium", Status: "FAIL"},
+		{ID: "DocumentDB.1", Severity: "Medium", Status: "FAIL"},
+	}}
+
+	got := NewPosture(current, service, frameworks, previous)
+
+	tests := []struct {
+		name     string
+		score    Score
+		previous float64
+		trend    string
+	}{
+		{"overall", got.Overall, 22.2, TrendUp},
+		{"cloudfront", got.Services[0], 28.6, TrendUp},
+		{"documentdb", got.Services[1], 0, TrendSame},
+		{"CIS", got.Frameworks[0], 0, TrendUp},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.score.Previous == nil || *test.score.Previous != test.previous || test.score.Trend != test.trend {
+				t.Errorf("score = %+v, want previous %v and trend %s", test.score, test.previous, test.trend)
+			}
+		})
+	}
+	if change := got.Overall.Change(); change != "↑ +33.4" {
+		t.Errorf("Change() = %q, want ↑ +33.4", change)
+	}
+}
+
+func TestNewPostureWithoutPreviousScore(t *testing.T) {
+	// A framework the previous report did not score has no trend
+	previous := &Report{Results: []Result{{ID: "CloudFront.3", Severity: "Medium", Status: "PASS"}}}
+	current := &Report{Results: []Result{{ID: "CloudFront.1", Severity: "High", Status: "PASS"}}}
+
+	got := NewPosture(current, service, frameworks, previous)
+	if got.Frameworks[0].Previous != nil || got.Frameworks[0].Trend != "" || got.Frameworks[0].Arrow() != "" {
+		t.Errorf("framework = %+v, want no trend", got.Frameworks[0])
+	}
+	if got.Overall.Trend != TrendSame {
+		t.Errorf("overall trend = %s, want %s", got.Overall.Trend, TrendSame)
+	}
+}
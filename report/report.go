@@ -0,0 +1,165 @@
+// report/report.go
+package report
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"time"
+
+	"aws-security-hub/compliance"
+	"aws-security-hub/inventory"
+	"aws-security-hub/logging"
+	"aws-security-hub/snippets"
+	"aws-security-hub/throttle"
+	"aws-security-hub/types"
+	"aws-security-hub/util"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+// Formats lists the supported report formats. "console" is a plain-text summary for terminals.
+var Formats = []string{"console", "json", "html"}
+
+// Result is the outcome of one control
+type Result struct {
+	ID          string             `json:"ID"`
+	Source      string             `json:"Source"` // inventory snapshot, template or plan the control ran against
+	Description string             `json:"Description"`
+	Severity    string             `json:"Severity"`
+	Status      string             `json:"Status"`
+	Account     string             `json:"Account,omitempty"`
+	Region      string             `json:"Region,omitempty"`
+	Duration    float64            `json:"Duration"` // seconds spent evaluating the control
+	Findings    types.Findings     `json:"Findings,omitempty"`
+	Snippets    []snippets.Snippet `json:"Snippets,omitempty"` // fixes for failing resources, when available
+}
+
+// Report is the outcome of a run of controls against one or more inventories
+type Report struct {
+	GeneratedAt time.Time         `json:"GeneratedAt"`
+	Results     []Result          `json:"Results"`
+	API         *throttle.Summary `json:"API,omitempty"`     // calls, retries and throttling errors of the run, when it called AWS
+	Posture     *Posture          `json:"Posture,omitempty"` // severity-weighted score of the results, set before writing
+}
+
+// Options controls what a run adds to its results
+type Options struct {
+	Snippets     bool                // attach infrastructure-as-code fixes to failing results
+	Suppressions []types.Suppression // accepted failures, reported as SUPPRESSED findings
+	Progress     func(Result)        // called with each result as its control completes, e.g. by audit tui
+	AWS          *aws.Config         // account and region scanned, for controls that call AWS themselves; nil offline
+}
+
+// Run evaluates controls against an inventory, logging each status as it completes. ctx is handed to
+// every control; once it is done the remaining controls are skipped, so the report only holds the
+// controls that completed. The first control always runs, so that a control whose own timeout ran
+// out while collecting its resources still reports their errors.
+func Run(ctx context.Context, inv *inventory.Inventory, source string, controls []types.Control, options Options) *Report {
+	requirements := compliance.SecurityHub()
+
+	report := &Report{GeneratedAt: time.Now().UTC()}
+	for i, control := range controls {
+		if i > 0 && ctx.Err() != nil {
+			slog.Warn("controls not evaluated", "count", len(controls)-i, "cause", context.Cause(ctx))
+			break
+		}
+		started := time.Now()
+		id := control.Metadata().ID
+		status, findings := types.Resolve(control.Evaluate(ctx, types.Clients{Inventory: inv, AWS: options.AWS}))
+		status, findings = types.Suppress(id, status, findings, options.Suppressions)
+		logger := logging.Control(id, inv)
+		if errored := findings.Errored(); len(errored) > 0 {
+			logger.Warn("resources could not be evaluated", "count", len(errored))
+		}
+		logger.Info("control evaluated", "status", status, "duration", time.Since(started))
+
+		result := Result{
+			ID:       id,
+			Source:   source,
+			Status:   status,
+			Account:  inv.AccountID,
+			Region:   inv.Region,
+			Duration: time.Since(started).Seconds(),
+			Findings: findings,
+		}
+		if requirement := Requirement(requirements, control); requirement != nil {
+			result.Description = requirement.Description
+			if len(requirement.Attributes) > 0 {
+				result.Severity = requirement.Attributes[0].Severity
+			}
+		}
+		if options.Snippets && status == "FAIL" {
+			result.Snippets = snippets.Generate(id, inv, findings)
+		}
+		report.Results = append(report.Results, result)
+		if options.Progress != nil {
+			options.Progress(result)
+		}
+	}
+	return report
+}
+
+// Target is the inventory of one account and region, with the configuration of that account and
+// region for the controls that call AWS themselves
+type Target struct {
+	Inventory *inventory.Inventory
+	AWS       *aws.Config
+}
+
+// RunTargets runs the controls against every target in turn and merges their reports
+func RunTargets(ctx context.Context, targets []Target, source string, controls []types.Control, options Options) *Report {
+	report := &Report{GeneratedAt: time.Now().UTC()}
+	for i, target := range targets {
+		if i > 0 && ctx.Err() != nil {
+			break
+		}
+		options.AWS = target.AWS
+		report.Add(Run(ctx, target.Inventory, source, controls, options))
+	}
+	return report
+}
+
+// Requirement returns the metadata of a control: its own for user-authored rules,
+// otherwise the matching requirement of the compliance JSON, or nil
+func Requirement(compliance *util.Compliance, control types.Control) *util.Requirement {
+	metadata := control.Metadata()
+	if metadata.Requirement != nil {
+		return metadata.Requirement
+	}
+	for i := range compliance.Requirements {
+		if compliance.Requirements[i].Id == metadata.ID {
+			return &compliance.Requirements[i]
+		}
+	}
+	return nil
+}
+
+// Add appends the results of another run
+func (r *Report) Add(other *Report) {
+	r.Results = append(r.Results, other.Results...)
+}
+
+// Failed reports whether any control failed
+func (r *Report) Failed() bool {
+	for _, result := range r.Results {
+		if result.Status == "FAIL" {
+			return true
+		}
+	}
+	return false
+}
+
+// Write renders the report in the given format
+func Write(w io.Writer, format string, report *Report) error {
+	switch format {
+	case "console":
+		return writeConsole(w, report)
+	case "json":
+		return writeJSON(w, report)
+	case "html":
+		return writeHTML(w, report)
+	}
+	return fmt.Errorf("unsupported format %q (supported: %v)", format, Formats)
+}
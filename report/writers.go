@@ -0,0 +1,134 @@
+// report/writers.go
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// writeConsole renders one line per control, its failing and errored resources under it, then
+// the number of controls of each status and the posture score
+func writeConsole(w io.Writer, report *Report) error {
+	counts := make(map[string]int)
+	for _, result := range report.Results {
+		counts[result.Status]++
+		var where []string
+		for _, value := range []string{result.Account, result.Region} {
+			if value != "" {
+				where = append(where, value)
+			}
+		}
+		line := fmt.Sprintf("[%s] %s", result.ID, result.Status)
+		if result.Description != "" {
+			line += " " + result.Description
+		}
+		if len(where) > 0 {
+			line += " (" + strings.Join(where, "/") + ")"
+		}
+		fmt.Fprintln(w, line)
+		for _, finding := range result.Findings {
+			if finding.Status == "FAIL" || finding.Status == "ERROR" {
+				fmt.Fprintf(w, "  └─[%s] %s: %s\n", finding.Status, finding.Resource, finding.Reason)
+			}
+		}
+	}
+	_, err := fmt.Fprintf(w, "\n%d control(s): %d PASS, %d FAIL, %d ERROR, %d NA\n",
+		len(report.Results), counts["PASS"], counts["FAIL"], counts["ERROR"], counts["NA"])
+	if err != nil {
+		return err
+	}
+	if report.API != nil {
+		fmt.Fprintf(w, "AWS API: %s\n", report.API)
+	}
+	if report.Posture == nil {
+		return nil
+	}
+
+	posture := report.Posture
+	fmt.Fprintf(w, "Posture score: %s\n", formatScore(posture.Overall))
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, group := range []struct {
+		kind   string
+		scores []Score
+	}{{"service", posture.Services}, {"framework", posture.Frameworks}} {
+		for _, score := range group.scores {
+			fmt.Fprintf(table, "  %s %s:\t%s\n", group.kind, score.Name, formatScore(score))
+		}
+	}
+	return table.Flush()
+}
+
+// formatScore renders a score with its trend, e.g. "72.5/100 ↑ +4.2 (3/4 passed)"
+func formatScore(score Score) string {
+	text := fmt.Sprintf("%.1f/100", score.Score)
+	if change := score.Change(); change != "" {
+		text += " " + change
+	}
+	return text + fmt.Sprintf(" (%d/%d passed)", score.Passed, score.Evaluated)
+}
+
+func writeJSON(w io.Writer, report *Report) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "    ")
+	return encoder.Encode(report)
+}
+
+var htmlTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>AWS Security Hub audit report</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; width: 100%; }
+th, td { border: 1px solid #ddd; padding: 6px 10px; text-align: left; vertical-align: top; }
+th { background: #f4f4f4; }
+.PASS { color: #1a7f37; font-weight: bold; }
+.FAIL { color: #cf222e; font-weight: bold; }
+.NA { color: #6e7781; }
+.ERROR { color: #9a6700; font-weight: bold; }
+pre { background: #f6f8fa; padding: 8px; margin: 4px 0; }
+.posture { width: auto; margin-bottom: 1.5em; }
+.up { color: #1a7f37; }
+.down { color: #cf222e; }
+details { margin: 4px 0; }
+</style>
+</head>
+<body>
+<h1>AWS Security Hub audit report</h1>
+<p>Generated at: {{.GeneratedAt.Format "2006-01-02 15:04:05 MST"}}</p>{{with .API}}
+<p>AWS API: {{.}}</p>{{end}}{{with .Posture}}
+<h2>Posture score: {{printf "%.1f" .Overall.Score}}/100 <span class="{{.Overall.Trend}}">{{.Overall.Change}}</span></h2>
+<table class="posture">
+<tr><th>Scope</th><th>Score</th><th>Trend</th><th>Passed</th></tr>
+<tr><td>Overall</td><td>{{printf "%.1f" .Overall.Score}}</td><td class="{{.Overall.Trend}}">{{.Overall.Change}}</td><td>{{.Overall.Passed}}/{{.Overall.Evaluated}}</td></tr>
+{{range .Services}}<tr><td>Service {{.Name}}</td><td>{{printf "%.1f" .Score}}</td><td class="{{.Trend}}">{{.Change}}</td><td>{{.Passed}}/{{.Evaluated}}</td></tr>
+{{end}}{{range .Frameworks}}<tr><td>Framework {{.Name}}</td><td>{{printf "%.1f" .Score}}</td><td class="{{.Trend}}">{{.Change}}</td><td>{{.Passed}}/{{.Evaluated}}</td></tr>
+{{end}}</table>{{end}}
+<table>
+<tr><th>Control</th><th>Source</th><th>Description</th><th>Severity</th><th>Status</th></tr>
+{{range .Results}}<tr>
+<td>{{.ID}}</td>
+<td>{{.Source}}</td>
+<td>{{.Description}}{{with .Findings.Failed}}
+<ul>{{range .}}<li>{{.Resource}}: {{.Reason}}</li>{{end}}</ul>{{end}}{{with .Findings.Errored}}
+<ul class="ERROR">{{range .}}<li>{{.Resource}}: {{.Reason}}</li>{{end}}</ul>{{end}}{{range .Snippets}}
+<details><summary>Fix for {{.Resource}}</summary>
+<p>Terraform</p><pre>{{.Terraform}}</pre>
+<p>CloudFormation</p><pre>{{.CloudFormation}}</pre>
+</details>{{end}}</td>
+<td>{{.Severity}}</td>
+<td class="{{.Status}}">{{.Status}}</td>
+</tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+func writeHTML(w io.Writer, report *Report) error {
+	return htmlTemplate.Execute(w, report)
+}
@@ -0,0 +1,37 @@
+// report/writers_test.go
+package report
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"aws-security-hub/throttle"
+)
+
+func TestWriteConsoleFooter(t *testing.T) {
+	api := &throttle.Summary{
+		Counts:   throttle.Counts{Calls: 12, Retries: 3, Throttles: 2},
+		Services: map[string]throttle.Counts{"CloudFront": {Calls: 4, Retries: 3, Throttles: 2}},
+	}
+	tests := []struct {
+		name   string
+		api    *throttle.Summary
+		footer string
+	}{
+		{"with API calls", api, "1 control(s): 1 PASS, 0 FAIL, 0 ERROR, 0 NA\nAWS API: 12 API call(s), 3 retried attempt(s), 2 throttled (calls/retries/throttled CloudFront: 4/3/2)\n"},
+		{"offline", nil, "1 control(s): 1 PASS, 0 FAIL, 0 ERROR, 0 NA\n"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var out bytes.Buffer
+			report := &Report{Results: []Result{{ID: "CloudFront.1", Status: "PASS"}}, API: test.api}
+			if err := writeConsole(&out, report); err != nil {
+				t.Fatal(err)
+			}
+			if !strings.HasSuffix(out.String(), "\n\n"+test.footer) {
+				t.Errorf("output = %q, want it to end with %q", out.String(), test.footer)
+			}
+		})
+	}
+}
@@ -0,0 +1,208 @@
+// rules/evaluate.go
+package rules
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"aws-security-hub/inventory"
+	"aws-security-hub/logging"
+	"aws-security-hub/types"
+	"aws-security-hub/util"
+)
+
+// Evaluate applies the rule to every selected resource. It returns FAIL when the condition does not
+// hold for a resource, and NA when the service was not collected or no resources were selected. Failed
+// API calls are never mistaken for non-compliance: a service that failed to collect, a resource whose
+// own calls failed, a service or parent resource whose calls failed (since the selection may then be
+// incomplete) and a condition that cannot be evaluated are recorded as errors, which make the rule ERROR
+// unless another resource fails.
+func (r Rule) Evaluate(inv *inventory.Inventory) (string, types.Findings) {
+	var findings types.Findings
+	logger := logging.Control(r.Requirement.Id, inv)
+	util.LogRequirementInfo(logger, r.Requirement)
+
+	document, err := normalize(inv)
+	if err != nil {
+		logger.Error("failed to normalize inventory", "error", err)
+		return "NA", findings
+	}
+
+	if r.Resource == "" {
+		return types.Resolve(r.result(logger, r.check(logger, document, nil, "inventory", &findings), 1), findings)
+	}
+
+	resources, parents, ok := selectPath(document, r.Resource)
+	if !ok {
+		service, _, _ := strings.Cut(r.Resource, ".")
+		return types.NotCollected(logger, inv, strings.ToLower(service), &findings), findings
+	}
+	for i, parent := range parents {
+		name := r.parentName(parent, i)
+		for _, apiErr := range apiErrors(parent) {
+			logger.Error("resources may be missing", "resource", name, "error", apiErr)
+			findings.Error(name, &apiErr)
+		}
+	}
+	if len(resources) == 0 {
+		logger.Info("no resources found", "path", r.Resource)
+		return types.Resolve("NA", findings)
+	}
+
+	failed := 0
+	for i, resource := range resources {
+		name := r.resourceName(document, resource, i)
+		if errs := apiErrors(resource); len(errs) > 0 {
+			logger.Warn("resource not fully collected", "resource", name, "error", errs[0])
+			findings.Error(name, &errs[0])
+			continue
+		}
+		if !r.check(logger, document, resource, name, &findings) {
+			failed++
+		}
+	}
+	return types.Resolve(r.result(logger, failed == 0, len(resources)-failed), findings)
+}
+
+// apiErrors returns the failed API calls recorded on a node of the inventory, e.g. on a distribution
+// whose GetDistribution call was denied
+func apiErrors(node interface{}) []inventory.APIError {
+	fields, ok := node.(map[string]interface{})
+	if !ok || fields["Errors"] == nil {
+		return nil
+	}
+	bytes, err := json.Marshal(fields["Errors"])
+	if err != nil {
+		return nil
+	}
+	var errs []inventory.APIError
+	if err := json.Unmarshal(bytes, &errs); err != nil {
+		return nil
+	}
+	return errs
+}
+
+// parentName names a node along the resource path: the service for the first one
+func (r Rule) parentName(node interface{}, index int) string {
+	if index == 0 {
+		service, _, _ := strings.Cut(r.Resource, ".")
+		return strings.ToLower(service)
+	}
+	if fields, ok := node.(map[string]interface{}); ok {
+		for _, key := range []string{"ID", "Identifier", "Name", "StageName"} {
+			if value, ok := fields[key].(string); ok && value != "" {
+				return value
+			}
+		}
+	}
+	return fmt.Sprintf("%s parent %d", r.Resource, index)
+}
+
+// check evaluates the condition for a resource, returning false only when it does not hold
+func (r Rule) check(logger *slog.Logger, document, resource interface{}, name string, findings *types.Findings) bool {
+	logger = logger.With("resource", name)
+	logger.Debug("checking resource")
+
+	output, _, err := r.condition.Eval(map[string]interface{}{
+		"resource":  resource,
+		"inventory": document,
+	})
+	if err != nil {
+		logger.Warn("condition could not be evaluated", "status", "ERROR", "error", err)
+		findings.Error(name, &inventory.APIError{Code: types.ConditionErrorCode, Message: fmt.Sprintf("condition could not be evaluated: %v", err)})
+		return true
+	}
+
+	passed, ok := output.Value().(bool)
+	if !ok {
+		logger.Warn("condition did not return a bool", "status", "ERROR", "value", fmt.Sprint(output.Value()))
+		findings.Error(name, &inventory.APIError{Code: types.ConditionErrorCode, Message: fmt.Sprintf("condition returned %v instead of a bool", output.Value())})
+		return true
+	}
+	if !passed {
+		logger.Info("condition not satisfied", "status", "FAIL", "condition", r.Condition)
+		findings.Fail(name, "Does not satisfy "+r.Condition)
+		return false
+	}
+	logger.Debug("condition satisfied", "status", "PASS", "condition", r.Condition)
+	findings.Pass(name, "Satisfies "+r.Condition)
+	return true
+}
+
+func (r Rule) result(logger *slog.Logger, passed bool, passedCount int) string {
+	if !passed {
+		logger.Debug("one or more resources do not satisfy the rule", "file", r.File)
+		return "FAIL"
+	}
+	logger.Debug("resources satisfy the rule", "count", passedCount)
+	return "PASS"
+}
+
+// resourceName evaluates the Name expression, or falls back to a common identifier field
+func (r Rule) resourceName(document, resource interface{}, index int) string {
+	if r.name != nil {
+		output, _, err := r.name.Eval(map[string]interface{}{"resource": resource, "inventory": document})
+		if err == nil {
+			return fmt.Sprint(output.Value())
+		}
+	}
+
+	if fields, ok := resource.(map[string]interface{}); ok {
+		for _, key := range []string{"ID", "Identifier", "Name", "StageName", "RouteKey"} {
+			if value, ok := fields[key].(string); ok && value != "" {
+				return value
+			}
+		}
+	}
+	return fmt.Sprintf("%s[%d]", r.Resource, index)
+}
+
+// normalize converts the inventory to the generic form seen by expressions, using its JSON field names
+func normalize(inv *inventory.Inventory) (interface{}, error) {
+	bytes, err := json.Marshal(inv)
+	if err != nil {
+		return nil, err
+	}
+
+	var document interface{}
+	if err := json.Unmarshal(bytes, &document); err != nil {
+		return nil, err
+	}
+	return document, nil
+}
+
+// selectPath follows a dot-separated path, flattening lists along the way. It returns the selected
+// nodes and the nodes passed through, starting with the service section, and reports false when the
+// service section the path starts with was not collected.
+func selectPath(document interface{}, path string) ([]interface{}, []interface{}, bool) {
+	nodes := []interface{}{document}
+	var parents []interface{}
+	for depth, key := range strings.Split(path, ".") {
+		if depth > 0 {
+			parents = append(parents, nodes...)
+		}
+		var next []interface{}
+		for _, node := range nodes {
+			fields, ok := node.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			value := fields[key]
+			if value == nil {
+				if depth == 0 {
+					return nil, nil, false
+				}
+				continue
+			}
+			if list, ok := value.([]interface{}); ok {
+				next = append(next, list...)
+			} else {
+				next = append(next, value)
+			}
+		}
+		nodes = next
+	}
+	return nodes, parents, true
+}
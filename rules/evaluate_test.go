@@ -0,0 +1,107 @@
+// rules/evaluate_test.go
+package rules
+
+import (
+	"testing"
+
+	"aws-security-hub/inventory"
+	"aws-security-hub/util"
+)
+
+func rule(t *testing.T, resource, condition string) Rule {
+	t.Helper()
+	r := Rule{Requirement: util.Requirement{Id: "Test.1"}, Resource: resource, Condition: condition}
+	if err := r.compile(); err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func TestEvaluateErrors(t *testing.T) {
+	denied := inventory.APIError{Operation: "GetDistribution", Code: "AccessDenied", Message: "denied"}
+	logging := "resource.Logging.Enabled"
+	tests := []struct {
+		name      string
+		resource  string
+		condition string
+		inv       *inventory.Inventory
+		status    string
+		statuses  map[string]string // by resource
+	}{
+		{
+			name:      "service failed to collect",
+			resource:  "CloudFront.Distributions",
+			condition: logging,
+			inv:       &inventory.Inventory{Errors: map[string]inventory.APIError{inventory.ServiceCloudFront: denied}},
+			status:    "ERROR",
+			statuses:  map[string]string{"cloudfront": "ERROR"},
+		},
+		{
+			name:      "service not collected",
+			resource:  "CloudFront.Distributions",
+			condition: logging,
+			inv:       &inventory.Inventory{},
+			status:    "NA",
+		},
+		{
+			name:      "resource with failed calls",
+			resource:  "CloudFront.Distributions",
+			condition: logging,
+			inv: &inventory.Inventory{CloudFront: &inventory.CloudFront{Distributions: []inventory.Distribution{
+				{ID: "denied", Errors: inventory.APIErrors{denied}},
+				{ID: "logged", Logging: inventory.Logging{Enabled: true}},
+			}}},
+			status:   "ERROR",
+			statuses: map[string]string{"denied": "ERROR", "logged": "PASS"},
+		},
+		{
+			name:      "failure outranks errors",
+			resource:  "CloudFront.Distributions",
+			condition: logging,
+			inv: &inventory.Inventory{CloudFront: &inventory.CloudFront{Distributions: []inventory.Distribution{
+				{ID: "denied", Errors: inventory.APIErrors{denied}},
+				{ID: "unlogged"},
+			}}},
+			status:   "FAIL",
+			statuses: map[string]string{"denied": "ERROR", "unlogged": "FAIL"},
+		},
+		{
+			name:      "condition error",
+			resource:  "CloudFront.Distributions",
+			condition: "resource.Missing.Field == 1",
+			inv: &inventory.Inventory{CloudFront: &inventory.CloudFront{Distributions: []inventory.Distribution{
+				{ID: "d1"},
+			}}},
+			status:   "ERROR",
+			statuses: map[string]string{"d1": "ERROR"},
+		},
+		{
+			name:      "listing failed partway",
+			resource:  "DocumentDB.Clusters",
+			condition: "resource.StorageEncrypted",
+			inv: &inventory.Inventory{DocumentDB: &inventory.DocumentDB{
+				Clusters: []inventory.DocDBCluster{{Identifier: "c1", StorageEncrypted: true}},
+				Errors:   inventory.APIErrors{{Operation: "DescribeDBClusters", Code: "Throttling"}},
+			}},
+			status:   "ERROR",
+			statuses: map[string]string{"documentdb": "ERROR", "c1": "PASS"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			status, findings := rule(t, test.resource, test.condition).Evaluate(test.inv)
+			if status != test.status {
+				t.Errorf("status = %s, want %s", status, test.status)
+			}
+			if len(findings) != len(test.statuses) {
+				t.Errorf("findings = %+v, want %v", findings, test.statuses)
+			}
+			for _, finding := range findings {
+				if want := test.statuses[finding.Resource]; finding.Status != want {
+					t.Errorf("%s = %s, want %s", finding.Resource, finding.Status, want)
+				}
+			}
+		})
+	}
+}
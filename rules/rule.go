@@ -0,0 +1,191 @@
+// rules/rule.go
+package rules
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"aws-security-hub/types"
+	"aws-security-hub/util"
+
+	"github.com/google/cel-go/cel"
+	"gopkg.in/yaml.v3"
+)
+
+// Rule is a user-authored control. Its metadata uses the fields of a compliance JSON requirement;
+// Condition is a CEL expression that must hold for every resource selected by Resource.
+type Rule struct {
+	util.Requirement `yaml:",inline"`
+
+	// Resource is a dot-separated path into the inventory (e.g. CloudFront.Distributions).
+	// Lists along the path are flattened. When empty, Condition is evaluated once against the inventory.
+	Resource string `json:"Resource" yaml:"Resource"`
+	// Condition is evaluated with `resource` bound to each selected resource and `inventory` to the
+	// whole inventory, using the JSON field names of the inventory snapshot
+	Condition string `json:"Condition" yaml:"Condition"`
+	// Name optionally overrides how a resource is reported (e.g. resource.ID + " " + resource.ARN)
+	Name string `json:"Name" yaml:"Name"`
+
+	File      string `json:"-" yaml:"-"`
+	condition cel.Program
+	name      cel.Program
+}
+
+var env *cel.Env
+
+func init() {
+	var err error
+	env, err = cel.NewEnv(
+		cel.Variable("resource", cel.DynType),
+		cel.Variable("inventory", cel.DynType),
+		cel.CrossTypeNumericComparisons(true),
+	)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// Load reads every .yaml, .yml and .json rule file in a directory and compiles its expressions.
+// A file may hold a single rule or a list of rules.
+func Load(dir string) ([]Rule, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rules directory: %v", err)
+	}
+
+	var files []string
+	for _, entry := range entries {
+		switch strings.ToLower(filepath.Ext(entry.Name())) {
+		case ".yaml", ".yml", ".json":
+			if !entry.IsDir() {
+				files = append(files, filepath.Join(dir, entry.Name()))
+			}
+		}
+	}
+	sort.Strings(files)
+
+	var rules []Rule
+	seen := make(map[string]string)
+	for _, file := range files {
+		loaded, err := loadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", file, err)
+		}
+		for _, rule := range loaded {
+			if previous, ok := seen[rule.Id]; ok {
+				return nil, fmt.Errorf("%s: rule %s is already defined in %s", file, rule.Id, previous)
+			}
+			seen[rule.Id] = file
+			rules = append(rules, rule)
+		}
+	}
+
+	return rules, nil
+}
+
+func loadFile(file string) ([]Rule, error) {
+	bytes, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rule file: %v", err)
+	}
+
+	var rules []Rule
+	if strings.EqualFold(filepath.Ext(file), ".json") {
+		if trimmed := strings.TrimSpace(string(bytes)); strings.HasPrefix(trimmed, "[") {
+			err = json.Unmarshal(bytes, &rules)
+		} else {
+			var rule Rule
+			err = json.Unmarshal(bytes, &rule)
+			rules = append(rules, rule)
+		}
+	} else {
+		var document yaml.Node
+		if err := yaml.Unmarshal(bytes, &document); err != nil {
+			return nil, fmt.Errorf("failed to parse rule file: %v", err)
+		}
+		if len(document.Content) == 0 {
+			return nil, nil
+		}
+		if document.Content[0].Kind == yaml.SequenceNode {
+			err = document.Content[0].Decode(&rules)
+		} else {
+			var rule Rule
+			err = document.Content[0].Decode(&rule)
+			rules = append(rules, rule)
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode rule: %v", err)
+	}
+
+	for i := range rules {
+		rules[i].File = file
+		if err := rules[i].compile(); err != nil {
+			return nil, err
+		}
+	}
+	return rules, nil
+}
+
+func (r *Rule) compile() error {
+	if r.Id == "" {
+		return fmt.Errorf("rule is missing Id")
+	}
+	if r.Condition == "" {
+		return fmt.Errorf("rule %s is missing Condition", r.Id)
+	}
+
+	program, err := compileExpression(r.Condition, true)
+	if err != nil {
+		return fmt.Errorf("rule %s: invalid Condition: %v", r.Id, err)
+	}
+	r.condition = program
+
+	if r.Name != "" {
+		program, err := compileExpression(r.Name, false)
+		if err != nil {
+			return fmt.Errorf("rule %s: invalid Name: %v", r.Id, err)
+		}
+		r.name = program
+	}
+	return nil
+}
+
+func compileExpression(expression string, boolean bool) (cel.Program, error) {
+	ast, issues := env.Compile(expression)
+	if issues != nil && issues.Err() != nil {
+		return nil, issues.Err()
+	}
+	if boolean && ast.OutputType() != cel.BoolType && ast.OutputType() != cel.DynType {
+		return nil, fmt.Errorf("expression must evaluate to a bool, got %s", ast.OutputType())
+	}
+	return env.Program(ast)
+}
+
+// Control adapts the rule to the runner used for built-in controls
+func (r Rule) Control() types.Control {
+	check := r.Id
+	if len(r.Checks) > 0 {
+		check = r.Checks[0]
+	}
+	requirement := r.Requirement
+	return types.InventoryControl{ID: r.Id, Check: check, Func: r.Evaluate, Requirement: &requirement}
+}
+
+// Controls loads the rules in a directory as controls
+func Controls(dir string) ([]types.Control, error) {
+	rules, err := Load(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var controls []types.Control
+	for _, rule := range rules {
+		controls = append(controls, rule.Control())
+	}
+	return controls, nil
+}
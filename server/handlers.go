@@ -0,0 +1,151 @@
+// server/handlers.go
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"aws-security-hub/audit"
+	"aws-security-hub/compliance"
+	"aws-security-hub/report"
+)
+
+// ControlInfo describes a control available for scanning
+type ControlInfo struct {
+	ID          string `json:"ID"`
+	Check       string `json:"Check"`
+	Service     string `json:"Service"`
+	Description string `json:"Description"`
+	Section     string `json:"Section"`
+	Severity    string `json:"Severity"`
+}
+
+// Handler returns the REST API:
+//
+//	GET  /controls                    list controls
+//	POST /scans                       queue a scan, body: {"Controls": [...], "Services": [...]}
+//	GET  /scans                       list scans
+//	GET  /scans/{id}                  poll a scan
+//	GET  /scans/{id}/results?format=  fetch the report of a finished scan (console, json or html)
+//	GET  /metrics                     Prometheus metrics of the finished scans, when enabled
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /controls", s.handleControls)
+	mux.HandleFunc("POST /scans", s.handleSubmit)
+	mux.HandleFunc("GET /scans", s.handleList)
+	mux.HandleFunc("GET /scans/{id}", s.handleGet)
+	mux.HandleFunc("GET /scans/{id}/results", s.handleResults)
+	if s.options.Metrics != nil {
+		mux.Handle("GET /metrics", s.options.Metrics.Handler())
+	}
+	return mux
+}
+
+func (s *Server) handleControls(w http.ResponseWriter, r *http.Request) {
+	controls := []ControlInfo{}
+	for _, control := range s.controls() {
+		metadata := control.Metadata()
+		info := ControlInfo{ID: metadata.ID, Check: metadata.Check, Service: audit.ControlService(control)}
+		if requirement := report.Requirement(compliance.SecurityHub(), control); requirement != nil {
+			info.Description = requirement.Description
+			if len(requirement.Attributes) > 0 {
+				info.Section = requirement.Attributes[0].Section
+				info.Severity = requirement.Attributes[0].Severity
+			}
+		}
+		controls = append(controls, info)
+	}
+	writeJSON(w, http.StatusOK, controls)
+}
+
+func (s *Server) handleSubmit(w http.ResponseWriter, r *http.Request) {
+	var request Request
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	scan, err := s.Submit(request)
+	if errors.Is(err, ErrQueueFull) {
+		writeError(w, http.StatusServiceUnavailable, err)
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	w.Header().Set("Location", "/scans/"+scan.ID)
+	writeJSON(w, http.StatusAccepted, scan)
+}
+
+func (s *Server) handleList(w http.ResponseWriter, r *http.Request) {
+	scans := s.List()
+	if scans == nil {
+		scans = []*Scan{}
+	}
+	writeJSON(w, http.StatusOK, scans)
+}
+
+func (s *Server) handleGet(w http.ResponseWriter, r *http.Request) {
+	scan, _, ok := s.Get(r.PathValue("id"))
+	if !ok {
+		writeError(w, http.StatusNotFound, errors.New("scan not found"))
+		return
+	}
+	writeJSON(w, http.StatusOK, scan)
+}
+
+// contentTypes are the content types of the report formats
+var contentTypes = map[string]string{
+	"console": "text/plain; charset=utf-8",
+	"json":    "application/json",
+	"html":    "text/html; charset=utf-8",
+}
+
+func (s *Server) handleResults(w http.ResponseWriter, r *http.Request) {
+	scan, result, ok := s.Get(r.PathValue("id"))
+	if !ok {
+		writeError(w, http.StatusNotFound, errors.New("scan not found"))
+		return
+	}
+	if result == nil {
+		writeError(w, http.StatusConflict, errors.New("scan is "+scan.Status))
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "json"
+	}
+	contentType, ok := contentTypes[format]
+	if !ok {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("unsupported format %s (supported: %s)", format, strings.Join(report.Formats, ", ")))
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+
+	if err := report.Write(w, format, result); err != nil {
+		slog.Error("failed to write scan results", "scan", scan.ID, "error", err)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "    ")
+	if err := encoder.Encode(value); err != nil {
+		slog.Error("failed to write response", "error", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"Error": err.Error()})
+}
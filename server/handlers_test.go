@@ -0,0 +1,89 @@
+// server/handlers_test.go
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"aws-security-hub/inventory"
+	"aws-security-hub/report"
+	"aws-security-hub/types"
+	"aws-security-hub/util"
+)
+
+func TestHandleResultsFormatsAndSuppressions(t *testing.T) {
+	control := types.InventoryControl{
+		ID:      "Example.1",
+		Check:   "example",
+		Service: inventory.ServiceDocumentDB,
+		Func: func(inv *inventory.Inventory) (string, types.Findings) {
+			var findings types.Findings
+			findings.Fail("orders", "Storage is not encrypted")
+			return "FAIL", findings
+		},
+		Requirement: &util.Requirement{Id: "Example.1", Description: "Example control", Checks: []string{"example"}},
+	}
+	collect := func(ctx context.Context, services []string) ([]report.Target, error) {
+		return []report.Target{{Inventory: &inventory.Inventory{}}}, nil
+	}
+	srv := New(func() []types.Control { return []types.Control{control} }, collect, Options{
+		Report: report.Options{Suppressions: []types.Suppression{{Control: "Example.1", Resource: "orders", Reason: "accepted"}}},
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	srv.Start(ctx)
+
+	scan, err := srv.Submit(Request{Controls: []string{"Example.1"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for deadline := time.Now().Add(5 * time.Second); ; {
+		if current, _, _ := srv.Get(scan.ID); current.Status == StatusDone {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("scan did not finish")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	tests := []struct {
+		format      string
+		status      int
+		contentType string
+		contains    string
+	}{
+		{format: "", status: http.StatusOK, contentType: "application/json", contains: `"SUPPRESSED"`},
+		{format: "json", status: http.StatusOK, contentType: "application/json", contains: `"SUPPRESSED"`},
+		{format: "html", status: http.StatusOK, contentType: "text/html; charset=utf-8", contains: "Example.1"},
+		{format: "console", status: http.StatusOK, contentType: "text/plain; charset=utf-8", contains: "[Example.1] PASS"},
+		{format: "csv", status: http.StatusBadRequest, contentType: "application/json", contains: "console, json, html"},
+	}
+	for _, test := range tests {
+		t.Run(test.format, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			srv.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/scans/"+scan.ID+"/results?format="+test.format, nil))
+			if recorder.Code != test.status {
+				t.Errorf("status = %d, want %d", recorder.Code, test.status)
+			}
+			if contentType := recorder.Header().Get("Content-Type"); contentType != test.contentType {
+				t.Errorf("content type = %s, want %s", contentType, test.contentType)
+			}
+			if !strings.Contains(recorder.Body.String(), test.contains) {
+				t.Errorf("body lacks %q:\n%s", test.contains, recorder.Body.String())
+			}
+		})
+	}
+}
+
+func TestContentTypesCoverFormats(t *testing.T) {
+	for _, format := range report.Formats {
+		if _, ok := contentTypes[format]; !ok {
+			t.Errorf("format %s has no content type", format)
+		}
+	}
+}
@@ -0,0 +1,244 @@
+// server/server.go
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"aws-security-hub/audit"
+	"aws-security-hub/metrics"
+	"aws-security-hub/report"
+	"aws-security-hub/types"
+)
+
+// Scan states
+const (
+	StatusQueued  = "queued"
+	StatusRunning = "running"
+	StatusDone    = "done"
+	StatusFailed  = "failed"
+)
+
+// ErrQueueFull is returned when the job queue has no room for another scan
+var ErrQueueFull = errors.New("scan queue is full")
+
+// Request selects the controls of a scan. Empty filters select everything.
+type Request struct {
+	Controls []string `json:"Controls"` // control IDs, e.g. CloudFront.1
+	Services []string `json:"Services"` // inventory services, e.g. cloudfront
+}
+
+// Scan is an asynchronous run of controls against freshly collected inventories
+type Scan struct {
+	ID         string     `json:"ID"`
+	Status     string     `json:"Status"`
+	Request    Request    `json:"Request"`
+	CreatedAt  time.Time  `json:"CreatedAt"`
+	StartedAt  *time.Time `json:"StartedAt,omitempty"`
+	FinishedAt *time.Time `json:"FinishedAt,omitempty"`
+	Warnings   []string   `json:"Warnings,omitempty"` // accounts, regions and services that failed to collect
+	Error      string     `json:"Error,omitempty"`
+
+	controls []types.Control
+	report   *report.Report
+}
+
+// Collector fetches an inventory of the given services for every account and region scanned, giving up
+// once ctx is done. The error reports what failed to collect alongside the inventories that were collected.
+type Collector func(ctx context.Context, services []string) ([]report.Target, error)
+
+// Options configures the job queue
+type Options struct {
+	Workers   int              // scans that run at the same time
+	QueueSize int              // scans that may wait for a worker
+	History   int              // finished scans kept for polling; the oldest are dropped first
+	Metrics   *metrics.Metrics // optional; served on /metrics
+	Report    report.Options   // applied to every scan, e.g. the suppressions of the configuration
+}
+
+// Server queues scans and keeps their results in memory
+type Server struct {
+	controls func() []types.Control
+	collect  Collector
+	options  Options
+	queue    chan *Scan
+
+	mu    sync.Mutex
+	scans map[string]*Scan
+	order []string
+}
+
+// New creates a server; call Start to run its workers
+func New(controls func() []types.Control, collect Collector, options Options) *Server {
+	if options.Workers < 1 {
+		options.Workers = 1
+	}
+	if options.QueueSize < 1 {
+		options.QueueSize = 1
+	}
+	return &Server{
+		controls: controls,
+		collect:  collect,
+		options:  options,
+		queue:    make(chan *Scan, options.QueueSize),
+		scans:    make(map[string]*Scan),
+	}
+}
+
+// Start runs the workers that take scans off the queue. Once ctx is done, running scans stop and
+// fail, and queued ones fail without running.
+func (s *Server) Start(ctx context.Context) {
+	for i := 0; i < s.options.Workers; i++ {
+		go func() {
+			for scan := range s.queue {
+				s.run(ctx, scan)
+			}
+		}()
+	}
+}
+
+// Submit validates a request and queues a scan for it
+func (s *Server) Submit(request Request) (*Scan, error) {
+	controls, err := audit.Select(s.controls(), request.Controls, request.Services)
+	if err != nil {
+		return nil, err
+	}
+
+	scan := &Scan{
+		ID:        newID(),
+		Status:    StatusQueued,
+		Request:   request,
+		CreatedAt: time.Now().UTC(),
+		controls:  controls,
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	select {
+	case s.queue <- scan:
+	default:
+		return nil, ErrQueueFull
+	}
+	s.scans[scan.ID] = scan
+	s.order = append(s.order, scan.ID)
+	s.prune()
+
+	slog.Info("scan queued", "scan", scan.ID, "controls", len(controls))
+	return scan.snapshot(), nil
+}
+
+// Get returns a copy of a scan and its report, which is nil until the scan is done
+func (s *Server) Get(id string) (*Scan, *report.Report, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	scan, ok := s.scans[id]
+	if !ok {
+		return nil, nil, false
+	}
+	return scan.snapshot(), scan.report, true
+}
+
+// List returns copies of the known scans, oldest first
+func (s *Server) List() []*Scan {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var scans []*Scan
+	for _, id := range s.order {
+		scans = append(scans, s.scans[id].snapshot())
+	}
+	return scans
+}
+
+func (s *Server) run(ctx context.Context, scan *Scan) {
+	s.update(scan, func() {
+		started := time.Now().UTC()
+		scan.Status = StatusRunning
+		scan.StartedAt = &started
+	})
+	slog.Info("scan started", "scan", scan.ID)
+
+	targets, err := s.collect(ctx, audit.Services(scan.controls))
+	var warnings []string
+	if err != nil {
+		warnings = append(warnings, strings.Split(err.Error(), "\n")...)
+	}
+
+	var result *report.Report
+	if len(targets) > 0 {
+		result = report.RunTargets(ctx, targets, "scan "+scan.ID, scan.controls, s.options.Report)
+		s.options.Metrics.ObserveReport(result)
+	} else {
+		s.options.Metrics.ObserveFailure()
+	}
+
+	s.update(scan, func() {
+		finished := time.Now().UTC()
+		scan.FinishedAt = &finished
+		scan.Warnings = warnings
+		if result == nil {
+			scan.Status = StatusFailed
+			scan.Error = fmt.Sprintf("failed to collect inventory: %v", err)
+			return
+		}
+		if ctx.Err() != nil {
+			scan.Status = StatusFailed
+			scan.Error = "the server stopped before the scan completed"
+			return
+		}
+		scan.Status = StatusDone
+		scan.report = result
+	})
+	slog.Info("scan finished", "scan", scan.ID, "status", scan.Status)
+}
+
+func (s *Server) update(scan *Scan, change func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	change()
+	s.prune()
+}
+
+// prune drops the oldest finished scans beyond the history limit; queued and running scans are kept
+func (s *Server) prune() {
+	if s.options.History < 1 {
+		return
+	}
+	finished := 0
+	for _, id := range s.order {
+		if status := s.scans[id].Status; status == StatusDone || status == StatusFailed {
+			finished++
+		}
+	}
+
+	var kept []string
+	for _, id := range s.order {
+		status := s.scans[id].Status
+		if finished > s.options.History && (status == StatusDone || status == StatusFailed) {
+			delete(s.scans, id)
+			finished--
+			continue
+		}
+		kept = append(kept, id)
+	}
+	s.order = kept
+}
+
+func (scan *Scan) snapshot() *Scan {
+	copied := *scan
+	return &copied
+}
+
+func newID() string {
+	bytes := make([]byte, 8)
+	if _, err := rand.Read(bytes); err != nil {
+		return fmt.Sprintf("%d", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(bytes)
+}
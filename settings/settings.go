@@ -0,0 +1,394 @@
+// settings/settings.go
+package settings
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"aws-security-hub/audit"
+	"aws-security-hub/identity"
+	"aws-security-hub/inventory"
+	"aws-security-hub/logging"
+	"aws-security-hub/notify"
+	"aws-security-hub/report"
+	"aws-security-hub/throttle"
+	"aws-security-hub/types"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Version is the version of the configuration format this build reads
+const Version = 1
+
+// Config is the scan configuration file. Every key may also be given as an AUDIT_ environment
+// variable (aws.profile as AUDIT_AWS_PROFILE), and most of them as flags, which take precedence.
+type Config struct {
+	Version          int                          `yaml:"version"`
+	AWS              AWS                          `yaml:"aws"`
+	API              API                          `yaml:"api"`
+	Regions          []string                     `yaml:"regions"`    // regions scanned by all; empty scans aws.region
+	Accounts         []Account                    `yaml:"accounts"`   // accounts scanned by all; empty scans the caller's account
+	Controls         []string                     `yaml:"controls"`   // control IDs; empty selects every control
+	Services         []string                     `yaml:"services"`   // inventory services; empty selects every service
+	Frameworks       []string                     `yaml:"frameworks"` // only controls mapped to these frameworks
+	Rules            string                       `yaml:"rules"`      // directory of rule files
+	Packs            []string                     `yaml:"packs"`      // control pack executables
+	Parameters       map[string]map[string]string `yaml:"parameters"` // control parameters by control ID, then parameter name
+	Suppressions     []types.Suppression          `yaml:"suppressions"`
+	SuppressionsFile string                       `yaml:"suppressions_file"` // more suppressions, added to these; audit tui appends to it
+	Outputs          Outputs                      `yaml:"outputs"`
+	Log              Log                          `yaml:"log"`
+	Notifiers        []notify.Config              `yaml:"notifiers"`   // notified of the failing controls of all
+	Concurrency      int                          `yaml:"concurrency"` // accounts and regions scanned at the same time
+	// Timeout bounds a whole command and ControlTimeout each control of all, including collecting the
+	// resources it needs, e.g. 10m and 30s; empty or 0 for none
+	Timeout        string `yaml:"timeout"`
+	ControlTimeout string `yaml:"control_timeout"`
+}
+
+// AWS selects the credentials and the default region
+type AWS struct {
+	Region               string `yaml:"region"`
+	Profile              string `yaml:"profile"`
+	RoleARN              string `yaml:"role_arn"`
+	ExternalID           string `yaml:"external_id"`
+	MFASerial            string `yaml:"mfa_serial"`
+	RoleSessionName      string `yaml:"role_session_name"`
+	RoleDuration         string `yaml:"role_duration"`
+	WebIdentityTokenFile string `yaml:"web_identity_token_file"`
+}
+
+// API configures how AWS API calls are retried and paced
+type API struct {
+	RetryMode   string             `yaml:"retry_mode"`
+	MaxAttempts int                `yaml:"max_attempts"`
+	MaxBackoff  string             `yaml:"max_backoff"`
+	RateLimits  map[string]float64 `yaml:"rate_limits"`
+}
+
+// Account is a member account scanned through a role
+type Account struct {
+	ID         string `yaml:"id"`
+	Name       string `yaml:"name"`
+	RoleARN    string `yaml:"role_arn"`
+	ExternalID string `yaml:"external_id"`
+}
+
+// Outputs configures the report of evaluate, all, cloudformation and terraform
+type Outputs struct {
+	Format          string `yaml:"format"`
+	Path            string `yaml:"path"` // empty writes to stdout
+	Snippets        bool   `yaml:"snippets"`
+	MetricsTextfile string `yaml:"metrics_textfile"`
+	HistoryDir      string `yaml:"history_dir"` // reports are stored here, and posture trends computed against the last one
+}
+
+// Log configures the diagnostics written to stderr, apart from the report
+type Log struct {
+	Level  string `yaml:"level"`  // debug, info, warn or error
+	Format string `yaml:"format"` // text or json
+}
+
+// Defaults returns the configuration used when neither the file, the environment nor flags set a key
+func Defaults() Config {
+	return Config{
+		Version:     Version,
+		AWS:         AWS{Region: "ap-northeast-2"},
+		API:         API{RetryMode: throttle.ModeAdaptive, MaxAttempts: 5, MaxBackoff: "20s"},
+		Outputs:     Outputs{Format: "console"},
+		Log:         Log{Level: "info", Format: "text"},
+		Concurrency: 1,
+	}
+}
+
+// Load reads a configuration file, rejecting unknown keys and unsupported versions. It returns the
+// file and the keys it sets, for viper to merge. Parameters are left out of the keys, since viper
+// would split control IDs such as DocumentDB.2 at the dot; they are only read from the file.
+func Load(filePath string) (*Config, map[string]interface{}, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read configuration: %v", err)
+	}
+
+	config := &Config{}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(config); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse configuration: %v", err)
+	}
+	if config.Version != Version {
+		return nil, nil, fmt.Errorf("unsupported configuration version %d (supported: %d)", config.Version, Version)
+	}
+
+	values := make(map[string]interface{})
+	if err := yaml.Unmarshal(data, &values); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse configuration: %v", err)
+	}
+	delete(values, "parameters")
+	return config, dates(values).(map[string]interface{}), nil
+}
+
+// Suppressions is the suppressions file
+type Suppressions struct {
+	Suppressions []types.Suppression `yaml:"suppressions"`
+}
+
+// LoadSuppressions reads a suppressions file. A file that does not exist yet holds no suppressions.
+func LoadSuppressions(filePath string) ([]types.Suppression, error) {
+	data, err := os.ReadFile(filePath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read suppressions: %v", err)
+	}
+
+	file := &Suppressions{}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(file); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("failed to parse suppressions: %v", err)
+	}
+	return file.Suppressions, nil
+}
+
+// AppendSuppression adds a suppression to a suppressions file, creating the file if needed
+func AppendSuppression(filePath string, suppression types.Suppression) error {
+	suppressions, err := LoadSuppressions(filePath)
+	if err != nil {
+		return err
+	}
+	var data bytes.Buffer
+	encoder := yaml.NewEncoder(&data)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(Suppressions{Suppressions: append(suppressions, suppression)}); err != nil {
+		return fmt.Errorf("failed to encode suppressions: %v", err)
+	}
+	if err := os.WriteFile(filePath, data.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write suppressions: %v", err)
+	}
+	return nil
+}
+
+// dates turns the timestamps YAML decodes unquoted dates into (suppression expiry dates) back into
+// the YYYY-MM-DD strings of the format
+func dates(value interface{}) interface{} {
+	switch value := value.(type) {
+	case time.Time:
+		return value.Format(time.DateOnly)
+	case map[string]interface{}:
+		for key, child := range value {
+			value[key] = dates(child)
+		}
+	case []interface{}:
+		for i, child := range value {
+			value[i] = dates(child)
+		}
+	}
+	return value
+}
+
+// Keys flattens a configuration into viper keys ("aws.profile"), so that viper knows every key
+// of the format even when it is only set in the environment
+func Keys(config Config) map[string]interface{} {
+	data, _ := yaml.Marshal(config)
+	var tree map[string]interface{}
+	yaml.Unmarshal(data, &tree)
+
+	keys := make(map[string]interface{})
+	var walk func(prefix string, node map[string]interface{})
+	walk = func(prefix string, node map[string]interface{}) {
+		for key, value := range node {
+			if child, ok := value.(map[string]interface{}); ok && len(child) > 0 {
+				walk(prefix+key+".", child)
+				continue
+			}
+			keys[prefix+key] = value
+		}
+	}
+	walk("", tree)
+	delete(keys, "parameters")
+	return keys
+}
+
+// Credentials returns the credential options of the aws section
+func (a AWS) Credentials() (identity.Options, error) {
+	options := identity.Options{
+		Profile:              a.Profile,
+		RoleARN:              a.RoleARN,
+		ExternalID:           a.ExternalID,
+		MFASerial:            a.MFASerial,
+		SessionName:          a.RoleSessionName,
+		WebIdentityTokenFile: a.WebIdentityTokenFile,
+	}
+	if a.RoleDuration != "" && a.RoleDuration != "0s" {
+		duration, err := time.ParseDuration(a.RoleDuration)
+		if err != nil {
+			return options, fmt.Errorf("invalid role duration %q: %v", a.RoleDuration, err)
+		}
+		options.Duration = duration
+	}
+	return options, options.Validate()
+}
+
+// Throttle returns the retry and rate limit options of the api section
+func (a API) Throttle() (throttle.Options, error) {
+	options := throttle.Options{Mode: a.RetryMode, MaxAttempts: a.MaxAttempts, RateLimits: a.RateLimits}
+	backoff, err := time.ParseDuration(a.MaxBackoff)
+	if err != nil {
+		return options, fmt.Errorf("invalid max backoff %q: %v", a.MaxBackoff, err)
+	}
+	options.MaxBackoff = backoff
+	return options, nil
+}
+
+// Timeouts returns the timeout of a command and of each control; zero means none
+func (c *Config) Timeouts() (command, control time.Duration, err error) {
+	if command, err = timeout(c.Timeout); err != nil {
+		return 0, 0, fmt.Errorf("invalid timeout %q: %v", c.Timeout, err)
+	}
+	if control, err = timeout(c.ControlTimeout); err != nil {
+		return 0, 0, fmt.Errorf("invalid control timeout %q: %v", c.ControlTimeout, err)
+	}
+	return command, control, nil
+}
+
+func timeout(value string) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+	duration, err := time.ParseDuration(value)
+	if err == nil && duration < 0 {
+		err = fmt.Errorf("must not be negative")
+	}
+	return duration, err
+}
+
+// Validate checks the resolved configuration against the available controls, services, frameworks,
+// report formats and notifiers, returning the first problem found
+func (c *Config) Validate(controls []types.Control) error {
+	if c.Version != Version {
+		return fmt.Errorf("unsupported configuration version %d (supported: %d)", c.Version, Version)
+	}
+	if _, err := c.AWS.Credentials(); err != nil {
+		return fmt.Errorf("aws: %v", err)
+	}
+	options, err := c.API.Throttle()
+	if err != nil {
+		return fmt.Errorf("api: %v", err)
+	}
+	if _, err := throttle.New(options); err != nil {
+		return fmt.Errorf("api: %v", err)
+	}
+
+	for i, region := range c.Regions {
+		if region == "" {
+			return fmt.Errorf("regions: region %d is empty", i+1)
+		}
+	}
+	for i, account := range c.Accounts {
+		if account.RoleARN == "" {
+			return fmt.Errorf("accounts: account %d is missing role_arn", i+1)
+		}
+	}
+
+	byID := make(map[string]types.Control)
+	for _, control := range controls {
+		byID[control.Metadata().ID] = control
+	}
+	if _, err := audit.Select(controls, c.Controls, c.Services); err != nil {
+		return fmt.Errorf("controls: %v", err)
+	}
+	for _, service := range c.Services {
+		if !contains(inventory.Services, service) {
+			return fmt.Errorf("services: unknown service %s (supported: %v)", service, inventory.Services)
+		}
+	}
+	for _, framework := range c.Frameworks {
+		if !audit.IsFramework(framework) {
+			return fmt.Errorf("frameworks: unknown framework %s (supported: %v)", framework, audit.Frameworks())
+		}
+	}
+
+	if err := c.ValidateParameters(controls); err != nil {
+		return err
+	}
+	for i, suppression := range c.Suppressions {
+		if err := suppression.Validate(); err != nil {
+			return fmt.Errorf("suppressions: suppression %d: %v", i+1, err)
+		}
+		if _, ok := byID[suppression.Control]; !ok {
+			return fmt.Errorf("suppressions: suppression %d: unknown control %s", i+1, suppression.Control)
+		}
+	}
+
+	if !contains(report.Formats, c.Outputs.Format) {
+		return fmt.Errorf("outputs: unsupported format %q (supported: %v)", c.Outputs.Format, report.Formats)
+	}
+	for i, notifier := range c.Notifiers {
+		if _, err := notify.New(notifier, notify.OnFailures); err != nil {
+			return fmt.Errorf("notifiers: notifier %d: %v", i+1, err)
+		}
+		if len(notifier.Groups) > 0 {
+			return fmt.Errorf("notifiers: notifier %d: groups only apply to the daemon schedule", i+1)
+		}
+	}
+	if c.Concurrency < 1 {
+		return fmt.Errorf("concurrency must be at least 1, got %d", c.Concurrency)
+	}
+	if _, _, err := c.Timeouts(); err != nil {
+		return err
+	}
+	if err := logging.Validate(c.Log.Level, c.Log.Format); err != nil {
+		return fmt.Errorf("log: %v", err)
+	}
+	return nil
+}
+
+// ValidateParameters checks that every parameter belongs to a control and has a valid value
+func (c *Config) ValidateParameters(controls []types.Control) error {
+	for id, values := range c.Parameters {
+		var control types.Control
+		for _, candidate := range controls {
+			if candidate.Metadata().ID == id {
+				control = candidate
+			}
+		}
+		if control == nil {
+			return fmt.Errorf("parameters: unknown control %s", id)
+		}
+		for name, value := range values {
+			parameter, ok := findParameter(control.Metadata().Parameters, name)
+			if !ok {
+				return fmt.Errorf("parameters: %s has no parameter %s", id, name)
+			}
+			if parameter.Validate != nil {
+				if err := parameter.Validate(value); err != nil {
+					return fmt.Errorf("parameters: %s %s: %v", id, name, err)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func findParameter(parameters []types.Parameter, name string) (types.Parameter, bool) {
+	for _, parameter := range parameters {
+		if parameter.Name == name {
+			return parameter, true
+		}
+	}
+	return types.Parameter{}, false
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
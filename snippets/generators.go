@@ -0,0 +1,313 @@
+// snippets/generators.go
+package snippets
+
+import (
+	"fmt"
+	"strings"
+
+	"aws-security-hub/audit/cloudfront"
+	"aws-security-hub/audit/documentdb"
+	"aws-security-hub/inventory"
+)
+
+// APIGateway.3
+func restStageTracing(inv *inventory.Inventory) []Snippet {
+	if inv.APIGateway == nil {
+		return nil
+	}
+	var snippets []Snippet
+	for _, restAPI := range inv.APIGateway.RestAPIs {
+		for _, stage := range restAPI.Stages {
+			if stage.TracingEnabled {
+				continue
+			}
+			id := qualified(restAPI.ID, stage.StageName)
+			snippets = append(snippets, Snippet{
+				Control:        "APIGateway.3",
+				Resource:       restAPI.ID + "/" + stage.StageName,
+				Terraform:      terraform("aws_api_gateway_stage", id, "xray_tracing_enabled = true"),
+				CloudFormation: cloudFormation("AWS::ApiGateway::Stage", id, "TracingEnabled: true"),
+			})
+		}
+	}
+	return snippets
+}
+
+// APIGateway.8
+func routeAuthorization(inv *inventory.Inventory) []Snippet {
+	if inv.APIGateway == nil {
+		return nil
+	}
+	var snippets []Snippet
+	for _, api := range inv.APIGateway.APIs {
+		for _, route := range api.Routes {
+			switch route.AuthorizationType {
+			case "AWS_IAM", "CUSTOM", "JWT":
+				continue
+			}
+			id := qualified(api.ID, route.RouteKey)
+			snippets = append(snippets, Snippet{
+				Control:        "APIGateway.8",
+				Resource:       api.ID + "/" + route.RouteKey,
+				Terraform:      terraform("aws_apigatewayv2_route", id, `authorization_type = "AWS_IAM" # or "CUSTOM" / "JWT" with authorizer_id`),
+				CloudFormation: cloudFormation("AWS::ApiGatewayV2::Route", id, "AuthorizationType: AWS_IAM # or CUSTOM / JWT with AuthorizerId"),
+			})
+		}
+	}
+	return snippets
+}
+
+// APIGateway.9
+func stageAccessLogging(inv *inventory.Inventory) []Snippet {
+	if inv.APIGateway == nil {
+		return nil
+	}
+	var snippets []Snippet
+	for _, api := range inv.APIGateway.APIs {
+		for _, stage := range api.Stages {
+			if stage.AccessLogDestinationARN != "" {
+				continue
+			}
+			id := qualified(api.ID, stage.StageName)
+			snippets = append(snippets, Snippet{
+				Control:  "APIGateway.9",
+				Resource: api.ID + "/" + stage.StageName,
+				Terraform: terraform("aws_apigatewayv2_stage", id,
+					"access_log_settings {",
+					"  destination_arn = aws_cloudwatch_log_group.api_access_logs.arn",
+					`  format          = jsonencode({ requestId = "$context.requestId", status = "$context.status" })`,
+					"}"),
+				CloudFormation: cloudFormation("AWS::ApiGatewayV2::Stage", id,
+					"AccessLogSettings:",
+					"  DestinationArn: !GetAtt ApiAccessLogs.Arn",
+					`  Format: '{"requestId":"$context.requestId","status":"$context.status"}'`),
+			})
+		}
+	}
+	return snippets
+}
+
+// CloudFront.1
+func distributionDefaultRootObject(inv *inventory.Inventory) []Snippet {
+	if inv.CloudFront == nil {
+		return nil
+	}
+	var snippets []Snippet
+	for _, distribution := range inv.CloudFront.Distributions {
+		if distribution.DefaultRootObject != "" || distribution.Errors.Find("GetDistribution") != nil {
+			continue
+		}
+		snippets = append(snippets, Snippet{
+			Control:        "CloudFront.1",
+			Resource:       distribution.ID,
+			Terraform:      terraform("aws_cloudfront_distribution", distribution.ID, `default_root_object = "index.html"`),
+			CloudFormation: cloudFormation("AWS::CloudFront::Distribution", distribution.ID, "DistributionConfig:", "  DefaultRootObject: index.html"),
+		})
+	}
+	return snippets
+}
+
+// CloudFront.3
+func distributionViewerProtocolPolicy(inv *inventory.Inventory) []Snippet {
+	if inv.CloudFront == nil {
+		return nil
+	}
+	var snippets []Snippet
+	for _, distribution := range inv.CloudFront.Distributions {
+		if distribution.Errors.Find("GetDistribution") != nil {
+			continue
+		}
+		var terraformBody, cloudFormationBody []string
+		if distribution.ViewerProtocolPolicy == "allow-all" {
+			terraformBody = append(terraformBody, "default_cache_behavior {", `  viewer_protocol_policy = "redirect-to-https"`, "}")
+			cloudFormationBody = append(cloudFormationBody, "  DefaultCacheBehavior:", "    ViewerProtocolPolicy: redirect-to-https")
+		}
+		var behaviors []string
+		for _, behavior := range distribution.CacheBehaviors {
+			if behavior.ViewerProtocolPolicy != "allow-all" {
+				continue
+			}
+			terraformBody = append(terraformBody, "ordered_cache_behavior {",
+				fmt.Sprintf("  path_pattern           = %q", behavior.PathPattern),
+				`  viewer_protocol_policy = "redirect-to-https"`, "}")
+			behaviors = append(behaviors, "      - PathPattern: "+behavior.PathPattern, "        ViewerProtocolPolicy: redirect-to-https")
+		}
+		if len(behaviors) > 0 {
+			cloudFormationBody = append(append(cloudFormationBody, "  CacheBehaviors:"), behaviors...)
+		}
+		if len(terraformBody) == 0 {
+			continue
+		}
+		snippets = append(snippets, Snippet{
+			Control:        "CloudFront.3",
+			Resource:       distribution.ID,
+			Terraform:      terraform("aws_cloudfront_distribution", distribution.ID, terraformBody...),
+			CloudFormation: cloudFormation("AWS::CloudFront::Distribution", distribution.ID, append([]string{"DistributionConfig:"}, cloudFormationBody...)...),
+		})
+	}
+	return snippets
+}
+
+// CloudFront.5
+func distributionLogging(inv *inventory.Inventory) []Snippet {
+	if inv.CloudFront == nil {
+		return nil
+	}
+	var snippets []Snippet
+	for _, distribution := range inv.CloudFront.Distributions {
+		if distribution.Logging.Enabled || distribution.Errors.Find("GetDistribution") != nil {
+			continue
+		}
+		snippets = append(snippets, Snippet{
+			Control:  "CloudFront.5",
+			Resource: distribution.ID,
+			Terraform: terraform("aws_cloudfront_distribution", distribution.ID,
+				"logging_config {",
+				"  bucket = aws_s3_bucket.cloudfront_logs.bucket_domain_name",
+				fmt.Sprintf("  prefix = %q", terraformName(distribution.ID)+"/"),
+				"}"),
+			CloudFormation: cloudFormation("AWS::CloudFront::Distribution", distribution.ID,
+				"DistributionConfig:",
+				"  Logging:",
+				"    Bucket: !GetAtt CloudFrontLogs.DomainName",
+				"    Prefix: "+logicalID(distribution.ID)+"/"),
+		})
+	}
+	return snippets
+}
+
+// CloudFront.14
+func distributionTags(inv *inventory.Inventory) []Snippet {
+	if inv.CloudFront == nil {
+		return nil
+	}
+	required := cloudfront.RequiredTagKeys()
+	var snippets []Snippet
+	for _, distribution := range inv.CloudFront.Distributions {
+		if distribution.Tags == nil {
+			continue
+		}
+		// The required tag keys the distribution lacks, or an Owner tag when any user-defined tag will do
+		var missing []string
+		for _, key := range required {
+			if _, ok := distribution.Tags[key]; !ok {
+				missing = append(missing, key)
+			}
+		}
+		if len(required) == 0 {
+			missing = []string{"Owner"}
+			for key := range distribution.Tags {
+				if !strings.HasPrefix(key, "aws:") {
+					missing = nil
+				}
+			}
+		}
+		if len(missing) == 0 {
+			continue
+		}
+
+		terraformBody := []string{"tags = { # keep the existing tags"}
+		cloudFormationBody := []string{"Tags: # keep the existing tags"}
+		for _, key := range missing {
+			terraformBody = append(terraformBody, fmt.Sprintf("  %q = %q", key, "team-name"))
+			cloudFormationBody = append(cloudFormationBody, "  - Key: "+key, "    Value: team-name")
+		}
+		snippets = append(snippets, Snippet{
+			Control:        "CloudFront.14",
+			Resource:       distribution.ID,
+			Terraform:      terraform("aws_cloudfront_distribution", distribution.ID, append(terraformBody, "}")...),
+			CloudFormation: cloudFormation("AWS::CloudFront::Distribution", distribution.ID, cloudFormationBody...),
+		})
+	}
+	return snippets
+}
+
+// DocumentDB.1
+func docdbStorageEncrypted(inv *inventory.Inventory) []Snippet {
+	return docdbClusters(inv, "DocumentDB.1", func(cluster inventory.DocDBCluster) bool { return cluster.StorageEncrypted },
+		[]string{"storage_encrypted = true # forces replacement; restore from a snapshot to keep data"},
+		[]string{"StorageEncrypted: true # requires replacement; restore from a snapshot to keep data"})
+}
+
+// DocumentDB.2
+func docdbBackupRetention(inv *inventory.Inventory) []Snippet {
+	minimum := documentdb.MinimumBackupRetentionPeriod()
+	return docdbClusters(inv, "DocumentDB.2", func(cluster inventory.DocDBCluster) bool { return cluster.BackupRetentionPeriod >= minimum },
+		[]string{fmt.Sprintf("backup_retention_period = %d", minimum)},
+		[]string{fmt.Sprintf("BackupRetentionPeriod: %d", minimum)})
+}
+
+// DocumentDB.4
+func docdbAuditLogs(inv *inventory.Inventory) []Snippet {
+	return docdbClusters(inv, "DocumentDB.4", func(cluster inventory.DocDBCluster) bool {
+		for _, export := range cluster.EnabledCloudwatchLogsExports {
+			if export == "audit" {
+				return true
+			}
+		}
+		return false
+	},
+		[]string{`enabled_cloudwatch_logs_exports = ["audit"] # also set audit_logs = "enabled" in the cluster parameter group`},
+		[]string{"EnableCloudwatchLogsExports:", "  - audit # also set audit_logs: enabled in the cluster parameter group"})
+}
+
+// DocumentDB.5
+func docdbDeletionProtection(inv *inventory.Inventory) []Snippet {
+	return docdbClusters(inv, "DocumentDB.5", func(cluster inventory.DocDBCluster) bool { return cluster.DeletionProtection },
+		[]string{"deletion_protection = true"},
+		[]string{"DeletionProtection: true"})
+}
+
+func docdbClusters(inv *inventory.Inventory, controlID string, passes func(inventory.DocDBCluster) bool, terraformBody, cloudFormationBody []string) []Snippet {
+	if inv.DocumentDB == nil {
+		return nil
+	}
+	var snippets []Snippet
+	for _, cluster := range inv.DocumentDB.Clusters {
+		if passes(cluster) {
+			continue
+		}
+		snippets = append(snippets, Snippet{
+			Control:        controlID,
+			Resource:       cluster.Identifier,
+			Terraform:      terraform("aws_docdb_cluster", cluster.Identifier, terraformBody...),
+			CloudFormation: cloudFormation("AWS::DocDB::DBCluster", cluster.Identifier, cloudFormationBody...),
+		})
+	}
+	return snippets
+}
+
+// S3.1
+func bucketPublicAccessBlock(inv *inventory.Inventory) []Snippet {
+	if inv.S3 == nil {
+		return nil
+	}
+	var snippets []Snippet
+	for _, bucket := range inv.S3.Buckets {
+		config := bucket.PublicAccessBlock
+		if len(bucket.Errors) > 0 || config != nil && config.BlockPublicAcls && config.IgnorePublicAcls && config.BlockPublicPolicy && config.RestrictPublicBuckets {
+			continue
+		}
+		name := terraformName(bucket.Name)
+		snippets = append(snippets, Snippet{
+			Control:  "S3.1",
+			Resource: bucket.Name,
+			Terraform: strings.Join([]string{
+				`resource "aws_s3_bucket_public_access_block" "` + name + `" {`,
+				"  bucket                  = aws_s3_bucket." + name + ".id",
+				"  block_public_acls       = true",
+				"  ignore_public_acls      = true",
+				"  block_public_policy     = true",
+				"  restrict_public_buckets = true",
+				"}",
+			}, "\n"),
+			CloudFormation: cloudFormation("AWS::S3::Bucket", bucket.Name,
+				"PublicAccessBlockConfiguration:",
+				"  BlockPublicAcls: true",
+				"  IgnorePublicAcls: true",
+				"  BlockPublicPolicy: true",
+				"  RestrictPublicBuckets: true"),
+		})
+	}
+	return snippets
+}
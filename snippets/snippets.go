@@ -0,0 +1,151 @@
+// snippets/snippets.go
+package snippets
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"aws-security-hub/inventory"
+	"aws-security-hub/types"
+)
+
+// Snippet is the infrastructure-as-code change that fixes one failing resource
+type Snippet struct {
+	Control        string `json:"Control"`
+	Resource       string `json:"Resource"`
+	Terraform      string `json:"Terraform"`
+	CloudFormation string `json:"CloudFormation"`
+}
+
+// generator returns a snippet for every resource of the inventory that fails a control
+type generator func(inv *inventory.Inventory) []Snippet
+
+var generators = map[string]generator{
+	"APIGateway.3":  restStageTracing,
+	"APIGateway.8":  routeAuthorization,
+	"APIGateway.9":  stageAccessLogging,
+	"CloudFront.1":  distributionDefaultRootObject,
+	"CloudFront.3":  distributionViewerProtocolPolicy,
+	"CloudFront.5":  distributionLogging,
+	"CloudFront.14": distributionTags,
+	"DocumentDB.1":  docdbStorageEncrypted,
+	"DocumentDB.2":  docdbBackupRetention,
+	"DocumentDB.4":  docdbAuditLogs,
+	"DocumentDB.5":  docdbDeletionProtection,
+	"S3.1":          bucketPublicAccessBlock,
+}
+
+// Generate returns the fix snippets of the resources that fail a control, as its findings report
+// them once suppressed, or nil when the control has no generator or its service was not collected
+func Generate(controlID string, inv *inventory.Inventory, findings types.Findings) []Snippet {
+	generate, ok := generators[controlID]
+	if !ok {
+		return nil
+	}
+	failed := make(map[string]bool)
+	for _, finding := range findings.Failed() {
+		failed[finding.Resource] = true
+	}
+
+	var result []Snippet
+	for _, snippet := range generate(inv) {
+		if failed[snippet.Resource] {
+			result = append(result, snippet)
+		}
+	}
+	return result
+}
+
+// ControlIDs lists the controls that have snippet generators
+func ControlIDs() []string {
+	var ids []string
+	for id := range generators {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+var (
+	terraformAddress = regexp.MustCompile(`(?:^|\.)aws_[a-z0-9_]+\.([A-Za-z0-9_-]+)(?:\[[^\]]*\])?$`)
+	templateLocation = regexp.MustCompile(`^([A-Za-z0-9]+) \(.*:\d+\)$`)
+	nonAlphanumeric  = regexp.MustCompile(`[^A-Za-z0-9]+`)
+	bracketSuffix    = regexp.MustCompile(`\[([^\[\]]+(?:\[[^\]]*\])?)\]$`)
+)
+
+// source strips a display name such as "GET /items [aws_apigatewayv2_route.items]"
+// down to the Terraform address or template location it carries
+func source(id string) string {
+	if match := bracketSuffix.FindStringSubmatch(id); match != nil {
+		return match[1]
+	}
+	return id
+}
+
+// qualified returns the identifier the snippet of a stage or route is named after: its own when it is
+// a Terraform address or template location, which are unique, otherwise prefixed with the ID of its
+// API, since stage names and route keys repeat across APIs
+func qualified(apiID, name string) string {
+	if id := source(name); terraformAddress.MatchString(id) || templateLocation.MatchString(id) {
+		return name
+	}
+	return apiID + "/" + name
+}
+
+// terraformName derives a resource name from an identifier: the name of a Terraform address,
+// or the identifier with every run of other characters replaced by an underscore
+func terraformName(id string) string {
+	id = source(id)
+	if match := terraformAddress.FindStringSubmatch(id); match != nil {
+		return match[1]
+	}
+	if match := templateLocation.FindStringSubmatch(id); match != nil {
+		id = match[1]
+	}
+	name := strings.Trim(nonAlphanumeric.ReplaceAllString(strings.ToLower(id), "_"), "_")
+	if name == "" || (name[0] >= '0' && name[0] <= '9') {
+		name = "r_" + name
+	}
+	return name
+}
+
+// logicalID derives a CloudFormation logical ID: the logical ID of a template location,
+// or the identifier in CamelCase
+func logicalID(id string) string {
+	id = source(id)
+	if match := templateLocation.FindStringSubmatch(id); match != nil {
+		return match[1]
+	}
+	if match := terraformAddress.FindStringSubmatch(id); match != nil {
+		id = match[1]
+	}
+	var logical strings.Builder
+	for _, part := range nonAlphanumeric.Split(id, -1) {
+		if part != "" {
+			logical.WriteString(strings.ToUpper(part[:1]) + part[1:])
+		}
+	}
+	if logical.Len() == 0 || (logical.String()[0] >= '0' && logical.String()[0] <= '9') {
+		return "Resource" + logical.String()
+	}
+	return logical.String()
+}
+
+// terraform renders a partial resource block holding only the arguments to change
+func terraform(resourceType, id string, body ...string) string {
+	lines := []string{`resource "` + resourceType + `" "` + terraformName(id) + `" {`, "  # ..."}
+	for _, line := range body {
+		lines = append(lines, "  "+line)
+	}
+	return strings.Join(append(lines, "}"), "\n")
+}
+
+// cloudFormation renders a partial resource holding only the properties to change
+func cloudFormation(resourceType, id string, properties ...string) string {
+	lines := []string{logicalID(id) + ":", "  Type: " + resourceType, "  Properties:"}
+	for _, line := range properties {
+		lines = append(lines, "    "+line)
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,115 @@
+// snippets/snippets_test.go
+package snippets
+
+import (
+	"strings"
+	"testing"
+
+	"aws-security-hub/inventory"
+	"aws-security-hub/types"
+)
+
+func TestGenerateCoversFailedFindings(t *testing.T) {
+	// Both APIs have a stage named prod, which only the second one fails
+	inv := &inventory.Inventory{APIGateway: &inventory.APIGateway{RestAPIs: []inventory.RestAPI{
+		{ID: "a1", Stages: []inventory.RestStage{{StageName: "prod"}, {StageName: "dev"}}},
+		{ID: "b2", Stages: []inventory.RestStage{{StageName: "prod"}}},
+	}}}
+	findings := types.Findings{
+		{Resource: "a1/prod", Status: "SUPPRESSED"},
+		{Resource: "a1/dev", Status: "FAIL"},
+		{Resource: "b2/prod", Status: "FAIL"},
+	}
+
+	tests := []struct {
+		resource  string
+		terraform string
+	}{
+		{"a1/dev", `resource "aws_api_gateway_stage" "a1_dev"`},
+		{"b2/prod", `resource "aws_api_gateway_stage" "b2_prod"`},
+	}
+	got := Generate("APIGateway.3", inv, findings)
+	if len(got) != len(tests) {
+		t.Fatalf("got %d snippets, want %d: %+v", len(got), len(tests), got)
+	}
+	for i, test := range tests {
+		if got[i].Resource != test.resource {
+			t.Errorf("snippet %d resource = %s, want %s", i, got[i].Resource, test.resource)
+		}
+		if !strings.HasPrefix(got[i].Terraform, test.terraform) {
+			t.Errorf("snippet %d terraform = %s, want %s", i, got[i].Terraform, test.terraform)
+		}
+	}
+}
+
+func TestQualified(t *testing.T) {
+	tests := []struct {
+		apiID, name string
+		terraform   string
+		logicalID   string
+	}{
+		{"a1", "prod", "a1_prod", "A1Prod"},
+		{"aws_api_gateway_rest_api.orders", "aws_api_gateway_stage.prod", "prod", "Prod"},
+		{"Api", "Stage (template.yaml:12)", "stage", "Stage"},
+		{"a1", "GET /items", "a1_get_items", "A1GETItems"},
+		{"aws_apigatewayv2_api.orders", "GET /items [aws_apigatewayv2_route.items]", "items", "Items"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			id := qualified(test.apiID, test.name)
+			if got := terraformName(id); got != test.terraform {
+				t.Errorf("terraformName() = %s, want %s", got, test.terraform)
+			}
+			if got := logicalID(id); got != test.logicalID {
+				t.Errorf("logicalID() = %s, want %s", got, test.logicalID)
+			}
+		})
+	}
+}
+
+func TestGenerateUsesControlParameters(t *testing.T) {
+	types.SetParameters(map[string]map[string]string{
+		"DocumentDB.2":  {"minimumBackupRetentionPeriod": "14"},
+		"CloudFront.14": {"requiredTagKeys": "Owner, CostCenter"},
+	})
+	defer types.SetParameters(nil)
+
+	inv := &inventory.Inventory{
+		CloudFront: &inventory.CloudFront{Distributions: []inventory.Distribution{
+			{ID: "E1", Tags: map[string]string{"Owner": "payments"}},
+		}},
+		DocumentDB: &inventory.DocumentDB{Clusters: []inventory.DocDBCluster{
+			{Identifier: "weekly", BackupRetentionPeriod: 7},
+		}},
+	}
+
+	tests := []struct {
+		control  string
+		resource string
+		contains []string
+		excludes []string
+	}{
+		{"DocumentDB.2", "weekly", []string{"backup_retention_period = 14", "BackupRetentionPeriod: 14"}, nil},
+		// Only the required key the distribution lacks is added
+		{"CloudFront.14", "E1", []string{`"CostCenter" = "team-name"`, "- Key: CostCenter"}, []string{`"Owner"`, "Key: Owner"}},
+	}
+	for _, test := range tests {
+		t.Run(test.control, func(t *testing.T) {
+			got := Generate(test.control, inv, types.Findings{{Resource: test.resource, Status: "FAIL"}})
+			if len(got) != 1 {
+				t.Fatalf("got %d snippets, want 1", len(got))
+			}
+			text := got[0].Terraform + "\n" + got[0].CloudFormation
+			for _, fragment := range test.contains {
+				if !strings.Contains(text, fragment) {
+					t.Errorf("snippet lacks %s:\n%s", fragment, text)
+				}
+			}
+			for _, fragment := range test.excludes {
+				if strings.Contains(text, fragment) {
+					t.Errorf("snippet has %s:\n%s", fragment, text)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,252 @@
+// throttle/throttle.go
+package throttle
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/smithy-go/middleware"
+)
+
+// Retry modes
+const (
+	ModeStandard = "standard"
+	ModeAdaptive = "adaptive" // standard retries plus client-side rate limiting once AWS starts throttling
+)
+
+// Options configures how API calls are retried and paced
+type Options struct {
+	Mode        string             // standard or adaptive
+	MaxAttempts int                // attempts per call, including the first one
+	MaxBackoff  time.Duration      // upper bound of the delay between attempts
+	RateLimits  map[string]float64 // requests per second by service, e.g. "ec2" or "apigateway"
+}
+
+// Policy holds the retryer, the rate limiters and the counters shared by every aws.Config it is
+// applied to, so that all clients of a run draw from the same limits and, in adaptive mode, the same
+// token bucket
+type Policy struct {
+	retryer  aws.Retryer
+	limiters map[string]*limiter
+	stats    *Stats
+}
+
+// New validates the options and creates a policy
+func New(options Options) (*Policy, error) {
+	if options.MaxAttempts < 1 {
+		return nil, fmt.Errorf("max attempts must be at least 1, got %d", options.MaxAttempts)
+	}
+	standard := func(o *retry.StandardOptions) {
+		o.MaxAttempts = options.MaxAttempts
+		if options.MaxBackoff > 0 {
+			o.MaxBackoff = options.MaxBackoff
+		}
+	}
+
+	policy := &Policy{limiters: make(map[string]*limiter), stats: &Stats{services: make(map[string]*Counts)}}
+	switch options.Mode {
+	case ModeStandard:
+		policy.retryer = retry.NewStandard(standard)
+	case ModeAdaptive:
+		policy.retryer = retry.NewAdaptiveMode(func(o *retry.AdaptiveModeOptions) {
+			o.StandardOptions = append(o.StandardOptions, standard)
+		})
+	default:
+		return nil, fmt.Errorf("unsupported retry mode %q (supported: %s, %s)", options.Mode, ModeStandard, ModeAdaptive)
+	}
+
+	for service, rps := range options.RateLimits {
+		if rps <= 0 {
+			return nil, fmt.Errorf("rate limit of %s must be positive, got %v", service, rps)
+		}
+		policy.limiters[serviceKey(service)] = newLimiter(rps)
+	}
+	return policy, nil
+}
+
+// Apply sets the retryer of cfg and adds the rate limiting and counting middleware to it
+func (p *Policy) Apply(cfg *aws.Config) {
+	cfg.Retryer = func() aws.Retryer { return p.retryer }
+	cfg.APIOptions = append(cfg.APIOptions, func(stack *middleware.Stack) error {
+		if err := stack.Initialize.Add(callCounter{p.stats}, middleware.After); err != nil {
+			return err
+		}
+		// Right after the retry middleware, so that every attempt waits for the limiter and is counted
+		attempt := attemptHandler{stats: p.stats, limiters: p.limiters}
+		if _, ok := stack.Finalize.Get("Retry"); ok {
+			return stack.Finalize.Insert(attempt, "Retry", middleware.After)
+		}
+		return stack.Finalize.Add(attempt, middleware.Before)
+	})
+}
+
+// Stats returns the counters of the calls made through the configs the policy was applied to
+func (p *Policy) Stats() *Stats {
+	return p.stats
+}
+
+// serviceKey normalizes service names, so that "API Gateway", "apigateway" and "api-gateway" match
+func serviceKey(service string) string {
+	return strings.NewReplacer(" ", "", "-", "", "_", "").Replace(strings.ToLower(service))
+}
+
+// Counts are the API calls made to one service
+type Counts struct {
+	Calls     int `json:"Calls"`
+	Retries   int `json:"Retries"`   // attempts beyond the first one of each call
+	Throttles int `json:"Throttles"` // attempts rejected by AWS with a throttling error
+}
+
+// Stats counts API calls, retries and throttling errors by service. It is safe for concurrent use.
+type Stats struct {
+	mu       sync.Mutex
+	services map[string]*Counts
+}
+
+// Summary is a snapshot of Stats
+type Summary struct {
+	Counts
+	Services map[string]Counts `json:"Services,omitempty"`
+}
+
+// Summary returns the totals and the counts of every service called so far
+func (s *Stats) Summary() Summary {
+	summary := Summary{Services: make(map[string]Counts)}
+	if s == nil {
+		return summary
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for service, counts := range s.services {
+		summary.Services[service] = *counts
+		summary.Calls += counts.Calls
+		summary.Retries += counts.Retries
+		summary.Throttles += counts.Throttles
+	}
+	return summary
+}
+
+// String renders the summary on one line, services in alphabetical order
+func (s Summary) String() string {
+	line := fmt.Sprintf("%d API call(s), %d retried attempt(s), %d throttled", s.Calls, s.Retries, s.Throttles)
+	if s.Retries == 0 && s.Throttles == 0 {
+		return line
+	}
+	var services []string
+	for service, counts := range s.Services {
+		if counts.Retries > 0 || counts.Throttles > 0 {
+			services = append(services, fmt.Sprintf("%s: %d/%d/%d", service, counts.Calls, counts.Retries, counts.Throttles))
+		}
+	}
+	sort.Strings(services)
+	return line + " (calls/retries/throttled " + strings.Join(services, ", ") + ")"
+}
+
+func (s *Stats) add(service string, update func(*Counts)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	counts, ok := s.services[service]
+	if !ok {
+		counts = &Counts{}
+		s.services[service] = counts
+	}
+	update(counts)
+}
+
+// callCounter counts operations once, however many attempts they take
+type callCounter struct{ stats *Stats }
+
+func (callCounter) ID() string { return "ThrottleCallCounter" }
+
+func (c callCounter) HandleInitialize(ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler) (
+	middleware.InitializeOutput, middleware.Metadata, error,
+) {
+	c.stats.add(awsmiddleware.GetServiceID(ctx), func(counts *Counts) { counts.Calls++ })
+	return next.HandleInitialize(context.WithValue(ctx, attemptsKey{}, new(int)), in)
+}
+
+// attemptsKey holds the number of attempts made so far by the operation of a context
+type attemptsKey struct{}
+
+// attemptHandler waits for the rate limit of the service before each attempt and counts retries and throttles
+type attemptHandler struct {
+	stats    *Stats
+	limiters map[string]*limiter
+}
+
+func (attemptHandler) ID() string { return "ThrottleAttempt" }
+
+var throttles = retry.IsErrorThrottles(retry.DefaultThrottles)
+
+func (a attemptHandler) HandleFinalize(ctx context.Context, in middleware.FinalizeInput, next middleware.FinalizeHandler) (
+	middleware.FinalizeOutput, middleware.Metadata, error,
+) {
+	service := awsmiddleware.GetServiceID(ctx)
+	if limiter, ok := a.limiters[serviceKey(service)]; ok {
+		if err := limiter.wait(ctx); err != nil {
+			return middleware.FinalizeOutput{}, middleware.Metadata{}, err
+		}
+	}
+
+	retried := false
+	if attempts, ok := ctx.Value(attemptsKey{}).(*int); ok {
+		*attempts++
+		retried = *attempts > 1
+	}
+	out, metadata, err := next.HandleFinalize(ctx, in)
+	throttled := err != nil && throttles.IsErrorThrottle(err) == aws.TrueTernary
+	if retried || throttled {
+		a.stats.add(service, func(counts *Counts) {
+			if retried {
+				counts.Retries++
+			}
+			if throttled {
+				counts.Throttles++
+			}
+		})
+	}
+	return out, metadata, err
+}
+
+// limiter spaces requests evenly at a fixed rate, without bursts
+type limiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+func newLimiter(rps float64) *limiter {
+	return &limiter{interval: time.Duration(float64(time.Second) / rps)}
+}
+
+// wait blocks until the next request slot, or until ctx is done
+func (l *limiter) wait(ctx context.Context) error {
+	l.mu.Lock()
+	now := time.Now()
+	slot := l.next
+	if slot.Before(now) {
+		slot = now
+	}
+	l.next = slot.Add(l.interval)
+	l.mu.Unlock()
+
+	delay := slot.Sub(now)
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,46 @@
+// throttle/throttle_test.go
+package throttle
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+func TestApplySharesRetryer(t *testing.T) {
+	for _, mode := range []string{ModeStandard, ModeAdaptive} {
+		t.Run(mode, func(t *testing.T) {
+			policy, err := New(Options{Mode: mode, MaxAttempts: 3})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var first, second aws.Config
+			policy.Apply(&first)
+			policy.Apply(&second)
+			if first.Retryer() != second.Retryer() || first.Retryer() != first.Retryer() {
+				t.Error("clients got different retryers, want the retryer of the policy")
+			}
+			if attempts := first.Retryer().MaxAttempts(); attempts != 3 {
+				t.Errorf("max attempts = %d, want 3", attempts)
+			}
+		})
+	}
+}
+
+func TestNewRejectsInvalidOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		options Options
+	}{
+		{"no attempts", Options{Mode: ModeStandard}},
+		{"unknown mode", Options{Mode: "legacy", MaxAttempts: 3}},
+		{"non-positive rate limit", Options{Mode: ModeStandard, MaxAttempts: 3, RateLimits: map[string]float64{"ec2": 0}}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := New(test.options); err == nil {
+				t.Error("error = nil, want an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+// tickets/github.go
+package tickets
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// GitHub manages issues of a repository. The fingerprint is kept in a hidden comment of the issue body.
+type GitHub struct {
+	config Config
+	token  string
+	client *http.Client
+}
+
+var githubFingerprint = regexp.MustCompile(`<!-- security-hub-fingerprint: ([0-9a-f]+) -->`)
+
+type githubIssue struct {
+	Number      int         `json:"number"`
+	Body        string      `json:"body"`
+	PullRequest interface{} `json:"pull_request"`
+}
+
+// Open lists the open issues carrying the label
+func (g *GitHub) Open() (map[string]Ticket, error) {
+	open := make(map[string]Ticket)
+	for page := 1; ; page++ {
+		query := url.Values{
+			"state":    {"open"},
+			"labels":   {g.config.Label},
+			"per_page": {"100"},
+			"page":     {strconv.Itoa(page)},
+		}
+		var issues []githubIssue
+		if err := request(g.client, http.MethodGet, g.url("issues")+"?"+query.Encode(), g.header(), nil, &issues); err != nil {
+			return nil, err
+		}
+		for _, issue := range issues {
+			match := githubFingerprint.FindStringSubmatch(issue.Body)
+			if issue.PullRequest != nil || match == nil {
+				continue
+			}
+			open[match[1]] = Ticket{ID: strconv.Itoa(issue.Number), Fingerprint: match[1]}
+		}
+		if len(issues) < 100 {
+			return open, nil
+		}
+	}
+}
+
+// Create opens an issue
+func (g *GitHub) Create(issue Issue) (Ticket, error) {
+	in := map[string]interface{}{
+		"title":  issue.Title,
+		"body":   fmt.Sprintf("%s\n<!-- security-hub-fingerprint: %s -->\n", issue.Body, issue.Fingerprint),
+		"labels": []string{g.config.Label},
+	}
+	var created githubIssue
+	if err := request(g.client, http.MethodPost, g.url("issues"), g.header(), in, &created); err != nil {
+		return Ticket{}, err
+	}
+	return Ticket{ID: strconv.Itoa(created.Number), Fingerprint: issue.Fingerprint}, nil
+}
+
+// Comment adds a comment to an issue
+func (g *GitHub) Comment(ticket Ticket, body string) error {
+	return request(g.client, http.MethodPost, g.url("issues", ticket.ID, "comments"), g.header(), map[string]string{"body": body}, nil)
+}
+
+// Close comments on an issue and closes it as completed
+func (g *GitHub) Close(ticket Ticket, comment string) error {
+	if err := g.Comment(ticket, comment); err != nil {
+		return err
+	}
+	in := map[string]string{"state": "closed", "state_reason": "completed"}
+	return request(g.client, http.MethodPatch, g.url("issues", ticket.ID), g.header(), in, nil)
+}
+
+func (g *GitHub) url(path ...string) string {
+	return strings.TrimSuffix(g.config.BaseURL, "/") + "/repos/" + g.config.Repository + "/" + strings.Join(path, "/")
+}
+
+func (g *GitHub) header() http.Header {
+	header := http.Header{"X-Github-Api-Version": {"2022-11-28"}}
+	if g.token != "" {
+		header.Set("Authorization", "Bearer "+g.token)
+	}
+	return header
+}
@@ -0,0 +1,127 @@
+// tickets/jira.go
+package tickets
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Jira manages issues of a project through the REST API v2. The fingerprint is kept in a label.
+type Jira struct {
+	config Config
+	token  string
+	client *http.Client
+}
+
+const jiraFingerprintPrefix = "security-hub-"
+
+// Open searches the unresolved issues of the project carrying the label
+func (j *Jira) Open() (map[string]Ticket, error) {
+	jql := fmt.Sprintf(`project = "%s" AND labels = "%s" AND statusCategory != Done`, j.config.Project, j.config.Label)
+	open := make(map[string]Ticket)
+	for startAt := 0; ; {
+		query := url.Values{
+			"jql":        {jql},
+			"fields":     {"labels"},
+			"startAt":    {strconv.Itoa(startAt)},
+			"maxResults": {"100"},
+		}
+		var page struct {
+			Total  int `json:"total"`
+			Issues []struct {
+				Key    string `json:"key"`
+				Fields struct {
+					Labels []string `json:"labels"`
+				} `json:"fields"`
+			} `json:"issues"`
+		}
+		if err := request(j.client, http.MethodGet, j.url("search")+"?"+query.Encode(), j.header(), nil, &page); err != nil {
+			return nil, err
+		}
+		for _, issue := range page.Issues {
+			for _, label := range issue.Fields.Labels {
+				if fingerprint, ok := strings.CutPrefix(label, jiraFingerprintPrefix); ok {
+					open[fingerprint] = Ticket{ID: issue.Key, Fingerprint: fingerprint}
+				}
+			}
+		}
+		startAt += len(page.Issues)
+		if len(page.Issues) == 0 || startAt >= page.Total {
+			return open, nil
+		}
+	}
+}
+
+// Create opens an issue
+func (j *Jira) Create(issue Issue) (Ticket, error) {
+	in := map[string]interface{}{
+		"fields": map[string]interface{}{
+			"project":     map[string]string{"key": j.config.Project},
+			"issuetype":   map[string]string{"name": j.config.IssueType},
+			"summary":     issue.Title,
+			"description": issue.Body,
+			"labels":      []string{j.config.Label, jiraFingerprintPrefix + issue.Fingerprint},
+		},
+	}
+	var created struct {
+		Key string `json:"key"`
+	}
+	if err := request(j.client, http.MethodPost, j.url("issue"), j.header(), in, &created); err != nil {
+		return Ticket{}, err
+	}
+	return Ticket{ID: created.Key, Fingerprint: issue.Fingerprint}, nil
+}
+
+// Comment adds a comment to an issue
+func (j *Jira) Comment(ticket Ticket, body string) error {
+	return request(j.client, http.MethodPost, j.url("issue", ticket.ID, "comment"), j.header(), map[string]string{"body": body}, nil)
+}
+
+// Close comments on an issue and moves it through the first transition into the Done category
+func (j *Jira) Close(ticket Ticket, comment string) error {
+	if err := j.Comment(ticket, comment); err != nil {
+		return err
+	}
+
+	var available struct {
+		Transitions []struct {
+			ID string `json:"id"`
+			To struct {
+				StatusCategory struct {
+					Key string `json:"key"`
+				} `json:"statusCategory"`
+			} `json:"to"`
+		} `json:"transitions"`
+	}
+	if err := request(j.client, http.MethodGet, j.url("issue", ticket.ID, "transitions"), j.header(), nil, &available); err != nil {
+		return err
+	}
+	for _, transition := range available.Transitions {
+		if transition.To.StatusCategory.Key == "done" {
+			in := map[string]interface{}{"transition": map[string]string{"id": transition.ID}}
+			return request(j.client, http.MethodPost, j.url("issue", ticket.ID, "transitions"), j.header(), in, nil)
+		}
+	}
+	return fmt.Errorf("issue %s has no transition to a done status", ticket.ID)
+}
+
+func (j *Jira) url(path ...string) string {
+	return strings.TrimSuffix(j.config.BaseURL, "/") + "/rest/api/2/" + strings.Join(path, "/")
+}
+
+func (j *Jira) header() http.Header {
+	header := http.Header{}
+	switch {
+	case j.token == "":
+	case j.config.User != "":
+		credentials := base64.StdEncoding.EncodeToString([]byte(j.config.User + ":" + j.token))
+		header.Set("Authorization", "Basic "+credentials)
+	default:
+		header.Set("Authorization", "Bearer "+j.token)
+	}
+	return header
+}
@@ -0,0 +1,232 @@
+// tickets/tickets.go
+package tickets
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"aws-security-hub/report"
+	"aws-security-hub/types"
+)
+
+// Trackers
+const (
+	TrackerGitHub = "github"
+	TrackerJira   = "jira"
+)
+
+// Config selects and authenticates against an issue tracker
+type Config struct {
+	Tracker    string `yaml:"tracker"`    // github or jira
+	BaseURL    string `yaml:"base_url"`   // API root; defaults to https://api.github.com for GitHub, required for Jira
+	Repository string `yaml:"repository"` // GitHub owner/name
+	Project    string `yaml:"project"`    // Jira project key
+	IssueType  string `yaml:"issue_type"` // Jira issue type, default Task
+	User       string `yaml:"user"`       // Jira Cloud account email; without it the token is sent as a bearer token
+	TokenEnv   string `yaml:"token_env"`  // environment variable holding the token, default GITHUB_TOKEN or JIRA_TOKEN
+	Label      string `yaml:"label"`      // label of the tickets managed by the auditor, default security-hub
+}
+
+// Ticket is an open ticket of a failing resource
+type Ticket struct {
+	ID          string // GitHub issue number or Jira issue key
+	Fingerprint string
+}
+
+// Issue is the content of a new ticket
+type Issue struct {
+	Fingerprint string
+	Title       string
+	Body        string
+}
+
+// Tracker opens, comments on and closes the tickets it manages
+type Tracker interface {
+	// Open returns the open tickets managed by the auditor, by fingerprint
+	Open() (map[string]Ticket, error)
+	Create(issue Issue) (Ticket, error)
+	Comment(ticket Ticket, body string) error
+	Close(ticket Ticket, comment string) error
+}
+
+// New validates a tracker configuration and creates its client
+func New(config Config) (Tracker, error) {
+	if config.Label == "" {
+		config.Label = "security-hub"
+	}
+	client := &http.Client{Timeout: 30 * time.Second}
+
+	switch config.Tracker {
+	case TrackerGitHub:
+		if !strings.Contains(config.Repository, "/") {
+			return nil, fmt.Errorf("github tracker needs repository as owner/name")
+		}
+		if config.BaseURL == "" {
+			config.BaseURL = "https://api.github.com"
+		}
+		return &GitHub{config: config, token: token(config, "GITHUB_TOKEN"), client: client}, nil
+	case TrackerJira:
+		if config.BaseURL == "" || config.Project == "" {
+			return nil, fmt.Errorf("jira tracker needs base_url and project")
+		}
+		if config.IssueType == "" {
+			config.IssueType = "Task"
+		}
+		return &Jira{config: config, token: token(config, "JIRA_TOKEN"), client: client}, nil
+	}
+	return nil, fmt.Errorf("unsupported tracker %q (supported: %s, %s)", config.Tracker, TrackerGitHub, TrackerJira)
+}
+
+func token(config Config, defaultEnv string) string {
+	if config.TokenEnv != "" {
+		return os.Getenv(config.TokenEnv)
+	}
+	return os.Getenv(defaultEnv)
+}
+
+// Fingerprint identifies a (control, resource) failure across runs
+func Fingerprint(account, region, controlID, resource string) string {
+	sum := sha256.Sum256([]byte(strings.Join([]string{account, region, controlID, resource}, "\x00")))
+	return hex.EncodeToString(sum[:8])
+}
+
+// Summary counts what a sync did
+type Summary struct {
+	Created   int
+	Commented int
+	Closed    int
+	Skipped   int // results of AWS scans whose account is unknown
+}
+
+// Sync opens a ticket for every failing resource of the report that has none, comments on the open
+// tickets of resources whose failure changed since the previous report (nil when there is none), and
+// closes the tickets of resources that pass again. Resources that are no longer reported keep their
+// tickets. Results of a region whose account could not be resolved are skipped, since their
+// fingerprints would not match the tickets opened with the account.
+func Sync(tracker Tracker, previous, result *report.Report) (Summary, error) {
+	var summary Summary
+	open, err := tracker.Open()
+	if err != nil {
+		return summary, fmt.Errorf("failed to list open tickets: %v", err)
+	}
+
+	before := make(map[string]types.Finding)
+	if previous != nil {
+		for _, r := range previous.Results {
+			for _, finding := range r.Findings {
+				before[Fingerprint(r.Account, r.Region, r.ID, finding.Resource)] = finding
+			}
+		}
+	}
+
+	when := result.GeneratedAt.UTC().Format(time.RFC3339)
+	for _, r := range result.Results {
+		if r.Account == "" && r.Region != "" {
+			slog.Warn("skipped tickets of a result without account", "control", r.ID, "region", r.Region)
+			summary.Skipped++
+			continue
+		}
+		for _, finding := range r.Findings {
+			fingerprint := Fingerprint(r.Account, r.Region, r.ID, finding.Resource)
+			ticket, exists := open[fingerprint]
+
+			switch {
+			case finding.Status == "FAIL" && !exists:
+				ticket, err := tracker.Create(Issue{
+					Fingerprint: fingerprint,
+					Title:       fmt.Sprintf("[%s] %s: %s", r.ID, finding.Resource, r.Description),
+					Body:        body(r, finding.Resource, finding.Reason),
+				})
+				if err != nil {
+					return summary, fmt.Errorf("failed to open ticket for %s %s: %v", r.ID, finding.Resource, err)
+				}
+				open[fingerprint] = ticket
+				slog.Info("opened ticket", "ticket", ticket.ID, "control", r.ID, "resource", finding.Resource)
+				summary.Created++
+			case finding.Status == "FAIL":
+				last, reported := before[fingerprint]
+				if previous == nil || (reported && last.Status == "FAIL" && last.Reason == finding.Reason) {
+					continue
+				}
+				comment := fmt.Sprintf("Failing again as of %s: %s", when, finding.Reason)
+				if reported && last.Status == "FAIL" {
+					comment = fmt.Sprintf("Still failing as of %s, now: %s", when, finding.Reason)
+				}
+				if err := tracker.Comment(ticket, comment); err != nil {
+					return summary, fmt.Errorf("failed to comment on ticket %s: %v", ticket.ID, err)
+				}
+				slog.Info("commented on ticket", "ticket", ticket.ID, "control", r.ID, "resource", finding.Resource)
+				summary.Commented++
+			case finding.Status == "PASS" && exists:
+				if err := tracker.Close(ticket, fmt.Sprintf("Passing as of %s: %s", when, finding.Reason)); err != nil {
+					return summary, fmt.Errorf("failed to close ticket %s: %v", ticket.ID, err)
+				}
+				delete(open, fingerprint)
+				slog.Info("closed ticket", "ticket", ticket.ID, "control", r.ID, "resource", finding.Resource)
+				summary.Closed++
+			}
+		}
+	}
+	return summary, nil
+}
+
+func body(r report.Result, resource, reason string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s\n\n", r.Description)
+	fmt.Fprintf(&b, "Control: %s\nSeverity: %s\nResource: %s\n", r.ID, r.Severity, resource)
+	if r.Account != "" {
+		fmt.Fprintf(&b, "Account: %s\n", r.Account)
+	}
+	if r.Region != "" {
+		fmt.Fprintf(&b, "Region: %s\n", r.Region)
+	}
+	fmt.Fprintf(&b, "Source: %s\nReason: %s\n", r.Source, reason)
+	return b.String()
+}
+
+// request sends a JSON request and decodes a JSON response into out, when given
+func request(client *http.Client, method, url string, header http.Header, in, out interface{}) error {
+	var payload io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		payload = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, url, payload)
+	if err != nil {
+		return err
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	req.Header.Set("Accept", "application/json")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	response, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode >= 300 {
+		message, _ := io.ReadAll(io.LimitReader(response.Body, 512))
+		return fmt.Errorf("%s %s responded with %s: %s", method, url, response.Status, strings.TrimSpace(string(message)))
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(response.Body).Decode(out)
+}
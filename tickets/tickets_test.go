@@ -0,0 +1,199 @@
+// tickets/tickets_test.go
+package tickets
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"aws-security-hub/report"
+	"aws-security-hub/types"
+)
+
+// mock records the requests of a tracker client and answers them with the handler
+type mock struct {
+	mu       sync.Mutex
+	requests []string // method and path, e.g. "POST /repos/example/findings/issues"
+	bodies   []map[string]interface{}
+}
+
+func (m *mock) serve(t *testing.T, handler func(method, path string) (int, string)) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		var body map[string]interface{}
+		json.Unmarshal(data, &body)
+		m.mu.Lock()
+		m.requests = append(m.requests, r.Method+" "+r.URL.Path)
+		m.bodies = append(m.bodies, body)
+		m.mu.Unlock()
+		if r.Header.Get("Authorization") == "" {
+			t.Errorf("%s %s sent without Authorization", r.Method, r.URL.Path)
+		}
+		status, response := handler(r.Method, r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		io.WriteString(w, response)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func (m *mock) calls(method string) []string {
+	var calls []string
+	for _, request := range m.requests {
+		if len(request) > len(method) && request[:len(method)+1] == method+" " {
+			calls = append(calls, request)
+		}
+	}
+	return calls
+}
+
+const account, region = "123456789012", "ap-northeast-2"
+
+// scan returns a report of DocumentDB.1 with the given status and reason by cluster
+func scan(hour int, findings ...types.Finding) *report.Report {
+	return &report.Report{
+		GeneratedAt: time.Date(2026, 1, 1, hour, 0, 0, 0, time.UTC),
+		Results: []report.Result{{
+			ID: "DocumentDB.1", Description: "DocumentDB clusters should be encrypted at rest", Severity: "Medium",
+			Status: "FAIL", Account: account, Region: region, Findings: findings,
+		}},
+	}
+}
+
+func TestSyncGitHub(t *testing.T) {
+	t.Setenv("TEST_GITHUB_TOKEN", "token")
+	var m mock
+	server := m.serve(t, func(method, path string) (int, string) {
+		switch {
+		case method == http.MethodGet && path == "/repos/example/findings/issues":
+			return http.StatusOK, fmt.Sprintf(`[
+{"number": 1, "body": "orders\n<!-- security-hub-fingerprint: %s -->\n"},
+{"number": 2, "body": "users\n<!-- security-hub-fingerprint: %s -->\n"},
+{"number": 3, "body": "archive\n<!-- security-hub-fingerprint: %s -->\n"},
+{"number": 4, "body": "pull request", "pull_request": {}}]`,
+				Fingerprint(account, region, "DocumentDB.1", "orders"),
+				Fingerprint(account, region, "DocumentDB.1", "users"),
+				Fingerprint(account, region, "DocumentDB.1", "archive"))
+		case method == http.MethodPost && path == "/repos/example/findings/issues":
+			return http.StatusCreated, `{"number": 5}`
+		}
+		return http.StatusOK, `{}`
+	})
+	tracker, err := New(Config{Tracker: TrackerGitHub, BaseURL: server.URL, Repository: "example/findings", TokenEnv: "TEST_GITHUB_TOKEN"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	previous := scan(1,
+		types.Finding{Resource: "orders", Status: "FAIL", Reason: "Storage is not encrypted"},
+		types.Finding{Resource: "users", Status: "FAIL", Reason: "Storage is not encrypted"},
+		types.Finding{Resource: "archive", Status: "FAIL", Reason: "Storage is not encrypted"})
+	current := scan(2,
+		types.Finding{Resource: "orders", Status: "FAIL", Reason: "Storage is not encrypted"},               // unchanged
+		types.Finding{Resource: "users", Status: "FAIL", Reason: "Storage is encrypted with a default key"}, // changed
+		types.Finding{Resource: "archive", Status: "PASS", Reason: "Storage is encrypted"},
+		types.Finding{Resource: "billing", Status: "FAIL", Reason: "Storage is not encrypted"})
+
+	summary, err := Sync(tracker, previous, current)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (Summary{Created: 1, Commented: 1, Closed: 1}); summary != want {
+		t.Errorf("summary = %+v, want %+v", summary, want)
+	}
+	wantPosts := []string{
+		"POST /repos/example/findings/issues/2/comments",
+		"POST /repos/example/findings/issues/3/comments",
+		"POST /repos/example/findings/issues",
+	}
+	if posts := m.calls(http.MethodPost); !reflect.DeepEqual(posts, wantPosts) {
+		t.Errorf("posts = %v, want %v", posts, wantPosts)
+	}
+	if patches := m.calls(http.MethodPatch); !reflect.DeepEqual(patches, []string{"PATCH /repos/example/findings/issues/3"}) {
+		t.Errorf("patches = %v, want issue 3 closed", patches)
+	}
+}
+
+func TestSyncJira(t *testing.T) {
+	t.Setenv("TEST_JIRA_TOKEN", "token")
+	var m mock
+	server := m.serve(t, func(method, path string) (int, string) {
+		switch {
+		case path == "/rest/api/2/search":
+			return http.StatusOK, fmt.Sprintf(`{"total": 1, "issues": [{"key": "SEC-1", "fields": {"labels": ["security-hub", "security-hub-%s"]}}]}`,
+				Fingerprint(account, region, "DocumentDB.1", "orders"))
+		case method == http.MethodPost && path == "/rest/api/2/issue":
+			return http.StatusCreated, `{"key": "SEC-2"}`
+		case method == http.MethodGet && path == "/rest/api/2/issue/SEC-1/transitions":
+			return http.StatusOK, `{"transitions": [{"id": "11", "to": {"statusCategory": {"key": "indeterminate"}}}, {"id": "31", "to": {"statusCategory": {"key": "done"}}}]}`
+		}
+		return http.StatusNoContent, ``
+	})
+	tracker, err := New(Config{Tracker: TrackerJira, BaseURL: server.URL, Project: "SEC", User: "me@example.com", TokenEnv: "TEST_JIRA_TOKEN"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	current := scan(2,
+		types.Finding{Resource: "orders", Status: "PASS", Reason: "Storage is encrypted"},
+		types.Finding{Resource: "users", Status: "FAIL", Reason: "Storage is not encrypted"})
+	summary, err := Sync(tracker, nil, current)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (Summary{Created: 1, Closed: 1}); summary != want {
+		t.Errorf("summary = %+v, want %+v", summary, want)
+	}
+	wantPosts := []string{
+		"POST /rest/api/2/issue/SEC-1/comment",
+		"POST /rest/api/2/issue/SEC-1/transitions",
+		"POST /rest/api/2/issue",
+	}
+	if posts := m.calls(http.MethodPost); !reflect.DeepEqual(posts, wantPosts) {
+		t.Errorf("posts = %v, want %v", posts, wantPosts)
+	}
+	for i, request := range m.requests {
+		if request == "POST /rest/api/2/issue/SEC-1/transitions" && !reflect.DeepEqual(m.bodies[i]["transition"], map[string]interface{}{"id": "31"}) {
+			t.Errorf("transition = %v, want the done transition 31", m.bodies[i]["transition"])
+		}
+		if request == "POST /rest/api/2/issue" {
+			fields := m.bodies[i]["fields"].(map[string]interface{})
+			want := []interface{}{"security-hub", "security-hub-" + Fingerprint(account, region, "DocumentDB.1", "users")}
+			if !reflect.DeepEqual(fields["labels"], want) {
+				t.Errorf("labels = %v, want %v", fields["labels"], want)
+			}
+		}
+	}
+}
+
+func TestSyncSkipsUnknownAccount(t *testing.T) {
+	t.Setenv("TEST_GITHUB_TOKEN", "token")
+	var m mock
+	server := m.serve(t, func(method, path string) (int, string) {
+		return http.StatusOK, `[]`
+	})
+	tracker, err := New(Config{Tracker: TrackerGitHub, BaseURL: server.URL, Repository: "example/findings", TokenEnv: "TEST_GITHUB_TOKEN"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	current := scan(2, types.Finding{Resource: "orders", Status: "FAIL", Reason: "Storage is not encrypted"})
+	current.Results[0].Account = ""
+	summary, err := Sync(tracker, nil, current)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (Summary{Skipped: 1}); summary != want {
+		t.Errorf("summary = %+v, want %+v", summary, want)
+	}
+	if posts := m.calls(http.MethodPost); len(posts) > 0 {
+		t.Errorf("posts = %v, want none", posts)
+	}
+}
@@ -0,0 +1,138 @@
+// tui/controls.go
+package tui
+
+import (
+	"strings"
+
+	"aws-security-hub/report"
+	"aws-security-hub/types"
+	"aws-security-hub/util"
+)
+
+// control is a row of the control list with the results received for it so far, one per account
+// and region scanned
+type control struct {
+	ID          string
+	Service     string // prefix of the control ID, e.g. CloudFront, or the service of a control pack control
+	Severity    string
+	Description string
+	Requirement *util.Requirement // compliance metadata, nil when unknown
+	Results     []report.Result
+}
+
+// newControl returns the row of a control with its compliance metadata
+func newControl(c types.Control, compliance *util.Compliance) *control {
+	metadata := c.Metadata()
+	service, _, _ := strings.Cut(metadata.ID, ".")
+	if metadata.Service != "" {
+		service = metadata.Service
+	}
+	row := &control{ID: metadata.ID, Service: service}
+	if requirement := report.Requirement(compliance, c); requirement != nil {
+		row.Requirement = requirement
+		row.Description = requirement.Description
+		if len(requirement.Attributes) > 0 {
+			row.Severity = requirement.Attributes[0].Severity
+		}
+	}
+	return row
+}
+
+// Status is the status of the control over every result: FAIL if any result failed, then ERROR, PASS
+// and NA, or PENDING before the first result
+func (c *control) Status() string {
+	if len(c.Results) == 0 {
+		return "PENDING"
+	}
+	for _, status := range []string{"FAIL", "ERROR", "PASS"} {
+		for _, result := range c.Results {
+			if result.Status == status {
+				return status
+			}
+		}
+	}
+	return "NA"
+}
+
+// Count returns the number of findings of every result with the given status, or of all findings
+// when status is empty
+func (c *control) Count(status string) int {
+	count := 0
+	for _, result := range c.Results {
+		for _, finding := range result.Findings {
+			if status == "" || finding.Status == status {
+				count++
+			}
+		}
+	}
+	return count
+}
+
+// resource locates a finding of a control: the result it belongs to, then its index in the findings
+type resource struct {
+	result  int
+	finding int
+}
+
+// Resources lists the findings of every result, failing ones first
+func (c *control) Resources() []resource {
+	var resources []resource
+	for _, status := range []string{"FAIL", "ERROR", "SUPPRESSED", "PASS"} {
+		for i, result := range c.Results {
+			for j, finding := range result.Findings {
+				if finding.Status == status {
+					resources = append(resources, resource{result: i, finding: j})
+				}
+			}
+		}
+	}
+	return resources
+}
+
+// Suppress applies a new suppression to every result of the control, returning the number of
+// findings it suppressed
+func (c *control) Suppress(suppression types.Suppression) int {
+	before := c.Count("SUPPRESSED")
+	for i := range c.Results {
+		result := &c.Results[i]
+		result.Status, result.Findings = types.Suppress(c.ID, result.Status, result.Findings, []types.Suppression{suppression})
+	}
+	return c.Count("SUPPRESSED") - before
+}
+
+// Filter values, cycled through with keys; the empty value matches everything
+var (
+	statusFilters   = []string{"", "FAIL", "ERROR", "PASS", "NA", "PENDING"}
+	severityFilters = []string{"", "CRITICAL", "HIGH", "MEDIUM", "LOW"}
+)
+
+// filter selects the rows of the control list
+type filter struct {
+	Service  string
+	Status   string
+	Severity string
+}
+
+// Matches reports whether a control passes the filter
+func (f filter) Matches(c *control) bool {
+	if f.Service != "" && c.Service != f.Service {
+		return false
+	}
+	if f.Status != "" && c.Status() != f.Status {
+		return false
+	}
+	if f.Severity != "" && !strings.EqualFold(c.Severity, f.Severity) {
+		return false
+	}
+	return true
+}
+
+// next returns the value after current in values, wrapping around; step -1 goes backwards
+func next(values []string, current string, step int) string {
+	for i, value := range values {
+		if value == current {
+			return values[(i+step+len(values))%len(values)]
+		}
+	}
+	return values[0]
+}
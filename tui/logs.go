@@ -0,0 +1,50 @@
+// tui/logs.go
+package tui
+
+import (
+	"strings"
+	"sync"
+)
+
+// maxLogLines is the number of log lines kept for the log view
+const maxLogLines = 1000
+
+// logBuffer keeps the last log lines while the UI owns the terminal, since writing them to stderr
+// would garble the screen
+type logBuffer struct {
+	mu      sync.Mutex
+	lines   []string
+	changed chan struct{} // receives a value when lines were added since the last draw
+}
+
+func newLogBuffer() *logBuffer {
+	return &logBuffer{changed: make(chan struct{}, 1)}
+}
+
+// Write adds the lines of a log record
+func (b *logBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	for _, line := range strings.Split(strings.TrimRight(string(p), "\n"), "\n") {
+		b.lines = append(b.lines, line)
+	}
+	if len(b.lines) > maxLogLines {
+		b.lines = append([]string(nil), b.lines[len(b.lines)-maxLogLines:]...)
+	}
+	b.mu.Unlock()
+
+	select {
+	case b.changed <- struct{}{}:
+	default:
+	}
+	return len(p), nil
+}
+
+// Tail returns the last n lines
+func (b *logBuffer) Tail(n int) []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if n > len(b.lines) {
+		n = len(b.lines)
+	}
+	return append([]string(nil), b.lines[len(b.lines)-n:]...)
+}
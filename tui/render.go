@@ -0,0 +1,343 @@
+// tui/render.go
+package tui
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// statusStyles are the SGR colours of each status
+var statusStyles = map[string]string{
+	"PASS":       "32",
+	"FAIL":       "31",
+	"ERROR":      "33",
+	"SUPPRESSED": "36",
+	"NA":         "2",
+	"PENDING":    "2",
+}
+
+// Styles of the bars
+const (
+	styleBar    = "7" // reverse video: title bar and selected row
+	styleHeader = "1"
+	styleDim    = "2"
+)
+
+// line builds a row of the screen out of cells, cutting it at the width of the terminal
+type line struct {
+	b    strings.Builder
+	left int    // columns left
+	base string // style of every cell, e.g. reverse video for the selected row
+}
+
+func newLine(width int, base string) *line {
+	return &line{left: width, base: base}
+}
+
+// add writes text padded or cut to size columns in the given style; size 0 takes every column left
+func (l *line) add(text string, size int, style string) *line {
+	if size <= 0 || size > l.left {
+		size = l.left
+	}
+	if size <= 0 {
+		return l
+	}
+	runes := []rune(strings.ReplaceAll(text, "\n", " "))
+	if len(runes) > size {
+		runes = append(runes[:max(size-1, 0)], '…')
+	}
+	text = string(runes) + strings.Repeat(" ", size-len(runes))
+	l.left -= size
+
+	if style = join(l.base, style); style == "" {
+		l.b.WriteString(text)
+	} else {
+		fmt.Fprintf(&l.b, "\x1b[%sm%s\x1b[0m", style, text)
+	}
+	return l
+}
+
+// String returns the row, filling the columns left in the base style
+func (l *line) String() string {
+	if l.base != "" && l.left > 0 {
+		l.add("", 0, "")
+	}
+	return l.b.String()
+}
+
+func join(styles ...string) string {
+	var parts []string
+	for _, style := range styles {
+		if style != "" {
+			parts = append(parts, style)
+		}
+	}
+	return strings.Join(parts, ";")
+}
+
+// wrap splits text into lines of at most width columns at spaces
+func wrap(text string, width int) []string {
+	var lines []string
+	for _, paragraph := range strings.Split(text, "\n") {
+		current := ""
+		for _, word := range strings.Fields(paragraph) {
+			if current != "" && utf8.RuneCountInString(current)+1+utf8.RuneCountInString(word) > width {
+				lines = append(lines, current)
+				current = ""
+			}
+			if current != "" {
+				current += " "
+			}
+			current += word
+		}
+		lines = append(lines, current)
+	}
+	return lines
+}
+
+// draw renders the current view over the whole screen
+func (u *UI) draw(w io.Writer) {
+	var rows []string
+	switch u.view {
+	case viewList:
+		rows = u.drawList()
+	case viewControl:
+		rows = u.drawControl()
+	case viewLogs:
+		rows = u.drawLogs()
+	}
+
+	var screen strings.Builder
+	screen.WriteString("\x1b[H")
+	for i := 0; i < u.height; i++ {
+		if i < len(rows) {
+			screen.WriteString(rows[i])
+		}
+		screen.WriteString("\x1b[K")
+		if i < u.height-1 {
+			screen.WriteString("\r\n")
+		}
+	}
+	io.WriteString(w, screen.String())
+}
+
+// footer returns the message line and the key help of the bottom of the screen
+func (u *UI) footer(help string) []string {
+	message := newLine(u.width, "")
+	switch {
+	case u.prompt != nil:
+		p := u.prompt
+		message.add(fmt.Sprintf("Suppress %s, %s: %s█", p.control, p.fields[p.field], p.values[p.field]), 0, styleHeader)
+		help = "enter next  esc cancel"
+	case u.message != "":
+		message.add(u.message, 0, styleHeader)
+	default:
+		if logs := u.logs.Tail(1); len(logs) > 0 {
+			message.add(logs[0], 0, styleDim)
+		}
+	}
+	return []string{message.String(), newLine(u.width, styleDim).add(help, 0, "").String()}
+}
+
+// progress describes how far the scan is
+func (u *UI) progress() string {
+	evaluated := 0
+	for _, row := range u.controls {
+		if len(row.Results) > 0 && len(row.Results) >= u.options.Runs {
+			evaluated++
+		}
+	}
+	switch {
+	case u.running:
+		return fmt.Sprintf("evaluating: %d/%d controls", evaluated, len(u.controls))
+	case u.stopped != nil:
+		return fmt.Sprintf("stopped: %v (%d/%d controls evaluated)", u.stopped, evaluated, len(u.controls))
+	}
+	return fmt.Sprintf("%d controls evaluated", evaluated)
+}
+
+func (u *UI) title(parts ...string) string {
+	return newLine(u.width, styleBar).add(" "+strings.Join(parts, " │ "), 0, "").String()
+}
+
+// listHeight is the number of controls that fit on the screen
+func (u *UI) listHeight() int {
+	return max(u.height-5, 1)
+}
+
+func (u *UI) drawList() []string {
+	rows := u.visible()
+	u.cursor = max(min(u.cursor, len(rows)-1), 0)
+	height := u.listHeight()
+	if u.cursor < u.offset {
+		u.offset = u.cursor
+	}
+	if u.cursor >= u.offset+height {
+		u.offset = u.cursor - height + 1
+	}
+	u.offset = max(min(u.offset, len(rows)-height), 0)
+
+	counts := make(map[string]int)
+	for _, row := range rows {
+		counts[row.Status()]++
+	}
+	var summary []string
+	for _, status := range statusFilters[1:] {
+		if counts[status] > 0 {
+			summary = append(summary, fmt.Sprintf("%d %s", counts[status], status))
+		}
+	}
+	filters := fmt.Sprintf(" service: %s │ status: %s │ severity: %s │ %d of %d controls: %s",
+		orAll(u.filter.Service), orAll(u.filter.Status), orAll(u.filter.Severity), len(rows), len(u.controls), strings.Join(summary, ", "))
+
+	screen := []string{
+		u.title("AWS Security Hub", u.options.Title, u.progress()),
+		newLine(u.width, "").add(filters, 0, "").String(),
+		newLine(u.width, styleHeader).add(" ID", 17, "").add("STATUS", 9, "").add("SEVERITY", 10, "").add("FAILING", 10, "").add("DESCRIPTION", 0, "").String(),
+	}
+	for i := u.offset; i < len(rows) && i < u.offset+height; i++ {
+		row := rows[i]
+		base, style := "", statusStyles[row.Status()]
+		if i == u.cursor {
+			base = styleBar
+		}
+		failing := "-"
+		if len(row.Results) > 0 {
+			failing = fmt.Sprintf("%d/%d", row.Count("FAIL"), row.Count(""))
+		}
+		screen = append(screen, newLine(u.width, base).
+			add(" "+row.ID, 17, "").
+			add(row.Status(), 9, style).
+			add(row.Severity, 10, "").
+			add(failing, 10, "").
+			add(row.Description, 0, "").String())
+	}
+	for len(screen) < u.height-2 {
+		screen = append(screen, "")
+	}
+	return append(screen, u.footer("↑↓ move  enter open  ←→ service  s status  v severity  c clear filters  l logs  q quit")...)
+}
+
+func orAll(value string) string {
+	if value == "" {
+		return "all"
+	}
+	return value
+}
+
+// info returns the compliance description of the current control, wrapped to the screen
+func (u *UI) info() []string {
+	c := u.current
+	width := max(u.width-2, 10)
+	var lines []string
+	lines = append(lines, wrap(c.Description, width)...)
+	if c.Requirement != nil && len(c.Requirement.Attributes) > 0 {
+		attribute := c.Requirement.Attributes[0]
+		lines = append(lines, "")
+		for _, field := range [][2]string{
+			{"Section", attribute.Section},
+			{"Category", attribute.Category},
+			{"Related requirements", attribute.RelatedRequirements},
+		} {
+			if field[1] != "" {
+				lines = append(lines, wrap(field[0]+": "+field[1], width)...)
+			}
+		}
+		if attribute.Description != "" {
+			lines = append(lines, "")
+			lines = append(lines, wrap(attribute.Description, width)...)
+		}
+	}
+	// Keep at least half of the screen for the resources
+	if limit := max((u.height-4)/2, 1); len(lines) > limit {
+		lines = append(lines[:limit-1], "…")
+	}
+	return append(lines, "")
+}
+
+// resourceHeight is the number of resources that fit on the screen
+func (u *UI) resourceHeight() int {
+	return max(u.height-5-len(u.info()), 1)
+}
+
+func (u *UI) drawControl() []string {
+	c := u.current
+	resources := c.Resources()
+	height := u.resourceHeight()
+	u.selected = max(min(u.selected, len(resources)-1), 0)
+	if u.selected < u.scroll {
+		u.scroll = u.selected
+	}
+	if u.selected >= u.scroll+height {
+		u.scroll = u.selected - height + 1
+	}
+
+	screen := []string{u.title(c.ID, c.Status(), orNone(c.Severity), u.progress())}
+	for _, text := range u.info() {
+		screen = append(screen, newLine(u.width, "").add(" "+text, 0, "").String())
+	}
+
+	var counts []string
+	for _, status := range []string{"FAIL", "ERROR", "SUPPRESSED", "PASS"} {
+		if count := c.Count(status); count > 0 {
+			counts = append(counts, fmt.Sprintf("%d %s", count, status))
+		}
+	}
+	targets := len(c.Results) > 1
+	header := newLine(u.width, styleHeader).add(" STATUS", 12, "").add(fmt.Sprintf("RESOURCE (%s)", strings.Join(counts, ", ")), 40, "")
+	if targets {
+		header.add("TARGET", 30, "")
+	}
+	screen = append(screen, header.add("REASON", 0, "").String())
+	if len(resources) == 0 {
+		screen = append(screen, newLine(u.width, styleDim).add(" No resources evaluated", 0, "").String())
+	}
+	for i := u.scroll; i < len(resources) && i < u.scroll+height; i++ {
+		result := c.Results[resources[i].result]
+		finding := result.Findings[resources[i].finding]
+		base := ""
+		if i == u.selected {
+			base = styleBar
+		}
+		row := newLine(u.width, base).add(" "+finding.Status, 12, statusStyles[finding.Status]).add(finding.Resource, 40, "")
+		if targets {
+			row.add(target(result.Account, result.Region, result.Source), 30, "")
+		}
+		screen = append(screen, row.add(finding.Reason, 0, "").String())
+	}
+	for len(screen) < u.height-2 {
+		screen = append(screen, "")
+	}
+	return append(screen, u.footer("↑↓ move  x suppress the selected resource  esc back  l logs  q quit")...)
+}
+
+func orNone(value string) string {
+	if value == "" {
+		return "no severity"
+	}
+	return value
+}
+
+// target names the account and region of a result, or its source without them
+func target(account, region, source string) string {
+	switch {
+	case account != "" && region != "":
+		return account + "/" + region
+	case region != "":
+		return region
+	}
+	return source
+}
+
+func (u *UI) drawLogs() []string {
+	screen := []string{u.title("Logs", u.progress())}
+	for _, text := range u.logs.Tail(u.height - 2) {
+		screen = append(screen, newLine(u.width, "").add(text, 0, "").String())
+	}
+	for len(screen) < u.height-1 {
+		screen = append(screen, "")
+	}
+	return append(screen, newLine(u.width, styleDim).add("esc back  q quit", 0, "").String())
+}
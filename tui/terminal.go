@@ -0,0 +1,106 @@
+// tui/terminal.go
+package tui
+
+import (
+	"io"
+	"os"
+	"unicode/utf8"
+)
+
+// terminal is the controlling terminal, switched to raw mode so that keys arrive as they are pressed
+type terminal struct {
+	in      *os.File
+	out     *os.File
+	resized chan os.Signal // receives a signal when the window size changes, where supported
+	restore func()         // leaves raw mode
+}
+
+// Escape sequences of the alternate screen, which keeps the scrollback of the shell intact
+const (
+	enterScreen = "\x1b[?1049h\x1b[?25l"
+	leaveScreen = "\x1b[?25h\x1b[?1049l"
+)
+
+// start switches to the alternate screen
+func (t *terminal) start() {
+	io.WriteString(t.out, enterScreen)
+}
+
+// close restores the screen and the mode the terminal was in
+func (t *terminal) close() {
+	io.WriteString(t.out, leaveScreen)
+	t.restore()
+}
+
+// keys reads key presses until stdin is closed, sending them as names: "up", "down", "left", "right",
+// "tab", "shift-tab", "enter", "esc", "backspace", "pgup", "pgdown", "home", "end", "ctrl-c", or the
+// character typed
+func (t *terminal) keys(keys chan<- string) {
+	defer close(keys)
+	buf := make([]byte, 256)
+	for {
+		n, err := t.in.Read(buf)
+		if err != nil {
+			return
+		}
+		for _, key := range parseKeys(buf[:n]) {
+			keys <- key
+		}
+	}
+}
+
+// sequences maps the escape sequences of special keys to their names
+var sequences = map[string]string{
+	"\x1b[A": "up", "\x1b[B": "down", "\x1b[C": "right", "\x1b[D": "left",
+	"\x1bOA": "up", "\x1bOB": "down", "\x1bOC": "right", "\x1bOD": "left",
+	"\x1b[Z": "shift-tab", "\x1b[5~": "pgup", "\x1b[6~": "pgdown",
+	"\x1b[H": "home", "\x1b[F": "end", "\x1b[1~": "home", "\x1b[4~": "end",
+	"\x1bOH": "home", "\x1bOF": "end",
+}
+
+// parseKeys splits what one read returned into keys. A lone ESC is the escape key; unknown escape
+// sequences are dropped.
+func parseKeys(data []byte) []string {
+	var keys []string
+	for len(data) > 0 {
+		switch data[0] {
+		case 0x1b:
+			if len(data) == 1 {
+				return append(keys, "esc")
+			}
+			end := 2
+			switch data[1] {
+			case '[':
+				// Parameters, then a final byte between @ and ~
+				for end < len(data) && (data[end] < 0x40 || data[end] > 0x7e) {
+					end++
+				}
+				end = min(end+1, len(data))
+			case 'O':
+				end = min(3, len(data))
+			}
+			if name, ok := sequences[string(data[:end])]; ok {
+				keys = append(keys, name)
+			}
+			data = data[end:]
+			continue
+		case '\r', '\n':
+			keys = append(keys, "enter")
+		case '\t':
+			keys = append(keys, "tab")
+		case 0x7f, 0x08:
+			keys = append(keys, "backspace")
+		case 0x03:
+			keys = append(keys, "ctrl-c")
+		default:
+			r, size := utf8.DecodeRune(data)
+			if r >= ' ' {
+				keys = append(keys, string(r))
+			}
+			data = data[size:]
+			continue
+		}
+		data = data[1:]
+	}
+	return keys
+}
@@ -0,0 +1,18 @@
+//go:build !linux && !darwin
+
+// tui/terminal_other.go
+package tui
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// openTerminal fails where raw mode is not implemented
+func openTerminal() (*terminal, error) {
+	return nil, fmt.Errorf("the terminal UI is not supported on %s", runtime.GOOS)
+}
+
+func (t *terminal) size() (int, int) {
+	return 80, 24
+}
@@ -0,0 +1,57 @@
+//go:build linux || darwin
+
+// tui/terminal_unix.go
+package tui
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+
+	"golang.org/x/sys/unix"
+)
+
+// openTerminal switches stdin to raw mode: no echo, no line buffering, and Ctrl-C read as a key
+// instead of interrupting the process
+func openTerminal() (*terminal, error) {
+	fd := int(os.Stdin.Fd())
+	saved, err := unix.IoctlGetTermios(fd, ioctlGetTermios)
+	if err != nil {
+		return nil, fmt.Errorf("stdin is not a terminal: %v", err)
+	}
+	if _, err := unix.IoctlGetWinsize(int(os.Stdout.Fd()), unix.TIOCGWINSZ); err != nil {
+		return nil, fmt.Errorf("stdout is not a terminal: %v", err)
+	}
+
+	raw := *saved
+	raw.Iflag &^= unix.IGNBRK | unix.BRKINT | unix.PARMRK | unix.ISTRIP | unix.INLCR | unix.IGNCR | unix.ICRNL | unix.IXON
+	raw.Lflag &^= unix.ECHO | unix.ECHONL | unix.ICANON | unix.ISIG | unix.IEXTEN
+	raw.Cflag &^= unix.CSIZE | unix.PARENB
+	raw.Cflag |= unix.CS8
+	raw.Cc[unix.VMIN] = 1
+	raw.Cc[unix.VTIME] = 0
+	if err := unix.IoctlSetTermios(fd, ioctlSetTermios, &raw); err != nil {
+		return nil, fmt.Errorf("failed to switch the terminal to raw mode: %v", err)
+	}
+
+	resized := make(chan os.Signal, 1)
+	signal.Notify(resized, unix.SIGWINCH)
+	return &terminal{
+		in:      os.Stdin,
+		out:     os.Stdout,
+		resized: resized,
+		restore: func() {
+			signal.Stop(resized)
+			unix.IoctlSetTermios(fd, ioctlSetTermios, saved)
+		},
+	}, nil
+}
+
+// size returns the columns and rows of the terminal
+func (t *terminal) size() (int, int) {
+	size, err := unix.IoctlGetWinsize(int(t.out.Fd()), unix.TIOCGWINSZ)
+	if err != nil || size.Col == 0 || size.Row == 0 {
+		return 80, 24
+	}
+	return int(size.Col), int(size.Row)
+}
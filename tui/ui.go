@@ -0,0 +1,351 @@
+// tui/ui.go
+package tui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"aws-security-hub/report"
+	"aws-security-hub/settings"
+	"aws-security-hub/types"
+	"aws-security-hub/util"
+)
+
+// Options configures the terminal UI
+type Options struct {
+	Title            string           // what the results come from, e.g. "scan of ap-northeast-2" or a report path
+	Controls         []types.Control  // controls listed before their first result arrives; others are added as they do
+	Compliance       *util.Compliance // descriptions and severities of the controls
+	Runs             int              // results expected per control, one per account and region; 0 when unknown
+	SuppressionsFile string           // file suppressions are appended to; empty disables suppressing
+}
+
+// Views of the UI
+const (
+	viewList    = iota // controls, filtered by service, status and severity
+	viewControl        // compliance description and resources of one control
+	viewLogs           // diagnostics logged since the UI started
+)
+
+// UI is a full-screen terminal UI listing controls as their results arrive. Results are added from
+// any goroutine; everything else belongs to the goroutine of Run.
+type UI struct {
+	options  Options
+	controls []*control
+	results  chan report.Result
+	done     chan error
+	quit     chan struct{}
+	logs     *logBuffer
+
+	running      bool
+	stopped      error // why the scan ended early, nil when it completed
+	suppressions []types.Suppression
+
+	view     int
+	back     int // view to return to from the logs
+	filter   filter
+	cursor   int // selected row of the list
+	offset   int // first row of the list on screen
+	current  *control
+	selected int // selected resource of the current control
+	scroll   int // first resource on screen
+	prompt   *prompt
+	message  string
+	width    int
+	height   int
+}
+
+// New returns a UI waiting for the results of the controls
+func New(options Options) *UI {
+	if options.Compliance == nil {
+		options.Compliance = &util.Compliance{}
+	}
+	u := &UI{
+		options: options,
+		results: make(chan report.Result, 64),
+		done:    make(chan error, 1),
+		quit:    make(chan struct{}),
+		logs:    newLogBuffer(),
+		running: true,
+	}
+	for _, c := range options.Controls {
+		u.controls = append(u.controls, newControl(c, options.Compliance))
+	}
+	return u
+}
+
+// Logs returns the writer to send diagnostics to while the UI runs; they are shown in the log view
+func (u *UI) Logs() io.Writer {
+	return u.logs
+}
+
+// Add hands a result to the UI. It may be called from several goroutines, e.g. as
+// report.Options.Progress, and returns at once after the UI quit.
+func (u *UI) Add(result report.Result) {
+	select {
+	case u.results <- result:
+	case <-u.quit:
+	}
+}
+
+// Done tells the UI that no more results will arrive: err is why the scan stopped early, nil when
+// every control completed
+func (u *UI) Done(err error) {
+	select {
+	case u.done <- err:
+	case <-u.quit:
+	}
+}
+
+// Run takes over the terminal until the user quits, or ctx is cancelled other than by its deadline:
+// a --timeout stops the scan, not the browsing of its results
+func (u *UI) Run(ctx context.Context) error {
+	term, err := openTerminal()
+	if err != nil {
+		return err
+	}
+	term.start()
+	defer term.close()
+	defer close(u.quit)
+
+	keys := make(chan string, 16)
+	go term.keys(keys)
+
+	cancelled := ctx.Done()
+	u.width, u.height = term.size()
+	for {
+		u.draw(term.out)
+		select {
+		case key, ok := <-keys:
+			if !ok || u.handle(key) {
+				return nil
+			}
+		case result := <-u.results:
+			u.add(result)
+		case err := <-u.done:
+			u.running, u.stopped = false, err
+		case <-u.logs.changed:
+		case <-term.resized:
+			u.width, u.height = term.size()
+		case <-cancelled:
+			if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return nil
+			}
+			cancelled = nil
+		}
+	}
+}
+
+// add records a result under its control, applying the suppressions made since the scan started
+func (u *UI) add(result report.Result) {
+	row := u.find(result.ID)
+	if row == nil {
+		service, _, _ := strings.Cut(result.ID, ".")
+		row = &control{ID: result.ID, Service: service, Severity: result.Severity, Description: result.Description}
+		if requirement := report.Requirement(u.options.Compliance, types.InventoryControl{ID: result.ID}); requirement != nil {
+			row.Requirement = requirement
+		}
+		u.controls = append(u.controls, row)
+	}
+	row.Results = append(row.Results, result)
+	for _, suppression := range u.suppressions {
+		if suppression.Control == row.ID {
+			last := &row.Results[len(row.Results)-1]
+			last.Status, last.Findings = types.Suppress(row.ID, last.Status, last.Findings, []types.Suppression{suppression})
+		}
+	}
+}
+
+func (u *UI) find(id string) *control {
+	for _, row := range u.controls {
+		if row.ID == id {
+			return row
+		}
+	}
+	return nil
+}
+
+// visible returns the controls that pass the filter
+func (u *UI) visible() []*control {
+	var rows []*control
+	for _, row := range u.controls {
+		if u.filter.Matches(row) {
+			rows = append(rows, row)
+		}
+	}
+	return rows
+}
+
+// services returns the services of the controls in the order they are listed, after "" for all
+func (u *UI) services() []string {
+	services := []string{""}
+	seen := make(map[string]bool)
+	for _, row := range u.controls {
+		if !seen[row.Service] {
+			seen[row.Service] = true
+			services = append(services, row.Service)
+		}
+	}
+	return services
+}
+
+// handle applies a key press, returning true when the UI should quit
+func (u *UI) handle(key string) bool {
+	if u.prompt != nil {
+		u.handlePrompt(key)
+		return false
+	}
+	u.message = ""
+	switch key {
+	case "q", "ctrl-c":
+		return true
+	case "l":
+		if u.view == viewLogs {
+			u.view = u.back
+		} else {
+			u.view, u.back = viewLogs, u.view
+		}
+		return false
+	}
+
+	switch u.view {
+	case viewList:
+		u.handleList(key)
+	case viewControl:
+		u.handleControl(key)
+	case viewLogs:
+		if key == "esc" || key == "backspace" || key == "left" {
+			u.view = u.back
+		}
+	}
+	return false
+}
+
+func (u *UI) handleList(key string) {
+	rows := u.visible()
+	switch key {
+	case "tab", "right":
+		u.filter.Service = next(u.services(), u.filter.Service, 1)
+		u.cursor = 0
+	case "shift-tab", "left":
+		u.filter.Service = next(u.services(), u.filter.Service, -1)
+		u.cursor = 0
+	case "s":
+		u.filter.Status = next(statusFilters, u.filter.Status, 1)
+		u.cursor = 0
+	case "v":
+		u.filter.Severity = next(severityFilters, u.filter.Severity, 1)
+		u.cursor = 0
+	case "c":
+		u.filter = filter{}
+		u.cursor = 0
+	case "enter":
+		if u.cursor < len(rows) {
+			u.current, u.selected, u.scroll = rows[u.cursor], 0, 0
+			u.view = viewControl
+		}
+	default:
+		u.cursor = move(key, u.cursor, len(rows), u.listHeight())
+	}
+}
+
+func (u *UI) handleControl(key string) {
+	resources := u.current.Resources()
+	switch key {
+	case "esc", "backspace", "left":
+		u.view = viewList
+	case "x":
+		if u.selected >= len(resources) {
+			return
+		}
+		r := resources[u.selected]
+		finding := u.current.Results[r.result].Findings[r.finding]
+		switch {
+		case u.options.SuppressionsFile == "":
+			u.message = "Set suppressions_file in the configuration (or --suppressions-file) to record suppressions"
+		case finding.Status != "FAIL":
+			u.message = "Only failing resources can be suppressed"
+		default:
+			u.prompt = newPrompt(u.current.ID, finding.Resource)
+		}
+	default:
+		u.selected = move(key, u.selected, len(resources), u.resourceHeight())
+	}
+}
+
+// move applies a navigation key to a cursor over count rows, of which page fit on the screen
+func move(key string, cursor, count, page int) int {
+	switch key {
+	case "up", "k":
+		cursor--
+	case "down", "j":
+		cursor++
+	case "pgup":
+		cursor -= max(page, 1)
+	case "pgdown":
+		cursor += max(page, 1)
+	case "home", "g":
+		cursor = 0
+	case "end", "G":
+		cursor = count - 1
+	}
+	return max(min(cursor, count-1), 0)
+}
+
+// prompt asks for the fields of a suppression one after the other
+type prompt struct {
+	control string
+	fields  []string // labels
+	values  []string
+	field   int
+}
+
+func newPrompt(controlID, resource string) *prompt {
+	return &prompt{
+		control: controlID,
+		fields:  []string{"resource pattern", "reason", "expires (YYYY-MM-DD, empty for never)"},
+		values:  []string{resource, "", ""},
+	}
+}
+
+func (u *UI) handlePrompt(key string) {
+	p := u.prompt
+	switch key {
+	case "esc", "ctrl-c":
+		u.prompt, u.message = nil, "Suppression cancelled"
+	case "backspace":
+		if value := []rune(p.values[p.field]); len(value) > 0 {
+			p.values[p.field] = string(value[:len(value)-1])
+		}
+	case "enter":
+		if p.field < len(p.fields)-1 {
+			p.field++
+			return
+		}
+		u.prompt = nil
+		u.suppress(types.Suppression{Control: p.control, Resource: p.values[0], Reason: p.values[1], Expires: p.values[2]})
+	default:
+		if len([]rune(key)) == 1 {
+			p.values[p.field] += key
+		}
+	}
+}
+
+// suppress writes a suppression to the suppressions file and applies it to the results shown
+func (u *UI) suppress(suppression types.Suppression) {
+	if err := suppression.Validate(); err != nil {
+		u.message = fmt.Sprintf("Invalid suppression: %v", err)
+		return
+	}
+	if err := settings.AppendSuppression(u.options.SuppressionsFile, suppression); err != nil {
+		u.message = err.Error()
+		return
+	}
+	u.suppressions = append(u.suppressions, suppression)
+	count := u.find(suppression.Control).Suppress(suppression)
+	u.selected = 0
+	u.message = fmt.Sprintf("%s: %d resource(s) suppressed, written to %s", suppression.Control, count, u.options.SuppressionsFile)
+}
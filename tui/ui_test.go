@@ -0,0 +1,233 @@
+// tui/ui_test.go
+package tui
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"aws-security-hub/report"
+	"aws-security-hub/settings"
+	"aws-security-hub/types"
+	"aws-security-hub/util"
+)
+
+// failing returns a result of CloudFront.1 in a region with a failing finding per resource
+func failing(region string, resources ...string) report.Result {
+	result := report.Result{ID: "CloudFront.1", Status: "FAIL", Region: region}
+	for _, resource := range resources {
+		result.Findings.Fail(resource, "No default root object")
+	}
+	return result
+}
+
+// press hands the keys to the UI one after the other
+func press(u *UI, keys ...string) {
+	for _, key := range keys {
+		u.handle(key)
+	}
+}
+
+// typed splits text into the keys that type it
+func typed(text string) []string {
+	return strings.Split(text, "")
+}
+
+func TestSuppressWritesBack(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "suppressions.yaml")
+	u := New(Options{SuppressionsFile: path})
+	u.add(failing("ap-northeast-2", "E1", "E2"))
+
+	// Open the control, select the first failing resource and fill in the prompt
+	press(u, "enter", "x")
+	if u.prompt == nil {
+		t.Fatalf("no prompt, message %q", u.message)
+	}
+	press(u, "enter")
+	press(u, typed("accepted")...)
+	press(u, "enter")
+	press(u, typed("2030-01-01")...)
+	press(u, "enter")
+
+	want := types.Suppression{Control: "CloudFront.1", Resource: "E1", Reason: "accepted", Expires: "2030-01-01"}
+	written, err := settings.LoadSuppressions(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(written, []types.Suppression{want}) {
+		t.Errorf("written = %+v, want %+v", written, want)
+	}
+	if want := "CloudFront.1: 1 resource(s) suppressed, written to " + path; u.message != want {
+		t.Errorf("message = %q, want %q", u.message, want)
+	}
+
+	row := u.find("CloudFront.1")
+	if got := row.Count("SUPPRESSED"); got != 1 {
+		t.Errorf("%d suppressed findings, want 1", got)
+	}
+	if row.Status() != "FAIL" {
+		t.Errorf("status = %s, want FAIL while E2 fails", row.Status())
+	}
+
+	// Results arriving later, e.g. from another region, get the suppression too
+	u.add(failing("us-east-1", "E1"))
+	if got := row.Results[1]; got.Status != "PASS" || got.Findings[0].Status != "SUPPRESSED" {
+		t.Errorf("later result = %+v, want its only failure suppressed", got)
+	}
+}
+
+func TestSuppressRefusals(t *testing.T) {
+	passing := report.Result{ID: "CloudFront.1", Status: "PASS"}
+	passing.Findings.Pass("E1", "Default root object set")
+
+	tests := []struct {
+		name    string
+		file    bool
+		result  report.Result
+		keys    []string
+		message string
+	}{
+		{
+			name:    "no suppressions file",
+			result:  failing("ap-northeast-2", "E1"),
+			keys:    []string{"enter", "x"},
+			message: "Set suppressions_file in the configuration (or --suppressions-file) to record suppressions",
+		},
+		{
+			name:    "passing resource",
+			file:    true,
+			result:  passing,
+			keys:    []string{"enter", "x"},
+			message: "Only failing resources can be suppressed",
+		},
+		{
+			name:    "missing reason",
+			file:    true,
+			result:  failing("ap-northeast-2", "E1"),
+			keys:    []string{"enter", "x", "enter", "enter", "enter"},
+			message: "Invalid suppression: missing reason",
+		},
+		{
+			name:    "invalid expiry",
+			file:    true,
+			result:  failing("ap-northeast-2", "E1"),
+			keys:    append(append([]string{"enter", "x", "enter", "o", "k", "enter"}, typed("soon")...), "enter"),
+			message: `Invalid suppression: invalid expiry date "soon", expected YYYY-MM-DD`,
+		},
+		{
+			name:    "cancelled",
+			file:    true,
+			result:  failing("ap-northeast-2", "E1"),
+			keys:    []string{"enter", "x", "esc"},
+			message: "Suppression cancelled",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "suppressions.yaml")
+			options := Options{}
+			if test.file {
+				options.SuppressionsFile = path
+			}
+			u := New(options)
+			u.add(test.result)
+			press(u, test.keys...)
+
+			if u.prompt != nil || u.message != test.message {
+				t.Errorf("prompt %v, message %q; want no prompt and %q", u.prompt != nil, u.message, test.message)
+			}
+			if written, _ := settings.LoadSuppressions(path); len(written) != 0 {
+				t.Errorf("written = %+v, want nothing", written)
+			}
+			if got := u.find("CloudFront.1").Count("SUPPRESSED"); got != 0 {
+				t.Errorf("%d suppressed findings, want none", got)
+			}
+		})
+	}
+}
+
+func TestPromptEditsTheResourcePattern(t *testing.T) {
+	u := New(Options{SuppressionsFile: filepath.Join(t.TempDir(), "suppressions.yaml")})
+	u.add(failing("ap-northeast-2", "E1", "E2"))
+
+	// Replace E1 with E*, which suppresses both distributions
+	press(u, "enter", "x", "backspace", "*", "enter", "o", "k", "enter", "enter")
+	if got := u.find("CloudFront.1").Status(); got != "PASS" {
+		t.Errorf("status = %s, want PASS (message %q)", got, u.message)
+	}
+}
+
+func TestAddListsUnknownControls(t *testing.T) {
+	compliance := &util.Compliance{Requirements: []util.Requirement{{Id: "S3.1", Description: "S3 public access block"}}}
+	u := New(Options{Compliance: compliance, Controls: []types.Control{types.InventoryControl{ID: "CloudFront.1", Service: "cloudfront"}}})
+
+	u.add(report.Result{ID: "S3.1", Status: "PASS", Severity: "Medium", Description: "S3 public access block"})
+
+	var ids []string
+	for _, row := range u.controls {
+		ids = append(ids, row.ID+" "+row.Service+" "+row.Status())
+	}
+	if want := []string{"CloudFront.1 cloudfront PENDING", "S3.1 S3 PASS"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("controls = %v, want %v", ids, want)
+	}
+	if requirement := u.find("S3.1").Requirement; requirement == nil || requirement.Id != "S3.1" {
+		t.Errorf("requirement = %+v", requirement)
+	}
+}
+
+func TestListFilters(t *testing.T) {
+	u := New(Options{})
+	u.add(failing("ap-northeast-2", "E1"))
+	u.add(report.Result{ID: "S3.1", Status: "PASS", Severity: "Medium"})
+	u.add(report.Result{ID: "EC2.1", Status: "ERROR", Severity: "Critical"})
+
+	visible := func() []string {
+		var ids []string
+		for _, row := range u.visible() {
+			ids = append(ids, row.ID)
+		}
+		return ids
+	}
+	tests := []struct {
+		key  string
+		want []string
+	}{
+		{"tab", []string{"CloudFront.1"}},       // service CloudFront
+		{"tab", []string{"S3.1"}},               // service S3
+		{"shift-tab", []string{"CloudFront.1"}}, // back to CloudFront
+		{"c", []string{"CloudFront.1", "S3.1", "EC2.1"}},
+		{"s", []string{"CloudFront.1"}}, // status FAIL
+		{"s", []string{"EC2.1"}},        // status ERROR
+		{"c", []string{"CloudFront.1", "S3.1", "EC2.1"}},
+		{"v", []string{"EC2.1"}}, // severity CRITICAL, ignoring case
+	}
+	for i, test := range tests {
+		press(u, test.key)
+		if got := visible(); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("after key %d (%s): visible = %v, want %v", i, test.key, got, test.want)
+		}
+	}
+}
+
+func TestMove(t *testing.T) {
+	tests := []struct {
+		key                 string
+		cursor, count, page int
+		want                int
+	}{
+		{"down", 0, 3, 10, 1},
+		{"j", 2, 3, 10, 2}, // stays on the last row
+		{"up", 0, 3, 10, 0},
+		{"pgdown", 0, 30, 10, 10},
+		{"pgup", 5, 30, 10, 0},
+		{"G", 0, 30, 10, 29},
+		{"home", 12, 30, 10, 0},
+		{"down", 0, 0, 10, 0}, // empty list
+	}
+	for _, test := range tests {
+		if got := move(test.key, test.cursor, test.count, test.page); got != test.want {
+			t.Errorf("move(%s, %d, %d, %d) = %d, want %d", test.key, test.cursor, test.count, test.page, got, test.want)
+		}
+	}
+}
@@ -9,6 +9,3 @@ import (
 type AWSClient struct {
 	Config aws.Config
 }
-
-// AWSClientInitializer defines a function type for initializing AWS client
-type AWSClientInitializer func() (*AWSClient, error)
@@ -0,0 +1,70 @@
+package types
+
+import (
+	"context"
+
+	"aws-security-hub/inventory"
+	"aws-security-hub/util"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+// Control is implemented by every control: the built-in ones of the audit packages, user-authored
+// rules and the controls of external control packs. Its methods are kept stable so that control packs
+// written against one release keep working with the next.
+type Control interface {
+	// Metadata describes the control
+	Metadata() Metadata
+	// Permissions are the IAM actions needed to collect the resources the control evaluates
+	Permissions() []string
+	// Evaluate returns the status of the control (PASS, FAIL, ERROR or NA) and a finding per evaluated
+	// resource (Findings is a []Finding), giving up once ctx is done. The status is returned rather
+	// than derived from the findings, since a control may be NA or ERROR without any resource to
+	// report, e.g. when its service was not collected.
+	Evaluate(ctx context.Context, clients Clients) (string, Findings)
+}
+
+// Metadata describes a control
+type Metadata struct {
+	ID      string // Security Hub control ID (e.g. CloudFront.1)
+	Check   string // Check name as listed in the compliance JSON and used as the command name
+	Service string // inventory service the control evaluates; empty derives it from the ID of built-in controls
+	// Parameters are the settings of the control that the configuration file may change
+	Parameters []Parameter
+	// Requirement carries the metadata of controls that are not in the compliance JSON (user-authored
+	// rules and control packs)
+	Requirement *util.Requirement
+}
+
+// Clients are what a control evaluates
+type Clients struct {
+	Inventory *inventory.Inventory // resources collected for the control
+	AWS       *aws.Config          // account and region scanned, nil when evaluating a snapshot, template or plan
+}
+
+// InventoryControl is a control that evaluates the inventory with a function, the way the audit
+// packages and user-authored rules define theirs
+type InventoryControl struct {
+	ID          string
+	Check       string
+	Service     string // see Metadata
+	Func        func(inv *inventory.Inventory) (string, Findings)
+	Actions     []string // returned by Permissions
+	Parameters  []Parameter
+	Requirement *util.Requirement
+}
+
+// Metadata describes the control
+func (c InventoryControl) Metadata() Metadata {
+	return Metadata{ID: c.ID, Check: c.Check, Service: c.Service, Parameters: c.Parameters, Requirement: c.Requirement}
+}
+
+// Permissions are the IAM actions needed to collect the resources the control evaluates
+func (c InventoryControl) Permissions() []string {
+	return c.Actions
+}
+
+// Evaluate runs the function of the control against the inventory
+func (c InventoryControl) Evaluate(ctx context.Context, clients Clients) (string, Findings) {
+	return c.Func(clients.Inventory)
+}
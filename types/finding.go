@@ -0,0 +1,84 @@
+// types/finding.go
+package types
+
+import (
+	"log/slog"
+
+	"aws-security-hub/inventory"
+)
+
+// ConditionErrorCode is the ErrorCode of findings whose rule condition could not be evaluated, as
+// opposed to the AWS error codes of failed API calls
+const ConditionErrorCode = "ConditionError"
+
+// Finding is the outcome of a control for a single resource
+type Finding struct {
+	Resource  string `json:"Resource"`
+	Status    string `json:"Status"` // PASS, FAIL, ERROR or SUPPRESSED
+	Reason    string `json:"Reason,omitempty"`
+	ErrorCode string `json:"ErrorCode,omitempty"` // AWS error code of an ERROR finding, e.g. AccessDenied
+}
+
+// Findings accumulates the per-resource outcomes of an evaluation
+type Findings []Finding
+
+// Pass records a resource that complies with the control
+func (f *Findings) Pass(resource, reason string) {
+	*f = append(*f, Finding{Resource: resource, Status: "PASS", Reason: reason})
+}
+
+// Fail records a resource that does not comply with the control
+func (f *Findings) Fail(resource, reason string) {
+	*f = append(*f, Finding{Resource: resource, Status: "FAIL", Reason: reason})
+}
+
+// Error records a resource that could not be evaluated because an AWS API call failed
+func (f *Findings) Error(resource string, err *inventory.APIError) {
+	*f = append(*f, Finding{Resource: resource, Status: "ERROR", Reason: err.Error(), ErrorCode: err.Code})
+}
+
+// Failed returns the failing findings
+func (f Findings) Failed() Findings {
+	return f.with("FAIL")
+}
+
+// Errored returns the findings of resources that could not be evaluated
+func (f Findings) Errored() Findings {
+	return f.with("ERROR")
+}
+
+func (f Findings) with(status string) Findings {
+	var result Findings
+	for _, finding := range f {
+		if finding.Status == status {
+			result = append(result, finding)
+		}
+	}
+	return result
+}
+
+// Resolve settles the status of a control from what its evaluation returned: a failing resource keeps
+// the control at FAIL, otherwise a resource that could not be evaluated turns PASS or NA into ERROR,
+// since the unknown resource might be non-compliant
+func Resolve(status string, findings Findings) (string, Findings) {
+	if status == "FAIL" || status == "ERROR" {
+		return status, findings
+	}
+	if errored := findings.Errored(); len(errored) > 0 {
+		return "ERROR", findings
+	}
+	return status, findings
+}
+
+// NotCollected returns the status of a control whose service is missing from the inventory:
+// ERROR, with a finding carrying the AWS error code, when collecting the service failed, NA when
+// it was not collected at all
+func NotCollected(logger *slog.Logger, inv *inventory.Inventory, service string, findings *Findings) string {
+	if err, ok := inv.Errors[service]; ok {
+		logger.Error("failed to collect service", "service", service, "error", err)
+		findings.Error(service, &err)
+		return "ERROR"
+	}
+	logger.Info("service not collected", "service", service)
+	return "NA"
+}
@@ -0,0 +1,52 @@
+// types/parameter.go
+package types
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+)
+
+// Parameter is a setting of a control that can be changed in the configuration file, named as in Security Hub
+type Parameter struct {
+	Name        string
+	Description string
+	Default     string
+	Validate    func(value string) error // nil accepts any value
+}
+
+var (
+	parametersMu sync.RWMutex
+	parameters   map[string]map[string]string // configured values by control ID, then parameter name
+)
+
+// SetParameters replaces the configured parameter values, by control ID then parameter name
+func SetParameters(values map[string]map[string]string) {
+	parametersMu.Lock()
+	defer parametersMu.Unlock()
+	parameters = values
+}
+
+// Value returns the configured value of the parameter for a control, or its default
+func (p Parameter) Value(controlID string) string {
+	parametersMu.RLock()
+	defer parametersMu.RUnlock()
+	if value, ok := parameters[controlID][p.Name]; ok {
+		return value
+	}
+	return p.Default
+}
+
+// IntBetween validates integer parameters within a range
+func IntBetween(min, max int) func(string) error {
+	return func(value string) error {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("%q is not an integer", value)
+		}
+		if n < min || n > max {
+			return fmt.Errorf("%d is not between %d and %d", n, min, max)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,89 @@
+// types/suppression.go
+package types
+
+import (
+	"fmt"
+	"log/slog"
+	"path"
+	"time"
+)
+
+// Suppression accepts the failure of a control for some resources, e.g. a bucket that is public on purpose
+type Suppression struct {
+	Control  string `yaml:"control" json:"Control"`
+	Resource string `yaml:"resource" json:"Resource"` // path.Match pattern of the resource; empty matches every resource
+	Reason   string `yaml:"reason" json:"Reason"`
+	Expires  string `yaml:"expires,omitempty" json:"Expires,omitempty"` // YYYY-MM-DD; the suppression stops applying after this day
+}
+
+// Validate checks the control, the resource pattern and the expiry date are usable
+func (s Suppression) Validate() error {
+	if s.Control == "" {
+		return fmt.Errorf("missing control")
+	}
+	if s.Reason == "" {
+		return fmt.Errorf("missing reason")
+	}
+	if _, err := path.Match(s.Resource, ""); err != nil {
+		return fmt.Errorf("invalid resource pattern %q: %v", s.Resource, err)
+	}
+	if s.Expires != "" {
+		if _, err := time.Parse(time.DateOnly, s.Expires); err != nil {
+			return fmt.Errorf("invalid expiry date %q, expected YYYY-MM-DD", s.Expires)
+		}
+	}
+	return nil
+}
+
+// Expired reports whether the suppression no longer applies at now
+func (s Suppression) Expired(now time.Time) bool {
+	if s.Expires == "" {
+		return false
+	}
+	expires, err := time.Parse(time.DateOnly, s.Expires)
+	return err == nil && now.After(expires.AddDate(0, 0, 1))
+}
+
+// Matches reports whether the suppression covers the resource of a control
+func (s Suppression) Matches(controlID, resource string) bool {
+	if s.Control != controlID {
+		return false
+	}
+	if s.Resource == "" {
+		return true
+	}
+	matched, _ := path.Match(s.Resource, resource)
+	return matched
+}
+
+// Suppress marks the failing findings covered by unexpired suppressions as SUPPRESSED. Once all of its
+// failing findings are suppressed, a failing control is ERROR if resources could not be evaluated, and
+// passes otherwise.
+func Suppress(controlID, status string, findings Findings, suppressions []Suppression) (string, Findings) {
+	now := time.Now()
+	suppressed := 0
+	for i, finding := range findings {
+		if finding.Status != "FAIL" {
+			continue
+		}
+		for _, suppression := range suppressions {
+			if suppression.Matches(controlID, finding.Resource) && !suppression.Expired(now) {
+				findings[i].Status = "SUPPRESSED"
+				findings[i].Reason = finding.Reason + " (suppressed: " + suppression.Reason + ")"
+				suppressed++
+				break
+			}
+		}
+	}
+	if suppressed == 0 {
+		return status, findings
+	}
+	slog.Info("findings suppressed", "control", controlID, "count", suppressed)
+	if status == "FAIL" && len(findings.Failed()) == 0 {
+		if len(findings.Errored()) > 0 {
+			return "ERROR", findings
+		}
+		return "PASS", findings
+	}
+	return status, findings
+}
@@ -0,0 +1,40 @@
+// types/suppression_test.go
+package types
+
+import "testing"
+
+func TestSuppress(t *testing.T) {
+	suppressions := []Suppression{{Control: "S3.1", Resource: "www-*", Reason: "public website"}}
+	tests := []struct {
+		name     string
+		findings Findings
+		want     string
+	}{
+		{
+			name:     "every failure suppressed",
+			findings: Findings{{Resource: "www-a", Status: "FAIL"}, {Resource: "logs", Status: "PASS"}},
+			want:     "PASS",
+		},
+		{
+			name:     "failure left",
+			findings: Findings{{Resource: "www-a", Status: "FAIL"}, {Resource: "logs", Status: "FAIL"}},
+			want:     "FAIL",
+		},
+		{
+			name:     "errors left",
+			findings: Findings{{Resource: "www-a", Status: "FAIL"}, {Resource: "logs", Status: "ERROR", ErrorCode: "AccessDenied"}},
+			want:     "ERROR",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			status, findings := Suppress("S3.1", "FAIL", test.findings, suppressions)
+			if status != test.want {
+				t.Errorf("status = %s, want %s", status, test.want)
+			}
+			if findings[0].Status != "SUPPRESSED" {
+				t.Errorf("www-a = %s, want SUPPRESSED", findings[0].Status)
+			}
+		})
+	}
+}
@@ -3,35 +3,35 @@ package util
 import (
 	"encoding/json"
 	"fmt"
-	"io/ioutil"
-	"log"
-	"os"
-	"strings"
+	"log/slog"
 )
 
 // Compliance structure to match the JSON structure
 type Compliance struct {
-	Requirements []struct {
-		Id          string `json:"Id"`
-		Description string `json:"Description"`
-	} `json:"Requirements"`
+	Requirements []Requirement `json:"Requirements"`
 }
 
-// Load compliance data from the JSON file
-func LoadComplianceData(filePath string) (*Compliance, error) {
-	file, err := os.Open(filePath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to open compliance file: %v", err)
-	}
-	defer file.Close()
+// Requirement is a single control in the compliance JSON
+type Requirement struct {
+	Id          string      `json:"Id" yaml:"Id"`
+	Description string      `json:"Description" yaml:"Description"`
+	Checks      []string    `json:"Checks" yaml:"Checks"`
+	Attributes  []Attribute `json:"Attributes" yaml:"Attributes"`
+}
 
-	bytes, err := ioutil.ReadAll(file)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read compliance file: %v", err)
-	}
+// Attribute holds the Security Hub metadata of a requirement
+type Attribute struct {
+	Section             string `json:"Section" yaml:"Section"`
+	RelatedRequirements string `json:"RelatedRequirements" yaml:"RelatedRequirements"`
+	Category            string `json:"Category" yaml:"Category"`
+	Severity            string `json:"Severity" yaml:"Severity"`
+	Description         string `json:"Description" yaml:"Description"`
+}
 
+// Parse compliance data from its JSON
+func ParseComplianceData(bytes []byte) (*Compliance, error) {
 	var compliance Compliance
-	err = json.Unmarshal(bytes, &compliance)
+	err := json.Unmarshal(bytes, &compliance)
 	if err != nil {
 		return nil, fmt.Errorf("failed to unmarshal compliance data: %v", err)
 	}
@@ -39,15 +39,18 @@ func LoadComplianceData(filePath string) (*Compliance, error) {
 	return &compliance, nil
 }
 
-// Print the specific compliance information
-func PrintComplianceInfo(compliance *Compliance, id string) {
+// Log the specific compliance information at debug level
+func LogComplianceInfo(logger *slog.Logger, compliance *Compliance, id string) {
 	for _, requirement := range compliance.Requirements {
 		if requirement.Id == id {
-			log.Println(strings.Repeat("-", 100))
-			log.Printf("[%s] %s\n", requirement.Id, requirement.Description)
-			log.Println(strings.Repeat("-", 100))
+			LogRequirementInfo(logger, requirement)
 			return
 		}
 	}
-	log.Printf("[ERROR] Compliance requirement with ID %s not found.\n", id)
+	logger.Warn("compliance requirement not found", "requirement", id)
+}
+
+// Log the description of a single requirement at debug level
+func LogRequirementInfo(logger *slog.Logger, requirement Requirement) {
+	logger.Debug("evaluating control", "description", requirement.Description)
 }